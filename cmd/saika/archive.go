@@ -0,0 +1,129 @@
+// cmd/saika/archive.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/saika-m/saika-lang/internal/archive"
+	"github.com/saika-m/saika-lang/internal/transpiler"
+)
+
+// exportCommand writes the given sources, with the project file around
+// them, as one archive that can be shared and imported again
+func exportCommand(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	output := fs.String("o", "", tr("write the archive to this file instead of stdout"))
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, tr("Usage:"), "saika export [-o <file"+archive.Ext+">] <file.saika|dir|dir/...>...")
+		printDefaults(fs)
+	}
+	args = parseArgs(fs, args)
+	if len(args) == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	a, err := exportArchive(args)
+	if err != nil {
+		fmt.Printf(tr("Error: %v\n"), err)
+		os.Exit(1)
+	}
+	if *output == "" {
+		os.Stdout.Write(archive.Format(a))
+		return
+	}
+	if err := os.WriteFile(*output, archive.Format(a), 0644); err != nil {
+		fmt.Printf(tr("Error: %v\n"), err)
+		os.Exit(1)
+	}
+}
+
+// exportArchive collects the sources named by args and their project file
+// into an archive, naming them relative to the project's directory or, when
+// there is no project, to the directory holding all the sources
+func exportArchive(args []string) (*archive.Archive, error) {
+	sources, err := transpiler.CollectSources(args)
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no Saika sources in %v", args)
+	}
+	project, err := transpiler.FindProject(filepath.Dir(sources[0]))
+	if err != nil {
+		return nil, err
+	}
+
+	paths := sources
+	if project != nil {
+		paths = append([]string{project.Path}, sources...)
+	}
+	dir, err := commonDir(paths)
+	if err != nil {
+		return nil, err
+	}
+	return archive.FromFiles(dir, paths)
+}
+
+// commonDir returns the innermost directory containing all of paths
+func commonDir(paths []string) (string, error) {
+	dir := ""
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return "", err
+		}
+		if dir == "" {
+			dir = filepath.Dir(abs)
+		}
+		for {
+			if rel, err := filepath.Rel(dir, abs); err == nil && filepath.IsLocal(rel) {
+				break
+			}
+			dir = filepath.Dir(dir)
+		}
+	}
+	return dir, nil
+}
+
+// importCommand extracts the files of an archive made by saika export,
+// read from a file or, given -, from stdin
+func importCommand(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	dir := fs.String("dir", ".", tr("extract the files into this directory"))
+	force := fs.Bool("force", false, tr("overwrite existing files"))
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, tr("Usage:"), "saika import [--dir <dir>] [--force] <file"+archive.Ext+"|->")
+		printDefaults(fs)
+	}
+	args = parseArgs(fs, args)
+	if len(args) != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	var data []byte
+	var err error
+	if args[0] == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(args[0])
+	}
+	if err != nil {
+		fmt.Printf(tr("Error: %v\n"), err)
+		os.Exit(1)
+	}
+
+	paths, err := archive.Parse(data).Extract(*dir, *force)
+	if err != nil {
+		fmt.Printf(tr("Error: %v\n"), err)
+		os.Exit(1)
+	}
+	for _, p := range paths {
+		fmt.Println(p)
+	}
+}
@@ -0,0 +1,75 @@
+// cmd/saika/binaries.go
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/saika-m/saika-lang/internal/transpiler"
+)
+
+// selectBinaries returns the project the sources belong to and the
+// binaries of it to build: the one named by --bin, or else all of them.
+// The project is nil when it lists no binaries, so the sources form one
+// program.
+func selectBinaries(opts *options, sources []string) (*transpiler.Project, []*transpiler.Binary, error) {
+	var project *transpiler.Project
+	if len(sources) > 0 {
+		var err error
+		if project, err = transpiler.FindProject(filepath.Dir(sources[0])); err != nil {
+			return nil, nil, err
+		}
+	}
+	if project == nil || len(project.Binaries) == 0 {
+		if opts.bin != "" {
+			return nil, nil, fmt.Errorf("--bin needs a %s listing binaries", transpiler.ProjectFile)
+		}
+		return nil, nil, nil
+	}
+	if opts.bin == "" {
+		return project, project.Binaries, nil
+	}
+	b, ok := project.Binary(opts.bin)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s has no binary %s; it has %s", project.Path, opts.bin, binaryNames(project.Binaries))
+	}
+	return project, []*transpiler.Binary{b}, nil
+}
+
+// projectProgram returns the sources of the one program run or flash
+// works on: a binary of the project, which --bin must choose when there
+// are several, or else all the sources
+func projectProgram(opts *options, sources []string) ([]string, error) {
+	project, binaries, err := selectBinaries(opts, sources)
+	if err != nil || project == nil {
+		return sources, err
+	}
+	if len(binaries) > 1 {
+		return nil, fmt.Errorf("%s has binaries %s; choose one with --bin", project.Path, binaryNames(binaries))
+	}
+	return project.Sources(binaries[0], sources)
+}
+
+// binaryNames lists the names of binaries for messages
+func binaryNames(binaries []*transpiler.Binary) string {
+	names := make([]string, len(binaries))
+	for i, b := range binaries {
+		names[i] = b.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// binaryOutputPath resolves where build writes a binary of a project:
+// next to the project file, named after the binary, unless -o says
+// otherwise, with {name} standing for the binary's name
+func binaryOutputPath(opts *options, project *transpiler.Project, b *transpiler.Binary) string {
+	dir := project.Dir()
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, dir); err == nil && !strings.HasPrefix(rel, "..") {
+			dir = rel
+		}
+	}
+	return expandOutput(opts, withExeSuffix(filepath.Join(dir, b.Name), targetGOOS()))
+}
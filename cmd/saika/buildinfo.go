@@ -0,0 +1,61 @@
+// cmd/saika/buildinfo.go
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/saika-m/saika-lang/internal/codegen"
+	"github.com/saika-m/saika-lang/internal/interp"
+)
+
+// version is the version of the saika toolchain
+const version = "0.1.0"
+
+// buildInfo returns the 构建信息 of a program whose first source file is
+// source. The program version and commit come from the git repository
+// holding the source, when there is one.
+func buildInfo(source string) interp.BuildInfo {
+	dir := filepath.Dir(source)
+	return interp.BuildInfo{
+		Version:     gitOutput(dir, "describe", "--tags", "--always", "--dirty"),
+		Commit:      gitOutput(dir, "rev-parse", "HEAD"),
+		Time:        time.Now().UTC().Format(time.RFC3339),
+		ToolVersion: version,
+	}
+}
+
+// buildInfoLDFlags returns the -ldflags value that fills in 构建信息 for a
+// program whose first source file is source
+func buildInfoLDFlags(source string) string {
+	info := buildInfo(source)
+	values := []struct{ name, value string }{
+		{codegen.BuildInfoVersionVar, info.Version},
+		{codegen.BuildInfoCommitVar, info.Commit},
+		{codegen.BuildInfoTimeVar, info.Time},
+		{codegen.BuildInfoToolVersionVar, info.ToolVersion},
+	}
+
+	flags := make([]string, 0, len(values))
+	for _, v := range values {
+		if v.value == "" {
+			// Keep the default from the support file
+			continue
+		}
+		flags = append(flags, fmt.Sprintf("-X 'main.%s=%s'", v.name, v.value))
+	}
+	return strings.Join(flags, " ")
+}
+
+// gitOutput runs git in dir and returns its trimmed output, or "" when git
+// is unavailable or dir is not in a repository
+func gitOutput(dir string, args ...string) string {
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,10 @@
+// cmd/saika/console_other.go
+
+//go:build !windows
+
+package main
+
+// setupConsole is a no-op on platforms whose terminals already use UTF-8
+func setupConsole() func() {
+	return func() {}
+}
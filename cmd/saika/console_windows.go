@@ -0,0 +1,35 @@
+// cmd/saika/console_windows.go
+package main
+
+import "syscall"
+
+// cpUTF8 is the Windows code page identifier for UTF-8
+const cpUTF8 = 65001
+
+var (
+	kernel32               = syscall.NewLazyDLL("kernel32.dll")
+	procGetConsoleOutputCP = kernel32.NewProc("GetConsoleOutputCP")
+	procSetConsoleOutputCP = kernel32.NewProc("SetConsoleOutputCP")
+	procGetConsoleCP       = kernel32.NewProc("GetConsoleCP")
+	procSetConsoleCP       = kernel32.NewProc("SetConsoleCP")
+)
+
+// setupConsole switches the console to UTF-8 so Chinese text printed by
+// saika and by the programs it runs displays correctly on code pages such
+// as 936. The returned function restores the original code pages.
+func setupConsole() func() {
+	outputCP, _, _ := procGetConsoleOutputCP.Call()
+	inputCP, _, _ := procGetConsoleCP.Call()
+	if outputCP == 0 || inputCP == 0 {
+		// Not attached to a console
+		return func() {}
+	}
+
+	procSetConsoleOutputCP.Call(cpUTF8)
+	procSetConsoleCP.Call(cpUTF8)
+
+	return func() {
+		procSetConsoleOutputCP.Call(outputCP)
+		procSetConsoleCP.Call(inputCP)
+	}
+}
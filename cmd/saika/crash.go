@@ -0,0 +1,155 @@
+// cmd/saika/crash.go
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/saika-m/saika-lang/internal/transpiler"
+)
+
+// crashSnippetLines is how many lines of the input a crash report quotes
+const crashSnippetLines = 30
+
+// crashFile is the source file saika was last working on, quoted in a
+// crash report
+var crashFile string
+
+// reportCrash, deferred first thing by main, turns a panic of saika itself
+// into a crash report written to a local file and a short message
+// pointing to it, instead of a Go stack dump. The report is not sent
+// anywhere. Setting SAIKA_NO_CRASH_REPORT lets the panic through.
+func reportCrash() {
+	v := recover()
+	if v == nil {
+		return
+	}
+	if os.Getenv("SAIKA_NO_CRASH_REPORT") != "" {
+		panic(v)
+	}
+
+	stack := debug.Stack()
+	fmt.Fprint(os.Stderr, tr("saika crashed. This is a bug in saika, not in your program.\n"))
+	path, err := writeCrashReport(v, stack)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, tr("The crash report could not be written: %v\n\npanic: %v\n\n%s"), err, v, stack)
+	} else {
+		fmt.Fprintf(os.Stderr, tr("A crash report was written to %s\n"), path)
+		fmt.Fprint(os.Stderr, tr("Please attach it when reporting the problem; it has not been sent anywhere.\n"))
+	}
+	os.Exit(2)
+}
+
+// writeCrashReport writes the report of a panic to a new file in the
+// user's cache directory, or the temporary directory, returning its path
+func writeCrashReport(v any, stack []byte) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	dir = filepath.Join(dir, "saika", "crashes")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	f, err := os.CreateTemp(dir, "crash-"+now.Format("20060102-150405")+"-*.txt")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "saika crash report\n\n")
+	fmt.Fprintf(&b, "version: %s\n", version)
+	fmt.Fprintf(&b, "go:      %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "time:    %s\n", now.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "command: saika %s\n\n", strings.Join(os.Args[1:], " "))
+	fmt.Fprintf(&b, "panic: %v\n\n%s\n", v, stack)
+	if source := crashSource(); source != "" {
+		b.WriteString(crashSnippet(source))
+	}
+
+	if _, err := f.WriteString(b.String()); err != nil {
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// crashSource returns the source file to quote in a crash report: the one
+// saika was working on, or else the first named on the command line
+func crashSource() string {
+	if crashFile != "" {
+		return crashFile
+	}
+	for _, arg := range os.Args[2:] {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		if sources, err := transpiler.CollectSources([]string{arg}); err == nil && len(sources) > 0 {
+			return sources[0]
+		}
+	}
+	return ""
+}
+
+// crashSnippet quotes the first lines of source with the contents of its
+// strings and comments removed, as they are where secrets would be
+func crashSnippet(source string) string {
+	src, err := os.ReadFile(source)
+	if err != nil {
+		return fmt.Sprintf("input: %s (unreadable: %v)\n", source, err)
+	}
+	lines := strings.Split(string(src), "\n")
+	shown := min(len(lines), crashSnippetLines)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "input: %s, lines 1-%d of %d, strings and comments removed\n\n", source, shown, len(lines))
+	inComment := false
+	for i, line := range lines[:shown] {
+		var redacted string
+		redacted, inComment = redactLine(line, inComment)
+		fmt.Fprintf(&b, "%4d  %s\n", i+1, redacted)
+	}
+	return b.String()
+}
+
+// redactLine empties the string literals and comments of a line of Saika;
+// inComment tells whether the line starts inside a /* */ comment, and the
+// result whether the next line does
+func redactLine(line string, inComment bool) (string, bool) {
+	var b strings.Builder
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		switch {
+		case inComment:
+			if runes[i] == '*' && i+1 < len(runes) && runes[i+1] == '/' {
+				b.WriteString("*/")
+				inComment = false
+				i++
+			}
+		case runes[i] == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			b.WriteString("//")
+			return b.String(), false
+		case runes[i] == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			b.WriteString("/*")
+			inComment = true
+			i++
+		case runes[i] == '"':
+			b.WriteString(`""`)
+			for i++; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' {
+					i++
+				}
+			}
+		default:
+			b.WriteRune(runes[i])
+		}
+	}
+	return b.String(), inComment
+}
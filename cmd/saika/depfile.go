@@ -0,0 +1,134 @@
+// cmd/saika/depfile.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/saika-m/saika-lang/internal/transpiler"
+)
+
+// depfileCommand prints, in the depfile format of Make and ninja, the
+// files that saika build would write for the given sources and the files
+// it reads to write them, so that a build system runs it again when one
+// of them changes
+func depfileCommand(t *transpiler.Transpiler, args []string) {
+	opts := &options{command: "build"}
+	fs := flag.NewFlagSet("depfile", flag.ExitOnError)
+	fs.StringVar(&opts.output, "o", "", tr("the `path` passed to saika build -o"))
+	fs.StringVar(&opts.bin, "bin", "", fmt.Sprintf(tr("list the binary `name`d in the project's %s"), transpiler.ProjectFile))
+	fs.StringVar(&opts.tempDir, "emit-temp-dir", "", tr("also list the Go files saika build --emit-temp-dir writes to `dir`"))
+	output := fs.String("depfile", "", tr("write the rules to `file` instead of stdout"))
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, tr("Usage:"), "saika depfile [flags] <file.saika|dir|dir/...>...")
+		printDefaults(fs)
+	}
+	args = parseArgs(fs, args)
+	if len(args) == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	rules, err := depfileRules(t, opts, args)
+	if err != nil {
+		fmt.Printf(tr("Error: %v\n"), err)
+		os.Exit(1)
+	}
+	if *output == "" {
+		fmt.Print(rules)
+		return
+	}
+	if err := os.WriteFile(*output, []byte(rules), 0644); err != nil {
+		fmt.Printf(tr("Error: %v\n"), err)
+		os.Exit(1)
+	}
+}
+
+// depfileRules returns one rule for each program saika build would build
+// from args, with the same outputs and workspace directories as it
+func depfileRules(t *transpiler.Transpiler, opts *options, args []string) (string, error) {
+	sources, err := transpiler.CollectSources(args)
+	if err != nil {
+		return "", err
+	}
+	if len(sources) == 0 {
+		return "", fmt.Errorf("no Saika sources in %v", args)
+	}
+	project, binaries, err := selectBinaries(opts, sources)
+	if err != nil {
+		return "", err
+	}
+	if project == nil {
+		return depfileRule(t, opts.tempDir, sources, outputPath(opts, args))
+	}
+
+	var rules strings.Builder
+	for _, b := range binaries {
+		binarySources, err := project.Sources(b, sources)
+		if err != nil {
+			return "", err
+		}
+		tempDir := opts.tempDir
+		if tempDir != "" && len(binaries) > 1 {
+			tempDir = filepath.Join(tempDir, b.Name)
+		}
+		rule, err := depfileRule(t, tempDir, binarySources, binaryOutputPath(opts, project, b))
+		if err != nil {
+			return "", err
+		}
+		rules.WriteString(rule)
+	}
+	return rules.String(), nil
+}
+
+// depfileRule returns the rule making outputFile, and the Go files in
+// tempDir when it is set, depend on the inputs of the program formed by
+// sources
+func depfileRule(t *transpiler.Transpiler, tempDir string, sources []string, outputFile string) (string, error) {
+	inputs, err := t.Inputs(sources)
+	if err != nil {
+		return "", err
+	}
+	targets := []string{outputFile}
+	if tempDir != "" {
+		for _, name := range transpiler.GoFileNames(sources) {
+			targets = append(targets, filepath.Join(tempDir, name))
+		}
+	}
+
+	var rule strings.Builder
+	for i, target := range targets {
+		if i > 0 {
+			rule.WriteString(" ")
+		}
+		rule.WriteString(depfileEscape(target))
+	}
+	rule.WriteString(":")
+	for _, input := range inputs {
+		rule.WriteString(" \\\n  " + depfileEscape(relativePath(input)))
+	}
+	rule.WriteString("\n")
+	return rule.String(), nil
+}
+
+// relativePath returns path relative to the working directory when it is
+// inside it, as the paths of the outputs are
+func relativePath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil || !filepath.IsAbs(path) {
+		return path
+	}
+	if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
+
+// depfileEscape escapes the characters that Make and ninja read specially
+// in the paths of a depfile
+func depfileEscape(path string) string {
+	return strings.NewReplacer(" ", `\ `, "#", `\#`, "$", "$$").Replace(filepath.ToSlash(path))
+}
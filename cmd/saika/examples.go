@@ -0,0 +1,239 @@
+// cmd/saika/examples.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/saika-m/saika-lang/internal/backend"
+	"github.com/saika-m/saika-lang/internal/transpiler"
+)
+
+// expectedOutputExt is appended to a program's path to name the file
+// holding its expected standard output
+const expectedOutputExt = ".out"
+
+// defaultCorpus is the directory saika examples runs without arguments
+const defaultCorpus = "examples"
+
+// examplesCommand builds and runs every program in a corpus directory and
+// compares their standard output with the expected-output files. Each
+// program is also run with the interpreter, which must print the same.
+func examplesCommand(t *transpiler.Transpiler, args []string) {
+	fs := flag.NewFlagSet("examples", flag.ExitOnError)
+	update := fs.Bool("update", false, tr("write each program's output as its expected output"))
+	interpret := fs.Bool("interp", true, tr("also run each program with the interpreter and compare its output"))
+	verbose := fs.Bool("v", false, tr("print the output of every program"))
+	timeout := fs.Duration("timeout", time.Minute, tr("stop a program after `duration`"))
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, tr("Usage:"), "saika examples [flags] [dir]")
+		printDefaults(fs)
+	}
+	args = parseArgs(fs, args)
+
+	corpus := defaultCorpus
+	switch len(args) {
+	case 0:
+	case 1:
+		corpus = args[0]
+	default:
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	programs, err := corpusPrograms(corpus)
+	if err != nil {
+		fmt.Printf(tr("Error: %v\n"), err)
+		os.Exit(1)
+	}
+
+	failed := 0
+	for _, program := range programs {
+		start := time.Now()
+		output, err := runExample(t, program, *timeout)
+		if err == nil {
+			err = compareOutput(program, output, *update)
+		}
+		if err == nil && *interpret {
+			var interpreted []byte
+			interpreted, err = interpretExample(t, program, *timeout)
+			if err == nil {
+				err = compareOutput(program, interpreted, false)
+			}
+			if err != nil {
+				err = fmt.Errorf("interpreter: %w", err)
+			}
+		}
+
+		elapsed := time.Since(start).Round(10 * time.Millisecond)
+		if err != nil {
+			fmt.Printf("FAIL %s (%s)\n     %s\n", program, elapsed, strings.ReplaceAll(err.Error(), "\n", "\n     "))
+			failed++
+			continue
+		}
+		fmt.Printf("ok   %s (%s)\n", program, elapsed)
+		if *verbose {
+			fmt.Print(string(output))
+		}
+	}
+
+	if failed > 0 {
+		fmt.Printf(tr("%d of %d programs failed\n"), failed, len(programs))
+		os.Exit(1)
+	}
+}
+
+// corpusPrograms lists the programs in a corpus: every Saika file directly
+// inside it, and every subdirectory containing Saika files, which is built
+// as one multi-file program
+func corpusPrograms(corpus string) ([]string, error) {
+	entries, err := os.ReadDir(corpus)
+	if err != nil {
+		return nil, err
+	}
+
+	var programs []string
+	for _, entry := range entries {
+		path := filepath.Join(corpus, entry.Name())
+		if entry.IsDir() {
+			if sources, err := transpiler.CollectSources([]string{path}); err == nil && len(sources) > 0 {
+				programs = append(programs, path)
+			}
+			continue
+		}
+		if filepath.Ext(entry.Name()) == transpiler.SourceExt {
+			programs = append(programs, path)
+		}
+	}
+
+	if len(programs) == 0 {
+		return nil, fmt.Errorf("no Saika programs in %s", corpus)
+	}
+	sort.Strings(programs)
+	return programs, nil
+}
+
+// runExample builds a program in a temporary workspace, runs it and
+// returns its standard output
+func runExample(t *transpiler.Transpiler, program string, timeout time.Duration) ([]byte, error) {
+	sources, err := transpiler.CollectSources([]string{program})
+	if err != nil {
+		return nil, err
+	}
+	results, err := t.TranspileProject(sources)
+	if err != nil {
+		return nil, err
+	}
+
+	ws := &workspace{ldflags: ldflagsFor(results, sources)}
+	ws.dir, ws.goFiles, err = t.CreateTempGoPackage(results)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(ws.dir)
+
+	binary := programPath(ws.dir)
+	if err := compile(ws, binary, false); err != nil {
+		return nil, fmt.Errorf("compiling: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, binary)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("program exceeded the time limit of %s", timeout)
+		}
+		return nil, fmt.Errorf("running: %v", err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// interpretExample runs a program with the interpreter and returns its
+// standard output
+func interpretExample(t *transpiler.Transpiler, program string, timeout time.Duration) ([]byte, error) {
+	sources, err := transpiler.CollectSources([]string{program})
+	if err != nil {
+		return nil, err
+	}
+	programs, err := t.LowerProject(context.Background(), sources)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]backend.File, len(programs))
+	for i, p := range programs {
+		files[i] = backend.File{Path: sources[i], Program: p}
+	}
+
+	var stdout bytes.Buffer
+	config := backend.RunConfig{
+		Stdout:    &stdout,
+		Args:      []string{"saika-program"},
+		StaticDir: transpiler.StaticDir(sources),
+	}
+	if usesBuildInfo(programs) {
+		config.BuildInfo = buildInfo(sources[0])
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := (backend.Interp{}).Run(ctx, files, config); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("program exceeded the time limit of %s", timeout)
+		}
+		return nil, fmt.Errorf("running: %v", err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// compareOutput compares a program's output with its expected-output
+// file, or writes the file when update is set
+func compareOutput(program string, output []byte, update bool) error {
+	expectedPath := program + expectedOutputExt
+	if update {
+		return os.WriteFile(expectedPath, output, 0644)
+	}
+
+	expected, err := os.ReadFile(expectedPath)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("no expected output %s; run with --update to create it", expectedPath)
+	}
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(expected, output) {
+		return nil
+	}
+	return fmt.Errorf("output differs from %s\n%s", expectedPath, outputDifference(string(expected), string(output)))
+}
+
+// outputDifference describes the first line where the output differs
+func outputDifference(expected, actual string) string {
+	want, got := strings.Split(expected, "\n"), strings.Split(actual, "\n")
+	for i := 0; i < len(want) || i < len(got); i++ {
+		var w, g string
+		if i < len(want) {
+			w = want[i]
+		}
+		if i < len(got) {
+			g = got[i]
+		}
+		if w != g {
+			return fmt.Sprintf("line %d:\n  want: %q\n  got:  %q", i+1, w, g)
+		}
+	}
+	return "outputs differ only in line endings"
+}
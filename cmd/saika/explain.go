@@ -0,0 +1,31 @@
+// cmd/saika/explain.go
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/saika-m/saika-lang/internal/diagnostic"
+)
+
+// explainCommand prints the detailed explanation of a diagnostic code, or
+// lists every code when none is given
+func explainCommand(args []string) {
+	if len(args) == 0 {
+		for _, code := range diagnostic.Codes() {
+			entry, _ := diagnostic.Lookup(code)
+			fmt.Printf("%s  %s\n", entry.Code, entry.Title)
+		}
+		return
+	}
+
+	code := diagnostic.Code(strings.ToUpper(args[0]))
+	entry, ok := diagnostic.Lookup(code)
+	if !ok {
+		fmt.Printf(tr("Error: unknown diagnostic code %s; run saika explain to list all codes\n"), args[0])
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s: %s\n\n%s", entry.Code, entry.Title, entry.Explanation)
+}
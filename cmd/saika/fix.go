@@ -0,0 +1,153 @@
+// cmd/saika/fix.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/saika-m/saika-lang/internal/diagnostic"
+	"github.com/saika-m/saika-lang/internal/transpiler"
+	"github.com/saika-m/saika-lang/internal/vet"
+)
+
+// maxFixPasses bounds how often fix re-checks a file; each pass applies
+// the fixes that did not overlap fixes applied earlier in it
+const maxFixPasses = 10
+
+// fixCommand lists the machine-applicable fixes for the errors and vet
+// warnings of the given sources, or applies them with --apply
+func fixCommand(t *transpiler.Transpiler, args []string) {
+	fs := flag.NewFlagSet("fix", flag.ExitOnError)
+	apply := fs.Bool("apply", false, tr("rewrite the source files with the suggested fixes"))
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, tr("Usage:"), "saika fix [--apply] <file.saika|dir|dir/...>...")
+		printDefaults(fs)
+	}
+	args = parseArgs(fs, args)
+	if len(args) == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	sources, err := transpiler.CollectSources(args)
+	if err != nil {
+		fmt.Printf(tr("Error: %v\n"), err)
+		os.Exit(1)
+	}
+
+	unfixed := false
+	for _, source := range sources {
+		project, err := transpiler.FindProject(filepath.Dir(source))
+		if err != nil {
+			fmt.Printf(tr("Error: %v\n"), err)
+			os.Exit(1)
+		}
+		var naming vet.Naming
+		t.Language = ""
+		if project != nil {
+			naming, t.Language = project.Naming, project.Language
+		}
+		var remaining diagnostic.List
+		if *apply {
+			remaining, err = applyFixes(t, source, naming)
+		} else {
+			remaining, err = listFixes(t, source, naming)
+		}
+		if err != nil {
+			fmt.Printf(tr("Error: %s: %v\n"), source, err)
+			os.Exit(1)
+		}
+		if reportUnfixed(source, remaining) {
+			unfixed = true
+		}
+	}
+	if unfixed {
+		os.Exit(1)
+	}
+}
+
+// listFixes prints each diagnostic in source that has a fix. When there
+// are none, it returns the diagnostics of source; otherwise the errors
+// without a fix may only follow from those with one, as after a
+// misspelled keyword, and it returns none.
+func listFixes(t *transpiler.Transpiler, source string, naming vet.Naming) (diagnostic.List, error) {
+	src, err := os.ReadFile(source)
+	if err != nil {
+		return nil, err
+	}
+
+	diags := diagnose(t, string(src), naming)
+	listed := false
+	for _, d := range diags {
+		if len(d.Fixes) == 0 {
+			continue
+		}
+		fmt.Printf("%s:%d:%d: [%s] %s\n", source, d.Range.Start.Line, d.Range.Start.Column, d.Code, d.Message)
+		for _, fix := range d.Fixes {
+			fmt.Printf(tr("    fix: %s\n"), fix.Title)
+		}
+		listed = true
+	}
+	if listed {
+		return nil, nil
+	}
+	return diags, nil
+}
+
+// reportUnfixed prints the errors in diags that have no fix, so that a
+// file fix cannot help with is not passed over in silence, and reports
+// whether there were any
+func reportUnfixed(source string, diags diagnostic.List) bool {
+	found := false
+	for _, d := range diags {
+		if d.Severity != diagnostic.Error || len(d.Fixes) > 0 {
+			continue
+		}
+		if !found {
+			fmt.Printf(tr("%s: no fix for these errors:\n"), source)
+			found = true
+		}
+		fmt.Printf("%s:%d:%d: [%s] %s\n", source, d.Range.Start.Line, d.Range.Start.Column, d.Code, d.Message)
+	}
+	return found
+}
+
+// applyFixes rewrites source with the fixes for its diagnostics, checking
+// again after each pass until nothing more can be fixed, and returns the
+// diagnostics left
+func applyFixes(t *transpiler.Transpiler, source string, naming vet.Naming) (diagnostic.List, error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, err
+	}
+	src, err := os.ReadFile(source)
+	if err != nil {
+		return nil, err
+	}
+
+	total := 0
+	var diags diagnostic.List
+	for pass := 0; pass < maxFixPasses; pass++ {
+		diags = diagnose(t, string(src), naming)
+		fixed, applied, err := diagnostic.ApplyFixes(src, diags)
+		if err != nil {
+			return nil, err
+		}
+		if applied == 0 {
+			break
+		}
+		src = fixed
+		total += applied
+	}
+
+	if total == 0 {
+		return diags, nil
+	}
+	if err := os.WriteFile(source, src, info.Mode().Perm()); err != nil {
+		return nil, err
+	}
+	fmt.Printf(tr("%s: applied %d fix(es)\n"), source, total)
+	return diagnose(t, string(src), naming), nil
+}
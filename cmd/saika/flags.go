@@ -0,0 +1,242 @@
+// cmd/saika/flags.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/saika-m/saika-lang/internal/backend"
+	"github.com/saika-m/saika-lang/internal/buildcache"
+	"github.com/saika-m/saika-lang/internal/transpiler"
+)
+
+// options holds the command-line flags shared by build and run
+type options struct {
+	command  string // build or run
+	keepTemp bool   // keep the generated Go workspace after the command finishes
+	tempDir  string // write the generated Go workspace to this directory
+	dryRun   bool   // print the planned actions without executing them
+	quiet    bool   // suppress informational messages
+	progress string // progress output format: text or json
+	force    bool   // overwrite existing files the command would otherwise refuse to replace
+	verify   bool   // transpile twice and fail if the generated code differs
+	prune    bool   // leave out the functions the program can never call
+	pool     bool   // declare the strings used more than once as constants
+	readable bool   // comment the generated Go with the Saika it came from
+	bin      string // the binary of the project to work on
+
+	remoteCache string             // URL of the remote build cache, if any
+	remote      *buildcache.Remote // the remote cache, set by validate
+
+	backendName string          // name of the backend to use
+	backend     backend.Backend // the backend, set by validate
+
+	// build only
+	output string // output path, possibly a template such as {name}-{goos}-{goarch}
+
+	// build and flash
+	target string // board or platform to compile for, for backends with their own compiler
+
+	// flash only
+	port string // serial port of the device to flash
+
+	// run only
+	timeout     time.Duration // kill the program after this long
+	memoryLimit string        // memory limit such as 256MiB
+	memoryBytes int64         // memoryLimit parsed into bytes
+	sandbox     bool          // run without network access, confined to the workspace
+	interp      bool          // shorthand for the interp backend
+	hot         bool          // rebuild and restart the program when a source changes
+	hotProxy    string        // listen=upstream address pair relayed to the program across restarts
+	programArgs []string      // arguments after "--", passed to the program
+}
+
+// newFlagSet creates the flag set for a command, registering its options
+func newFlagSet(command string, opts *options) *flag.FlagSet {
+	fs := flag.NewFlagSet(command, flag.ExitOnError)
+	fs.BoolVar(&opts.keepTemp, "keep-temp", false, tr("keep the generated Go workspace and print its location"))
+	fs.StringVar(&opts.tempDir, "emit-temp-dir", "", tr("write the generated Go workspace to `dir` and keep it"))
+	fs.BoolVar(&opts.dryRun, "dry-run", false, tr("print the files and commands that would be used without running them"))
+	fs.BoolVar(&opts.quiet, "q", false, tr("suppress informational messages"))
+	fs.StringVar(&opts.progress, "progress", "text", tr("progress output `format`: text or json"))
+	fs.BoolVar(&opts.force, "force", false, tr("overwrite existing files in the output or workspace directory"))
+	fs.BoolVar(&opts.verify, "verify", false, tr("transpile twice and fail if the generated code differs"))
+	fs.BoolVar(&opts.prune, "prune", false, tr("leave out the private functions that 入口 can never reach"))
+	fs.BoolVar(&opts.pool, "pool-strings", false, tr("declare the string literals a file uses more than once as constants of the generated Go"))
+	fs.BoolVar(&opts.readable, "readable", false, tr("comment each statement of the generated Go with the Saika construct it came from"))
+	defaultBackend := backend.Default
+	if command == "flash" {
+		defaultBackend = "tinygo"
+	}
+	fs.StringVar(&opts.backendName, "backend", defaultBackend, fmt.Sprintf(tr("generate or run the program with the `name`d backend: %s"), strings.Join(backend.Names(), ", ")))
+	fs.StringVar(&opts.bin, "bin", "", fmt.Sprintf(tr("work on the binary `name`d in the project's %s"), transpiler.ProjectFile))
+	fs.StringVar(&opts.remoteCache, "remote-cache", os.Getenv(remoteCacheEnv), fmt.Sprintf(tr("share transpiled files and executables through the HTTP cache at `url`; defaults to $%s"), remoteCacheEnv))
+	if command == "build" {
+		fs.StringVar(&opts.output, "o", "", tr("write the executable to `path`; may use {name}, {goos}, {goarch} and {ext}"))
+	}
+	if command == "build" || command == "flash" {
+		fs.StringVar(&opts.target, "target", "", tr("compile for the `board` or platform, e.g. pico; needs --backend=tinygo"))
+	}
+	if command == "flash" {
+		fs.StringVar(&opts.port, "port", "", tr("flash the device at serial `port` instead of the one found automatically"))
+	}
+	if command == "run" {
+		fs.DurationVar(&opts.timeout, "timeout", 0, tr("stop the program after `duration`, e.g. 10s"))
+		fs.StringVar(&opts.memoryLimit, "memory-limit", "", tr("stop the program when it uses more than `size`, e.g. 256MiB"))
+		fs.BoolVar(&opts.sandbox, "sandbox", false, tr("run the program without network access, confined to its workspace"))
+		fs.BoolVar(&opts.interp, "interp", false, tr("interpret the program instead of compiling it; same as --backend=interp"))
+		fs.BoolVar(&opts.hot, "hot", false, tr("rebuild and restart the program whenever a source changes"))
+		fs.StringVar(&opts.hotProxy, "hot-proxy", "", tr("with --hot, keep listening on the first address of `listen=upstream` and relay connections to the program at the second, e.g. :8080=:8081"))
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "%s saika %s [flags] <file.saika|dir|dir/...>...\n", tr("Usage:"), command)
+		printDefaults(fs)
+	}
+	return fs
+}
+
+// parseArgs parses flags that may appear before or after positional
+// arguments and returns the positional arguments. Everything after a
+// "--" terminator is treated as positional.
+func parseArgs(fs *flag.FlagSet, args []string) []string {
+	positional := []string{}
+
+	for {
+		fs.Parse(args)
+		rest := fs.Args()
+
+		consumed := len(args) - len(rest)
+		if consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...)
+		}
+		if len(rest) == 0 {
+			return positional
+		}
+
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// splitProgramArgs splits the arguments of saika run at the first "--"
+// into its own arguments and those of the program
+func splitProgramArgs(args []string) ([]string, []string) {
+	for i, arg := range args {
+		if arg == "--" {
+			return args[:i], args[i+1:]
+		}
+	}
+	return args, nil
+}
+
+// validate checks option values that the flag package cannot
+func (opts *options) validate() error {
+	if opts.progress != "text" && opts.progress != "json" {
+		return fmt.Errorf("invalid --progress value %q, expected text or json", opts.progress)
+	}
+	if err := checkOutputTemplate(opts.output); err != nil {
+		return err
+	}
+	if opts.memoryLimit != "" {
+		bytes, err := parseSize(opts.memoryLimit)
+		if err != nil {
+			return fmt.Errorf("invalid --memory-limit value: %v", err)
+		}
+		opts.memoryBytes = bytes
+	}
+	if err := opts.validateHot(); err != nil {
+		return err
+	}
+	if opts.interp {
+		if opts.backendName != backend.Default && opts.backendName != "interp" {
+			return fmt.Errorf("--interp cannot be used with --backend=%s", opts.backendName)
+		}
+		opts.backendName = "interp"
+	}
+	if opts.remoteCache != "" {
+		remote, err := buildcache.NewRemote(opts.remoteCache)
+		if err != nil {
+			return fmt.Errorf("invalid --remote-cache value: %v", err)
+		}
+		opts.remote = remote
+	}
+	b, err := backend.New(opts.backendName)
+	if err != nil {
+		return fmt.Errorf("invalid --backend value: %v", err)
+	}
+	opts.backend = b
+
+	if _, ok := b.(backend.Compiler); !ok && opts.target != "" {
+		return fmt.Errorf("--target cannot be used with the %s backend", b.Name())
+	}
+	if opts.command == "flash" {
+		if _, ok := b.(backend.Flasher); !ok {
+			return fmt.Errorf("the %s backend cannot flash devices", b.Name())
+		}
+		if opts.target == "" {
+			return fmt.Errorf("flash needs a --target board, e.g. --target=pico")
+		}
+	}
+
+	if _, ok := b.(backend.Runner); ok {
+		if opts.command == "build" {
+			return fmt.Errorf("the %s backend runs programs and cannot build executables", b.Name())
+		}
+		// These flags concern the Go workspace or the program's process,
+		// which a program run by the backend has neither of
+		incompatible := []struct {
+			name string
+			set  bool
+		}{
+			{"--keep-temp", opts.keepTemp},
+			{"--emit-temp-dir", opts.tempDir != ""},
+			{"--verify", opts.verify},
+			{"--memory-limit", opts.memoryLimit != ""},
+			{"--sandbox", opts.sandbox},
+			{"--hot", opts.hot},
+			{"--readable", opts.readable},
+		}
+		for _, f := range incompatible {
+			if f.set {
+				return fmt.Errorf("%s cannot be used with the %s backend", f.name, b.Name())
+			}
+		}
+	}
+	return nil
+}
+
+// validateHot checks the flags of saika run --hot. The program is started
+// again on every change, so the limits of a single run do not apply.
+func (opts *options) validateHot() error {
+	if opts.hotProxy != "" {
+		if !opts.hot {
+			return fmt.Errorf("--hot-proxy needs --hot")
+		}
+		listen, upstream, ok := strings.Cut(opts.hotProxy, "=")
+		if !ok || listen == "" || upstream == "" {
+			return fmt.Errorf("invalid --hot-proxy value %q, expected listen=upstream such as :8080=:8081", opts.hotProxy)
+		}
+	}
+	if !opts.hot {
+		return nil
+	}
+	incompatible := []struct {
+		name string
+		set  bool
+	}{
+		{"--timeout", opts.timeout > 0},
+		{"--memory-limit", opts.memoryLimit != ""},
+		{"--sandbox", opts.sandbox},
+		{"--verify", opts.verify},
+		{"--dry-run", opts.dryRun},
+	}
+	for _, f := range incompatible {
+		if f.set {
+			return fmt.Errorf("%s cannot be used with --hot", f.name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+// cmd/saika/flash.go
+package main
+
+import (
+	"os"
+	"os/exec"
+
+	"github.com/saika-m/saika-lang/internal/backend"
+	"github.com/saika-m/saika-lang/internal/transpiler"
+)
+
+// flashCommand compiles a program for a microcontroller and writes it to
+// the attached device
+func flashCommand(t *transpiler.Transpiler, opts *options, pr *progress, args []string) {
+	flasher := opts.backend.(backend.Flasher)
+	sources := programSources(opts, pr, collectSources(pr, args))
+	checkCollisions(opts, pr, sources, "")
+
+	if opts.dryRun {
+		ws := plannedWorkspace(opts, sources)
+		printPlan(sources, ws, flasher.FlashArgs(opts.target, opts.port, ws.ldflags, ws.goFiles))
+		return
+	}
+
+	ws := transpileToWorkspace(t, opts, pr, sources)
+
+	pr.started(phaseCompile, "")
+	flashArgs := flasher.FlashArgs(opts.target, opts.port, ws.ldflags, ws.goFiles)
+	cmd := exec.Command(flashArgs[0], flashArgs[1:]...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		ws.exit(phaseCompile, err, "Error flashing device")
+	}
+
+	pr.finished(phaseCompile, "", "")
+	pr.infof("Successfully flashed %s\n", opts.target)
+	ws.cleanup()
+}
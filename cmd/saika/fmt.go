@@ -0,0 +1,140 @@
+// cmd/saika/fmt.go
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/saika-m/saika-lang/internal/transpiler"
+)
+
+// fmtDaemonHelp describes the protocol of saika fmt --daemon
+const fmtDaemonHelp = `
+With --daemon, each request on stdin is a JSON object such as
+  {"id": 1, "source": "包 main ..."}
+and each is answered, in order, with one line of JSON on stdout:
+  {"id": 1, "source": "包 main\n..."}   the formatted source
+  {"id": 1, "error": "parser errors: ..."}   when the source does not parse
+The id, which may be any JSON value, is passed back as it was sent.`
+
+// fmtCommand prints the given sources formatted, rewrites them with
+// --write, or serves format requests on stdin with --daemon
+func fmtCommand(t *transpiler.Transpiler, args []string) {
+	fs := flag.NewFlagSet("fmt", flag.ExitOnError)
+	write := fs.Bool("write", false, tr("rewrite the source files instead of printing them"))
+	daemon := fs.Bool("daemon", false, tr("format the buffers sent on stdin until it closes; see saika fmt --help"))
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, tr("Usage:"), "saika fmt [--write] <file.saika|dir|dir/...>...")
+		fmt.Fprintln(os.Stderr, "       saika fmt --daemon")
+		printDefaults(fs)
+		fmt.Fprintln(os.Stderr, tr(fmtDaemonHelp))
+	}
+	args = parseArgs(fs, args)
+
+	if *daemon {
+		if err := fmtDaemon(t, os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, tr("Error: %v\n"), err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(args) == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	sources, err := transpiler.CollectSources(args)
+	if err != nil {
+		fmt.Printf(tr("Error: %v\n"), err)
+		os.Exit(1)
+	}
+
+	failed := false
+	for _, source := range sources {
+		if err := formatFile(t, source, *write); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", source, err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// formatFile prints source formatted, or rewrites it if it changes
+func formatFile(t *transpiler.Transpiler, source string, write bool) error {
+	info, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+	src, err := os.ReadFile(source)
+	if err != nil {
+		return err
+	}
+	formatted, err := t.Format(string(src))
+	if err != nil {
+		return err
+	}
+
+	if !write {
+		fmt.Print(formatted)
+		return nil
+	}
+	if formatted == string(src) {
+		return nil
+	}
+	if err := os.WriteFile(source, []byte(formatted), info.Mode().Perm()); err != nil {
+		return err
+	}
+	fmt.Printf(tr("%s: formatted\n"), source)
+	return nil
+}
+
+// fmtRequest is a buffer sent to saika fmt --daemon
+type fmtRequest struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Source string          `json:"source"`
+}
+
+// fmtResponse answers a fmtRequest with the formatted buffer or an error
+type fmtResponse struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Source string          `json:"source,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// fmtDaemon answers the format requests read from r on w, one line each,
+// until r ends. A single process serves all of an editor's saves, so each
+// costs no process startup.
+func fmtDaemon(t *transpiler.Transpiler, r io.Reader, w io.Writer) error {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	out := bufio.NewWriter(w)
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	for {
+		var req fmtRequest
+		if err := dec.Decode(&req); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("bad request: %v", err)
+		}
+
+		resp := fmtResponse{ID: req.ID}
+		if formatted, err := t.Format(req.Source); err != nil {
+			resp.Error = err.Error()
+		} else {
+			resp.Source = formatted
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+		if err := out.Flush(); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// cmd/saika/grep.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/saika-m/saika-lang/internal/transpiler"
+)
+
+// grepCommand prints the declarations and uses of a symbol across the
+// given sources. Names are resolved like the checker resolves them, so
+// only identifiers that refer to a declaration of the symbol match, not
+// text in strings or members of other packages.
+func grepCommand(t *transpiler.Transpiler, args []string) {
+	fs := flag.NewFlagSet("grep", flag.ExitOnError)
+	symbol := fs.String("symbol", "", tr("print the declarations and uses of the symbol `name`"))
+	declarations := fs.Bool("declarations", false, tr("print only the declarations"))
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, tr("Usage:"), "saika grep --symbol <name> [--declarations] <file.saika|dir|dir/...>...")
+		printDefaults(fs)
+	}
+	args = parseArgs(fs, args)
+	if len(args) == 0 || *symbol == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	sources, err := transpiler.CollectSources(args)
+	if err != nil {
+		fmt.Printf(tr("Error: %v\n"), err)
+		os.Exit(1)
+	}
+	refs, err := t.References(sources)
+	if err != nil {
+		fmt.Printf(tr("Error: %v\n"), err)
+		os.Exit(1)
+	}
+
+	lines := map[int][]string{} // source lines of each file, read on demand
+	found := false
+	for _, ref := range refs {
+		isDeclaration := ref.Ident == ref.Declaration
+		if ref.Declaration.Value != *symbol || (*declarations && !isDeclaration) {
+			continue
+		}
+		found = true
+
+		if _, ok := lines[ref.File]; !ok {
+			src, _ := os.ReadFile(sources[ref.File])
+			lines[ref.File] = strings.Split(string(src), "\n")
+		}
+		text := ""
+		if n := ref.Ident.Token.Line; n <= len(lines[ref.File]) {
+			text = strings.TrimSpace(lines[ref.File][n-1])
+		}
+		kind := "use"
+		if isDeclaration {
+			kind = "declaration"
+		}
+		fmt.Printf("%s:%d:%d: %s: %s\n", sources[ref.File], ref.Ident.Token.Line, ref.Ident.Token.Column, kind, text)
+	}
+	if !found {
+		os.Exit(1)
+	}
+}
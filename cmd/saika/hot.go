@@ -0,0 +1,257 @@
+// cmd/saika/hot.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/saika-m/saika-lang/internal/transpiler"
+)
+
+const (
+	// hotPollInterval is how often saika run --hot looks for changed sources
+	hotPollInterval = 300 * time.Millisecond
+
+	// hotStopTimeout is how long a program may take to shut down before
+	// it is killed to make way for the new build
+	hotStopTimeout = 5 * time.Second
+
+	// hotDialTimeout is how long the proxy waits for the program to listen,
+	// which covers a restart
+	hotDialTimeout = 30 * time.Second
+)
+
+// hotRunCommand runs the program, and rebuilds and restarts it whenever
+// one of its sources changes until saika is interrupted. A build that
+// fails leaves the previous program running.
+func hotRunCommand(t *transpiler.Transpiler, opts *options, pr *progress, args []string) {
+	ws := &workspace{dir: opts.tempDir, keep: opts.keepTemp || opts.tempDir != "", progress: pr}
+	ws.compiler, ws.target = workspaceCompiler(opts)
+	if ws.dir == "" {
+		dir, err := os.MkdirTemp("", "saika-temp")
+		if err != nil {
+			pr.exit(phaseTranspile, "", err, "Error")
+		}
+		ws.dir = dir
+	}
+
+	if opts.hotProxy != "" {
+		listen, upstream, _ := strings.Cut(opts.hotProxy, "=")
+		proxy, err := startHotProxy(listen, upstream)
+		if err != nil {
+			ws.exit(phaseRun, err, "Error")
+		}
+		defer proxy.Close()
+		pr.infof("Proxying %s to %s\n", proxy.Addr(), upstream)
+	}
+
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, forwardedSignals...)
+	defer signal.Stop(interrupts)
+
+	ticker := time.NewTicker(hotPollInterval)
+	defer ticker.Stop()
+
+	var program *hotProgram
+	stamp, generation := "", 0
+	for {
+		if s := sourceStamp(t, args); s != stamp {
+			if stamp != "" {
+				pr.infof("Sources changed; rebuilding\n")
+			}
+			stamp = s
+			generation++
+			binary, err := hotBuild(t, opts, ws, args, generation)
+			switch {
+			case err != nil:
+				pr.failed(phaseCompile, "", err, "Error building program")
+				pr.infof("Waiting for changes\n")
+			default:
+				if program != nil {
+					program.stop()
+					os.Remove(program.binary)
+				}
+				if program, err = startHotProgram(opts, binary); err != nil {
+					pr.failed(phaseRun, "", err, "Error running file")
+				}
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-program.exited():
+			if program.err != nil {
+				pr.failed(phaseRun, "", program.err, "Program exited")
+			} else {
+				pr.infof("Program exited\n")
+			}
+			pr.infof("Waiting for changes\n")
+			program = nil
+		case <-interrupts:
+			if program != nil {
+				program.stop()
+			}
+			ws.cleanup()
+			return
+		}
+	}
+}
+
+// sourceStamp describes the sources named by args, the files of their
+// static directory, which 静态文件 embeds, and the template files they
+// embed, with their sizes and modification times, so that any edit, new
+// file or removal changes it
+func sourceStamp(t *transpiler.Transpiler, args []string) string {
+	sources, err := transpiler.CollectSources(args)
+	if err != nil {
+		return err.Error()
+	}
+	static, err := transpiler.StaticFiles(sources)
+	if err != nil {
+		return err.Error()
+	}
+	// A source that does not parse has no templates until it is fixed;
+	// the build reports the error
+	templates, _ := t.TemplateFiles(sources)
+	var b strings.Builder
+	for _, source := range slices.Concat(sources, static, templates) {
+		if info, err := os.Stat(source); err == nil {
+			fmt.Fprintf(&b, "%s %d %d\n", source, info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	return b.String()
+}
+
+// hotBuild transpiles and compiles the sources into the workspace. Each
+// build gets its own executable, as the previous one may still be running.
+func hotBuild(t *transpiler.Transpiler, opts *options, ws *workspace, args []string, generation int) (string, error) {
+	sources, err := transpiler.CollectSources(args)
+	if err == nil {
+		sources, err = projectProgram(opts, sources)
+	}
+	if err != nil {
+		return "", err
+	}
+	results, goFiles, err := t.TranspileProjectTo(context.Background(), ws.dir, sources)
+	if err != nil {
+		return "", err
+	}
+	ws.goFiles = goFiles
+	ws.ldflags = ldflagsFor(results, sources)
+	if ws.module, err = t.WriteModules(context.Background(), ws.dir, sources); err != nil {
+		return "", err
+	}
+
+	binary := withExeSuffix(filepath.Join(ws.dir, fmt.Sprintf("saika-program-%d", generation)), runtime.GOOS)
+	if err := compile(ws, binary, false); err != nil {
+		return "", err
+	}
+	return binary, nil
+}
+
+// hotProgram is one running build of the program
+type hotProgram struct {
+	binary string
+	cmd    *exec.Cmd
+	done   chan struct{} // closed once the program has exited
+	err    error         // how the program exited, set before done is closed
+}
+
+// startHotProgram starts binary in its own process group, so that a
+// restart stops any processes it started as well
+func startHotProgram(opts *options, binary string) (*hotProgram, error) {
+	cmd := exec.Command(binary, opts.programArgs...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+	isolateProcessGroup(cmd, false)
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	p := &hotProgram{binary: binary, cmd: cmd, done: make(chan struct{})}
+	go func() {
+		p.err = cmd.Wait()
+		close(p.done)
+	}()
+	return p, nil
+}
+
+// exited returns a channel that is closed once the program has exited; it
+// blocks forever when there is no program
+func (p *hotProgram) exited() <-chan struct{} {
+	if p == nil {
+		return nil
+	}
+	return p.done
+}
+
+// stop asks the program to shut down, which runs its 捕获信号 handlers, and
+// kills it if it is still running after hotStopTimeout
+func (p *hotProgram) stop() {
+	signalProcessGroup(p.cmd.Process, terminateSignal)
+	select {
+	case <-p.done:
+	case <-time.After(hotStopTimeout):
+		signalProcessGroup(p.cmd.Process, os.Kill)
+		<-p.done
+	}
+}
+
+// startHotProxy listens on listen and relays each connection to upstream,
+// where the program listens. The listening socket outlives restarts of
+// the program: a connection made while it restarts waits until the new
+// build listens.
+func startHotProxy(listen, upstream string) (net.Listener, error) {
+	l, err := net.Listen("tcp", listen)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go relay(conn, upstream)
+		}
+	}()
+	return l, nil
+}
+
+// relay copies data both ways between client and the program at upstream
+func relay(client net.Conn, upstream string) {
+	defer client.Close()
+
+	deadline := time.Now().Add(hotDialTimeout)
+	var server net.Conn
+	for {
+		var err error
+		if server, err = net.Dial("tcp", upstream); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	defer server.Close()
+
+	go func() {
+		io.Copy(server, client)
+		if tcp, ok := server.(*net.TCPConn); ok {
+			tcp.CloseWrite()
+		}
+	}()
+	io.Copy(client, server)
+}
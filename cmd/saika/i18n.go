@@ -0,0 +1,249 @@
+// cmd/saika/i18n.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// lang is the language of saika's own messages: "en", or "zh" for Chinese.
+// Diagnostics are not affected; their explanations carry both languages.
+var lang = detectLang()
+
+// detectLang chooses the message language from the locale environment,
+// as the C library does: the first of LC_ALL, LC_MESSAGES and LANG that is
+// set decides, and a Chinese locale such as zh_CN.UTF-8 selects Chinese
+func detectLang() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			if strings.HasPrefix(value, "zh") {
+				return "zh"
+			}
+			return "en"
+		}
+	}
+	return "en"
+}
+
+// parseLang removes a leading --lang=<zh|en> or --lang <zh|en> from args,
+// which comes before the command, and sets lang from it
+func parseLang(args []string) ([]string, error) {
+	if len(args) == 0 {
+		return args, nil
+	}
+	var value string
+	switch {
+	case strings.HasPrefix(args[0], "--lang="):
+		value, args = strings.TrimPrefix(args[0], "--lang="), args[1:]
+	case args[0] == "--lang" && len(args) > 1:
+		value, args = args[1], args[2:]
+	default:
+		return args, nil
+	}
+	switch value {
+	case "zh", "en":
+		lang = value
+		return args, nil
+	}
+	return args, fmt.Errorf("invalid --lang value %q, expected zh or en", value)
+}
+
+// tr returns the translation of an English message, or format string, into
+// the current language. Messages without a translation are left in English.
+func tr(msg string) string {
+	if lang == "zh" {
+		if translated, ok := zhMessages[msg]; ok {
+			return translated
+		}
+	}
+	return msg
+}
+
+// printDefaults prints the flags of fs like fs.PrintDefaults, with the
+// names of their value types and defaults in the current language; their
+// usage is translated when they are defined
+func printDefaults(fs *flag.FlagSet) {
+	fs.VisitAll(func(f *flag.Flag) {
+		var b strings.Builder
+		fmt.Fprintf(&b, "  -%s", f.Name)
+		name, usage := flag.UnquoteUsage(f)
+		if name != "" {
+			b.WriteString(" " + tr(name))
+		}
+		// Single-letter boolean flags fit on the line of their usage
+		if b.Len() <= 4 {
+			b.WriteString("\t")
+		} else {
+			b.WriteString("\n    \t")
+		}
+		b.WriteString(strings.ReplaceAll(usage, "\n", "\n    \t"))
+
+		if def := f.DefValue; def != "" && def != "false" && def != "0" && def != "0s" {
+			if getter, ok := f.Value.(flag.Getter); ok {
+				if _, ok := getter.Get().(string); ok {
+					def = fmt.Sprintf("%q", def)
+				}
+			}
+			fmt.Fprintf(&b, tr(" (default %s)"), def)
+		}
+		fmt.Fprintln(fs.Output(), b.String())
+	})
+}
+
+// zhMessages holds the Chinese translations of saika's messages, keyed by
+// the English text passed to tr
+var zhMessages = map[string]string{
+	// Usage
+	"Usage:":                               "用法：",
+	"Flags:":                               "选项：",
+	"Compile Saika files to an executable": "将 Saika 文件编译为可执行文件",
+	"Run Saika files as one program":       "将 Saika 文件作为一个程序运行",
+	"Compile with TinyGo and flash a microcontroller":                                    "用 TinyGo 编译并烧录到微控制器",
+	"List or apply suggested fixes":                                                      "列出或应用建议的修复",
+	"Report likely mistakes, such as 推迟 in a loop":                                       "报告可能的错误，例如在循环中使用 推迟",
+	"Format Saika files; --daemon serves editors on stdin":                               "格式化 Saika 文件；--daemon 通过标准输入为编辑器服务",
+	"Run example programs and compare their output":                                      "运行示例程序并比较输出",
+	"Compare ASTs with their .ast snapshots":                                             "将语法树与 .ast 快照比较",
+	"Show the Go code generated for each function":                                       "显示每个函数生成的 Go 代码",
+	"Find the declarations and uses of a symbol":                                         "查找符号的声明和使用",
+	"Explain a diagnostic code, or list all codes":                                       "解释诊断代码，或列出所有代码",
+	"Write the Chinese alias table of a protobuf package":                                "生成 protobuf 包的中文别名表",
+	"Write sources and saika.json as one shareable archive":                              "将源文件和 saika.json 写成一个可分享的归档",
+	"Extract the files of an archive made by saika export":                               "解开 saika export 生成的归档中的文件",
+	"Print the inputs and outputs of a build as a Make depfile":                          "以 Make 依赖文件格式打印构建的输入和输出",
+	"Show saika's messages in Chinese or English; the default follows LANG":              "以中文或英文显示 saika 的消息；默认取决于 LANG",
+	"Keep the generated Go workspace and print its location":                             "保留生成的 Go 工作区并打印其位置",
+	"Write the generated Go workspace to dir and keep it":                                "将生成的 Go 工作区写入 dir 并保留",
+	"Print the planned files and commands without running them":                          "打印计划生成的文件和命令，但不执行",
+	"Suppress informational messages":                                                    "不显示提示信息",
+	"Emit one JSON progress event per line":                                              "每行输出一个 JSON 进度事件",
+	"Overwrite existing files in the output or workspace directory":                      "覆盖输出或工作区目录中已有的文件",
+	"Transpile twice and fail if the generated code differs":                             "转译两次，生成的代码不同则失败",
+	"Work on the named binary of the project's saika.json":                               "处理项目 saika.json 中指定名称的程序",
+	"Share transpiled files and executables through an HTTP cache":                       "通过 HTTP 缓存共享转译结果和可执行文件",
+	"Leave out the private functions that 入口 can never reach":                            "省略入口永远不会调用到的私有函数",
+	"Declare repeated string literals once as constants of the generated Go":             "把重复的字符串字面量在生成的 Go 代码中声明为常量",
+	"Comment the generated Go with the Saika construct each statement came from":         "在生成的 Go 代码中用注释标明每条语句来自的 Saika 结构",
+	"Generate or run the program with the named backend: go (default), interp or tinygo": "用指定的后端生成或运行程序：go（默认）、interp 或 tinygo",
+	"(build) Output path; may use {name}, {goos}, {goarch} and {ext}":                    "（build）输出路径；可以使用 {name}、{goos}、{goarch} 和 {ext}",
+	"(build, flash) Compile for a board or platform; needs --backend=tinygo":             "（build、flash）为开发板或平台编译；需要 --backend=tinygo",
+	"(flash) Serial port of the device to flash":                                         "（flash）要烧录的设备的串口",
+	"(run) Stop the program after the given time, e.g. 10s":                              "（run）在给定时间后停止程序，例如 10s",
+	"(run) Stop the program when it uses more memory, e.g. 256MiB":                       "（run）程序使用更多内存时停止，例如 256MiB",
+	"(run) Run without network access, confined to the workspace":                        "（run）在无网络访问、限于工作区的环境中运行",
+	"(run) Rebuild and restart the program whenever a source changes":                    "（run）源文件变化时重新构建并重启程序",
+	"(run) With --hot, keep listening on l and relay connections to the program at u":    "（run）与 --hot 一起使用，持续监听 l 并将连接转发给位于 u 的程序",
+	"(run) Interpret the program instead of compiling it; same as --backend=interp":      "（run）解释执行程序而不编译；等同于 --backend=interp",
+	"(run) Pass the remaining arguments to the program":                                  "（run）将其余参数传给程序",
+
+	// Per-command usage and flags
+	" (default %s)": "（默认 %s）",
+	"string":        "字符串",
+	"duration":      "时长",
+	"value":         "值",
+	"keep the generated Go workspace and print its location":                                                                                    "保留生成的 Go 工作区并打印其位置",
+	"write the generated Go workspace to `dir` and keep it":                                                                                     "将生成的 Go 工作区写入 `目录` 并保留",
+	"print the files and commands that would be used without running them":                                                                      "打印将会使用的文件和命令，但不执行",
+	"suppress informational messages":                                                                                                           "不显示提示信息",
+	"progress output `format`: text or json":                                                                                                    "进度输出的 `格式`：text 或 json",
+	"overwrite existing files in the output or workspace directory":                                                                             "覆盖输出或工作区目录中已有的文件",
+	"transpile twice and fail if the generated code differs":                                                                                    "转译两次，生成的代码不同则失败",
+	"leave out the private functions that 入口 can never reach":                                                                                   "省略 入口 永远不会调用到的私有函数",
+	"declare the string literals a file uses more than once as constants of the generated Go":                                                   "把文件中多次使用的字符串字面量声明为生成的 Go 代码中的常量",
+	"comment each statement of the generated Go with the Saika construct it came from":                                                          "在生成的 Go 代码中用注释标明每条语句来自的 Saika 结构",
+	"generate or run the program with the `name`d backend: %s":                                                                                  "用指定 `名称` 的后端生成或运行程序：%s",
+	"work on the binary `name`d in the project's %s":                                                                                            "处理项目 %s 中指定 `名称` 的程序",
+	"share transpiled files and executables through the HTTP cache at `url`; defaults to $%s":                                                   "通过位于 `网址` 的 HTTP 缓存共享转译结果和可执行文件；默认取 $%s",
+	"write the executable to `path`; may use {name}, {goos}, {goarch} and {ext}":                                                                "将可执行文件写入 `路径`；可以使用 {name}、{goos}、{goarch} 和 {ext}",
+	"compile for the `board` or platform, e.g. pico; needs --backend=tinygo":                                                                    "为 `开发板` 或平台编译，例如 pico；需要 --backend=tinygo",
+	"flash the device at serial `port` instead of the one found automatically":                                                                  "烧录串口 `端口` 上的设备，而不是自动找到的设备",
+	"stop the program after `duration`, e.g. 10s":                                                                                               "在 `时长` 之后停止程序，例如 10s",
+	"stop the program when it uses more than `size`, e.g. 256MiB":                                                                               "程序使用的内存超过 `大小` 时停止，例如 256MiB",
+	"run the program without network access, confined to its workspace":                                                                         "在无网络访问、限于工作区的环境中运行程序",
+	"interpret the program instead of compiling it; same as --backend=interp":                                                                   "解释执行程序而不编译；等同于 --backend=interp",
+	"rebuild and restart the program whenever a source changes":                                                                                 "源文件变化时重新构建并重启程序",
+	"with --hot, keep listening on the first address of `listen=upstream` and relay connections to the program at the second, e.g. :8080=:8081": "与 --hot 一起使用，持续监听 `监听=上游` 中的第一个地址，并将连接转发给位于第二个地址的程序，例如 :8080=:8081",
+	"write the archive to this file instead of stdout":                                                                                          "将归档写入此文件，而不是标准输出",
+	"extract the files into this directory":                                                                                                     "将文件解到此目录",
+	"overwrite existing files":                                                                                                                  "覆盖已有的文件",
+	"the `path` passed to saika build -o":                                                                                                       "传给 saika build -o 的 `路径`",
+	"list the binary `name`d in the project's %s":                                                                                               "列出项目 %s 中指定 `名称` 的程序",
+	"also list the Go files saika build --emit-temp-dir writes to `dir`":                                                                        "同时列出 saika build --emit-temp-dir 写入 `目录` 的 Go 文件",
+	"write the rules to `file` instead of stdout":                                                                                               "将规则写入 `文件`，而不是标准输出",
+	"write each program's output as its expected output":                                                                                        "把每个程序的输出写为其预期输出",
+	"also run each program with the interpreter and compare its output":                                                                         "同时用解释器运行每个程序并比较输出",
+	"print the output of every program":                                                                                                         "打印每个程序的输出",
+	"stop a program after `duration`":                                                                                                           "在 `时长` 之后停止程序",
+	"rewrite the source files with the suggested fixes":                                                                                         "用建议的修复改写源文件",
+	"rewrite the source files instead of printing them":                                                                                         "改写源文件，而不是打印它们",
+	"format the buffers sent on stdin until it closes; see saika fmt --help":                                                                    "格式化从标准输入发来的缓冲区，直到输入关闭；参见 saika fmt --help",
+	"print the declarations and uses of the symbol `name`":                                                                                      "打印符号 `名称` 的声明和使用",
+	"print only the declarations":                                                                                                               "只打印声明",
+	"print only the line ranges, without the generated code":                                                                                    "只打印行范围，不打印生成的代码",
+	"declare the string literals a file uses more than once as constants":                                                                       "把文件中多次使用的字符串字面量声明为常量",
+	"comment each statement with the Saika construct it came from":                                                                              "用注释标明每条语句来自的 Saika 结构",
+	"Go import path of the generated package":                                                                                                   "生成的包的 Go 导入路径",
+	"Chinese name of the package; defaults to its Go name":                                                                                      "包的中文名称；默认为其 Go 名称",
+	"alias table to write; defaults to <package>%s":                                                                                             "要写入的别名表；默认为 <包名>%s",
+	"also write Saika constants for the tagged enum values to this file":                                                                        "同时把带标记的枚举值的 Saika 常量写入此文件",
+	"Saika package of the wrapper file":                                                                                                         "包装文件的 Saika 包",
+	"write the snapshots instead of comparing them":                                                                                             "写入快照，而不是比较它们",
+	fmtDaemonHelp: `
+使用 --daemon 时，标准输入上的每个请求都是一个 JSON 对象，例如
+  {"id": 1, "source": "包 main ..."}
+每个请求按顺序在标准输出上得到一行 JSON 回答：
+  {"id": 1, "source": "包 main\n..."}   格式化后的源代码
+  {"id": 1, "error": "parser errors: ..."}   源代码无法解析时
+id 可以是任意 JSON 值，会原样返回。`,
+
+	// Errors and progress
+	"Error: %v\n":                         "错误：%v\n",
+	"Error":                               "错误",
+	"Unknown command: %s\n":               "未知命令：%s\n",
+	"Error transpiling file":              "转译文件出错",
+	"Error transpiling workspace modules": "转译工作区模块出错",
+	"Error creating temporary file":       "创建临时文件出错",
+	"Error compiling file":                "编译文件出错",
+	"Error running file":                  "运行文件出错",
+	"Error building program":              "构建程序出错",
+	"Error flashing device":               "烧录设备出错",
+	"Interrupted; run again with the same --emit-temp-dir to resume": "已中断；使用相同的 --emit-temp-dir 再次运行即可继续",
+	"Program exited":                  "程序已退出",
+	"Program exited\n":                "程序已退出\n",
+	"Successfully built: %s\n":        "构建成功：%s\n",
+	"Successfully flashed %s\n":       "烧录成功：%s\n",
+	"Generated Go code kept in: %s\n": "生成的 Go 代码保存在：%s\n",
+	"For more information about this error, try `saika explain %s`.\n": "要了解此错误的详细信息，请运行 `saika explain %s`。\n",
+	"Proxying %s to %s\n":           "正在将 %s 转发到 %s\n",
+	"Sources changed; rebuilding\n": "源文件已更改；正在重新构建\n",
+	"Waiting for changes\n":         "正在等待更改\n",
+	"%s: formatted\n":               "%s：已格式化\n",
+	"%d of %d programs failed\n":    "%[2]d 个程序中有 %[1]d 个失败\n",
+	"Error: %s: %v\n":               "错误：%s：%v\n",
+	"Error: unknown diagnostic code %s; run saika explain to list all codes\n": "错误：未知的诊断代码 %s；运行 saika explain 可列出所有代码\n",
+	"    fix: %s\n":                               "    修复：%s\n",
+	"%s: no fix for these errors:\n":              "%s：以下错误没有修复：\n",
+	"%s: applied %d fix(es)\n":                    "%s：已应用 %d 个修复\n",
+	"Error: no declarations are tagged with %q\n": "错误：没有声明带有 %q 标记\n",
+	"Wrote %d names for %s to %s\n":               "已将 %[2]s 的 %[1]d 个名字写入 %[3]s\n",
+	"Wrote %d constants to %s\n":                  "已将 %d 个常量写入 %s\n",
+	"%s: updated %s%s\n":                          "%s：已更新 %s%s\n",
+
+	// Remote cache
+	"Warning: remote cache not used: %v\n":                 "警告：未使用远程缓存：%v\n",
+	"Warning: executable not stored in remote cache: %v\n": "警告：可执行文件未存入远程缓存：%v\n",
+
+	// Dry runs
+	"Would transpile:\n":                    "将转译：\n",
+	"Would run: %s\n":                       "将运行：%s\n",
+	"Would keep generated Go code in: %s\n": "将把生成的 Go 代码保存在：%s\n",
+	"Would run with the %s backend:\n":      "将使用 %s 后端运行：\n",
+
+	// Crashes
+	"saika crashed. This is a bug in saika, not in your program.\n":                 "saika 崩溃了。这是 saika 的缺陷，而不是你的程序的问题。\n",
+	"The crash report could not be written: %v\n\npanic: %v\n\n%s":                  "无法写入崩溃报告：%v\n\npanic: %v\n\n%s",
+	"A crash report was written to %s\n":                                            "崩溃报告已写入 %s\n",
+	"Please attach it when reporting the problem; it has not been sent anywhere.\n": "报告问题时请附上该文件；它没有被发送到任何地方。\n",
+}
@@ -0,0 +1,93 @@
+// cmd/saika/interp.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/signal"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/backend"
+	"github.com/saika-m/saika-lang/internal/codegen"
+	"github.com/saika-m/saika-lang/internal/transpiler"
+)
+
+// interpretCommand runs a program with a backend that runs programs itself,
+// such as the interpreter, so that neither Go code nor the Go toolchain is
+// needed
+func interpretCommand(t *transpiler.Transpiler, opts *options, pr *progress, runner backend.Runner, args []string) {
+	sources := programSources(opts, pr, collectSources(pr, args))
+	if opts.dryRun {
+		fmt.Printf(tr("Would run with the %s backend:\n"), runner.Name())
+		for _, source := range sources {
+			fmt.Printf("  %s\n", source)
+		}
+		return
+	}
+
+	t.Progress = func(e transpiler.Event) {
+		if e.Kind == transpiler.FileStarted {
+			pr.started(phaseTranspile, e.Path)
+		}
+	}
+	programs, err := t.LowerProject(context.Background(), sources)
+	if err != nil {
+		file := ""
+		var fileErr *transpiler.FileError
+		if errors.As(err, &fileErr) {
+			file = fileErr.Path
+		}
+		pr.exit(phaseTranspile, file, err, "Error transpiling file")
+	}
+
+	files := make([]backend.File, len(programs))
+	for i, program := range programs {
+		files[i] = backend.File{Path: sources[i], Program: program}
+		pr.finished(phaseTranspile, sources[i], "")
+	}
+
+	config := backend.RunConfig{
+		Args:      append([]string{"saika-program"}, opts.programArgs...),
+		StaticDir: transpiler.StaticDir(sources),
+	}
+	if usesBuildInfo(programs) {
+		config.BuildInfo = buildInfo(sources[0])
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), forwardedSignals...)
+	defer stop()
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+
+	pr.started(phaseRun, "")
+	err = runner.Run(ctx, files, config)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		err = fmt.Errorf("program exceeded the time limit of %s", opts.timeout)
+	case errors.Is(err, context.Canceled):
+		err = errors.New("program interrupted")
+	}
+	if err != nil {
+		pr.exit(phaseRun, "", err, "Error running file")
+	}
+	pr.finished(phaseRun, "", "")
+}
+
+// usesBuildInfo reports whether any of programs refers to 构建信息, whose
+// values take a moment to collect from git
+func usesBuildInfo(programs []*ast.Program) bool {
+	used := false
+	for _, program := range programs {
+		ast.Inspect(program, func(node ast.Node) bool {
+			if ident, ok := node.(*ast.Identifier); ok && ident.Value == codegen.BuildInfoName {
+				used = true
+			}
+			return !used
+		})
+	}
+	return used
+}
@@ -0,0 +1,129 @@
+// cmd/saika/limits.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// sizeUnits maps the suffixes accepted by GOMEMLIMIT to their size in bytes
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"TiB", 1 << 40},
+	{"GiB", 1 << 30},
+	{"MiB", 1 << 20},
+	{"KiB", 1 << 10},
+	{"B", 1},
+}
+
+// parseSize parses a size such as 512MiB or 1GiB into bytes
+func parseSize(s string) (int64, error) {
+	number, multiplier := s, int64(1)
+	for _, unit := range sizeUnits {
+		if rest, ok := strings.CutSuffix(s, unit.suffix); ok {
+			number, multiplier = rest, unit.bytes
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(strings.TrimSpace(number), 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%q is not a size like 256MiB", s)
+	}
+	return n * multiplier, nil
+}
+
+// runProgram runs the compiled Saika program, forwarding shutdown signals
+// to it and stopping it with a clear error if it exceeds the time or memory
+// limits in opts
+func runProgram(opts *options, binary string) error {
+	ctx := context.Background()
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, binary, opts.programArgs...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+	if opts.sandbox {
+		if err := sandbox(cmd, filepath.Dir(binary)); err != nil {
+			return err
+		}
+	}
+	if opts.memoryBytes > 0 {
+		// GOMEMLIMIT makes the Go runtime collect garbage harder near the
+		// limit; the watcher below enforces it where the platform allows
+		env := cmd.Env
+		if env == nil {
+			env = os.Environ()
+		}
+		cmd.Env = append(env, fmt.Sprintf("GOMEMLIMIT=%d", opts.memoryBytes))
+	}
+
+	// Run the program in its own process group so signals and limits
+	// reach any processes it starts too
+	restoreTerminal := isolateProcessGroup(cmd, !opts.sandbox)
+	defer restoreTerminal()
+	cmd.Cancel = func() error {
+		return signalProcessGroup(cmd.Process, os.Kill)
+	}
+
+	// Relay shutdown signals and wait for the program to exit on its own
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardedSignals...)
+	defer signal.Stop(signals)
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				signalProcessGroup(cmd.Process, sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var exceededMemory atomic.Bool
+	stop := func() {}
+	if opts.memoryBytes > 0 {
+		stop = watchMemory(cmd.Process, opts.memoryBytes, func() {
+			exceededMemory.Store(true)
+			signalProcessGroup(cmd.Process, os.Kill)
+		})
+	}
+
+	err := cmd.Wait()
+	stop()
+
+	switch {
+	case exceededMemory.Load():
+		return fmt.Errorf("program exceeded the memory limit of %s", opts.memoryLimit)
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("program exceeded the time limit of %s", opts.timeout)
+	}
+	return err
+}
+
+// memoryPollInterval is how often the memory watcher samples the program
+const memoryPollInterval = 50 * time.Millisecond
@@ -2,98 +2,524 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
 
+	"github.com/saika-m/saika-lang/internal/backend"
+	"github.com/saika-m/saika-lang/internal/codegen"
 	"github.com/saika-m/saika-lang/internal/transpiler"
 )
 
 func main() {
-	if len(os.Args) < 3 {
+	defer reportCrash()
+
+	args, err := parseLang(os.Args[1:])
+	if err != nil {
+		fmt.Printf(tr("Error: %v\n"), err)
+		os.Exit(1)
+	}
+	if len(args) == 0 {
 		printUsage()
 		os.Exit(1)
 	}
 
-	command := os.Args[1]
-	saikaFile := os.Args[2]
+	command := args[0]
 
-	// Create a transpiler
+	// Restoring the console is skipped when a command exits early, which
+	// leaves the terminal in UTF-8 mode at worst
+	restoreConsole := setupConsole()
+	defer restoreConsole()
+
+	// Create a transpiler; commands build from the files it writes, so it
+	// need not keep generated code in memory
 	t := transpiler.New()
+	t.MaxRetainedCode = -1
+	t.Progress = func(e transpiler.Event) {
+		if e.Kind == transpiler.FileStarted {
+			crashFile = e.Path
+		}
+	}
 
 	switch command {
-	case "build":
-		buildCommand(t, saikaFile)
-	case "run":
-		runCommand(t, saikaFile)
+	case "build", "run", "flash":
+		opts := &options{command: command}
+		fs := newFlagSet(command, opts)
+		args := args[1:]
+		if command == "run" {
+			args, opts.programArgs = splitProgramArgs(args)
+		}
+		args = parseArgs(fs, args)
+		if len(args) == 0 {
+			fs.Usage()
+			os.Exit(1)
+		}
+		if err := opts.validate(); err != nil {
+			fmt.Printf(tr("Error: %v\n"), err)
+			os.Exit(1)
+		}
+
+		pr := newProgress(opts)
+		t.Backend = opts.backend
+		t.Prune = opts.prune
+		t.PoolStrings = opts.pool
+		t.Readable = opts.readable
+		t.RemoteCache = opts.remote
+		switch command {
+		case "build":
+			buildCommand(t, opts, pr, args)
+		case "run":
+			runCommand(t, opts, pr, args)
+		case "flash":
+			flashCommand(t, opts, pr, args)
+		}
+	case "fix":
+		fixCommand(t, args[1:])
+	case "vet":
+		vetCommand(t, args[1:])
+	case "fmt":
+		fmtCommand(t, args[1:])
+	case "examples":
+		examplesCommand(t, args[1:])
+	case "snapshot":
+		snapshotCommand(t, args[1:])
+	case "map":
+		mapCommand(t, args[1:])
+	case "grep":
+		grepCommand(t, args[1:])
+	case "explain":
+		explainCommand(args[1:])
+	case "protoc":
+		protocCommand(args[1:])
+	case "export":
+		exportCommand(args[1:])
+	case "import":
+		importCommand(args[1:])
+	case "depfile":
+		depfileCommand(t, args[1:])
 	default:
-		fmt.Printf("Unknown command: %s\n", command)
+		fmt.Printf(tr("Unknown command: %s\n"), command)
 		printUsage()
 		os.Exit(1)
 	}
 }
 
+// usageCommands lists the commands printUsage describes
+var usageCommands = []struct{ syntax, summary string }{
+	{"saika build [flags] <file.saika|dir|dir/...>...", "Compile Saika files to an executable"},
+	{"saika run [flags] <file.saika|dir|dir/...>...", "Run Saika files as one program"},
+	{"saika flash --target <board> <file.saika|dir>...", "Compile with TinyGo and flash a microcontroller"},
+	{"saika fix [--apply] <file.saika|dir|dir/...>...", "List or apply suggested fixes"},
+	{"saika vet <file.saika|dir|dir/...>...", "Report likely mistakes, such as 推迟 in a loop"},
+	{"saika fmt [--write] <file.saika|dir|dir/...>...", "Format Saika files; --daemon serves editors on stdin"},
+	{"saika examples [flags] [dir]", "Run example programs and compare their output"},
+	{"saika snapshot [--update] <file.saika|dir>...", "Compare ASTs with their .ast snapshots"},
+	{"saika map [--brief] <file.saika|dir|dir/...>...", "Show the Go code generated for each function"},
+	{"saika grep --symbol <name> <file.saika|dir>...", "Find the declarations and uses of a symbol"},
+	{"saika explain [SK0001]", "Explain a diagnostic code, or list all codes"},
+	{"saika protoc --import-path <path> <file.pb.go>...", "Write the Chinese alias table of a protobuf package"},
+	{"saika export [-o <file.txtar>] <file.saika|dir>...", "Write sources and saika.json as one shareable archive"},
+	{"saika import [--dir <dir>] <file.txtar|->", "Extract the files of an archive made by saika export"},
+	{"saika depfile [-o <path>] <file.saika|dir>...", "Print the inputs and outputs of a build as a Make depfile"},
+}
+
+// usageFlags lists the flags printUsage describes
+var usageFlags = []struct{ flag, summary string }{
+	{"--lang <zh|en>", "Show saika's messages in Chinese or English; the default follows LANG"},
+	{"--keep-temp", "Keep the generated Go workspace and print its location"},
+	{"--emit-temp-dir <dir>", "Write the generated Go workspace to dir and keep it"},
+	{"--dry-run", "Print the planned files and commands without running them"},
+	{"-q", "Suppress informational messages"},
+	{"--progress=json", "Emit one JSON progress event per line"},
+	{"--force", "Overwrite existing files in the output or workspace directory"},
+	{"--verify", "Transpile twice and fail if the generated code differs"},
+	{"--prune", "Leave out the private functions that 入口 can never reach"},
+	{"--pool-strings", "Declare repeated string literals once as constants of the generated Go"},
+	{"--readable", "Comment the generated Go with the Saika construct each statement came from"},
+	{"--bin <name>", "Work on the named binary of the project's saika.json"},
+	{"--remote-cache <url>", "Share transpiled files and executables through an HTTP cache"},
+	{"--backend <name>", "Generate or run the program with the named backend: go (default), interp or tinygo"},
+	{"-o <path>", "(build) Output path; may use {name}, {goos}, {goarch} and {ext}"},
+	{"--target <board>", "(build, flash) Compile for a board or platform; needs --backend=tinygo"},
+	{"--port <port>", "(flash) Serial port of the device to flash"},
+	{"--timeout <duration>", "(run) Stop the program after the given time, e.g. 10s"},
+	{"--memory-limit <size>", "(run) Stop the program when it uses more memory, e.g. 256MiB"},
+	{"--sandbox", "(run) Run without network access, confined to the workspace"},
+	{"--hot", "(run) Rebuild and restart the program whenever a source changes"},
+	{"--hot-proxy <l=u>", "(run) With --hot, keep listening on l and relay connections to the program at u"},
+	{"--interp", "(run) Interpret the program instead of compiling it; same as --backend=interp"},
+	{"-- <args>", "(run) Pass the remaining arguments to the program"},
+}
+
 func printUsage() {
-	fmt.Println("Usage:")
-	fmt.Println("  saika build <file.saika>  - Compile the Saika file to an executable")
-	fmt.Println("  saika run <file.saika>    - Run the Saika file")
+	fmt.Println(tr("Usage:"))
+	for _, c := range usageCommands {
+		fmt.Printf("  %-48s - %s\n", c.syntax, tr(c.summary))
+	}
+	fmt.Println()
+	fmt.Println(tr("Flags:"))
+	for _, f := range usageFlags {
+		fmt.Printf("  %-21s %s\n", f.flag, tr(f.summary))
+	}
 }
 
-func buildCommand(t *transpiler.Transpiler, saikaFile string) {
-	// Transpile the Saika file to Go
-	goCode, err := t.TranspileFile(saikaFile)
+// workspace is the directory holding the Go code generated for one command
+type workspace struct {
+	dir      string
+	goFiles  []string
+	ldflags  string // linker flags for go build, e.g. to fill in 构建信息
+	keep     bool
+	module   bool // dir holds a go.mod, as the program imports workspace modules
+	progress *progress
+
+	compiler backend.Compiler // compiles the workspace instead of go build, if set
+	target   string           // board or platform the compiler targets
+}
+
+// cleanup removes the workspace, or reports where it was kept
+func (w *workspace) cleanup() {
+	if w.keep {
+		w.progress.kept(w.dir)
+		return
+	}
+	os.RemoveAll(w.dir)
+}
+
+// exit reports an error, cleans up the workspace and exits
+func (w *workspace) exit(phase string, err error, message string) {
+	w.progress.failed(phase, "", err, message)
+	w.cleanup()
+	os.Exit(1)
+}
+
+// collectSources expands the command-line arguments into Saika source files
+func collectSources(pr *progress, args []string) []string {
+	sources, err := transpiler.CollectSources(args)
 	if err != nil {
-		fmt.Printf("Error transpiling file: %v\n", err)
-		os.Exit(1)
+		pr.exit(phaseTranspile, "", err, "Error")
 	}
+	return sources
+}
 
-	// Create a temporary Go file
-	tempGoFile, tempDir, err := t.CreateTempGoFile(goCode)
+// programSources returns the sources of the one program run or flash
+// works on, exiting if the project does not say which
+func programSources(opts *options, pr *progress, sources []string) []string {
+	sources, err := projectProgram(opts, sources)
 	if err != nil {
-		fmt.Printf("Error creating temporary file: %v\n", err)
-		os.Exit(1)
+		pr.exit(phaseTranspile, "", err, "Error")
+	}
+	return sources
+}
+
+// transpileToWorkspace transpiles sources into a Go package in a temporary
+// (or requested) directory
+func transpileToWorkspace(t *transpiler.Transpiler, opts *options, pr *progress, sources []string) *workspace {
+	// Transpile the Saika files to Go; they are checked together so that
+	// names declared in one file resolve in the others
+	t.Progress = func(e transpiler.Event) {
+		if e.Kind == transpiler.FileStarted {
+			pr.started(phaseTranspile, e.Path)
+		}
+	}
+	var (
+		results []*transpiler.TranspileResult
+		err     error
+	)
+	ws := &workspace{keep: opts.keepTemp || opts.tempDir != "", progress: pr}
+	ws.compiler, ws.target = workspaceCompiler(opts)
+	switch {
+	case opts.verify:
+		results, err = t.Verify(sources)
+	case opts.tempDir != "":
+		// A requested directory keeps its progress, so an interrupted
+		// transpilation resumes when the command is run again
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		ws.dir = opts.tempDir
+		results, ws.goFiles, err = t.TranspileProjectTo(ctx, opts.tempDir, sources)
+		stop()
+		if errors.Is(err, context.Canceled) {
+			pr.exit(phaseTranspile, "", err, "Interrupted; run again with the same --emit-temp-dir to resume")
+		}
+	default:
+		ws.dir, err = os.MkdirTemp("", "saika-temp")
+		if err == nil {
+			results, ws.goFiles, err = t.TranspileProjectTo(context.Background(), ws.dir, sources)
+		}
+	}
+	if err != nil {
+		file := ""
+		var fileErr *transpiler.FileError
+		if errors.As(err, &fileErr) {
+			file = fileErr.Path
+		}
+		pr.exit(phaseTranspile, file, err, "Error transpiling file")
 	}
-	defer os.RemoveAll(tempDir) // Clean up temporary directory
 
-	// Compile the Go file
-	outputFile := strings.TrimSuffix(saikaFile, ".saika")
-	cmd := exec.Command("go", "build", "-o", outputFile, tempGoFile)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	// Verified results are only in memory and still need writing
+	if opts.verify {
+		if opts.tempDir != "" {
+			ws.dir = opts.tempDir
+			ws.goFiles, err = t.WriteGoPackage(opts.tempDir, results)
+		} else {
+			ws.dir, ws.goFiles, err = t.CreateTempGoPackage(results)
+		}
+		if err != nil {
+			pr.exit(phaseTranspile, "", err, "Error creating temporary file")
+		}
+	}
 
-	if err := cmd.Run(); err != nil {
-		fmt.Printf("Error compiling file: %v\n", err)
-		os.Exit(1)
+	// Workspace modules the program imports are generated alongside it
+	if ws.module, err = t.WriteModules(context.Background(), ws.dir, sources); err != nil {
+		pr.exit(phaseTranspile, "", err, "Error transpiling workspace modules")
+	}
+
+	for i, source := range sources {
+		pr.finished(phaseTranspile, source, ws.goFiles[i])
+	}
+
+	ws.ldflags = ldflagsFor(results, sources)
+	if err := t.RemoteCacheError(); err != nil {
+		pr.infof("Warning: remote cache not used: %v\n", err)
 	}
 
-	fmt.Printf("Successfully built: %s\n", outputFile)
+	return ws
 }
 
-func runCommand(t *transpiler.Transpiler, saikaFile string) {
-	// Transpile the Saika file to Go
-	goCode, err := t.TranspileFile(saikaFile)
-	if err != nil {
-		fmt.Printf("Error transpiling file: %v\n", err)
-		os.Exit(1)
+// ldflagsFor returns the linker flags needed by the support features of
+// the transpiled results
+func ldflagsFor(results []*transpiler.TranspileResult, sources []string) string {
+	for _, feature := range transpiler.RequiredFeatures(results) {
+		if feature == codegen.FeatureBuildInfo {
+			return buildInfoLDFlags(sources[0])
+		}
 	}
+	return ""
+}
 
-	// Create a temporary Go file
-	tempGoFile, tempDir, err := t.CreateTempGoFile(goCode)
-	if err != nil {
-		fmt.Printf("Error creating temporary file: %v\n", err)
-		os.Exit(1)
+// checkCollisions exits if the command would overwrite files it should not;
+// output is empty for commands that do not write an executable
+func checkCollisions(opts *options, pr *progress, sources []string, output string) {
+	if output != "" {
+		if err := checkOutputCollision(output, sources, opts.force); err != nil {
+			pr.exit(phaseCompile, "", err, "Error")
+		}
+	}
+	if opts.tempDir != "" {
+		if err := checkWorkspaceCollision(plannedWorkspace(opts, sources), opts.force); err != nil {
+			pr.exit(phaseTranspile, "", err, "Error")
+		}
+	}
+}
+
+// plannedWorkspace describes the workspace a command would create, using
+// $WORK for a temporary directory that does not exist yet
+func plannedWorkspace(opts *options, sources []string) *workspace {
+	ws := &workspace{dir: "$WORK", keep: opts.keepTemp || opts.tempDir != ""}
+	ws.compiler, ws.target = workspaceCompiler(opts)
+	if opts.tempDir != "" {
+		ws.dir = opts.tempDir
+	}
+	for _, name := range transpiler.GoFileNames(sources) {
+		ws.goFiles = append(ws.goFiles, filepath.Join(ws.dir, name))
+	}
+	return ws
+}
+
+// printPlan prints the files a command would transpile and the commands it would run
+func printPlan(sources []string, ws *workspace, commands ...[]string) {
+	fmt.Print(tr("Would transpile:\n"))
+	for i, source := range sources {
+		fmt.Printf("  %s -> %s\n", source, ws.goFiles[i])
+	}
+
+	for _, command := range commands {
+		quoted := make([]string, len(command))
+		for i, arg := range command {
+			quoted[i] = arg
+			if strings.ContainsAny(arg, " \t\"'") {
+				quoted[i] = strconv.Quote(arg)
+			}
+		}
+		fmt.Printf(tr("Would run: %s\n"), strings.Join(quoted, " "))
 	}
-	defer os.RemoveAll(tempDir) // Clean up temporary directory
 
-	// Run the Go file
-	cmd := exec.Command("go", "run", tempGoFile)
-	cmd.Stdout = os.Stdout
+	if ws.keep {
+		fmt.Printf(tr("Would keep generated Go code in: %s\n"), ws.dir)
+	}
+}
+
+// programPath returns where run builds the program inside the workspace;
+// run always builds for the host, so a cross-compiling GOOS is ignored
+func programPath(dir string) string {
+	return withExeSuffix(filepath.Join(dir, "saika-program"), runtime.GOOS)
+}
+
+// workspaceCompiler returns the compiler of the selected backend, if it has
+// its own, and the target to compile for
+func workspaceCompiler(opts *options) (backend.Compiler, string) {
+	compiler, _ := opts.backend.(backend.Compiler)
+	return compiler, opts.target
+}
+
+// buildArgs returns the command line that compiles the workspace into
+// output: go build, or the backend's own compiler
+func buildArgs(output string, ws *workspace) []string {
+	if ws.compiler != nil {
+		return ws.compiler.BuildArgs(ws.target, output, ws.ldflags, ws.goFiles)
+	}
+	args := []string{"go", "build", "-o", output}
+	if ws.ldflags != "" {
+		args = append(args, "-ldflags", ws.ldflags)
+	}
+	return append(args, ws.goFiles...)
+}
+
+// compile builds the workspace, writing the executable to output.
+// A static build disables cgo so the program needs no shared libraries.
+// With workspace modules, go build runs in the workspace, where their
+// go.mod is.
+func compile(ws *workspace, output string, static bool) error {
+	dir := ""
+	if ws.module {
+		var err error
+		if ws, output, err = absoluteWorkspace(ws, output); err != nil {
+			return err
+		}
+		dir = ws.dir
+	}
+	args := buildArgs(output, ws)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = dir
+	cmd.Stdout = os.Stderr
 	cmd.Stderr = os.Stderr
-	cmd.Stdin = os.Stdin
+	if static {
+		cmd.Env = append(os.Environ(), "CGO_ENABLED=0")
+	}
+	return cmd.Run()
+}
 
-	if err := cmd.Run(); err != nil {
-		fmt.Printf("Error running file: %v\n", err)
-		os.Exit(1)
+// absoluteWorkspace returns a copy of ws, and output, with absolute paths,
+// so that they are found from the workspace directory
+func absoluteWorkspace(ws *workspace, output string) (*workspace, string, error) {
+	abs := *ws
+	abs.goFiles = make([]string, len(ws.goFiles))
+	for i, file := range ws.goFiles {
+		var err error
+		if abs.goFiles[i], err = filepath.Abs(file); err != nil {
+			return nil, "", err
+		}
+	}
+	var err error
+	if abs.dir, err = filepath.Abs(ws.dir); err != nil {
+		return nil, "", err
+	}
+	output, err = filepath.Abs(output)
+	return &abs, output, err
+}
+
+func buildCommand(t *transpiler.Transpiler, opts *options, pr *progress, args []string) {
+	sources := collectSources(pr, args)
+	project, binaries, err := selectBinaries(opts, sources)
+	if err != nil {
+		pr.exit(phaseTranspile, "", err, "Error")
+	}
+	if project == nil {
+		buildProgram(t, opts, pr, sources, outputPath(opts, args))
+		return
+	}
+
+	// Each binary of the project is built from its own sources, and in a
+	// workspace directory of its own when several are kept
+	for _, b := range binaries {
+		binarySources, err := project.Sources(b, sources)
+		if err != nil {
+			pr.exit(phaseTranspile, "", err, "Error")
+		}
+		binaryOpts := *opts
+		if opts.tempDir != "" && len(binaries) > 1 {
+			binaryOpts.tempDir = filepath.Join(opts.tempDir, b.Name)
+		}
+		buildProgram(t, &binaryOpts, pr, binarySources, binaryOutputPath(opts, project, b))
+	}
+}
+
+// buildProgram builds the program formed by sources into outputFile
+func buildProgram(t *transpiler.Transpiler, opts *options, pr *progress, sources []string, outputFile string) {
+	checkCollisions(opts, pr, sources, outputFile)
+
+	if opts.dryRun {
+		ws := plannedWorkspace(opts, sources)
+		printPlan(sources, ws, buildArgs(outputFile, ws))
+		return
+	}
+
+	ws := transpileToWorkspace(t, opts, pr, sources)
+
+	// Compile the Go files; go build refuses to replace files that are not
+	// executables, so a forced overwrite removes the old file first
+	if opts.force {
+		os.Remove(outputFile)
+	}
+	pr.started(phaseCompile, "")
+	key, found := fetchBinary(opts, ws, outputFile)
+	if !found {
+		if err := compile(ws, outputFile, false); err != nil {
+			ws.exit(phaseCompile, err, "Error compiling file")
+		}
+		storeBinary(opts, ws, key, outputFile)
+	}
+
+	pr.finished(phaseCompile, "", outputFile)
+	pr.infof("Successfully built: %s\n", outputFile)
+	ws.cleanup()
+}
+
+func runCommand(t *transpiler.Transpiler, opts *options, pr *progress, args []string) {
+	if runner, ok := opts.backend.(backend.Runner); ok {
+		interpretCommand(t, opts, pr, runner, args)
+		return
 	}
+
+	if opts.hot {
+		hotRunCommand(t, opts, pr, args)
+		return
+	}
+
+	sources := programSources(opts, pr, collectSources(pr, args))
+	checkCollisions(opts, pr, sources, "")
+
+	if opts.dryRun {
+		ws := plannedWorkspace(opts, sources)
+		binary := programPath(ws.dir)
+		printPlan(sources, ws, buildArgs(binary, ws), append([]string{binary}, opts.programArgs...))
+		return
+	}
+
+	ws := transpileToWorkspace(t, opts, pr, sources)
+
+	// Compile the Go files into the workspace
+	pr.started(phaseCompile, "")
+	binary := programPath(ws.dir)
+
+	// The sandbox has no shared libraries, so link statically
+	if err := compile(ws, binary, opts.sandbox); err != nil {
+		ws.exit(phaseCompile, err, "Error compiling file")
+	}
+	pr.finished(phaseCompile, "", binary)
+
+	// Run the resulting binary
+	pr.started(phaseRun, "")
+	if err := runProgram(opts, binary); err != nil {
+		ws.exit(phaseRun, err, "Error running file")
+	}
+
+	pr.finished(phaseRun, "", "")
+	ws.cleanup()
 }
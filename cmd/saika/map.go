@@ -0,0 +1,58 @@
+// cmd/saika/map.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/saika-m/saika-lang/internal/transpiler"
+)
+
+// mapCommand prints, for each function of the given sources, the Go code
+// generated for it with its line numbers in the generated file
+func mapCommand(t *transpiler.Transpiler, args []string) {
+	fs := flag.NewFlagSet("map", flag.ExitOnError)
+	brief := fs.Bool("brief", false, tr("print only the line ranges, without the generated code"))
+	fs.BoolVar(&t.Prune, "prune", false, tr("leave out the private functions that 入口 can never reach"))
+	fs.BoolVar(&t.PoolStrings, "pool-strings", false, tr("declare the string literals a file uses more than once as constants"))
+	fs.BoolVar(&t.Readable, "readable", false, tr("comment each statement with the Saika construct it came from"))
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, tr("Usage:"), "saika map [--brief] [--prune] [--pool-strings] [--readable] <file.saika|dir|dir/...>...")
+		printDefaults(fs)
+	}
+	args = parseArgs(fs, args)
+	if len(args) == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	sources, err := transpiler.CollectSources(args)
+	if err != nil {
+		fmt.Printf(tr("Error: %v\n"), err)
+		os.Exit(1)
+	}
+	results, err := t.TranspileProject(sources)
+	if err != nil {
+		fmt.Printf(tr("Error: %v\n"), err)
+		os.Exit(1)
+	}
+
+	goFiles := transpiler.GoFileNames(sources)
+	for i, result := range results {
+		lines := strings.Split(result.GoCode, "\n")
+		for _, fn := range result.Functions {
+			fmt.Printf("%s:%d:%d: %s -> %s:%d-%d (%s)\n",
+				result.SourcePath, fn.Source.Start.Line, fn.Source.Start.Column, fn.Name,
+				goFiles[i], fn.GoStart, fn.GoEnd, fn.GoName)
+			if *brief {
+				continue
+			}
+			for n := fn.GoStart; n <= fn.GoEnd && n <= len(lines); n++ {
+				fmt.Printf("%6d  %s\n", n, lines[n-1])
+			}
+			fmt.Println()
+		}
+	}
+}
@@ -0,0 +1,42 @@
+// cmd/saika/memwatch_linux.go
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// watchMemory polls the resident memory of proc and calls exceeded once it
+// grows beyond limit bytes. The returned function stops the watcher.
+func watchMemory(proc *os.Process, limit int64, exceeded func()) func() {
+	done := make(chan struct{})
+	pageSize := int64(os.Getpagesize())
+
+	go func() {
+		ticker := time.NewTicker(memoryPollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				data, err := os.ReadFile(fmt.Sprintf("/proc/%d/statm", proc.Pid))
+				if err != nil {
+					return
+				}
+				var size, resident int64
+				if _, err := fmt.Sscan(string(data), &size, &resident); err != nil {
+					return
+				}
+				if resident*pageSize > limit {
+					exceeded()
+					return
+				}
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
@@ -0,0 +1,13 @@
+// cmd/saika/memwatch_other.go
+
+//go:build !linux
+
+package main
+
+import "os"
+
+// watchMemory is a no-op where resident memory cannot be sampled; the
+// program is still started with GOMEMLIMIT set.
+func watchMemory(proc *os.Process, limit int64, exceeded func()) func() {
+	return func() {}
+}
@@ -0,0 +1,140 @@
+// cmd/saika/output.go
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/saika-m/saika-lang/internal/transpiler"
+)
+
+// outputPlaceholders lists the placeholders accepted in -o templates
+var outputPlaceholders = map[string]bool{
+	"{name}":   true, // executable name derived from the sources
+	"{goos}":   true, // target operating system
+	"{goarch}": true, // target architecture
+	"{ext}":    true, // .exe on Windows, empty elsewhere
+}
+
+var placeholderPattern = regexp.MustCompile(`\{[^{}]*\}`)
+
+// checkOutputTemplate reports unknown placeholders in an -o template
+func checkOutputTemplate(template string) error {
+	for _, placeholder := range placeholderPattern.FindAllString(template, -1) {
+		if !outputPlaceholders[placeholder] {
+			return fmt.Errorf("unknown placeholder %s in -o, expected one of {name}, {goos}, {goarch}, {ext}", placeholder)
+		}
+	}
+	return nil
+}
+
+// defaultOutputName names the executable after the first source argument.
+// A file foo.saika builds foo next to it; a directory builds an executable
+// named after the directory inside it.
+func defaultOutputName(args []string) string {
+	first := strings.TrimSuffix(filepath.ToSlash(args[0]), "/...")
+	if first == "" {
+		first = "."
+	}
+	first = filepath.FromSlash(first)
+
+	if info, err := os.Stat(first); err == nil && info.IsDir() {
+		name := "saika-program"
+		if abs, err := filepath.Abs(first); err == nil {
+			name = filepath.Base(abs)
+		}
+		return withExeSuffix(filepath.Join(first, name), targetGOOS())
+	}
+	return withExeSuffix(strings.TrimSuffix(first, transpiler.SourceExt), targetGOOS())
+}
+
+// outputPath resolves where build writes the executable. Without -o the
+// default name is used; an -o naming an existing directory places the
+// default name inside it; otherwise -o is expanded as a template.
+func outputPath(opts *options, args []string) string {
+	return expandOutput(opts, defaultOutputName(args))
+}
+
+// expandOutput applies -o to the default path of an executable
+func expandOutput(opts *options, defaultPath string) string {
+	if opts.output == "" {
+		return defaultPath
+	}
+
+	if info, err := os.Stat(opts.output); err == nil && info.IsDir() {
+		return filepath.Join(opts.output, filepath.Base(defaultPath))
+	}
+
+	goos := targetGOOS()
+	name := strings.TrimSuffix(filepath.Base(defaultPath), exeExt(goos))
+	path := strings.NewReplacer(
+		"{name}", name,
+		"{goos}", goos,
+		"{goarch}", targetGOARCH(),
+		"{ext}", exeExt(goos),
+	).Replace(opts.output)
+
+	if strings.Contains(opts.output, "{ext}") {
+		return path
+	}
+	return withExeSuffix(path, goos)
+}
+
+// checkOutputCollision reports when writing the executable to output would
+// destroy something other than a previous build. Sources are never
+// overwritten; other existing files that do not look like executables are
+// only replaced with --force.
+func checkOutputCollision(output string, sources []string, force bool) error {
+	info, err := os.Stat(output)
+	if err != nil {
+		return nil
+	}
+	if info.IsDir() {
+		return fmt.Errorf("output %s is a directory", output)
+	}
+	for _, source := range sources {
+		if sourceInfo, err := os.Stat(source); err == nil && os.SameFile(info, sourceInfo) {
+			return fmt.Errorf("output %s would overwrite source file %s", output, source)
+		}
+	}
+	if !force && !looksExecutable(output, info) {
+		return fmt.Errorf("output %s already exists and is not an executable; use --force to overwrite it", output)
+	}
+	return nil
+}
+
+// checkWorkspaceCollision reports Go files that writing the workspace into
+// an existing directory would overwrite, unless force is set. Files that an
+// earlier run recorded in the directory's state are saika's own.
+func checkWorkspaceCollision(ws *workspace, force bool) error {
+	if force {
+		return nil
+	}
+	state, err := transpiler.LoadProjectState(ws.dir)
+	if err != nil {
+		return err
+	}
+	ours := map[string]bool{}
+	for _, f := range state.Files {
+		ours[f.Output] = true
+	}
+
+	for _, goFile := range ws.goFiles {
+		if _, err := os.Stat(goFile); err == nil && !ours[goFile] {
+			return fmt.Errorf("%s already exists; use --force to overwrite it", goFile)
+		}
+	}
+	return nil
+}
+
+// looksExecutable reports whether an existing file is plausibly the result
+// of an earlier build
+func looksExecutable(path string, info os.FileInfo) bool {
+	if strings.EqualFold(filepath.Ext(path), ".exe") {
+		return true
+	}
+	return info.Mode()&0111 != 0
+}
@@ -0,0 +1,41 @@
+// cmd/saika/platform.go
+package main
+
+import (
+	"os"
+	"runtime"
+	"strings"
+)
+
+// targetGOOS returns the operating system go build will compile for,
+// honouring a GOOS set for cross-compilation
+func targetGOOS() string {
+	if goos := os.Getenv("GOOS"); goos != "" {
+		return goos
+	}
+	return runtime.GOOS
+}
+
+// targetGOARCH returns the architecture go build will compile for
+func targetGOARCH() string {
+	if goarch := os.Getenv("GOARCH"); goarch != "" {
+		return goarch
+	}
+	return runtime.GOARCH
+}
+
+// exeExt returns the executable file extension used by goos
+func exeExt(goos string) string {
+	if goos == "windows" {
+		return ".exe"
+	}
+	return ""
+}
+
+// withExeSuffix adds the executable suffix required by goos to name
+func withExeSuffix(name, goos string) string {
+	if goos == "windows" && !strings.HasSuffix(strings.ToLower(name), ".exe") {
+		return name + ".exe"
+	}
+	return name
+}
@@ -0,0 +1,126 @@
+// cmd/saika/progress.go
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/saika-m/saika-lang/internal/diagnostic"
+)
+
+// Build phases reported in progress events
+const (
+	phaseTranspile = "transpile"
+	phaseCompile   = "compile"
+	phaseRun       = "run"
+)
+
+// progress reports what a command is doing, either as human-readable
+// messages or as one JSON event per line for build tools
+type progress struct {
+	json  bool // emit JSON events instead of messages
+	quiet bool // suppress informational messages
+}
+
+// event is a structured progress event emitted with --progress=json
+type event struct {
+	Event       string            `json:"event"` // started, finished, error or kept
+	Phase       string            `json:"phase,omitempty"`
+	File        string            `json:"file,omitempty"`
+	Output      string            `json:"output,omitempty"`
+	Message     string            `json:"message,omitempty"`
+	Diagnostics []eventDiagnostic `json:"diagnostics,omitempty"`
+	Time        time.Time         `json:"time"`
+}
+
+// eventDiagnostic is a diagnostic attached to an error event
+type eventDiagnostic struct {
+	Severity string `json:"severity"`
+	Code     string `json:"code,omitempty"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Message  string `json:"message"`
+}
+
+// newProgress creates a reporter for the given options
+func newProgress(opts *options) *progress {
+	return &progress{json: opts.progress == "json", quiet: opts.quiet}
+}
+
+// emit writes a JSON event to stdout
+func (p *progress) emit(e event) {
+	e.Time = time.Now()
+	data, _ := json.Marshal(e)
+	fmt.Println(string(data))
+}
+
+// started reports that work on a file or phase has begun
+func (p *progress) started(phase, file string) {
+	if file != "" {
+		crashFile = file
+	}
+	if p.json {
+		p.emit(event{Event: "started", Phase: phase, File: file})
+	}
+}
+
+// finished reports that work on a file or phase completed, producing output
+func (p *progress) finished(phase, file, output string) {
+	if p.json {
+		p.emit(event{Event: "finished", Phase: phase, File: file, Output: output})
+	}
+}
+
+// infof prints an informational message unless quiet or in JSON mode
+func (p *progress) infof(format string, args ...interface{}) {
+	if !p.json && !p.quiet {
+		fmt.Printf(tr(format), args...)
+	}
+}
+
+// kept reports where a preserved workspace can be found
+func (p *progress) kept(dir string) {
+	if p.json {
+		p.emit(event{Event: "kept", Output: dir})
+		return
+	}
+	fmt.Printf(tr("Generated Go code kept in: %s\n"), dir)
+}
+
+// failed reports an error; message is the human-readable prefix, e.g.
+// "Error transpiling file", which is translated with tr
+func (p *progress) failed(phase, file string, err error, message string) {
+	var diags diagnostic.List
+	hasDiags := errors.As(err, &diags)
+
+	if !p.json {
+		fmt.Printf("%s: %v\n", tr(message), err)
+		if code := diags.FirstCode(); code != "" {
+			fmt.Printf(tr("For more information about this error, try `saika explain %s`.\n"), code)
+		}
+		return
+	}
+
+	e := event{Event: "error", Phase: phase, File: file, Message: err.Error()}
+	if hasDiags {
+		for _, d := range diags {
+			e.Diagnostics = append(e.Diagnostics, eventDiagnostic{
+				Severity: d.Severity.String(),
+				Code:     string(d.Code),
+				Line:     d.Range.Start.Line,
+				Column:   d.Range.Start.Column,
+				Message:  d.Message,
+			})
+		}
+	}
+	p.emit(e)
+}
+
+// exit reports an error and exits the process
+func (p *progress) exit(phase, file string, err error, message string) {
+	p.failed(phase, file, err, message)
+	os.Exit(1)
+}
@@ -0,0 +1,66 @@
+// cmd/saika/protoc.go
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/saika-m/saika-lang/internal/protoc"
+	"github.com/saika-m/saika-lang/internal/stdlib"
+)
+
+// protocCommand writes the alias table, and optionally Saika wrapper
+// declarations, for Go files generated by protoc-gen-go and
+// protoc-gen-go-grpc. Builds pick up alias tables next to their sources.
+func protocCommand(args []string) {
+	fs := flag.NewFlagSet("protoc", flag.ExitOnError)
+	importPath := fs.String("import-path", "", tr("Go import path of the generated package"))
+	name := fs.String("name", "", tr("Chinese name of the package; defaults to its Go name"))
+	output := fs.String("o", "", fmt.Sprintf(tr("alias table to write; defaults to <package>%s"), stdlib.AliasFileExt))
+	wrappers := fs.String("wrappers", "", tr("also write Saika constants for the tagged enum values to this file"))
+	pkg := fs.String("package", "main", tr("Saika package of the wrapper file"))
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, tr("Usage:"), "saika protoc --import-path <path> [flags] <file.pb.go>...")
+		printDefaults(fs)
+	}
+	args = parseArgs(fs, args)
+	if len(args) == 0 || *importPath == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	table, err := protoc.Build(args, *importPath, *name)
+	if err != nil {
+		fmt.Printf(tr("Error: %v\n"), err)
+		os.Exit(1)
+	}
+	if len(table.Members) == 0 {
+		fmt.Printf(tr("Error: no declarations are tagged with %q\n"), protoc.Tag)
+		os.Exit(1)
+	}
+
+	data, err := json.MarshalIndent(table.Package, "", "  ")
+	if err != nil {
+		fmt.Printf(tr("Error: %v\n"), err)
+		os.Exit(1)
+	}
+	if *output == "" {
+		*output = path.Base(*importPath) + stdlib.AliasFileExt
+	}
+	if err := os.WriteFile(*output, append(data, '\n'), 0644); err != nil {
+		fmt.Printf(tr("Error: %v\n"), err)
+		os.Exit(1)
+	}
+	fmt.Printf(tr("Wrote %d names for %s to %s\n"), len(table.Members), table.Name, *output)
+
+	if *wrappers != "" {
+		if err := os.WriteFile(*wrappers, []byte(table.Wrappers(*pkg)), 0644); err != nil {
+			fmt.Printf(tr("Error: %v\n"), err)
+			os.Exit(1)
+		}
+		fmt.Printf(tr("Wrote %d constants to %s\n"), len(table.Enums), *wrappers)
+	}
+}
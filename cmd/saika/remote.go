@@ -0,0 +1,91 @@
+// cmd/saika/remote.go
+package main
+
+import (
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/saika-m/saika-lang/internal/buildcache"
+	"github.com/saika-m/saika-lang/internal/transpiler"
+)
+
+// remoteCacheEnv names the environment variable giving the default of
+// --remote-cache, so that a CI farm can set it once for every build
+const remoteCacheEnv = "SAIKA_REMOTE_CACHE"
+
+// binaryKey returns the key that the executable compiled from the workspace
+// is stored under in the remote cache: a hash of the files in it, the
+// linker flags, the platform or board it is compiled for, and the version
+// of the compiler
+func binaryKey(ws *workspace) (string, error) {
+	compiler := buildArgs("", ws)[0]
+	version, err := exec.Command(compiler, "version").Output()
+	if err != nil {
+		return "", err
+	}
+	parts := [][]byte{[]byte("compile"), version, []byte(ws.ldflags), []byte(ws.target), []byte(targetGOOS()), []byte(targetGOARCH())}
+
+	// WalkDir visits the files in lexical order, so the key does not
+	// depend on the order they were written in
+	err = filepath.WalkDir(ws.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || d.Name() == transpiler.StateFile {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(ws.dir, path)
+		if err != nil {
+			return err
+		}
+		parts = append(parts, []byte(filepath.ToSlash(rel)), data)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return buildcache.Key(parts...), nil
+}
+
+// fetchBinary writes the executable the remote cache holds for the
+// workspace to outputFile. It returns the executable's key, empty when
+// the cache cannot be used, and whether it was found.
+func fetchBinary(opts *options, ws *workspace, outputFile string) (string, bool) {
+	if opts.remote == nil {
+		return "", false
+	}
+	key, err := binaryKey(ws)
+	if err != nil {
+		ws.progress.infof("Warning: remote cache not used: %v\n", err)
+		return "", false
+	}
+	data, err := opts.remote.Get(key)
+	if err != nil {
+		ws.progress.infof("Warning: remote cache not used: %v\n", err)
+		return "", false
+	}
+	if data == nil {
+		return key, false
+	}
+	if err := os.WriteFile(outputFile, data, 0755); err != nil {
+		return key, false
+	}
+	return key, true
+}
+
+// storeBinary gives the remote cache the executable compiled to outputFile
+func storeBinary(opts *options, ws *workspace, key, outputFile string) {
+	if key == "" {
+		return
+	}
+	data, err := os.ReadFile(outputFile)
+	if err == nil {
+		err = opts.remote.Put(key, data)
+	}
+	if err != nil {
+		ws.progress.infof("Warning: executable not stored in remote cache: %v\n", err)
+	}
+}
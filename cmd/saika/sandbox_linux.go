@@ -0,0 +1,35 @@
+// cmd/saika/sandbox_linux.go
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+)
+
+// sandbox confines cmd to root: the program runs as root of a new user
+// namespace with its own empty network namespace (no network access), its
+// own mount and PID namespaces, and root as its filesystem root. The binary
+// must live directly inside root and be statically linked.
+func sandbox(cmd *exec.Cmd, root string) error {
+	name := "/" + filepath.Base(cmd.Path)
+	cmd.Path = name
+	cmd.Args[0] = name
+	cmd.Dir = "/"
+	cmd.Env = []string{"PATH=/", "HOME=/"}
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags: syscall.CLONE_NEWUSER | syscall.CLONE_NEWNET |
+			syscall.CLONE_NEWNS | syscall.CLONE_NEWPID,
+		UidMappings: []syscall.SysProcIDMap{
+			{ContainerID: 0, HostID: os.Getuid(), Size: 1},
+		},
+		GidMappings: []syscall.SysProcIDMap{
+			{ContainerID: 0, HostID: os.Getgid(), Size: 1},
+		},
+		GidMappingsEnableSetgroups: false,
+		Chroot:                     root,
+		Pdeathsig:                  syscall.SIGKILL,
+	}
+	return nil
+}
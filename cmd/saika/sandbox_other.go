@@ -0,0 +1,17 @@
+// cmd/saika/sandbox_other.go
+
+//go:build !linux
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// sandbox refuses to run untrusted programs where no isolation facility
+// is available, rather than silently running them unconfined
+func sandbox(cmd *exec.Cmd, root string) error {
+	return fmt.Errorf("sandboxed execution is not supported on %s", runtime.GOOS)
+}
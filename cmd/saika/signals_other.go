@@ -0,0 +1,31 @@
+// cmd/saika/signals_other.go
+
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package main
+
+import (
+	"os"
+	"os/exec"
+)
+
+// forwardedSignals are relayed from saika to the running program
+var forwardedSignals = []os.Signal{os.Interrupt}
+
+// terminateSignal stops the program, which cannot be asked to shut down here
+var terminateSignal os.Signal = os.Kill
+
+// isolateProcessGroup is a no-op where process groups are unavailable; the
+// console delivers Ctrl-C to the program as well as to saika
+func isolateProcessGroup(cmd *exec.Cmd, foreground bool) func() {
+	return func() {}
+}
+
+// signalProcessGroup stops the program; interrupts cannot be forwarded here
+func signalProcessGroup(proc *os.Process, sig os.Signal) error {
+	if sig == os.Interrupt {
+		// The program received the console's Ctrl-C itself
+		return nil
+	}
+	return proc.Kill()
+}
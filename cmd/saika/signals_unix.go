@@ -0,0 +1,66 @@
+// cmd/saika/signals_unix.go
+
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"unsafe"
+)
+
+// forwardedSignals are relayed from saika to the running program
+var forwardedSignals = []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT}
+
+// terminateSignal asks the program to shut down, as for a restart
+var terminateSignal os.Signal = syscall.SIGTERM
+
+// isolateProcessGroup starts cmd in its own process group. When saika owns
+// the terminal and foreground is allowed, the group also becomes the
+// terminal's foreground group, so the program can read input and receives
+// Ctrl-C directly. The returned function gives the terminal back to saika.
+func isolateProcessGroup(cmd *exec.Cmd, foreground bool) func() {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+
+	tty := int(os.Stdin.Fd())
+	if foreground && ownsTerminal(tty) {
+		cmd.SysProcAttr.Foreground = true
+		cmd.SysProcAttr.Ctty = tty
+		return func() { reclaimTerminal(tty) }
+	}
+
+	cmd.SysProcAttr.Setpgid = true
+	return func() {}
+}
+
+// ownsTerminal reports whether fd is a terminal whose foreground process
+// group is saika's own
+func ownsTerminal(fd int) bool {
+	var pgrp int32
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.TIOCGPGRP, uintptr(unsafe.Pointer(&pgrp)))
+	return errno == 0 && int(pgrp) == syscall.Getpgrp()
+}
+
+// reclaimTerminal makes saika's process group the foreground group of fd again
+func reclaimTerminal(fd int) {
+	// Changing the foreground group from the background raises SIGTTOU
+	signal.Ignore(syscall.SIGTTOU)
+	defer signal.Reset(syscall.SIGTTOU)
+
+	pgrp := int32(syscall.Getpgrp())
+	syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.TIOCSPGRP, uintptr(unsafe.Pointer(&pgrp)))
+}
+
+// signalProcessGroup sends sig to every process in the program's group
+func signalProcessGroup(proc *os.Process, sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return proc.Signal(sig)
+	}
+	return syscall.Kill(-proc.Pid, s)
+}
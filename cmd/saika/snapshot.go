@@ -0,0 +1,47 @@
+// cmd/saika/snapshot.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/saika-m/saika-lang/internal/transpiler"
+)
+
+// snapshotCommand compares the ASTs of the given sources with their
+// snapshot files, or rewrites the snapshots with --update
+func snapshotCommand(t *transpiler.Transpiler, args []string) {
+	fs := flag.NewFlagSet("snapshot", flag.ExitOnError)
+	update := fs.Bool("update", false, tr("write the snapshots instead of comparing them"))
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, tr("Usage:"), "saika snapshot [--update] <file.saika|dir|dir/...>...")
+		printDefaults(fs)
+	}
+	args = parseArgs(fs, args)
+	if len(args) == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	sources, err := transpiler.CollectSources(args)
+	if err != nil {
+		fmt.Printf(tr("Error: %v\n"), err)
+		os.Exit(1)
+	}
+
+	failed := false
+	for _, source := range sources {
+		changed, err := t.CheckSnapshot(source, *update)
+		switch {
+		case err != nil:
+			fmt.Printf("%s: %v\n", source, err)
+			failed = true
+		case changed:
+			fmt.Printf(tr("%s: updated %s%s\n"), source, source, transpiler.SnapshotExt)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,72 @@
+// cmd/saika/vet.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/saika-m/saika-lang/internal/diagnostic"
+	"github.com/saika-m/saika-lang/internal/transpiler"
+	"github.com/saika-m/saika-lang/internal/vet"
+)
+
+// vetCommand reports likely mistakes in the given sources, and names that
+// break the naming conventions of their project, exiting with status 1 if
+// it finds any
+func vetCommand(t *transpiler.Transpiler, args []string) {
+	fs := flag.NewFlagSet("vet", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, tr("Usage:"), "saika vet <file.saika|dir|dir/...>...")
+		printDefaults(fs)
+	}
+	args = parseArgs(fs, args)
+	if len(args) == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	sources, err := transpiler.CollectSources(args)
+	if err != nil {
+		fmt.Printf(tr("Error: %v\n"), err)
+		os.Exit(1)
+	}
+
+	found := false
+	for _, source := range sources {
+		src, err := os.ReadFile(source)
+		if err != nil {
+			fmt.Printf(tr("Error: %s: %v\n"), source, err)
+			os.Exit(1)
+		}
+		project, err := transpiler.FindProject(filepath.Dir(source))
+		if err != nil {
+			fmt.Printf(tr("Error: %v\n"), err)
+			os.Exit(1)
+		}
+		var naming vet.Naming
+		t.Language = ""
+		if project != nil {
+			naming, t.Language = project.Naming, project.Language
+		}
+
+		for _, d := range diagnose(t, string(src), naming) {
+			fmt.Printf("%s:%d:%d: %s: [%s] %s\n", source, d.Range.Start.Line, d.Range.Start.Column, d.Severity, d.Code, d.Message)
+			found = true
+		}
+	}
+	if found {
+		os.Exit(1)
+	}
+}
+
+// diagnose returns the diagnostics of src, followed by its vet warnings
+// when it has no errors
+func diagnose(t *transpiler.Transpiler, src string, naming vet.Naming) diagnostic.List {
+	program, diags := t.Check(src)
+	if !diags.HasErrors() {
+		diags = append(diags, vet.Check(program, naming)...)
+	}
+	return diags
+}
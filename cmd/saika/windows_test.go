@@ -0,0 +1,125 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWithExeSuffix(t *testing.T) {
+	for name, want := range map[string]string{
+		`hello`:          `hello.exe`,
+		`hello.exe`:      `hello.exe`,
+		`HELLO.EXE`:      `HELLO.EXE`,
+		`dist\app`:       `dist\app.exe`,
+		`dist\app.saika`: `dist\app.saika.exe`,
+	} {
+		if got := withExeSuffix(name, "windows"); got != want {
+			t.Errorf("withExeSuffix(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestProgramPath(t *testing.T) {
+	got := programPath(`C:\work\saika-1`)
+	if want := `C:\work\saika-1\saika-program.exe`; got != want {
+		t.Errorf("programPath = %q, want %q", got, want)
+	}
+}
+
+func TestDefaultOutputName(t *testing.T) {
+	t.Setenv("GOOS", "")
+	dir := t.TempDir()
+	app := filepath.Join(dir, "应用")
+	if err := os.Mkdir(app, 0755); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(app, "你好.saika")
+	if err := os.WriteFile(file, []byte("包 main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct{ arg, want string }{
+		{file, filepath.Join(app, "你好.exe")},
+		{app, filepath.Join(app, "应用.exe")},
+		{app + `\...`, filepath.Join(app, "应用.exe")},
+		{filepath.ToSlash(app) + "/...", filepath.Join(app, "应用.exe")},
+	} {
+		if got := defaultOutputName([]string{c.arg}); got != c.want {
+			t.Errorf("defaultOutputName(%q) = %q, want %q", c.arg, got, c.want)
+		}
+	}
+}
+
+func TestExpandOutput(t *testing.T) {
+	t.Setenv("GOOS", "")
+	t.Setenv("GOARCH", "amd64")
+	dir := t.TempDir()
+
+	for _, c := range []struct{ output, want string }{
+		{`dist\app`, `dist\app.exe`},
+		{`dist\{name}-{goos}-{goarch}{ext}`, `dist\hello-windows-amd64.exe`},
+		{`dist\{name}`, `dist\hello.exe`},
+		{dir, filepath.Join(dir, "hello.exe")},
+	} {
+		opts := &options{output: c.output}
+		if got := expandOutput(opts, `src\hello.exe`); got != c.want {
+			t.Errorf("expandOutput(-o %s) = %q, want %q", c.output, got, c.want)
+		}
+	}
+}
+
+// TestBuildAndRun builds saika, then uses it as cmd.exe would: with a
+// glob pattern left unexpanded, a backslash path and Chinese output
+func TestBuildAndRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds saika and a program with the go command")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("the go command is not installed")
+	}
+
+	dir := t.TempDir()
+	saika := filepath.Join(dir, "saika.exe")
+	if out, err := exec.Command("go", "build", "-o", saika, ".").CombinedOutput(); err != nil {
+		t.Fatalf("go build saika: %v\n%s", err, out)
+	}
+
+	src := filepath.Join(dir, "src")
+	if err := os.Mkdir(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"main.saika":  "包 main\n\n导入 \"fmt\"\n\n数 入口() {\n\tfmt.Println(问候())\n}\n",
+		"greet.saika": "包 main\n\n数 问候() 字符串 {\n\t返回 \"你好，世界\"\n}\n",
+	}
+	for name, code := range files {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(code), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	output := filepath.Join(dir, "out", "hello")
+	if err := os.Mkdir(filepath.Dir(output), 0755); err != nil {
+		t.Fatal(err)
+	}
+	build := exec.Command(saika, "build", "-o", output, src+`\*.saika`)
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("saika build: %v\n%s", err, out)
+	}
+	if _, err := os.Stat(output + ".exe"); err != nil {
+		t.Fatalf("build did not write %s.exe: %v", output, err)
+	}
+
+	out, err := exec.Command(output + ".exe").Output()
+	if err != nil {
+		t.Fatalf("run %s.exe: %v", output, err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "你好，世界" {
+		t.Errorf("program printed %q, want %q", got, "你好，世界")
+	}
+}
@@ -0,0 +1,167 @@
+// Package archive reads and writes Saika archives: the files of a program,
+// such as its sources and saika.json, in one text that can be shared as a
+// single snippet. The format is that of Go's txtar:
+//
+//	两个文件的示例
+//	-- saika.json --
+//	{"entry": ["开始"]}
+//	-- main.saika --
+//	包 main
+//	...
+//
+// Text before the first file header is a comment. Each file holds the
+// lines from its header up to the next header or the end of the archive.
+package archive
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Ext is the extension archive files are saved with
+const Ext = ".txtar"
+
+// Archive is a set of named files with a comment
+type Archive struct {
+	Comment []byte
+	Files   []File
+}
+
+// File is one file of an archive, named by a slash-separated path
+// relative to the directory the archive is extracted to
+type File struct {
+	Name string
+	Data []byte
+}
+
+var (
+	headerStart = []byte("-- ")
+	headerEnd   = []byte(" --")
+)
+
+// Parse parses an archive. Any text is an archive, so Parse does not fail;
+// Check reports files that cannot be extracted.
+func Parse(data []byte) *Archive {
+	a := &Archive{}
+	var name string
+	a.Comment, name, data = nextFile(data)
+	for name != "" {
+		f := File{Name: name}
+		f.Data, name, data = nextFile(data)
+		a.Files = append(a.Files, f)
+	}
+	return a
+}
+
+// nextFile splits data at the next file header, returning the text before
+// it, the name in the header and the text after it
+func nextFile(data []byte) (before []byte, name string, after []byte) {
+	for i := 0; i < len(data); {
+		line := data[i:]
+		end := bytes.IndexByte(line, '\n')
+		if end >= 0 {
+			line = line[:end+1]
+		}
+		if name, ok := headerName(line); ok {
+			return data[:i], name, data[i+len(line):]
+		}
+		i += len(line)
+	}
+	return data, "", nil
+}
+
+// headerName returns the name of a file header line
+func headerName(line []byte) (string, bool) {
+	line = bytes.TrimRight(line, "\r\n")
+	if !bytes.HasPrefix(line, headerStart) || !bytes.HasSuffix(line, headerEnd) || len(line) < len(headerStart)+len(headerEnd) {
+		return "", false
+	}
+	name := strings.TrimSpace(string(line[len(headerStart) : len(line)-len(headerEnd)]))
+	return name, name != ""
+}
+
+// Format returns the text of an archive. Files that do not end in a
+// newline get one, so that the next header starts a line.
+func Format(a *Archive) []byte {
+	var out bytes.Buffer
+	out.Write(withNewline(a.Comment))
+	for _, f := range a.Files {
+		fmt.Fprintf(&out, "-- %s --\n", f.Name)
+		out.Write(withNewline(f.Data))
+	}
+	return out.Bytes()
+}
+
+// withNewline returns data ending in a newline, unless it is empty
+func withNewline(data []byte) []byte {
+	if len(data) == 0 || data[len(data)-1] == '\n' {
+		return data
+	}
+	return append(data[:len(data):len(data)], '\n')
+}
+
+// Check reports the first file that cannot be extracted: one whose name
+// is not a relative path within the directory, or that appears twice
+func (a *Archive) Check() error {
+	seen := map[string]bool{}
+	for _, f := range a.Files {
+		if !filepath.IsLocal(filepath.FromSlash(f.Name)) || path.Clean(f.Name) != f.Name {
+			return fmt.Errorf("archive file %q is not a relative path in the directory", f.Name)
+		}
+		if seen[f.Name] {
+			return fmt.Errorf("archive has two files named %s", f.Name)
+		}
+		seen[f.Name] = true
+	}
+	return nil
+}
+
+// Extract writes the files of the archive into dir, returning their
+// paths. Existing files are only overwritten when force is set.
+func (a *Archive) Extract(dir string, force bool) ([]string, error) {
+	if err := a.Check(); err != nil {
+		return nil, err
+	}
+	paths := make([]string, len(a.Files))
+	for i, f := range a.Files {
+		paths[i] = filepath.Join(dir, filepath.FromSlash(f.Name))
+		if _, err := os.Stat(paths[i]); err == nil && !force {
+			return nil, fmt.Errorf("%s already exists; use --force to overwrite it", paths[i])
+		}
+	}
+	for i, f := range a.Files {
+		if err := os.MkdirAll(filepath.Dir(paths[i]), 0755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(paths[i], f.Data, 0644); err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+// FromFiles creates an archive of the given files, named by their paths
+// relative to dir, which must contain them all
+func FromFiles(dir string, paths []string) (*Archive, error) {
+	a := &Archive{}
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel(dir, abs)
+		if err != nil || !filepath.IsLocal(rel) {
+			return nil, fmt.Errorf("%s is outside %s", p, dir)
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		a.Files = append(a.Files, File{Name: filepath.ToSlash(rel), Data: data})
+	}
+	return a, nil
+}
@@ -2,6 +2,8 @@ package ast
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -45,8 +47,9 @@ func (p *Program) String() string {
 
 // PackageStatement represents a package declaration
 type PackageStatement struct {
-	Token Token
-	Name  string
+	Token     Token
+	Name      string
+	NameToken Token // the identifier holding Name
 }
 
 func (ps *PackageStatement) statementNode()       {}
@@ -57,8 +60,10 @@ func (ps *PackageStatement) String() string {
 
 // ImportStatement represents an import declaration
 type ImportStatement struct {
-	Token Token
-	Path  string
+	Token     Token
+	Path      string
+	PathToken Token // the string literal holding Path
+	Rparen    Token // the closing ')' of a parenthesized import
 }
 
 func (is *ImportStatement) statementNode()       {}
@@ -71,7 +76,8 @@ func (is *ImportStatement) String() string {
 type VarStatement struct {
 	Token Token // the '变量' token
 	Name  *Identifier
-	Value Expression
+	Type  Expression // nil when the variable has the type of its value
+	Value Expression // nil when the variable starts as the zero value of Type
 }
 
 func (vs *VarStatement) statementNode()       {}
@@ -81,20 +87,58 @@ func (vs *VarStatement) String() string {
 
 	out.WriteString(vs.TokenLiteral() + " ")
 	out.WriteString(vs.Name.String())
-	out.WriteString(" = ")
-
+	if vs.Type != nil {
+		out.WriteString(" " + vs.Type.String())
+	}
 	if vs.Value != nil {
+		out.WriteString(" = ")
 		out.WriteString(vs.Value.String())
 	}
 
 	return out.String()
 }
 
+// VarListStatement represents a declaration of several variables taking
+// the results of one call, such as 变量 值, 错 = 解析(文本)
+type VarListStatement struct {
+	Token Token // the '变量' token
+	Names []*Identifier
+	Value Expression
+}
+
+func (vs *VarListStatement) statementNode()       {}
+func (vs *VarListStatement) TokenLiteral() string { return vs.Token.Literal }
+func (vs *VarListStatement) String() string {
+	names := []string{}
+	for _, name := range vs.Names {
+		names = append(names, name.String())
+	}
+	return vs.TokenLiteral() + " " + strings.Join(names, ", ") + " = " + vs.Value.String()
+}
+
+// AssignListStatement represents assigning the results of one call to
+// several targets, such as 值, 忽略 = 解析(文本)
+type AssignListStatement struct {
+	Token   Token // the '=' token
+	Targets []Expression
+	Value   Expression
+}
+
+func (as *AssignListStatement) statementNode()       {}
+func (as *AssignListStatement) TokenLiteral() string { return as.Token.Literal }
+func (as *AssignListStatement) String() string {
+	targets := []string{}
+	for _, target := range as.Targets {
+		targets = append(targets, target.String())
+	}
+	return strings.Join(targets, ", ") + " = " + as.Value.String()
+}
+
 // ConstStatement represents a constant declaration
 type ConstStatement struct {
-	Token Token // the '常量' token
+	Token Token // the '常量' token, unset in a ConstGroupStatement
 	Name  *Identifier
-	Value Expression
+	Value Expression // nil when it repeats the value before it in a group
 }
 
 func (cs *ConstStatement) statementNode()       {}
@@ -113,6 +157,66 @@ func (cs *ConstStatement) String() string {
 	return out.String()
 }
 
+// ConstGroupStatement represents constants declared together, such as
+//
+//	常量 (
+//		甲 = 序号
+//		乙
+//		丙
+//	)
+//
+// A constant without a value repeats the value of the one before it, and
+// 序号, Go's iota, counts the constants of the group from 0, so 甲, 乙 and
+// 丙 are 0, 1 and 2.
+type ConstGroupStatement struct {
+	Token  Token // the '常量' token
+	Consts []*ConstStatement
+	Rparen Token // the ')' token
+}
+
+func (cg *ConstGroupStatement) statementNode()       {}
+func (cg *ConstGroupStatement) TokenLiteral() string { return cg.Token.Literal }
+func (cg *ConstGroupStatement) String() string {
+	consts := make([]string, len(cg.Consts))
+	for i, c := range cg.Consts {
+		consts[i] = c.Name.String()
+		if c.Value != nil {
+			consts[i] += " = " + c.Value.String()
+		}
+	}
+	return cg.TokenLiteral() + " (" + strings.Join(consts, "; ") + ")"
+}
+
+// OptionStatement represents a command-line option declaration, a package
+// variable set from a flag of the same name when the program starts:
+//
+//	选项 端口 整数 = 8080 "监听端口"
+type OptionStatement struct {
+	Token Token // the '选项' token
+	Name  *Identifier
+	Type  *Identifier
+	Value Expression
+	Usage *StringLiteral // may be nil
+}
+
+func (op *OptionStatement) statementNode()       {}
+func (op *OptionStatement) TokenLiteral() string { return op.Token.Literal }
+func (op *OptionStatement) String() string {
+	var out strings.Builder
+
+	out.WriteString(op.TokenLiteral() + " ")
+	out.WriteString(op.Name.String() + " ")
+	out.WriteString(op.Type.String())
+	out.WriteString(" = ")
+	out.WriteString(op.Value.String())
+
+	if op.Usage != nil {
+		out.WriteString(" \"" + op.Usage.String() + "\"")
+	}
+
+	return out.String()
+}
+
 // ReturnStatement represents a return statement
 type ReturnStatement struct {
 	Token       Token // the '返回' token
@@ -135,17 +239,25 @@ func (rs *ReturnStatement) String() string {
 
 // TypedParam represents a parameter with a type
 type TypedParam struct {
-	Name *Identifier
-	Type *Identifier
+	Name     *Identifier
+	Type     Expression
+	Variadic bool // whether the parameter takes any number of arguments, as in 数字 ...整数
 }
 
 // FunctionStatement represents a function declaration
 type FunctionStatement struct {
-	Token      Token // the '數' token
+	Token      Token       // the '數' token
+	Receiver   *TypedParam // of a method, as in 数 (v 向量) 长度() 浮点; nil for a function
 	Name       *Identifier
+	TypeParams []*TypeParam // of a generic function, as in 最大[T 可比较]
 	Parameters []*TypedParam
 	Body       *BlockStatement
-	ReturnType *Identifier
+	ReturnType Expression
+
+	// ChangesReceiver is whether a method changes its receiver, by
+	// assigning to it or by calling a method that does, which lowering
+	// sets from what checking the package found
+	ChangesReceiver bool
 }
 
 func (fs *FunctionStatement) statementNode()       {}
@@ -155,7 +267,9 @@ func (fs *FunctionStatement) String() string {
 
 	params := []string{}
 	for _, p := range fs.Parameters {
-		if p.Type != nil {
+		if p.Variadic {
+			params = append(params, p.Name.String()+" ..."+p.Type.String())
+		} else if p.Type != nil {
 			params = append(params, p.Name.String()+" "+p.Type.String())
 		} else {
 			params = append(params, p.Name.String())
@@ -164,7 +278,17 @@ func (fs *FunctionStatement) String() string {
 
 	out.WriteString(fs.TokenLiteral())
 	out.WriteString(" ")
+	if fs.Receiver != nil {
+		out.WriteString("(" + fs.Receiver.Name.String() + " " + fs.Receiver.Type.String() + ") ")
+	}
 	out.WriteString(fs.Name.String())
+	if len(fs.TypeParams) > 0 {
+		typeParams := make([]string, len(fs.TypeParams))
+		for i, tp := range fs.TypeParams {
+			typeParams[i] = tp.Name.String() + " " + tp.Constraint.String()
+		}
+		out.WriteString("[" + strings.Join(typeParams, ", ") + "]")
+	}
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
 	out.WriteString(")")
@@ -180,6 +304,164 @@ func (fs *FunctionStatement) String() string {
 	return out.String()
 }
 
+// TypeParam is a type parameter of a generic function or type with the
+// constraint on its type arguments, such as T 可比较
+type TypeParam struct {
+	Name       *Identifier
+	Constraint Expression
+}
+
+// UnionType is a constraint satisfied by any of several types, such as
+// 整数 | 浮点
+type UnionType struct {
+	Types []Expression
+}
+
+func (ut *UnionType) expressionNode()      {}
+func (ut *UnionType) TokenLiteral() string { return ut.Types[0].TokenLiteral() }
+func (ut *UnionType) String() string {
+	types := make([]string, len(ut.Types))
+	for i, t := range ut.Types {
+		types[i] = t.String()
+	}
+	return strings.Join(types, " | ")
+}
+
+// FunctionLiteral represents an anonymous function, which may use the
+// variables of the scope it appears in
+type FunctionLiteral struct {
+	Token      Token // the '数' token
+	Parameters []*TypedParam
+	ReturnType Expression
+	Body       *BlockStatement
+}
+
+func (fl *FunctionLiteral) expressionNode()      {}
+func (fl *FunctionLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FunctionLiteral) String() string {
+	var out strings.Builder
+
+	params := []string{}
+	for _, p := range fl.Parameters {
+		if p.Variadic {
+			params = append(params, p.Name.String()+" ..."+p.Type.String())
+		} else if p.Type != nil {
+			params = append(params, p.Name.String()+" "+p.Type.String())
+		} else {
+			params = append(params, p.Name.String())
+		}
+	}
+
+	out.WriteString(fl.TokenLiteral())
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") ")
+	if fl.ReturnType != nil {
+		out.WriteString(fl.ReturnType.String() + " ")
+	}
+	out.WriteString(fl.Body.String())
+
+	return out.String()
+}
+
+// InterfaceStatement represents an interface type declaration
+type InterfaceStatement struct {
+	Token   Token // the '接口' token
+	Name    *Identifier
+	Methods []*MethodSignature
+	Rbrace  Token // the '}' token
+}
+
+// TypeStatement represents a type declaration: a defined type, 类型 身份证
+// 字符串, or an alias, 类型 身份证 = 字符串. A generic defined type has type
+// parameters, as in 类型 栈[T 任意] 结构 { 元素 切片[T] }, and is
+// instantiated with type arguments, as in 栈[整数].
+type TypeStatement struct {
+	Token      Token // the '类型' token
+	Name       *Identifier
+	TypeParams []*TypeParam
+	Alias      bool
+	Type       Expression
+}
+
+func (ts *TypeStatement) statementNode()       {}
+func (ts *TypeStatement) TokenLiteral() string { return ts.Token.Literal }
+func (ts *TypeStatement) String() string {
+	name := ts.Name.String()
+	if len(ts.TypeParams) > 0 {
+		typeParams := make([]string, len(ts.TypeParams))
+		for i, tp := range ts.TypeParams {
+			typeParams[i] = tp.Name.String() + " " + tp.Constraint.String()
+		}
+		name += "[" + strings.Join(typeParams, ", ") + "]"
+	}
+	if ts.Alias {
+		return ts.TokenLiteral() + " " + name + " = " + ts.Type.String()
+	}
+	return ts.TokenLiteral() + " " + name + " " + ts.Type.String()
+}
+
+// EnumStatement represents an enumeration, 枚举 颜色 { 红, 绿, 蓝 }: a
+// defined integer type with a constant of it for each member, numbered
+// from 0 in order
+type EnumStatement struct {
+	Token   Token // the '枚举' token
+	Name    *Identifier
+	Members []*Identifier
+	Rbrace  Token // the '}' token
+}
+
+func (es *EnumStatement) statementNode()       {}
+func (es *EnumStatement) TokenLiteral() string { return es.Token.Literal }
+func (es *EnumStatement) String() string {
+	members := make([]string, len(es.Members))
+	for i, m := range es.Members {
+		members[i] = m.String()
+	}
+	return es.TokenLiteral() + " " + es.Name.String() + " { " + strings.Join(members, ", ") + " }"
+}
+
+// MethodSignature is a method listed in an interface declaration
+type MethodSignature struct {
+	Name       *Identifier
+	Parameters []*TypedParam
+	ReturnType Expression
+}
+
+func (is *InterfaceStatement) statementNode()       {}
+func (is *InterfaceStatement) TokenLiteral() string { return is.Token.Literal }
+func (is *InterfaceStatement) String() string {
+	var out strings.Builder
+
+	out.WriteString(is.TokenLiteral())
+	out.WriteString(" ")
+	out.WriteString(is.Name.String())
+	out.WriteString(" { ")
+	for _, m := range is.Methods {
+		params := []string{}
+		for _, p := range m.Parameters {
+			if p.Type != nil {
+				params = append(params, p.Name.String()+" "+p.Type.String())
+			} else {
+				params = append(params, p.Name.String())
+			}
+		}
+
+		out.WriteString(m.Name.String())
+		out.WriteString("(")
+		out.WriteString(strings.Join(params, ", "))
+		out.WriteString(")")
+		if m.ReturnType != nil {
+			out.WriteString(" ")
+			out.WriteString(m.ReturnType.String())
+		}
+		out.WriteString("; ")
+	}
+	out.WriteString("}")
+
+	return out.String()
+}
+
 // IfStatement represents an if statement
 type IfStatement struct {
 	Token       Token // the '如果' token
@@ -242,10 +524,285 @@ func (fs *ForStatement) String() string {
 	return out.String()
 }
 
+// WhileStatement represents a loop that runs while its condition holds
+type WhileStatement struct {
+	Token     Token // the '当' token
+	Condition Expression
+	Body      *BlockStatement
+}
+
+func (ws *WhileStatement) statementNode()       {}
+func (ws *WhileStatement) TokenLiteral() string { return ws.Token.Literal }
+func (ws *WhileStatement) String() string {
+	var out strings.Builder
+
+	out.WriteString("for ")
+	out.WriteString(ws.Condition.String())
+	out.WriteString(" ")
+	out.WriteString(ws.Body.String())
+
+	return out.String()
+}
+
+// CharLoopStatement represents a loop over the characters of a string,
+// binding each as a one-character string and, optionally, its index
+// counted in characters:
+//
+//	逐字符 (位置, 字) = 文本 { ... }
+type CharLoopStatement struct {
+	Token Token       // the '逐字符' token
+	Index *Identifier // nil when only the character is named
+	Char  *Identifier
+	Value Expression
+	Body  *BlockStatement
+}
+
+func (cs *CharLoopStatement) statementNode()       {}
+func (cs *CharLoopStatement) TokenLiteral() string { return cs.Token.Literal }
+func (cs *CharLoopStatement) String() string {
+	var out strings.Builder
+
+	out.WriteString(cs.TokenLiteral())
+	out.WriteString(" (")
+	if cs.Index != nil {
+		out.WriteString(cs.Index.String())
+		out.WriteString(", ")
+	}
+	out.WriteString(cs.Char.String())
+	out.WriteString(") = ")
+	out.WriteString(cs.Value.String())
+	out.WriteString(" ")
+	out.WriteString(cs.Body.String())
+
+	return out.String()
+}
+
+// RangeStatement represents a loop over the elements of a slice or array,
+// or the values of an iterator, a struct value with the methods 下一个()
+// 布尔, which moves to the next value and reports whether there is one,
+// and 值(), which returns it:
+//
+//	范围 (学生) = 名单 { ... }
+//
+// The loop works on a copy of an iterator, which it moves along.
+type RangeStatement struct {
+	Token Token // the '范围' token
+	Name  *Identifier
+	Value Expression
+	Body  *BlockStatement
+
+	// Element is the type of the values of an iterator, which lowering
+	// sets from what checking the program found; nil for other values
+	Element Expression
+}
+
+func (rs *RangeStatement) statementNode()       {}
+func (rs *RangeStatement) TokenLiteral() string { return rs.Token.Literal }
+func (rs *RangeStatement) String() string {
+	var out strings.Builder
+
+	out.WriteString(rs.TokenLiteral())
+	out.WriteString(" (")
+	out.WriteString(rs.Name.String())
+	out.WriteString(") = ")
+	out.WriteString(rs.Value.String())
+	out.WriteString(" ")
+	out.WriteString(rs.Body.String())
+
+	return out.String()
+}
+
+// QueryStatement represents a database query whose body runs once for
+// each result row, with the row's columns bound to typed variables:
+//
+//	查询 (名字 字符串, 年龄 整数) = 库("SELECT name, age FROM users WHERE age > ?", 18) { ... }
+//
+// The call names the database first and passes the SQL and its arguments.
+// A query that fails is returned from the enclosing function when its last
+// result is 错误, and panics otherwise.
+type QueryStatement struct {
+	Token   Token // the '查询' token
+	Columns []*TypedParam
+	Call    *CallExpression
+	Body    *BlockStatement
+}
+
+func (qs *QueryStatement) statementNode()       {}
+func (qs *QueryStatement) TokenLiteral() string { return qs.Token.Literal }
+func (qs *QueryStatement) String() string {
+	var out strings.Builder
+
+	columns := []string{}
+	for _, c := range qs.Columns {
+		columns = append(columns, c.Name.String()+" "+c.Type.String())
+	}
+
+	out.WriteString(qs.TokenLiteral())
+	out.WriteString(" (")
+	out.WriteString(strings.Join(columns, ", "))
+	out.WriteString(") = ")
+	out.WriteString(qs.Call.String())
+	out.WriteString(" ")
+	out.WriteString(qs.Body.String())
+
+	return out.String()
+}
+
+// SignalStatement represents a handler run once the program receives an
+// interrupt or termination signal, for shutting down gracefully
+type SignalStatement struct {
+	Token Token // the '捕获信号' token
+	Body  *BlockStatement
+}
+
+func (ss *SignalStatement) statementNode()       {}
+func (ss *SignalStatement) TokenLiteral() string { return ss.Token.Literal }
+func (ss *SignalStatement) String() string {
+	return ss.TokenLiteral() + " " + ss.Body.String()
+}
+
+// DeferStatement represents a call that runs when the enclosing function
+// returns
+type DeferStatement struct {
+	Token Token // the '推迟' token
+	Call  *CallExpression
+}
+
+func (ds *DeferStatement) statementNode()       {}
+func (ds *DeferStatement) TokenLiteral() string { return ds.Token.Literal }
+func (ds *DeferStatement) String() string {
+	return ds.TokenLiteral() + " " + ds.Call.String()
+}
+
+// GoStatement represents a call that runs in a goroutine of its own
+type GoStatement struct {
+	Token Token // the '协程' token
+	Call  *CallExpression
+}
+
+func (gs *GoStatement) statementNode()       {}
+func (gs *GoStatement) TokenLiteral() string { return gs.Token.Literal }
+func (gs *GoStatement) String() string {
+	return gs.TokenLiteral() + " " + gs.Call.String()
+}
+
+// SendStatement represents sending a value on a channel, as in 通道 <- 1
+type SendStatement struct {
+	Token   Token // the '<-' token
+	Channel Expression
+	Value   Expression
+}
+
+func (ss *SendStatement) statementNode()       {}
+func (ss *SendStatement) TokenLiteral() string { return ss.Token.Literal }
+func (ss *SendStatement) String() string {
+	return ss.Channel.String() + " <- " + ss.Value.String()
+}
+
+// Receive returns the receive of the channel operation of a 情况 clause:
+// <-通道 on its own, assigned as in 值 = <-通道, or declared as in
+// 变量 值 = <-通道. It returns nil for a send or any other statement.
+func Receive(comm Statement) *PrefixExpression {
+	var expr Expression
+	switch comm := comm.(type) {
+	case *VarStatement:
+		expr = comm.Value
+	case *ExpressionStatement:
+		expr = comm.Expression
+		if assign, ok := expr.(*AssignExpression); ok {
+			expr = assign.Value
+		}
+	}
+	if prefix, ok := expr.(*PrefixExpression); ok && prefix.Operator == "<-" {
+		return prefix
+	}
+	return nil
+}
+
+// SelectStatement represents a 监听 statement, which waits until one of
+// the channel operations of its cases can proceed
+type SelectStatement struct {
+	Token  Token // the '监听' token
+	Cases  []*SelectCase
+	Rbrace Token // the '}' token
+}
+
+func (ss *SelectStatement) statementNode()       {}
+func (ss *SelectStatement) TokenLiteral() string { return ss.Token.Literal }
+func (ss *SelectStatement) String() string {
+	var out strings.Builder
+	out.WriteString("select { ")
+	for _, c := range ss.Cases {
+		if c.Comm != nil {
+			out.WriteString("case " + c.Comm.String() + ": ")
+		} else {
+			out.WriteString("default: ")
+		}
+		for _, stmt := range c.Body {
+			out.WriteString(stmt.String() + "; ")
+		}
+	}
+	out.WriteString("}")
+	return out.String()
+}
+
+// SelectCase is a 情况 clause of a 监听 statement with its channel
+// operation, or the 默认 clause that runs when no operation can proceed
+type SelectCase struct {
+	Token Token     // the '情况' or '默认' token
+	Comm  Statement // the send or receive; nil for 默认
+	Body  []Statement
+}
+
+// SwitchStatement represents a 选择 statement, which runs the first case
+// whose value equals its tag, or whose value is true when it has none
+type SwitchStatement struct {
+	Token  Token      // the '选择' token
+	Tag    Expression // nil for 选择 { 情况 条件: … }
+	Cases  []*SwitchCase
+	Rbrace Token // the '}' token
+}
+
+func (ss *SwitchStatement) statementNode()       {}
+func (ss *SwitchStatement) TokenLiteral() string { return ss.Token.Literal }
+func (ss *SwitchStatement) String() string {
+	var out strings.Builder
+	out.WriteString("switch ")
+	if ss.Tag != nil {
+		out.WriteString(ss.Tag.String() + " ")
+	}
+	out.WriteString("{ ")
+	for _, c := range ss.Cases {
+		if len(c.Values) > 0 {
+			values := make([]string, len(c.Values))
+			for i, v := range c.Values {
+				values[i] = v.String()
+			}
+			out.WriteString("case " + strings.Join(values, ", ") + ": ")
+		} else {
+			out.WriteString("default: ")
+		}
+		for _, stmt := range c.Body {
+			out.WriteString(stmt.String() + "; ")
+		}
+	}
+	out.WriteString("}")
+	return out.String()
+}
+
+// SwitchCase is a 情况 clause of a 选择 statement with its values, or the
+// 默认 clause that runs when no case matches
+type SwitchCase struct {
+	Token  Token        // the '情况' or '默认' token
+	Values []Expression // empty for 默认
+	Body   []Statement
+}
+
 // BlockStatement represents a block of statements enclosed in { }
 type BlockStatement struct {
 	Token      Token // the '{' token
 	Statements []Statement
+	Rbrace     Token // the '}' token
 }
 
 func (bs *BlockStatement) statementNode()       {}
@@ -297,16 +854,60 @@ func (il *IntegerLiteral) expressionNode()      {}
 func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
 func (il *IntegerLiteral) String() string       { return il.Token.Literal }
 
+// FloatLiteral represents a floating-point literal
+type FloatLiteral struct {
+	Token Token
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode()      {}
+func (fl *FloatLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FloatLiteral) String() string       { return fl.Token.Literal }
+
+// UnitLiteral represents a number of durations or byte sizes, such as
+// 500毫秒 or 10兆字节
+type UnitLiteral struct {
+	Token Token
+	Value float64 // number of units
+	Unit  string  // unit suffix, a key of Units
+}
+
+func (ul *UnitLiteral) expressionNode()      {}
+func (ul *UnitLiteral) TokenLiteral() string { return ul.Token.Literal }
+func (ul *UnitLiteral) String() string       { return ul.Token.Literal }
+
+// Number returns the number part of the literal as written
+func (ul *UnitLiteral) Number() string {
+	return strings.TrimSuffix(ul.Token.Literal, ul.Unit)
+}
+
 // StringLiteral represents a string literal
 type StringLiteral struct {
 	Token Token
-	Value string
+	Value string // as written between the quotes, escape sequences included
 }
 
 func (sl *StringLiteral) expressionNode()      {}
 func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
 func (sl *StringLiteral) String() string       { return "\"" + sl.Value + "\"" }
 
+// Text returns the string the literal denotes, with its escape sequences
+// decoded as Go decodes them: "a\tb" is a, a tab and b. The parser rejects
+// literals that do not decode, for which Value is returned as it is.
+func (sl *StringLiteral) Text() string {
+	text, err := UnquoteString(sl.Value)
+	if err != nil {
+		return sl.Value
+	}
+	return text
+}
+
+// UnquoteString decodes the escape sequences of the text of a string
+// literal, written between its quotes
+func UnquoteString(value string) (string, error) {
+	return strconv.Unquote(`"` + value + `"`)
+}
+
 // BooleanLiteral represents a boolean literal
 type BooleanLiteral struct {
 	Token Token
@@ -362,6 +963,21 @@ func (ae *AssignExpression) String() string {
 	return fmt.Sprintf("%s = %s", ae.Left.String(), ae.Value.String())
 }
 
+// CompoundAssignExpression represents an assignment that applies an
+// operator to the target, such as x += 1
+type CompoundAssignExpression struct {
+	Token    Token  // the compound assignment token, e.g. '+='
+	Operator string // the operator applied, e.g. "+"
+	Left     Expression
+	Value    Expression
+}
+
+func (ce *CompoundAssignExpression) expressionNode()      {}
+func (ce *CompoundAssignExpression) TokenLiteral() string { return ce.Token.Literal }
+func (ce *CompoundAssignExpression) String() string {
+	return fmt.Sprintf("%s %s= %s", ce.Left.String(), ce.Operator, ce.Value.String())
+}
+
 // MemberExpression represents a member expression like fmt.Println
 type MemberExpression struct {
 	Token    Token // the '.' token
@@ -380,6 +996,8 @@ type CallExpression struct {
 	Token     Token // The '(' token
 	Function  Expression
 	Arguments []Expression
+	Ellipsis  bool  // whether the last argument is spread, as in f(s...)
+	Rparen    Token // The ')' token
 }
 
 func (ce *CallExpression) expressionNode()      {}
@@ -395,17 +1013,322 @@ func (ce *CallExpression) String() string {
 	out.WriteString(ce.Function.String())
 	out.WriteString("(")
 	out.WriteString(strings.Join(args, ", "))
+	if ce.Ellipsis {
+		out.WriteString("...")
+	}
 	out.WriteString(")")
 
 	return out.String()
 }
 
+// MapType represents a map type such as 映射[字符串]整数
+type MapType struct {
+	Token Token // the '映射' token
+	Key   Expression
+	Value Expression
+}
+
+func (mt *MapType) expressionNode()      {}
+func (mt *MapType) TokenLiteral() string { return mt.Token.Literal }
+func (mt *MapType) String() string {
+	return "map[" + mt.Key.String() + "]" + mt.Value.String()
+}
+
+// MapLiteral represents a map literal such as 映射[字符串]整数{"a": 1}
+type MapLiteral struct {
+	Token  Token // the '{' token
+	Type   *MapType
+	Pairs  []*MapPair
+	Rbrace Token // the '}' token
+}
+
+// MapPair is a key and its value in a map literal
+type MapPair struct {
+	Key   Expression
+	Value Expression
+}
+
+func (ml *MapLiteral) expressionNode()      {}
+func (ml *MapLiteral) TokenLiteral() string { return ml.Token.Literal }
+func (ml *MapLiteral) String() string {
+	pairs := []string{}
+	for _, pair := range ml.Pairs {
+		pairs = append(pairs, pair.Key.String()+": "+pair.Value.String())
+	}
+	return ml.Type.String() + "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// StructType represents a struct type such as
+//
+//	结构 {
+//		名字 字符串
+//		年龄 整数
+//	}
+//
+// whose fields are separated by newlines or semicolons
+type StructType struct {
+	Token  Token // the '结构' token
+	Fields []*Field
+	Rbrace Token // the '}' token
+}
+
+// Field is a named field of a struct type
+type Field struct {
+	Name *Identifier
+	Type Expression
+}
+
+func (st *StructType) expressionNode()      {}
+func (st *StructType) TokenLiteral() string { return st.Token.Literal }
+func (st *StructType) String() string {
+	fields := []string{}
+	for _, f := range st.Fields {
+		fields = append(fields, f.Name.String()+" "+f.Type.String())
+	}
+	return "struct { " + strings.Join(fields, "; ") + " }"
+}
+
+// CompositeLiteral represents a value of a struct type given by the
+// values of its fields, such as 用户{名字: "张三", 年龄: 30}. Fields
+// left out have their zero value.
+type CompositeLiteral struct {
+	Token  Token // the '{' token
+	Type   Expression
+	Fields []*FieldValue
+	Rbrace Token // the '}' token
+}
+
+// FieldValue is a field and its value in a composite literal
+type FieldValue struct {
+	Name  *Identifier
+	Value Expression
+}
+
+func (cl *CompositeLiteral) expressionNode()      {}
+func (cl *CompositeLiteral) TokenLiteral() string { return cl.Token.Literal }
+func (cl *CompositeLiteral) String() string {
+	fields := []string{}
+	for _, f := range cl.Fields {
+		fields = append(fields, f.Name.String()+": "+f.Value.String())
+	}
+	return cl.Type.String() + "{" + strings.Join(fields, ", ") + "}"
+}
+
+// SliceType represents a slice type such as 切片[整数]
+type SliceType struct {
+	Token Token // the '切片' token
+	Elem  Expression
+}
+
+func (st *SliceType) expressionNode()      {}
+func (st *SliceType) TokenLiteral() string { return st.Token.Literal }
+func (st *SliceType) String() string {
+	return "[]" + st.Elem.String()
+}
+
+// ChanType represents a channel type such as 通道 整数
+type ChanType struct {
+	Token Token // the '通道' token
+	Elem  Expression
+}
+
+func (ct *ChanType) expressionNode()      {}
+func (ct *ChanType) TokenLiteral() string { return ct.Token.Literal }
+func (ct *ChanType) String() string {
+	return "chan " + ct.Elem.String()
+}
+
+// FuncType represents a function type such as 数(请求) 响应, which lists
+// the types of the parameters without their names
+type FuncType struct {
+	Token      Token // the '数' token
+	Parameters []Expression
+	Variadic   bool  // whether the last parameter is written ...T
+	Rparen     Token // the ')' token
+	ReturnType Expression
+}
+
+func (ft *FuncType) expressionNode()      {}
+func (ft *FuncType) TokenLiteral() string { return ft.Token.Literal }
+func (ft *FuncType) String() string {
+	params := make([]string, len(ft.Parameters))
+	for i, param := range ft.Parameters {
+		params[i] = param.String()
+	}
+	if ft.Variadic {
+		params[len(params)-1] = "..." + params[len(params)-1]
+	}
+	out := "func(" + strings.Join(params, ", ") + ")"
+	if ft.ReturnType != nil {
+		out += " " + ft.ReturnType.String()
+	}
+	return out
+}
+
+// ResultList represents the results of a function returning several
+// values, such as (整数, 错误), or named results, such as (商 整数, 余数 整数)
+type ResultList struct {
+	Token  Token         // the '(' token
+	Names  []*Identifier // the name of each result, nil when they are unnamed
+	Types  []Expression
+	Rparen Token // the ')' token
+}
+
+func (rl *ResultList) expressionNode()      {}
+func (rl *ResultList) TokenLiteral() string { return rl.Token.Literal }
+func (rl *ResultList) String() string {
+	types := []string{}
+	for i, t := range rl.Types {
+		if len(rl.Names) > 0 {
+			types = append(types, rl.Names[i].String()+" "+t.String())
+			continue
+		}
+		types = append(types, t.String())
+	}
+	return "(" + strings.Join(types, ", ") + ")"
+}
+
+// ExpressionList represents the values of a return statement returning
+// several values, such as 返回 0, 错
+type ExpressionList struct {
+	Values []Expression
+}
+
+func (el *ExpressionList) expressionNode()      {}
+func (el *ExpressionList) TokenLiteral() string { return el.Values[0].TokenLiteral() }
+func (el *ExpressionList) String() string {
+	values := []string{}
+	for _, v := range el.Values {
+		values = append(values, v.String())
+	}
+	return strings.Join(values, ", ")
+}
+
+// SliceLiteral represents a slice literal such as 切片[整数]{1, 2, 3}
+type SliceLiteral struct {
+	Token    Token // the '{' token
+	Type     *SliceType
+	Elements []Expression
+	Rbrace   Token // the '}' token
+}
+
+func (sl *SliceLiteral) expressionNode()      {}
+func (sl *SliceLiteral) TokenLiteral() string { return sl.Token.Literal }
+func (sl *SliceLiteral) String() string {
+	elements := []string{}
+	for _, el := range sl.Elements {
+		elements = append(elements, el.String())
+	}
+	return sl.Type.String() + "{" + strings.Join(elements, ", ") + "}"
+}
+
+// ArrayType represents an array type such as 数组[5]整数
+type ArrayType struct {
+	Token Token // the '数组' token
+	Len   Expression
+	Elem  Expression
+}
+
+func (at *ArrayType) expressionNode()      {}
+func (at *ArrayType) TokenLiteral() string { return at.Token.Literal }
+func (at *ArrayType) String() string {
+	return "[" + at.Len.String() + "]" + at.Elem.String()
+}
+
+// ArrayLiteral represents an array literal such as 数组[3]整数{1, 2, 3}
+type ArrayLiteral struct {
+	Token    Token // the '{' token
+	Type     *ArrayType
+	Elements []Expression
+	Rbrace   Token // the '}' token
+}
+
+func (al *ArrayLiteral) expressionNode()      {}
+func (al *ArrayLiteral) TokenLiteral() string { return al.Token.Literal }
+func (al *ArrayLiteral) String() string {
+	elements := []string{}
+	for _, el := range al.Elements {
+		elements = append(elements, el.String())
+	}
+	return al.Type.String() + "{" + strings.Join(elements, ", ") + "}"
+}
+
+// IndexExpression represents indexing such as m["a"], or the
+// instantiation of a generic function or type with type arguments, such
+// as 首[整数]; several type arguments are an ExpressionList
+type IndexExpression struct {
+	Token    Token // the '[' token
+	Left     Expression
+	Index    Expression
+	Rbracket Token // the ']' token
+}
+
+func (ie *IndexExpression) expressionNode()      {}
+func (ie *IndexExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IndexExpression) String() string {
+	return "(" + ie.Left.String() + "[" + ie.Index.String() + "])"
+}
+
+// SliceExpression represents a slice expression like s[1:3], s[:n], s[n:]
+// or the three-index s[低:高:容量]
+type SliceExpression struct {
+	Token    Token // the '[' token
+	Left     Expression
+	Low      Expression // nil when omitted
+	High     Expression // nil when omitted
+	Max      Expression // nil unless three indices are given
+	Rbracket Token      // the ']' token
+}
+
+func (se *SliceExpression) expressionNode()      {}
+func (se *SliceExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SliceExpression) String() string {
+	var out strings.Builder
+
+	out.WriteString("(" + se.Left.String() + "[")
+	if se.Low != nil {
+		out.WriteString(se.Low.String())
+	}
+	out.WriteString(":")
+	if se.High != nil {
+		out.WriteString(se.High.String())
+	}
+	if se.Max != nil {
+		out.WriteString(":" + se.Max.String())
+	}
+	out.WriteString("])")
+
+	return out.String()
+}
+
+// Position describes a location in Saika source code
+type Position struct {
+	Line          int // 1-based line number
+	Column        int // 1-based column, counted in runes
+	DisplayColumn int // 1-based column with tabs expanded to the lexer's tab width
+	Offset        int // 0-based byte offset
+	RuneOffset    int // 0-based rune offset
+}
+
+// IsValid reports whether the position has been set
+func (pos Position) IsValid() bool {
+	return pos.Line > 0
+}
+
 // Token represents a token produced by the lexer
 type Token struct {
 	Type    TokenType
 	Literal string
-	Line    int
-	Column  int
+	Raw     string // exact source text, e.g. 0x1F or "a\"b" including quotes
+	Position
+	End Position // position immediately after the last character of the token
+}
+
+// Comment is a // or /* */ comment. The parser skips comments; the lexer
+// collects them so that a formatter can keep them.
+type Comment struct {
+	Text string // the comment including its // or /* */
+	Range
 }
 
 // TokenType represents the type of a token
@@ -417,6 +1340,8 @@ const (
 	EOF     = "EOF"
 	IDENT   = "IDENT"
 	INT     = "INT"
+	FLOAT   = "FLOAT"
+	UNIT    = "UNIT" // number with a unit suffix, e.g. 500毫秒
 	STRING  = "STRING"
 
 	// Chinese keywords
@@ -427,6 +1352,14 @@ const (
 	ELSE      = "ELSE"      // 否则
 	FOR       = "FOR"       // 循环
 	WHILE     = "WHILE"     // 当
+	QUERY     = "QUERY"     // 查询
+	CHARS     = "CHARS"     // 逐字符
+	RANGE     = "RANGE"     // 范围
+	OPTION    = "OPTION"    // 选项
+	SIGNAL    = "SIGNAL"    // 捕获信号
+	DEFER     = "DEFER"     // 推迟
+	GO        = "GO"        // 协程
+	SELECT    = "SELECT"    // 监听
 	BREAK     = "BREAK"     // 中断
 	CONTINUE  = "CONTINUE"  // 继续
 	SWITCH    = "SWITCH"    // 选择
@@ -439,9 +1372,14 @@ const (
 	FALSE     = "FALSE"     // 假
 	STRUCT    = "STRUCT"    // 结构
 	INTERFACE = "INTERFACE" // 接口
+	TYPE      = "TYPE"      // 类型
+	ENUM      = "ENUM"      // 枚举
 	MAP       = "MAP"       // 映射
 	SLICE     = "SLICE"     // 切片
 	ARRAY     = "ARRAY"     // 数组
+	CHAN      = "CHAN"      // 通道
+	PANIC     = "PANIC"     // 恐慌
+	RECOVER   = "RECOVER"   // 恢复
 	PUBLIC    = "PUBLIC"    // 公开
 	PRIVATE   = "PRIVATE"   // 私有
 
@@ -450,6 +1388,7 @@ const (
 	TYPE_INT    = "TYPE_INT"    // 整数
 	TYPE_FLOAT  = "TYPE_FLOAT"  // 浮点
 	TYPE_BOOL   = "TYPE_BOOL"   // 布尔
+	TYPE_ERROR  = "TYPE_ERROR"  // 错误
 
 	// Operators
 	ASSIGN   = "="
@@ -460,10 +1399,34 @@ const (
 	SLASH    = "/"
 	PERCENT  = "%"
 	DOT      = "."
+	ELLIPSIS = "..."
+	ARROW    = "<-" // channel send and receive
+
+	// Bitwise operators
+	AMPERSAND   = "&"
+	PIPE        = "|"
+	CARET       = "^"
+	AND_NOT     = "&^"
+	SHIFT_LEFT  = "<<"
+	SHIFT_RIGHT = ">>"
+
+	// Compound assignments
+	PLUS_ASSIGN        = "+="
+	MINUS_ASSIGN       = "-="
+	ASTERISK_ASSIGN    = "*="
+	SLASH_ASSIGN       = "/="
+	PERCENT_ASSIGN     = "%="
+	AMPERSAND_ASSIGN   = "&="
+	PIPE_ASSIGN        = "|="
+	CARET_ASSIGN       = "^="
+	AND_NOT_ASSIGN     = "&^="
+	SHIFT_LEFT_ASSIGN  = "<<="
+	SHIFT_RIGHT_ASSIGN = ">>="
 
 	// Delimiters
 	COMMA     = ","
 	SEMICOLON = ";"
+	COLON     = ":"
 	LPAREN    = "("
 	RPAREN    = ")"
 	LBRACE    = "{"
@@ -480,34 +1443,99 @@ const (
 	GTE    = ">="
 )
 
+// Unit is a unit of a unit literal
+type Unit struct {
+	Duration bool  // whether the unit measures a time.Duration rather than bytes
+	Size     int64 // nanoseconds or bytes in one unit
+}
+
+// Units maps the suffixes of unit literals to their units. Byte sizes use
+// binary multiples, so 1千字节 is 1024 bytes.
+var Units = map[string]Unit{
+	"纳秒":  {Duration: true, Size: 1},
+	"微秒":  {Duration: true, Size: 1e3},
+	"毫秒":  {Duration: true, Size: 1e6},
+	"秒":   {Duration: true, Size: 1e9},
+	"分钟":  {Duration: true, Size: 60e9},
+	"小时":  {Duration: true, Size: 3600e9},
+	"字节":  {Size: 1},
+	"千字节": {Size: 1 << 10},
+	"兆字节": {Size: 1 << 20},
+	"吉字节": {Size: 1 << 30},
+	"太字节": {Size: 1 << 40},
+}
+
 // Keywords maps keyword strings to their token types
 var Keywords = map[string]TokenType{
-	"数":   FUNC,
-	"包":   PACKAGE,
-	"导入":  IMPORT,
-	"如果":  IF,
-	"否则":  ELSE,
-	"循环":  FOR,
-	"当":   WHILE,
-	"中断":  BREAK,
-	"继续":  CONTINUE,
-	"选择":  SWITCH,
-	"情况":  CASE,
-	"默认":  DEFAULT,
-	"返回":  RETURN,
-	"变量":  VAR,
-	"常量":  CONST,
-	"真":   TRUE,
-	"假":   FALSE,
-	"结构":  STRUCT,
-	"接口":  INTERFACE,
-	"映射":  MAP,
-	"切片":  SLICE,
-	"数组":  ARRAY,
-	"公开":  PUBLIC,
-	"私有":  PRIVATE,
-	"字符串": TYPE_STRING,
-	"整数":  TYPE_INT,
-	"浮点":  TYPE_FLOAT,
-	"布尔":  TYPE_BOOL,
+	"数":    FUNC,
+	"包":    PACKAGE,
+	"导入":   IMPORT,
+	"如果":   IF,
+	"否则":   ELSE,
+	"循环":   FOR,
+	"当":    WHILE,
+	"查询":   QUERY,
+	"逐字符":  CHARS,
+	"范围":   RANGE,
+	"选项":   OPTION,
+	"捕获信号": SIGNAL,
+	"推迟":   DEFER,
+	"协程":   GO,
+	"监听":   SELECT,
+	"中断":   BREAK,
+	"继续":   CONTINUE,
+	"选择":   SWITCH,
+	"情况":   CASE,
+	"默认":   DEFAULT,
+	"返回":   RETURN,
+	"变量":   VAR,
+	"常量":   CONST,
+	"真":    TRUE,
+	"假":    FALSE,
+	"结构":   STRUCT,
+	"接口":   INTERFACE,
+	"类型":   TYPE,
+	"枚举":   ENUM,
+	"映射":   MAP,
+	"切片":   SLICE,
+	"数组":   ARRAY,
+	"通道":   CHAN,
+	"恐慌":   PANIC,
+	"恢复":   RECOVER,
+	"公开":   PUBLIC,
+	"私有":   PRIVATE,
+	"字符串":  TYPE_STRING,
+	"整数":   TYPE_INT,
+	"浮点":   TYPE_FLOAT,
+	"布尔":   TYPE_BOOL,
+	"错误":   TYPE_ERROR,
+}
+
+// KeywordNames returns the spellings of every keyword in a stable order
+func KeywordNames() []string {
+	names := make([]string, 0, len(Keywords))
+	for name := range Keywords {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BlankName is the Chinese spelling of the blank identifier _. A value
+// assigned to either is discarded, as in 变量 值, 忽略 = 解析(s).
+const BlankName = "忽略"
+
+// IsBlank returns whether name is the blank identifier, _ or BlankName
+func IsBlank(name string) bool {
+	return name == "_" || name == BlankName
+}
+
+// ReturnsError reports whether the last of a function's results, given
+// by its return type, is an 错误
+func ReturnsError(result Expression) bool {
+	if list, ok := result.(*ResultList); ok && len(list.Types) > 0 {
+		result = list.Types[len(list.Types)-1]
+	}
+	typ, ok := result.(*Identifier)
+	return ok && typ.Value == "错误"
 }
@@ -0,0 +1,62 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Dump returns an indented description of the tree rooted at node with
+// one field per line. Tokens are left out, so the dump only changes when
+// the structure of the tree does, which makes it suitable for snapshots.
+func Dump(node Node) string {
+	var out strings.Builder
+	dumpValue(&out, reflect.ValueOf(&node).Elem(), 0)
+	out.WriteString("\n")
+	return out.String()
+}
+
+// dumpValue writes v to out, indenting nested lines by depth
+func dumpValue(out *strings.Builder, v reflect.Value, depth int) {
+	indent := strings.Repeat("  ", depth+1)
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			out.WriteString("nil")
+			return
+		}
+		dumpValue(out, v.Elem(), depth)
+	case reflect.Struct:
+		out.WriteString(v.Type().Name() + " {")
+		wrote := false
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() || field.Type == tokenType {
+				continue
+			}
+			out.WriteString("\n" + indent + field.Name + ": ")
+			dumpValue(out, v.Field(i), depth+1)
+			wrote = true
+		}
+		if wrote {
+			out.WriteString("\n" + indent[2:])
+		}
+		out.WriteString("}")
+	case reflect.Slice:
+		if v.Len() == 0 {
+			out.WriteString("[]")
+			return
+		}
+		out.WriteString("[")
+		for i := 0; i < v.Len(); i++ {
+			fmt.Fprintf(out, "\n%s%d: ", indent, i)
+			dumpValue(out, v.Index(i), depth+1)
+		}
+		out.WriteString("\n" + indent[2:] + "]")
+	case reflect.String:
+		fmt.Fprintf(out, "%q", v.String())
+	default:
+		fmt.Fprintf(out, "%v", v.Interface())
+	}
+}
@@ -0,0 +1,106 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Equal reports whether two nodes are structurally identical. Tokens, and
+// therefore source positions and original spellings, are ignored.
+func Equal(a, b Node) bool {
+	d := &differ{limit: 1}
+	d.diff(nodeName(a), reflect.ValueOf(&a).Elem(), reflect.ValueOf(&b).Elem())
+	return len(d.diffs) == 0
+}
+
+// Diff compares two nodes structurally, ignoring tokens, and returns one
+// human-readable line per difference, e.g.
+//
+//	Program.Statements[1].Value.Operator: "+" != "-"
+//
+// An empty result means the trees are equal.
+func Diff(a, b Node) []string {
+	d := &differ{}
+	d.diff(nodeName(a), reflect.ValueOf(&a).Elem(), reflect.ValueOf(&b).Elem())
+	return d.diffs
+}
+
+// differ accumulates structural differences between two values
+type differ struct {
+	diffs []string
+	limit int // stop after this many differences; 0 means no limit
+}
+
+// done reports whether enough differences have been collected
+func (d *differ) done() bool {
+	return d.limit > 0 && len(d.diffs) >= d.limit
+}
+
+// addf records a difference at the given path
+func (d *differ) addf(path, format string, args ...interface{}) {
+	d.diffs = append(d.diffs, path+": "+fmt.Sprintf(format, args...))
+}
+
+// diff walks a and b in parallel, recording every difference below path
+func (d *differ) diff(path string, a, b reflect.Value) {
+	if d.done() {
+		return
+	}
+
+	switch a.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		switch {
+		case a.IsNil() && b.IsNil():
+			return
+		case a.IsNil() || b.IsNil():
+			d.addf(path, "%s != %s", describe(a), describe(b))
+			return
+		}
+		if a.Kind() == reflect.Interface && a.Elem().Type() != b.Elem().Type() {
+			d.addf(path, "%s != %s", describe(a), describe(b))
+			return
+		}
+		d.diff(path, a.Elem(), b.Elem())
+	case reflect.Struct:
+		if a.Type() == tokenType {
+			return
+		}
+		for i := 0; i < a.NumField(); i++ {
+			d.diff(path+"."+a.Type().Field(i).Name, a.Field(i), b.Field(i))
+		}
+	case reflect.Slice:
+		if a.Len() != b.Len() {
+			d.addf(path, "length %d != %d", a.Len(), b.Len())
+		}
+		for i := 0; i < a.Len() && i < b.Len(); i++ {
+			d.diff(fmt.Sprintf("%s[%d]", path, i), a.Index(i), b.Index(i))
+		}
+	default:
+		if a.Interface() != b.Interface() {
+			d.addf(path, "%#v != %#v", a.Interface(), b.Interface())
+		}
+	}
+}
+
+// describe returns a short description of a pointer or interface value
+func describe(v reflect.Value) string {
+	if v.IsNil() {
+		return "nil"
+	}
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	return v.Type().String()
+}
+
+// nodeName returns the unqualified type name of a node for use as a path root
+func nodeName(node Node) string {
+	if node == nil {
+		return "<nil>"
+	}
+	t := reflect.TypeOf(node)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
@@ -0,0 +1,885 @@
+package ast
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// Fprint writes node to w as Saika source code. The output is valid Saika
+// that parses back to an equivalent AST; parentheses dropped by the parser
+// are reinserted wherever operator precedence requires them.
+func Fprint(w io.Writer, node Node) error {
+	p := &printer{}
+	p.printNode(node)
+	_, err := io.WriteString(w, p.out.String())
+	return err
+}
+
+// FprintComments writes node to w as Fprint does, with the comments of
+// its source, in source order, in their places between statements: a
+// comment on the line a statement ends on follows it, and any other
+// comment goes on a line of its own before the statement or closing brace
+// that follows it. No comment is dropped.
+func FprintComments(w io.Writer, node Node, comments []Comment) error {
+	p := &printer{comments: comments}
+	p.printNode(node)
+	for p.pending(math.MaxInt) {
+		p.write(p.takeComment())
+		p.write("\n")
+	}
+	_, err := io.WriteString(w, p.out.String())
+	return err
+}
+
+// Sprint returns node formatted as Saika source code
+func Sprint(node Node) string {
+	var out strings.Builder
+	Fprint(&out, node)
+	return out.String()
+}
+
+// printer holds the state used while printing Saika source
+type printer struct {
+	out    strings.Builder
+	indent int
+
+	// comments holds the comments not yet printed, in source order
+	comments []Comment
+
+	// exprLev mirrors the parser's nesting level: it is negative in the
+	// header of a statement with a block, where a composite literal must
+	// be parenthesized, and counts the brackets an expression is in
+	exprLev int
+}
+
+// operatorPrecedences mirrors the parser's binding strength of infix operators
+var operatorPrecedences = map[string]int{
+	"==": 2,
+	"!=": 2,
+	"<":  3,
+	">":  3,
+	"<=": 3,
+	">=": 3,
+	"+":  4,
+	"-":  4,
+	"|":  4,
+	"^":  4,
+	"*":  5,
+	"/":  5,
+	"%":  5,
+	"&":  5,
+	"&^": 5,
+	"<<": 5,
+	">>": 5,
+}
+
+// prefixPrecedence is the binding strength of prefix operators
+const prefixPrecedence = 6
+
+// write appends text to the output
+func (p *printer) write(s string) {
+	p.out.WriteString(s)
+}
+
+// writef appends formatted text to the output
+func (p *printer) writef(format string, args ...interface{}) {
+	fmt.Fprintf(&p.out, format, args...)
+}
+
+// newline starts a new line at the current indentation
+func (p *printer) newline() {
+	p.out.WriteString("\n")
+	p.out.WriteString(strings.Repeat("\t", p.indent))
+}
+
+// pending reports whether a comment not yet printed starts before offset
+func (p *printer) pending(offset int) bool {
+	return len(p.comments) > 0 && p.comments[0].Start.Offset < offset
+}
+
+// takeComment returns the next comment, which then counts as printed
+func (p *printer) takeComment() string {
+	text := p.comments[0].Text
+	p.comments = p.comments[1:]
+	return text
+}
+
+// commentsBefore prints the comments that start before offset, each on a
+// line of its own, at the start of a line
+func (p *printer) commentsBefore(offset int) {
+	for p.pending(offset) {
+		p.write(p.takeComment())
+		p.newline()
+	}
+}
+
+// trailingComment prints a comment that starts on the line where stmt
+// ends after the end of stmt
+func (p *printer) trailingComment(stmt Statement) {
+	end := NodeRange(stmt).End
+	if len(p.comments) > 0 && p.comments[0].Start.Line == end.Line && p.comments[0].Start.Offset >= end.Offset {
+		p.write(" ")
+		p.write(p.takeComment())
+	}
+}
+
+// printNode prints any node
+func (p *printer) printNode(node Node) {
+	switch node := node.(type) {
+	case *Program:
+		p.printProgram(node)
+	case Statement:
+		p.printStatement(node)
+	case Expression:
+		p.printExpression(node)
+	}
+}
+
+// printProgram prints top-level statements, separating declarations with blank lines
+func (p *printer) printProgram(program *Program) {
+	for i, stmt := range program.Statements {
+		if i > 0 {
+			p.write("\n")
+			if separatesWithBlankLine(program.Statements[i-1], stmt) {
+				p.write("\n")
+			}
+		}
+		p.commentsBefore(NodeRange(stmt).Start.Offset)
+		p.printStatement(stmt)
+		p.trailingComment(stmt)
+	}
+	if len(program.Statements) > 0 {
+		p.write("\n")
+	}
+}
+
+// separatesWithBlankLine reports whether a blank line belongs between two
+// consecutive top-level statements
+func separatesWithBlankLine(prev, next Statement) bool {
+	_, prevImport := prev.(*ImportStatement)
+	_, nextImport := next.(*ImportStatement)
+	if prevImport && nextImport {
+		return false
+	}
+
+	_, prevPackage := prev.(*PackageStatement)
+	return hasBody(prev) || hasBody(next) || prevPackage || prevImport != nextImport
+}
+
+// hasBody reports whether a top-level statement is a declaration with a
+// braced body, such as a function
+func hasBody(stmt Statement) bool {
+	switch stmt := stmt.(type) {
+	case *FunctionStatement, *InterfaceStatement, *ConstGroupStatement:
+		return true
+	case *EnumStatement:
+		return stmt.Rbrace.Line > stmt.Token.Line
+	case *TypeStatement:
+		st, ok := stmt.Type.(*StructType)
+		return ok && st.Rbrace.Line > st.Token.Line
+	}
+	return false
+}
+
+// printStatement prints a single statement without a trailing newline
+func (p *printer) printStatement(stmt Statement) {
+	switch stmt := stmt.(type) {
+	case *PackageStatement:
+		p.writef("包 %s", stmt.Name)
+	case *ImportStatement:
+		p.writef("导入 \"%s\"", stmt.Path)
+	case *VarStatement:
+		p.writef("变量 %s", stmt.Name.Value)
+		if stmt.Type != nil {
+			p.write(" ")
+			p.printExpression(stmt.Type)
+		}
+		if stmt.Value != nil {
+			p.write(" = ")
+			p.printExpression(stmt.Value)
+		}
+	case *VarListStatement:
+		p.write("变量 ")
+		for i, name := range stmt.Names {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.write(name.Value)
+		}
+		p.write(" = ")
+		p.printExpression(stmt.Value)
+	case *AssignListStatement:
+		for i, target := range stmt.Targets {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.printExpression(target)
+		}
+		p.write(" = ")
+		p.printExpression(stmt.Value)
+	case *ConstStatement:
+		p.writef("常量 %s = ", stmt.Name.Value)
+		p.printExpression(stmt.Value)
+	case *ConstGroupStatement:
+		p.printConstGroupStatement(stmt)
+	case *OptionStatement:
+		p.writef("选项 %s %s = ", stmt.Name.Value, stmt.Type.Value)
+		p.printExpression(stmt.Value)
+		if stmt.Usage != nil {
+			p.write(" ")
+			p.printExpression(stmt.Usage)
+		}
+	case *ReturnStatement:
+		p.write("返回")
+		if stmt.ReturnValue != nil {
+			p.write(" ")
+			p.printExpression(stmt.ReturnValue)
+		}
+	case *FunctionStatement:
+		p.printFunctionStatement(stmt)
+	case *InterfaceStatement:
+		p.printInterfaceStatement(stmt)
+	case *EnumStatement:
+		p.printEnumStatement(stmt)
+	case *TypeStatement:
+		p.write("类型 " + stmt.Name.Value)
+		if len(stmt.TypeParams) > 0 {
+			p.printTypeParams(stmt.TypeParams)
+		}
+		p.write(" ")
+		if stmt.Alias {
+			p.write("= ")
+		}
+		p.printExpression(stmt.Type)
+	case *IfStatement:
+		p.write("如果 ")
+		p.printHeader(stmt.Condition)
+		p.write(" ")
+		p.printBlockStatement(stmt.Consequence)
+		if stmt.Alternative != nil {
+			p.write(" 否则 ")
+			p.printBlockStatement(stmt.Alternative)
+		}
+	case *ForStatement:
+		p.printForStatement(stmt)
+	case *WhileStatement:
+		p.write("当 ")
+		p.printHeader(stmt.Condition)
+		p.write(" ")
+		p.printBlockStatement(stmt.Body)
+	case *CharLoopStatement:
+		p.write("逐字符 (")
+		if stmt.Index != nil {
+			p.write(stmt.Index.Value + ", ")
+		}
+		p.write(stmt.Char.Value + ") = ")
+		p.printHeader(stmt.Value)
+		p.write(" ")
+		p.printBlockStatement(stmt.Body)
+	case *RangeStatement:
+		p.write("范围 (" + stmt.Name.Value + ") = ")
+		p.printHeader(stmt.Value)
+		p.write(" ")
+		p.printBlockStatement(stmt.Body)
+	case *QueryStatement:
+		p.write("查询 ")
+		p.printSignature(stmt.Columns, nil)
+		p.write(" = ")
+		p.printExpression(stmt.Call)
+		p.write(" ")
+		p.printBlockStatement(stmt.Body)
+	case *SignalStatement:
+		p.write("捕获信号 ")
+		p.printBlockStatement(stmt.Body)
+	case *DeferStatement:
+		p.write("推迟 ")
+		p.printExpression(stmt.Call)
+	case *GoStatement:
+		p.write("协程 ")
+		p.printExpression(stmt.Call)
+	case *SendStatement:
+		p.printExpression(stmt.Channel)
+		p.write(" <- ")
+		p.printExpression(stmt.Value)
+	case *SelectStatement:
+		p.printSelectStatement(stmt)
+	case *SwitchStatement:
+		p.printSwitchStatement(stmt)
+	case *BlockStatement:
+		p.printBlockStatement(stmt)
+	case *ExpressionStatement:
+		p.printExpression(stmt.Expression)
+	}
+}
+
+// printFunctionStatement prints a function declaration
+func (p *printer) printFunctionStatement(stmt *FunctionStatement) {
+	p.write("数 ")
+	if stmt.Receiver != nil {
+		p.writef("(%s ", stmt.Receiver.Name.Value)
+		p.printExpression(stmt.Receiver.Type)
+		p.write(") ")
+	}
+	p.write(stmt.Name.Value)
+	if len(stmt.TypeParams) > 0 {
+		p.printTypeParams(stmt.TypeParams)
+	}
+	p.printSignature(stmt.Parameters, stmt.ReturnType)
+	p.write(" ")
+	p.printBlockStatement(stmt.Body)
+}
+
+// printTypeParams prints the bracketed type parameters of a generic
+// function or type
+func (p *printer) printTypeParams(typeParams []*TypeParam) {
+	p.write("[")
+	for i, tp := range typeParams {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.writef("%s ", tp.Name.Value)
+		p.printExpression(tp.Constraint)
+	}
+	p.write("]")
+}
+
+// printInterfaceStatement prints an interface declaration with one method
+// per line
+func (p *printer) printInterfaceStatement(stmt *InterfaceStatement) {
+	p.writef("接口 %s {", stmt.Name.Value)
+	p.indent++
+	for _, method := range stmt.Methods {
+		p.newline()
+		p.commentsBefore(method.Name.Token.Offset)
+		p.write(method.Name.Value)
+		p.printSignature(method.Parameters, method.ReturnType)
+	}
+	for p.pending(stmt.Rbrace.Offset) {
+		p.newline()
+		p.write(p.takeComment())
+	}
+	p.indent--
+	p.newline()
+	p.write("}")
+}
+
+// printConstGroupStatement prints a group of constants with one constant
+// per line
+func (p *printer) printConstGroupStatement(stmt *ConstGroupStatement) {
+	p.write("常量 (")
+	p.indent++
+	for _, c := range stmt.Consts {
+		p.newline()
+		p.commentsBefore(c.Name.Token.Offset)
+		p.write(c.Name.Value)
+		if c.Value != nil {
+			p.write(" = ")
+			p.printExpression(c.Value)
+		}
+		p.trailingComment(c)
+	}
+	for p.pending(stmt.Rparen.Offset) {
+		p.newline()
+		p.write(p.takeComment())
+	}
+	p.indent--
+	p.newline()
+	p.write(")")
+}
+
+// printEnumStatement prints an enumeration on one line, or with one member
+// per line if it spans several lines in the source
+func (p *printer) printEnumStatement(stmt *EnumStatement) {
+	p.writef("枚举 %s {", stmt.Name.Value)
+	if stmt.Rbrace.Line == stmt.Token.Line {
+		for i, member := range stmt.Members {
+			if i > 0 {
+				p.write(",")
+			}
+			p.writef(" %s", member.Value)
+		}
+		p.write(" }")
+		return
+	}
+
+	p.indent++
+	for _, member := range stmt.Members {
+		p.newline()
+		p.commentsBefore(member.Token.Offset)
+		p.write(member.Value)
+	}
+	for p.pending(stmt.Rbrace.Offset) {
+		p.newline()
+		p.write(p.takeComment())
+	}
+	p.indent--
+	p.newline()
+	p.write("}")
+}
+
+// printStructType prints a struct type on one line when it was written on
+// one, and otherwise with a field per line
+func (p *printer) printStructType(st *StructType) {
+	p.write("结构 {")
+	if st.Rbrace.Line == st.Token.Line {
+		for i, field := range st.Fields {
+			if i > 0 {
+				p.write(";")
+			}
+			p.writef(" %s ", field.Name.Value)
+			p.printExpression(field.Type)
+		}
+		if len(st.Fields) > 0 {
+			p.write(" ")
+		}
+		p.write("}")
+		return
+	}
+
+	p.indent++
+	for _, field := range st.Fields {
+		p.newline()
+		p.commentsBefore(field.Name.Token.Offset)
+		p.writef("%s ", field.Name.Value)
+		p.printExpression(field.Type)
+	}
+	for p.pending(st.Rbrace.Offset) {
+		p.newline()
+		p.write(p.takeComment())
+	}
+	p.indent--
+	p.newline()
+	p.write("}")
+}
+
+// printCompositeLiteral prints a composite literal on one line when it was
+// written on one, and otherwise with a field per line, each followed by a
+// comma
+func (p *printer) printCompositeLiteral(lit *CompositeLiteral) {
+	// In a header, a '{' after a type name would start the block
+	if _, anonymous := lit.Type.(*StructType); !anonymous && p.exprLev < 0 {
+		p.write("(")
+		defer p.write(")")
+	}
+	p.exprLev++
+	defer func() { p.exprLev-- }()
+
+	p.printExpression(lit.Type)
+	p.write("{")
+	if lit.Rbrace.Line == lit.Token.Line {
+		for i, field := range lit.Fields {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.writef("%s: ", field.Name.Value)
+			p.printExpression(field.Value)
+		}
+		p.write("}")
+		return
+	}
+
+	p.indent++
+	for _, field := range lit.Fields {
+		p.newline()
+		p.commentsBefore(field.Name.Token.Offset)
+		p.writef("%s: ", field.Name.Value)
+		p.printExpression(field.Value)
+		p.write(",")
+	}
+	for p.pending(lit.Rbrace.Offset) {
+		p.newline()
+		p.write(p.takeComment())
+	}
+	p.indent--
+	p.newline()
+	p.write("}")
+}
+
+// printSignature prints a parenthesized parameter list and return type
+func (p *printer) printSignature(params []*TypedParam, returnType Expression) {
+	p.write("(")
+	for i, param := range params {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.write(param.Name.Value)
+		if param.Type != nil {
+			p.write(" ")
+			if param.Variadic {
+				p.write("...")
+			}
+			p.printExpression(param.Type)
+		}
+	}
+	p.write(")")
+
+	if returnType != nil {
+		p.write(" ")
+		p.printExpression(returnType)
+	}
+}
+
+// printExpressions prints a comma-separated list of expressions
+func (p *printer) printExpressions(exprs []Expression) {
+	for i, expr := range exprs {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.printExpression(expr)
+	}
+}
+
+// printSelectStatement prints a 监听 statement with its clauses at its own
+// indentation and their statements indented below them
+func (p *printer) printSelectStatement(stmt *SelectStatement) {
+	p.write("监听 {")
+	for _, clause := range stmt.Cases {
+		p.newline()
+		p.commentsBefore(clause.Token.Offset)
+		if clause.Comm != nil {
+			p.write("情况 ")
+			p.printStatement(clause.Comm)
+			p.write(":")
+		} else {
+			p.write("默认:")
+		}
+
+		p.indent++
+		for _, s := range clause.Body {
+			p.newline()
+			p.commentsBefore(NodeRange(s).Start.Offset)
+			p.printStatement(s)
+			p.trailingComment(s)
+		}
+		p.indent--
+	}
+	for p.pending(stmt.Rbrace.Offset) {
+		p.newline()
+		p.write(p.takeComment())
+	}
+	p.newline()
+	p.write("}")
+}
+
+// printSwitchStatement prints a 选择 statement with its clauses at its own
+// indentation and their statements indented below them
+func (p *printer) printSwitchStatement(stmt *SwitchStatement) {
+	p.write("选择 ")
+	if stmt.Tag != nil {
+		p.printHeader(stmt.Tag)
+		p.write(" ")
+	}
+	p.write("{")
+	for _, clause := range stmt.Cases {
+		p.newline()
+		p.commentsBefore(clause.Token.Offset)
+		if len(clause.Values) > 0 {
+			p.write("情况 ")
+			p.printExpressions(clause.Values)
+			p.write(":")
+		} else {
+			p.write("默认:")
+		}
+
+		p.indent++
+		for _, s := range clause.Body {
+			p.newline()
+			p.commentsBefore(NodeRange(s).Start.Offset)
+			p.printStatement(s)
+			p.trailingComment(s)
+		}
+		p.indent--
+	}
+	for p.pending(stmt.Rbrace.Offset) {
+		p.newline()
+		p.write(p.takeComment())
+	}
+	p.newline()
+	p.write("}")
+}
+
+// printForStatement prints a three-clause loop
+func (p *printer) printForStatement(stmt *ForStatement) {
+	p.write("循环 ")
+	exprLev := p.exprLev
+	p.exprLev = -1
+	if stmt.Init != nil {
+		p.printStatement(stmt.Init)
+	}
+	p.write("; ")
+	if stmt.Condition != nil {
+		p.printExpression(stmt.Condition)
+	}
+	p.write(";")
+	if stmt.Update != nil {
+		p.write(" ")
+		p.printStatement(stmt.Update)
+	}
+	p.exprLev = exprLev
+	p.write(" ")
+	p.printBlockStatement(stmt.Body)
+}
+
+// printHeader prints the expression in the header of a statement with a
+// block
+func (p *printer) printHeader(expr Expression) {
+	exprLev := p.exprLev
+	p.exprLev = -1
+	p.printExpression(expr)
+	p.exprLev = exprLev
+}
+
+// printBlockStatement prints a braced block with its statements indented
+func (p *printer) printBlockStatement(block *BlockStatement) {
+	if block == nil || (len(block.Statements) == 0 && !p.pending(block.Rbrace.Offset)) {
+		p.write("{\n")
+		p.write(strings.Repeat("\t", p.indent))
+		p.write("}")
+		return
+	}
+
+	exprLev := p.exprLev
+	p.exprLev = 0
+	defer func() { p.exprLev = exprLev }()
+
+	p.write("{")
+	p.indent++
+	for _, stmt := range block.Statements {
+		p.newline()
+		p.commentsBefore(NodeRange(stmt).Start.Offset)
+		p.printStatement(stmt)
+		p.trailingComment(stmt)
+	}
+	for p.pending(block.Rbrace.Offset) {
+		p.newline()
+		p.write(p.takeComment())
+	}
+	p.indent--
+	p.newline()
+	p.write("}")
+}
+
+// printExpression prints an expression
+func (p *printer) printExpression(expr Expression) {
+	switch expr := expr.(type) {
+	case *Identifier:
+		p.write(expr.Value)
+	case *IntegerLiteral:
+		if expr.Token.Raw != "" {
+			p.write(expr.Token.Raw)
+		} else {
+			p.writef("%d", expr.Value)
+		}
+	case *FloatLiteral:
+		p.write(expr.Token.Literal)
+	case *UnitLiteral:
+		p.write(expr.Token.Literal)
+	case *StringLiteral:
+		p.writef("\"%s\"", expr.Value)
+	case *BooleanLiteral:
+		if expr.Value {
+			p.write("真")
+		} else {
+			p.write("假")
+		}
+	case *PrefixExpression:
+		p.write(expr.Operator)
+		p.printOperand(expr.Right, prefixPrecedence, false)
+	case *InfixExpression:
+		precedence := operatorPrecedences[expr.Operator]
+		p.printOperand(expr.Left, precedence, false)
+		p.writef(" %s ", expr.Operator)
+		p.printOperand(expr.Right, precedence, true)
+	case *AssignExpression:
+		p.printExpression(expr.Left)
+		p.write(" = ")
+		p.printExpression(expr.Value)
+	case *CompoundAssignExpression:
+		p.printExpression(expr.Left)
+		p.writef(" %s= ", expr.Operator)
+		p.printExpression(expr.Value)
+	case *MemberExpression:
+		p.printOperand(expr.Object, prefixPrecedence+1, false)
+		p.write(".")
+		p.printExpression(expr.Property)
+	case *IndexExpression:
+		p.printOperand(expr.Left, prefixPrecedence+1, false)
+		p.write("[")
+		p.exprLev++
+		p.printExpression(expr.Index)
+		p.exprLev--
+		p.write("]")
+	case *SliceExpression:
+		p.printOperand(expr.Left, prefixPrecedence+1, false)
+		p.write("[")
+		if expr.Low != nil {
+			p.printExpression(expr.Low)
+		}
+		p.write(":")
+		if expr.High != nil {
+			p.printExpression(expr.High)
+		}
+		if expr.Max != nil {
+			p.write(":")
+			p.printExpression(expr.Max)
+		}
+		p.write("]")
+	case *MapType:
+		p.write("映射[")
+		p.printExpression(expr.Key)
+		p.write("]")
+		p.printExpression(expr.Value)
+	case *SliceType:
+		p.write("切片[")
+		p.printExpression(expr.Elem)
+		p.write("]")
+	case *ChanType:
+		p.write("通道 ")
+		p.printExpression(expr.Elem)
+	case *FuncType:
+		p.write("数(")
+		for i, param := range expr.Parameters {
+			if i > 0 {
+				p.write(", ")
+			}
+			if expr.Variadic && i == len(expr.Parameters)-1 {
+				p.write("...")
+			}
+			p.printExpression(param)
+		}
+		p.write(")")
+		if expr.ReturnType != nil {
+			p.write(" ")
+			p.printExpression(expr.ReturnType)
+		}
+	case *ResultList:
+		p.write("(")
+		if len(expr.Names) == 0 {
+			p.printExpressions(expr.Types)
+		}
+		for i, name := range expr.Names {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.printExpression(name)
+			p.write(" ")
+			p.printExpression(expr.Types[i])
+		}
+		p.write(")")
+	case *ExpressionList:
+		p.printExpressions(expr.Values)
+	case *UnionType:
+		for i, t := range expr.Types {
+			if i > 0 {
+				p.write(" | ")
+			}
+			p.printExpression(t)
+		}
+	case *SliceLiteral:
+		p.printExpression(expr.Type)
+		p.write("{")
+		p.exprLev++
+		for i, el := range expr.Elements {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.printExpression(el)
+		}
+		p.exprLev--
+		p.write("}")
+	case *ArrayType:
+		p.write("数组[")
+		p.printExpression(expr.Len)
+		p.write("]")
+		p.printExpression(expr.Elem)
+	case *ArrayLiteral:
+		p.printExpression(expr.Type)
+		p.write("{")
+		p.exprLev++
+		for i, el := range expr.Elements {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.printExpression(el)
+		}
+		p.exprLev--
+		p.write("}")
+	case *StructType:
+		p.printStructType(expr)
+	case *CompositeLiteral:
+		p.printCompositeLiteral(expr)
+	case *MapLiteral:
+		p.printExpression(expr.Type)
+		p.write("{")
+		p.exprLev++
+		for i, pair := range expr.Pairs {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.printExpression(pair.Key)
+			p.write(": ")
+			p.printExpression(pair.Value)
+		}
+		p.exprLev--
+		p.write("}")
+	case *FunctionLiteral:
+		p.write("数")
+		p.printSignature(expr.Parameters, expr.ReturnType)
+		p.write(" ")
+		p.printBlockStatement(expr.Body)
+	case *CallExpression:
+		p.printOperand(expr.Function, prefixPrecedence+1, false)
+		p.write("(")
+		p.exprLev++
+		for i, arg := range expr.Arguments {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.printExpression(arg)
+		}
+		if expr.Ellipsis {
+			p.write("...")
+		}
+		p.exprLev--
+		p.write(")")
+	}
+}
+
+// printOperand prints an operand of an operator with the given precedence,
+// adding parentheses when the operand binds more loosely. Right operands
+// of equal precedence are parenthesized because operators are left-associative.
+func (p *printer) printOperand(expr Expression, precedence int, right bool) {
+	if NeedsParens(expr, precedence, right) {
+		p.write("(")
+		p.exprLev++
+		p.printExpression(expr)
+		p.exprLev--
+		p.write(")")
+		return
+	}
+	p.printExpression(expr)
+}
+
+// NeedsParens reports whether expr must be parenthesized as an operand of an
+// operator with the given precedence; right marks the right operand of an
+// infix operator
+func NeedsParens(expr Expression, precedence int, right bool) bool {
+	inner := Precedence(expr)
+	return inner < precedence || (right && inner == precedence)
+}
+
+// Precedence returns how tightly an expression binds when used as an operand
+func Precedence(expr Expression) int {
+	switch expr := expr.(type) {
+	case *InfixExpression:
+		return operatorPrecedences[expr.Operator]
+	case *PrefixExpression:
+		return prefixPrecedence
+	case *AssignExpression, *CompoundAssignExpression:
+		return 1
+	default:
+		return prefixPrecedence + 2
+	}
+}
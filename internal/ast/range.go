@@ -0,0 +1,66 @@
+package ast
+
+import "reflect"
+
+// Range represents the span of source code covered by a token or node.
+// Start is the position of the first character and End is the position
+// immediately after the last character.
+type Range struct {
+	Start Position
+	End   Position
+}
+
+// IsValid reports whether the range has been set
+func (r Range) IsValid() bool {
+	return r.Start.IsValid() && r.End.IsValid()
+}
+
+// TokenRange returns the range covered by a token
+func TokenRange(tok Token) Range {
+	return Range{Start: tok.Position, End: tok.End}
+}
+
+// NodeRange returns the range covered by a node, derived from the
+// earliest and latest tokens reachable from it
+func NodeRange(node Node) Range {
+	var r Range
+	if node == nil {
+		return r
+	}
+
+	collectTokenRange(reflect.ValueOf(node), &r)
+	return r
+}
+
+var tokenType = reflect.TypeOf(Token{})
+
+// collectTokenRange widens r to include every token reachable from v
+func collectTokenRange(v reflect.Value, r *Range) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			collectTokenRange(v.Elem(), r)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			collectTokenRange(v.Index(i), r)
+		}
+	case reflect.Struct:
+		if v.Type() == tokenType {
+			tok := v.Interface().(Token)
+			if !tok.Position.IsValid() {
+				return
+			}
+			if !r.Start.IsValid() || tok.Offset < r.Start.Offset {
+				r.Start = tok.Position
+			}
+			if tok.End.IsValid() && (!r.End.IsValid() || tok.End.Offset > r.End.Offset) {
+				r.End = tok.End
+			}
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			collectTokenRange(v.Field(i), r)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// DeepCopy returns a copy of node that shares no memory with the original,
+// so the copy can be mutated freely
+func DeepCopy[T Node](node T) T {
+	v := reflect.ValueOf(node)
+	if !v.IsValid() {
+		return node
+	}
+	return copyValue(v).Interface().(T)
+}
+
+// copyValue recursively copies v
+func copyValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		c := reflect.New(v.Elem().Type())
+		c.Elem().Set(copyValue(v.Elem()))
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(copyValue(v.Elem()))
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(copyValue(v.Index(i)))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), copyValue(iter.Value()))
+		}
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(copyValue(v.Field(i)))
+			}
+		}
+		return c
+	default:
+		return v
+	}
+}
+
+var nodeType = reflect.TypeOf((*Node)(nil)).Elem()
+
+// Rewrite returns a transformed copy of node; the original tree is left
+// untouched. fn is called on every node in depth-first post-order, after
+// the node's children have been rewritten, and its result replaces the
+// node. Returning the argument keeps the node. Returning nil removes an
+// element from a statement or expression list and clears any other field.
+// Rewrite panics if fn returns a node that cannot be stored in the parent field.
+func Rewrite(node Node, fn func(Node) Node) Node {
+	if node == nil {
+		return nil
+	}
+	return rewriteNode(DeepCopy(node), fn)
+}
+
+// rewriteNode rewrites the children of node in place and then applies fn to it
+func rewriteNode(node Node, fn func(Node) Node) Node {
+	v := reflect.ValueOf(node)
+	if v.Kind() == reflect.Ptr && !v.IsNil() && v.Elem().Kind() == reflect.Struct {
+		rewriteFields(v.Elem(), fn)
+	}
+	return fn(node)
+}
+
+// rewriteFields rewrites every exported field of the struct value v
+func rewriteFields(v reflect.Value, fn func(Node) Node) {
+	if v.Type() == tokenType {
+		return
+	}
+	for i := 0; i < v.NumField(); i++ {
+		if v.Field(i).CanSet() {
+			rewriteField(v.Field(i), fn)
+		}
+	}
+}
+
+// rewriteField rewrites a single settable field or slice element
+func rewriteField(f reflect.Value, fn func(Node) Node) {
+	switch f.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if f.IsNil() {
+			return
+		}
+		if f.Type().Implements(nodeType) {
+			setNode(f, rewriteNode(f.Interface().(Node), fn))
+		} else if f.Kind() == reflect.Ptr && f.Elem().Kind() == reflect.Struct {
+			// Helper structs such as *TypedParam hold nodes without being nodes
+			rewriteFields(f.Elem(), fn)
+		}
+	case reflect.Slice:
+		if f.Type().Elem().Implements(nodeType) {
+			kept := reflect.MakeSlice(f.Type(), 0, f.Len())
+			for i := 0; i < f.Len(); i++ {
+				elem := f.Index(i)
+				if isNilValue(elem) {
+					kept = reflect.Append(kept, elem)
+					continue
+				}
+				result := rewriteNode(elem.Interface().(Node), fn)
+				if isNilNode(result) {
+					continue
+				}
+				slot := reflect.New(f.Type().Elem()).Elem()
+				setNode(slot, result)
+				kept = reflect.Append(kept, slot)
+			}
+			f.Set(kept)
+			return
+		}
+		for i := 0; i < f.Len(); i++ {
+			rewriteField(f.Index(i), fn)
+		}
+	case reflect.Struct:
+		rewriteFields(f, fn)
+	}
+}
+
+// setNode stores result in the field f, panicking on a type mismatch
+func setNode(f reflect.Value, result Node) {
+	if isNilNode(result) {
+		f.Set(reflect.Zero(f.Type()))
+		return
+	}
+	rv := reflect.ValueOf(result)
+	if !rv.Type().AssignableTo(f.Type()) {
+		panic(fmt.Sprintf("ast.Rewrite: cannot use %T as %s", result, f.Type()))
+	}
+	f.Set(rv)
+}
+
+// isNilNode reports whether node is nil or a typed nil pointer
+func isNilNode(node Node) bool {
+	return node == nil || isNilValue(reflect.ValueOf(node))
+}
+
+// isNilValue reports whether v holds a nil pointer or interface
+func isNilValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// TypeArguments returns the type arguments that the index of an
+// instantiation gives: the values of an expression list, or the index
+func TypeArguments(index Expression) []Expression {
+	if list, ok := index.(*ExpressionList); ok {
+		return list.Values
+	}
+	return []Expression{index}
+}
+
+// BindTypeParams maps the names of the type parameters params to the type
+// arguments args given for them, or returns nil when there are none or
+// their numbers differ
+func BindTypeParams(params []*Identifier, args []Expression) map[string]Expression {
+	if len(params) == 0 || len(params) != len(args) {
+		return nil
+	}
+	types := make(map[string]Expression, len(params))
+	for i, param := range params {
+		types[param.Value] = args[i]
+	}
+	return types
+}
+
+// Substitute returns the type typ with the types that types maps the
+// names of type parameters to in place of them. Only the parts that
+// change are copied.
+func Substitute(typ Expression, types map[string]Expression) Expression {
+	switch t := typ.(type) {
+	case *Identifier:
+		if arg, ok := types[t.Value]; ok {
+			return arg
+		}
+	case *SliceType:
+		return &SliceType{Token: t.Token, Elem: Substitute(t.Elem, types)}
+	case *ArrayType:
+		return &ArrayType{Token: t.Token, Len: t.Len, Elem: Substitute(t.Elem, types)}
+	case *ChanType:
+		return &ChanType{Token: t.Token, Elem: Substitute(t.Elem, types)}
+	case *MapType:
+		return &MapType{Token: t.Token, Key: Substitute(t.Key, types), Value: Substitute(t.Value, types)}
+	case *FuncType:
+		fn := *t
+		fn.Parameters = make([]Expression, len(t.Parameters))
+		for i, param := range t.Parameters {
+			fn.Parameters[i] = Substitute(param, types)
+		}
+		fn.ReturnType = Substitute(t.ReturnType, types)
+		return &fn
+	case *ResultList:
+		results := *t
+		results.Types = make([]Expression, len(t.Types))
+		for i, result := range t.Types {
+			results.Types[i] = Substitute(result, types)
+		}
+		return &results
+	case *StructType:
+		st := *t
+		st.Fields = make([]*Field, len(t.Fields))
+		for i, field := range t.Fields {
+			st.Fields[i] = &Field{Name: field.Name, Type: Substitute(field.Type, types)}
+		}
+		return &st
+	case *IndexExpression:
+		inst := *t
+		inst.Index = Substitute(t.Index, types)
+		return &inst
+	case *ExpressionList:
+		list := *t
+		list.Values = make([]Expression, len(t.Values))
+		for i, value := range t.Values {
+			list.Values[i] = Substitute(value, types)
+		}
+		return &list
+	}
+	return typ
+}
@@ -0,0 +1,44 @@
+package ast
+
+import "reflect"
+
+// Inspect traverses the tree rooted at node in depth-first order, calling
+// fn for each node before its children. If fn returns false, the children
+// of that node are skipped.
+func Inspect(node Node, fn func(Node) bool) {
+	if isNilNode(node) {
+		return
+	}
+	inspectValue(reflect.ValueOf(node), fn)
+}
+
+// inspectValue visits the nodes reachable from v
+func inspectValue(v reflect.Value, fn func(Node) bool) {
+	switch v.Kind() {
+	case reflect.Interface:
+		if !v.IsNil() {
+			inspectValue(v.Elem(), fn)
+		}
+	case reflect.Ptr:
+		if v.IsNil() {
+			return
+		}
+		if v.Type().Implements(nodeType) && !fn(v.Interface().(Node)) {
+			return
+		}
+		inspectValue(v.Elem(), fn)
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			inspectValue(v.Index(i), fn)
+		}
+	case reflect.Struct:
+		if v.Type() == tokenType {
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				inspectValue(v.Field(i), fn)
+			}
+		}
+	}
+}
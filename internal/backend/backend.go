@@ -0,0 +1,108 @@
+// Package backend defines the interface between the Saika front end and the
+// code generators or runtimes that consume its lowered programs, and keeps
+// a registry of them so that tools can select one by name.
+package backend
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/codegen"
+	"github.com/saika-m/saika-lang/internal/interp"
+)
+
+// Default is the name of the backend used when none is selected
+const Default = "go"
+
+// Artifact is the output a backend generates for one source file
+type Artifact struct {
+	Code      string   // generated Go source
+	Package   string   // package the code belongs to
+	Features  []string // support features the code needs, see codegen.SupportSource
+	Templates []string // template files the code embeds, see codegen.TemplateDirName
+
+	// Functions maps each function of the file to the code generated for it
+	Functions []codegen.FunctionMapping
+}
+
+// Backend generates code from programs lowered by ir.Lower
+type Backend interface {
+	// Name returns the name the backend is registered under
+	Name() string
+
+	// Generate generates the code for one lowered file
+	Generate(program *ast.Program) (*Artifact, error)
+}
+
+// File is one lowered source file of a program
+type File struct {
+	Path    string
+	Program *ast.Program
+}
+
+// RunConfig describes the environment a Runner runs a program in
+type RunConfig struct {
+	Stdout    io.Writer
+	Args      []string // os.Args of the program, starting with its name
+	BuildInfo interp.BuildInfo
+	StaticDir string // directory 静态文件 reads, if the program has one
+}
+
+// Runner is implemented by backends that run programs themselves instead
+// of generating code for go build. Their Generate returns an error.
+type Runner interface {
+	Backend
+
+	// Run runs the program formed by files until it exits or ctx is done
+	Run(ctx context.Context, files []File, config RunConfig) error
+}
+
+// Explainer is implemented by backends that generate Go code and can
+// explain it to readers learning Go from their Saika programs
+type Explainer interface {
+	Backend
+
+	// Explained returns the backend generating the same code, with a
+	// comment before each statement relating it to its Saika source
+	Explained() Backend
+}
+
+// factories maps backend names to constructors
+var factories = map[string]func() Backend{}
+
+// Register makes a backend available by name. It panics if the name is
+// already registered.
+func Register(name string, factory func() Backend) {
+	if _, ok := factories[name]; ok {
+		panic(fmt.Sprintf("backend: %s registered twice", name))
+	}
+	factories[name] = factory
+}
+
+// New creates the backend registered under name
+func New(name string) (Backend, error) {
+	factory, ok := factories[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown backend %q, expected one of %v", name, Names())
+	}
+	return factory(), nil
+}
+
+// Names returns the names of the registered backends, sorted
+func Names() []string {
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	Register(Default, func() Backend { return Go{} })
+	Register("interp", func() Backend { return Interp{} })
+	Register("tinygo", func() Backend { return TinyGo{} })
+}
@@ -0,0 +1,32 @@
+package backend
+
+import (
+	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/codegen"
+)
+
+// Go generates Go source for go build; it is the default backend
+type Go struct {
+	Readable bool // comment the code for learners, see codegen.Generator
+}
+
+// Name returns the name of the backend
+func (Go) Name() string { return Default }
+
+// Generate generates the Go code for one lowered file
+func (b Go) Generate(program *ast.Program) (*Artifact, error) {
+	g := codegen.New(program)
+	g.Readable = b.Readable
+	code := g.Generate()
+
+	return &Artifact{
+		Code:      code,
+		Package:   g.PackageName(),
+		Features:  g.Features(),
+		Templates: g.Templates(),
+		Functions: g.Functions(),
+	}, nil
+}
+
+// Explained returns the backend generating the same code with comments
+func (Go) Explained() Backend { return Go{Readable: true} }
@@ -0,0 +1,43 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/interp"
+)
+
+// Interp runs programs with the tree-walking interpreter, without
+// generating code or needing the Go toolchain
+type Interp struct{}
+
+// Name returns the name of the backend
+func (Interp) Name() string { return "interp" }
+
+// Generate fails: the interpreter runs programs instead of generating code
+func (Interp) Generate(program *ast.Program) (*Artifact, error) {
+	return nil, fmt.Errorf("the interp backend runs programs and does not generate code")
+}
+
+// Run interprets the program formed by files
+func (Interp) Run(ctx context.Context, files []File, config RunConfig) error {
+	in := interp.New()
+	if config.Stdout != nil {
+		in.Stdout = config.Stdout
+	}
+	if config.Args != nil {
+		in.Args = config.Args
+	}
+	in.BuildInfo = config.BuildInfo
+	in.StaticDir = config.StaticDir
+	if in.BuildInfo.Version == "" {
+		in.BuildInfo.Version = "dev"
+	}
+
+	interpFiles := make([]interp.File, len(files))
+	for i, f := range files {
+		interpFiles[i] = interp.File{Path: f.Path, Program: f.Program}
+	}
+	return in.Run(ctx, interpFiles)
+}
@@ -0,0 +1,193 @@
+package backend
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/codegen"
+	"github.com/saika-m/saika-lang/internal/stdlib"
+)
+
+// Compiler is implemented by backends whose generated code is compiled by
+// a toolchain other than go build
+type Compiler interface {
+	Backend
+
+	// BuildArgs returns the command line that compiles files into output
+	// for target, or for the host when target is empty
+	BuildArgs(target, output, ldflags string, files []string) []string
+}
+
+// Flasher is implemented by backends that can program a microcontroller
+type Flasher interface {
+	Compiler
+
+	// FlashArgs returns the command line that compiles files for target
+	// and flashes them to the device at port, or the default port when
+	// port is empty
+	FlashArgs(target, port, ldflags string, files []string) []string
+}
+
+// tinyGoPackages lists the Go packages that TinyGo compiles fully on
+// microcontrollers; the rest of the standard library depends on reflection
+// or an operating system that boards do not have
+var tinyGoPackages = map[string]bool{
+	"bytes":           true,
+	"device/arm":      true,
+	"device/avr":      true,
+	"device/rp":       true,
+	"encoding/binary": true,
+	"encoding/hex":    true,
+	"errors":          true,
+	"fmt":             true,
+	"image/color":     true,
+	"io":              true,
+	"machine":         true,
+	"math":            true,
+	"math/bits":       true,
+	"math/rand":       true,
+	"os":              true,
+	"runtime":         true,
+	"sort":            true,
+	"strconv":         true,
+	"strings":         true,
+	"sync":            true,
+	"time":            true,
+	"unicode":         true,
+	"unicode/utf8":    true,
+}
+
+// TinyGo generates Go code restricted to what TinyGo supports, to be
+// compiled with tinygo build and flashed to microcontrollers
+type TinyGo struct {
+	Readable bool // comment the code for learners, see codegen.Generator
+}
+
+// Name returns the name of the backend
+func (TinyGo) Name() string { return "tinygo" }
+
+// Generate generates the Go code for one lowered file, failing when the
+// file imports a package TinyGo cannot compile or uses a feature whose
+// support code imports one
+func (b TinyGo) Generate(program *ast.Program) (*Artifact, error) {
+	var unsupported []string
+	for _, stmt := range program.Statements {
+		imp, ok := stmt.(*ast.ImportStatement)
+		if !ok {
+			continue
+		}
+		if goPath := stdlib.GoPath(imp.Path); !tinyGoPackages[goPath] {
+			unsupported = append(unsupported, fmt.Sprintf("Line %d:%d package %q is not supported by TinyGo",
+				imp.Token.Line, imp.Token.Column, imp.Path))
+		}
+	}
+	if len(unsupported) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(unsupported, "\n"))
+	}
+
+	artifact, err := Go{Readable: b.Readable}.Generate(program)
+	if err != nil {
+		return nil, err
+	}
+	// Features used by the same builtin, such as the template files and
+	// the templates of 渲染模板, are reported together
+	var uses []ast.Token
+	missing := map[ast.Token][]string{}
+	for _, feature := range artifact.Features {
+		for _, path := range codegen.SupportImports(feature) {
+			if tinyGoPackages[path] {
+				continue
+			}
+			tok := featureUse(program, feature)
+			if _, ok := missing[tok]; !ok {
+				uses = append(uses, tok)
+			}
+			if !slices.Contains(missing[tok], path) {
+				missing[tok] = append(missing[tok], path)
+			}
+		}
+	}
+	for _, tok := range uses {
+		paths := missing[tok]
+		sort.Strings(paths)
+		unsupported = append(unsupported, fmt.Sprintf("Line %d:%d %s is not supported by TinyGo, which cannot compile %s",
+			tok.Line, tok.Column, tok.Literal, strings.Join(paths, ", ")))
+	}
+	if len(unsupported) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(unsupported, "\n"))
+	}
+	return artifact, nil
+}
+
+// featureUse returns the token of the first statement or builtin of
+// program that needs the support feature
+func featureUse(program *ast.Program, feature string) ast.Token {
+	var use *ast.Token
+	ast.Inspect(program, func(node ast.Node) bool {
+		if use != nil {
+			return false
+		}
+		var tok ast.Token
+		var used []string
+		switch node := node.(type) {
+		case *ast.OptionStatement:
+			tok, used = node.Token, []string{codegen.FeatureOptions}
+		case *ast.SignalStatement:
+			tok, used = node.Token, []string{codegen.FeatureSignals}
+		case *ast.QueryStatement:
+			tok, used = node.Token, []string{codegen.FeatureQuery}
+		case *ast.Identifier:
+			switch node.Value {
+			case codegen.OpenDatabaseName:
+				tok, used = node.Token, []string{codegen.FeatureQuery}
+			case codegen.RenderTemplateName, codegen.WriteTemplateName:
+				tok, used = node.Token, []string{codegen.FeatureTemplate, codegen.FeatureTemplateFiles}
+			case codegen.StaticFilesName:
+				tok, used = node.Token, []string{codegen.FeatureStatic}
+			}
+		}
+		if slices.Contains(used, feature) {
+			use = &tok
+		}
+		return true
+	})
+	if use == nil {
+		return ast.Token{Literal: feature, Position: ast.NodeRange(program).Start}
+	}
+	return *use
+}
+
+// Explained returns the backend generating the same code with comments
+func (TinyGo) Explained() Backend { return TinyGo{Readable: true} }
+
+// BuildArgs returns the tinygo build command line
+func (TinyGo) BuildArgs(target, output, ldflags string, files []string) []string {
+	return tinyGoArgs("build", target, "-o", output, ldflags, files)
+}
+
+// FlashArgs returns the tinygo flash command line
+func (TinyGo) FlashArgs(target, port, ldflags string, files []string) []string {
+	if port == "" {
+		return tinyGoArgs("flash", target, "", "", ldflags, files)
+	}
+	return tinyGoArgs("flash", target, "-port", port, ldflags, files)
+}
+
+// tinyGoArgs assembles a tinygo command line; flag and value are omitted
+// when flag is empty
+func tinyGoArgs(command, target, flag, value, ldflags string, files []string) []string {
+	args := []string{"tinygo", command}
+	if target != "" {
+		args = append(args, "-target", target)
+	}
+	if flag != "" {
+		args = append(args, flag, value)
+	}
+	if ldflags != "" {
+		args = append(args, "-ldflags", ldflags)
+	}
+	return append(args, files...)
+}
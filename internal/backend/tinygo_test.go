@@ -0,0 +1,48 @@
+package backend_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/backend"
+	"github.com/saika-m/saika-lang/internal/ir"
+	"github.com/saika-m/saika-lang/internal/lexer"
+	"github.com/saika-m/saika-lang/internal/parser"
+)
+
+func lower(t *testing.T, source string) *ast.Program {
+	p := parser.New(lexer.New(source))
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("parse: %v", errs)
+	}
+	return ir.Lower(program, nil)
+}
+
+// TestTinyGoUnsupportedFeatures rejects the features whose support code
+// imports packages that TinyGo cannot compile, at their Saika position
+func TestTinyGoUnsupportedFeatures(t *testing.T) {
+	for name, c := range map[string]struct{ source, want string }{
+		"option":   {"包 main\n\n选项 n 整数 = 1 \"次数\"\n", "Line 3:1 选项"},
+		"signal":   {"包 main\n\n数 入口() {\n\t捕获信号 {\n\t}\n}\n", "Line 4:2 捕获信号"},
+		"query":    {"包 main\n\n数 入口() {\n\t变量 db = 打开数据库(\"sqlite\", \"a.db\")\n\t查询 (名 字符串) = db.Query(\"x\") {\n\t}\n}\n", "Line 4:10 打开数据库"},
+		"template": {"包 main\n\n数 入口() {\n\t变量 s = 渲染模板(\"a.html\", 1)\n\ts = s\n}\n", "Line 4:9 渲染模板"},
+	} {
+		_, err := backend.TinyGo{}.Generate(lower(t, c.source))
+		if err == nil {
+			t.Errorf("%s: TinyGo accepted the program", name)
+			continue
+		}
+		if !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: error %q does not mention %q", name, err, c.want)
+		}
+	}
+}
+
+func TestTinyGoSupported(t *testing.T) {
+	source := "包 main\n\n导入 \"fmt\"\n\n数 入口() {\n\tfmt.Println(\"你好\"[0], 500毫秒)\n}\n"
+	if _, err := (backend.TinyGo{}).Generate(lower(t, source)); err != nil {
+		t.Errorf("TinyGo rejected a supported program: %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+// Package buildcache shares build outputs, such as transpiled Go files and
+// compiled executables, through a remote cache: an HTTP server storing each
+// output under a key hashed from everything that produced it. The protocol
+// is that of a plain content-addressed store:
+//
+//	GET <url>/<key>  returns the stored output, or 404 Not Found
+//	PUT <url>/<key>  stores the request body as the output
+//
+// Outputs are only ever added under a new key, so any server that keeps
+// what it is sent, such as nginx with WebDAV or an object store bucket,
+// can serve as a cache.
+package buildcache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Remote is a remote cache
+type Remote struct {
+	URL    string // base URL that keys are appended to
+	Client *http.Client
+}
+
+// NewRemote returns the remote cache at rawURL, an http or https URL
+func NewRemote(rawURL string) (*Remote, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("remote cache %q is not an http or https URL", rawURL)
+	}
+	return &Remote{
+		URL:    strings.TrimSuffix(rawURL, "/"),
+		Client: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Key hashes the parts that produce an output into its key. Each part is
+// hashed with its length, so that no two lists of parts share a key.
+func Key(parts ...[]byte) string {
+	h := sha256.New()
+	for _, part := range parts {
+		binary.Write(h, binary.BigEndian, uint64(len(part)))
+		h.Write(part)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// Get returns the output stored under key, or nil when there is none
+func (r *Remote) Get(key string) ([]byte, error) {
+	resp, err := r.Client.Get(r.URL + "/" + key)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from remote cache: %v", key, err)
+		}
+		return data, nil
+	case http.StatusNotFound:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("remote cache GET %s: %s", key, resp.Status)
+}
+
+// Put stores data under key
+func (r *Remote) Put(key string, data []byte) error {
+	req, err := http.NewRequest(http.MethodPut, r.URL+"/"+key, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("remote cache PUT %s: %s", key, resp.Status)
+	}
+	return nil
+}
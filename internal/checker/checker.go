@@ -0,0 +1,891 @@
+// Package checker performs the semantic checks on a parsed Saika program
+// that the parser cannot, so that mistakes are reported against the Saika
+// source instead of surfacing later from go build.
+package checker
+
+import (
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/codegen"
+	"github.com/saika-m/saika-lang/internal/diagnostic"
+	"github.com/saika-m/saika-lang/internal/ir"
+	"github.com/saika-m/saika-lang/internal/stdlib"
+	"github.com/saika-m/saika-lang/internal/suggest"
+)
+
+// Check checks a program that forms a package on its own
+func Check(program *ast.Program) diagnostic.List {
+	return CheckPackage([]string{""}, []*ast.Program{program})[0]
+}
+
+// CheckPackage checks the files of one package together, so that names
+// declared at the top level of one file are visible in the others. Paths
+// name the files in diagnostics that refer to another file. It returns the
+// diagnostics of each file, in the order of files.
+func CheckPackage(paths []string, files []*ast.Program) []diagnostic.List {
+	out, _, _ := checkPackage(paths, files, false, Features{})
+	return out
+}
+
+// CheckPackageInfo checks the files of one package like CheckPackage,
+// allowing the given features, and also returns what lowering each file
+// needs to know about it, in the order of files
+func CheckPackageInfo(paths []string, files []*ast.Program, features Features) ([]diagnostic.List, []*ir.Info) {
+	out, _, infos := checkPackage(paths, files, false, features)
+	return out, infos
+}
+
+// Reference is an occurrence of a name declared in the source: the
+// declaration itself, or a use that resolves to it
+type Reference struct {
+	File        int             // index of the file the occurrence is in
+	Ident       *ast.Identifier // the occurrence
+	DeclFile    int             // index of the file of the declaration
+	Declaration *ast.Identifier // the declaring identifier; Ident for a declaration
+}
+
+// References resolves the names in the files of one package like
+// CheckPackage and returns every declaration and every use of a declared
+// name, ordered by file and position. Builtins and imported packages are
+// not declared in the source and have no references.
+func References(files []*ast.Program) []Reference {
+	_, refs, _ := checkPackage(make([]string, len(files)), files, true, Features{Overloading: true})
+	sort.SliceStable(refs, func(i, j int) bool {
+		if refs[i].File != refs[j].File {
+			return refs[i].File < refs[j].File
+		}
+		return refs[i].Ident.Token.Offset < refs[j].Ident.Token.Offset
+	})
+	return refs
+}
+
+// checkPackage checks the files of one package, also collecting the
+// references to declared names when references is set
+func checkPackage(paths []string, files []*ast.Program, references bool, features Features) ([]diagnostic.List, []Reference, []*ir.Info) {
+	var refs []Reference
+	pkg := newScope(universe)
+	out := make([]diagnostic.List, len(files))
+	declared := map[string]declaration{}
+	types := collectTypes(files)
+	for i, file := range files {
+		for _, name := range declareTopLevel(pkg, i, file) {
+			if references {
+				refs = append(refs, Reference{File: i, Ident: name, DeclFile: i, Declaration: name})
+			}
+			if name.Value == "init" || ast.IsBlank(name.Value) {
+				continue
+			}
+			if first, ok := declared[name.Value]; ok {
+				out[i] = append(out[i], redeclared(name, first, paths[first.file], first.file == i))
+				continue
+			}
+			declared[name.Value] = declaration{file: i, name: name}
+		}
+	}
+	methods := map[string]declaration{}
+	for i, file := range files {
+		for _, stmt := range file.Statements {
+			fn, ok := stmt.(*ast.FunctionStatement)
+			if !ok || fn.Receiver == nil || ast.IsBlank(fn.Name.Value) {
+				continue
+			}
+			typ, _ := codegen.ReceiverType(fn)
+			key := typ + "." + fn.Name.Value
+			if first, ok := methods[key]; ok {
+				out[i] = append(out[i], redeclared(fn.Name, first, paths[first.file], first.file == i))
+				continue
+			}
+			methods[key] = declaration{file: i, name: fn.Name}
+		}
+	}
+	(&checker{types: types}).topLevelTypes(pkg, files)
+	(&checker{types: types}).changingMethods(pkg)
+
+	infos := make([]*ir.Info, len(files))
+	for i, file := range files {
+		infos[i] = ir.NewInfo()
+		for _, stmt := range file.Statements {
+			if fn, ok := stmt.(*ast.FunctionStatement); ok && fn.Receiver != nil && types.changesReceiver(fn) {
+				infos[i].ChangedReceivers[fn.Token.Offset] = true
+			}
+		}
+		c := &checker{program: file, file: i, types: types, features: features, info: infos[i], translated: map[string]*stdlib.Package{}, packages: map[string]string{}}
+		if references {
+			c.references = &refs
+		}
+		c.checkImports()
+		c.checkNames(pkg)
+		out[i] = append(out[i], c.diags...)
+	}
+	return out, refs, infos
+}
+
+// declaration is a name declared in one of the files of a package
+type declaration struct {
+	file int // index of the file
+	name *ast.Identifier
+}
+
+// redeclared reports name, which was already declared by first. Path
+// names the file of first unless it is the file of name.
+func redeclared(name *ast.Identifier, first declaration, path string, sameFile bool) *diagnostic.Diagnostic {
+	pos := first.name.Token.Position
+	at := fmt.Sprintf("line %d:%d", pos.Line, pos.Column)
+	if !sameFile {
+		at = fmt.Sprintf("%s:%d:%d", path, pos.Line, pos.Column)
+	}
+	return diagnostic.AtNode(diagnostic.Redeclared, name, "%s redeclared; other declaration at %s", name.Value, at)
+}
+
+// checker holds the state of checking a single file
+type checker struct {
+	program *ast.Program
+	file    int // index of the file in its package
+	diags   diagnostic.List
+
+	// references collects the references to declared names, when set
+	references *[]Reference
+
+	// translated maps the Chinese names of imported translated packages
+	translated map[string]*stdlib.Package
+
+	// packages maps the names imported packages are used by to their Go
+	// import paths
+	packages map[string]string
+
+	// types holds the types and functions declared in the package
+	types *packageTypes
+
+	// features holds the language features the package may use
+	features Features
+
+	// info collects the operations on strings and the overloaded
+	// operators in the file
+	info *ir.Info
+
+	// function names the function whose body is being checked, and result
+	// is its result type, nil when it returns nothing
+	function string
+	result   ast.Expression
+}
+
+// declareTopLevel declares the package-level names of the file with index
+// i in pkg and returns them in the order they are declared
+func declareTopLevel(pkg *scope, i int, file *ast.Program) []*ast.Identifier {
+	var names []*ast.Identifier
+	for _, stmt := range file.Statements {
+		switch stmt := stmt.(type) {
+		case *ast.FunctionStatement:
+			// Methods are selected from their receivers instead
+			if stmt.Receiver == nil {
+				pkg.define(i, stmt.Name)
+				names = append(names, stmt.Name)
+			}
+		case *ast.VarStatement:
+			pkg.define(i, stmt.Name)
+			names = append(names, stmt.Name)
+		case *ast.VarListStatement:
+			for _, name := range stmt.Names {
+				pkg.define(i, name)
+				names = append(names, name)
+			}
+		case *ast.ConstStatement:
+			pkg.defineConstant(i, stmt.Name)
+			names = append(names, stmt.Name)
+		case *ast.ConstGroupStatement:
+			for _, c := range stmt.Consts {
+				pkg.defineConstant(i, c.Name)
+				names = append(names, c.Name)
+			}
+		case *ast.InterfaceStatement:
+			pkg.define(i, stmt.Name)
+			names = append(names, stmt.Name)
+		case *ast.TypeStatement:
+			pkg.define(i, stmt.Name)
+			names = append(names, stmt.Name)
+		case *ast.EnumStatement:
+			pkg.define(i, stmt.Name)
+			names = append(names, stmt.Name)
+			for _, member := range stmt.Members {
+				pkg.defineConstant(i, member)
+				names = append(names, member)
+			}
+		case *ast.OptionStatement:
+			pkg.define(i, stmt.Name)
+			names = append(names, stmt.Name)
+		}
+	}
+	return names
+}
+
+// importNames returns the names an import makes available in the file: the
+// Go package name and, for a translated package, its Chinese name
+func importNames(imp *ast.ImportStatement) []string {
+	if pkg, ok := stdlib.LookupPath(imp.Path); ok {
+		return []string{pkg.GoName(), pkg.Name}
+	}
+	return []string{path.Base(imp.Path)}
+}
+
+// checkImports reports imported packages that the program never refers to
+func (c *checker) checkImports() {
+	used := map[string]bool{}
+	ast.Inspect(c.program, func(node ast.Node) bool {
+		if member, ok := node.(*ast.MemberExpression); ok {
+			if ident, ok := member.Object.(*ast.Identifier); ok {
+				used[ident.Value] = true
+			}
+		}
+		return true
+	})
+
+	imported := map[string]string{} // Go import path to the path as written
+	for _, stmt := range c.program.Statements {
+		imp, ok := stmt.(*ast.ImportStatement)
+		if !ok {
+			continue
+		}
+
+		goPath := stdlib.GoPath(imp.Path)
+		if first, ok := imported[goPath]; ok {
+			d := diagnostic.AtNode(diagnostic.DuplicateImport, imp, "%q is already imported as %q", imp.Path, first)
+			d.WithFix(fmt.Sprintf("remove import %q", imp.Path), diagnostic.Delete(ast.NodeRange(imp)))
+			c.diags = append(c.diags, d)
+			continue
+		}
+		imported[goPath] = imp.Path
+
+		isUsed := false
+		for _, name := range importNames(imp) {
+			isUsed = isUsed || used[name]
+		}
+		if isUsed {
+			continue
+		}
+
+		d := diagnostic.AtNode(diagnostic.UnusedImport, imp, "%q imported and not used", imp.Path)
+		d.WithFix(fmt.Sprintf("remove import %q", imp.Path), diagnostic.Delete(ast.NodeRange(imp)))
+		c.diags = append(c.diags, d)
+	}
+}
+
+// importEdit returns an edit adding an import of importPath after the
+// package clause or the last import of the file
+func (c *checker) importEdit(importPath string) diagnostic.TextEdit {
+	var anchor ast.Node
+	for _, stmt := range c.program.Statements {
+		switch stmt.(type) {
+		case *ast.PackageStatement, *ast.ImportStatement:
+			anchor = stmt
+		}
+	}
+
+	text := fmt.Sprintf("导入 %q", importPath)
+	if anchor == nil {
+		start := ast.Position{Line: 1, Column: 1, DisplayColumn: 1}
+		return diagnostic.Insert(start, text+"\n")
+	}
+	return diagnostic.Insert(ast.NodeRange(anchor).End, "\n"+text)
+}
+
+// checkNames reports identifiers that do not resolve to a declaration
+func (c *checker) checkNames(pkg *scope) {
+	file := newScope(pkg)
+	for _, stmt := range c.program.Statements {
+		imp, ok := stmt.(*ast.ImportStatement)
+		if !ok {
+			continue
+		}
+		for _, name := range importNames(imp) {
+			file.declare(name)
+			c.packages[name] = stdlib.GoPath(imp.Path)
+		}
+		if translated, ok := stdlib.LookupPath(imp.Path); ok {
+			c.translated[translated.Name] = translated
+		}
+	}
+
+	for _, stmt := range c.program.Statements {
+		c.statement(stmt, file, true)
+	}
+}
+
+// statement checks the names used by stmt. Top-level declarations are
+// already in scope; local ones are declared once their value is checked.
+func (c *checker) statement(stmt ast.Statement, s *scope, topLevel bool) {
+	switch stmt := stmt.(type) {
+	case *ast.FunctionStatement:
+		// Type parameters are visible in the signature and body only
+		if len(stmt.TypeParams) > 0 {
+			s = newScope(s)
+			for _, tp := range stmt.TypeParams {
+				c.define(s, tp.Name, false)
+			}
+		}
+		if stmt.Receiver != nil {
+			// So are those of the generic type of the receiver
+			typ, typeParams := codegen.ReceiverType(stmt)
+			if len(typeParams) > 0 {
+				s = newScope(s)
+				for _, param := range typeParams {
+					c.define(s, param, false)
+				}
+			}
+			c.receiver(stmt, s)
+			params := append([]*ast.TypedParam{stmt.Receiver}, stmt.Parameters...)
+			c.functionBody(typ+"."+stmt.Name.Value, params, stmt.ReturnType, stmt.Body, s)
+			break
+		}
+		c.functionBody(stmt.Name.Value, stmt.Parameters, stmt.ReturnType, stmt.Body, s)
+	case *ast.VarStatement:
+		c.expression(stmt.Value, s)
+		c.implements(stmt.Value, stmt.Type, s, "variable declaration")
+		if !topLevel {
+			typ := stmt.Type
+			if typ == nil {
+				typ = c.typeOf(stmt.Value, s)
+			}
+			c.define(s, stmt.Name, false)
+			s.setType(stmt.Name.Value, typ)
+		}
+	case *ast.VarListStatement:
+		c.expression(stmt.Value, s)
+		if !topLevel {
+			types := c.valueTypes(stmt.Value, len(stmt.Names), s)
+			for i, name := range stmt.Names {
+				c.define(s, name, false)
+				s.setType(name.Value, types[i])
+			}
+		}
+	case *ast.ConstStatement:
+		c.constantValue(stmt, s)
+		if !topLevel {
+			typ := c.typeOf(stmt.Value, s)
+			c.define(s, stmt.Name, true)
+			s.setType(stmt.Name.Value, typ)
+		}
+	case *ast.ConstGroupStatement:
+		var typ ast.Expression
+		for _, constant := range stmt.Consts {
+			if constant.Value != nil {
+				c.constantValue(constant, s)
+				typ = c.typeOf(constant.Value, s)
+			}
+			if !topLevel {
+				c.define(s, constant.Name, true)
+				s.setType(constant.Name.Value, typ)
+			}
+		}
+	case *ast.OptionStatement:
+		c.expression(stmt.Value, s)
+		c.option(stmt, topLevel)
+	case *ast.TypeStatement:
+		if !topLevel {
+			c.define(s, stmt.Name, false)
+		}
+	case *ast.EnumStatement:
+		if !topLevel {
+			c.define(s, stmt.Name, false)
+			for _, member := range stmt.Members {
+				c.define(s, member, true)
+				s.setType(member.Value, stmt.Name)
+			}
+		}
+	case *ast.ReturnStatement:
+		c.expression(stmt.ReturnValue, s)
+		c.returnStatement(stmt, s)
+	case *ast.IfStatement:
+		c.expression(stmt.Condition, s)
+		c.block(stmt.Consequence, s)
+		c.block(stmt.Alternative, s)
+	case *ast.ForStatement:
+		loop := newScope(s)
+		if stmt.Init != nil {
+			c.statement(stmt.Init, loop, false)
+		}
+		c.expression(stmt.Condition, loop)
+		if stmt.Update != nil {
+			c.statement(stmt.Update, loop, false)
+		}
+		c.block(stmt.Body, loop)
+	case *ast.WhileStatement:
+		c.expression(stmt.Condition, s)
+		c.block(stmt.Body, s)
+	case *ast.CharLoopStatement:
+		c.expression(stmt.Value, s)
+		loop := newScope(s)
+		if stmt.Index != nil {
+			c.define(loop, stmt.Index, false)
+			loop.setType(stmt.Index.Value, intType)
+		}
+		c.define(loop, stmt.Char, false)
+		loop.setType(stmt.Char.Value, stringType)
+		c.block(stmt.Body, loop)
+	case *ast.RangeStatement:
+		c.expression(stmt.Value, s)
+		loop := newScope(s)
+		c.define(loop, stmt.Name, false)
+		loop.setType(stmt.Name.Value, c.rangeElement(stmt, s))
+		c.block(stmt.Body, loop)
+	case *ast.QueryStatement:
+		c.expression(stmt.Call, s)
+		row := newScope(s)
+		for _, column := range stmt.Columns {
+			c.define(row, column.Name, false)
+			row.setType(column.Name.Value, column.Type)
+		}
+		c.block(stmt.Body, row)
+	case *ast.SignalStatement:
+		// The handler runs as a function of its own, returning nothing
+		function, result := c.function, c.result
+		c.function, c.result = "捕获信号", nil
+		c.block(stmt.Body, s)
+		c.function, c.result = function, result
+	case *ast.DeferStatement:
+		c.expression(stmt.Call, s)
+	case *ast.GoStatement:
+		c.expression(stmt.Call, s)
+	case *ast.SendStatement:
+		c.expression(stmt.Channel, s)
+		c.expression(stmt.Value, s)
+	case *ast.SelectStatement:
+		for _, clause := range stmt.Cases {
+			// A variable receiving the value is local to its case
+			scope := newScope(s)
+			if clause.Comm != nil {
+				c.statement(clause.Comm, scope, false)
+			}
+			c.statements(clause.Body, scope)
+		}
+	case *ast.SwitchStatement:
+		c.expression(stmt.Tag, s)
+		for _, clause := range stmt.Cases {
+			for _, value := range clause.Values {
+				c.expression(value, s)
+			}
+			c.statements(clause.Body, newScope(s))
+		}
+	case *ast.BlockStatement:
+		c.block(stmt, s)
+	case *ast.AssignListStatement:
+		for _, target := range stmt.Targets {
+			c.target(target, s)
+		}
+		c.expression(stmt.Value, s)
+	case *ast.ExpressionStatement:
+		// A lone name may be a misspelled keyword, as in 返货
+		if ident, ok := stmt.Expression.(*ast.Identifier); ok {
+			c.resolve(ident, s, ast.KeywordNames()...)
+			return
+		}
+		c.expression(stmt.Expression, s)
+	}
+}
+
+// functionBody checks the parameters and body of a function, whose
+// returns are checked against result. The names of named results are
+// declared along with the parameters. Function literals may use the
+// names of the scope s they appear in.
+func (c *checker) functionBody(name string, params []*ast.TypedParam, result ast.Expression, body *ast.BlockStatement, s *scope) {
+	fn := newScope(s)
+	seen := map[string]declaration{}
+	names := make([]*ast.Identifier, 0, len(params))
+	types := make([]ast.Expression, 0, len(params))
+	for _, param := range params {
+		names = append(names, param.Name)
+		types = append(types, paramType(param))
+	}
+	if results, ok := result.(*ast.ResultList); ok {
+		names = append(names, results.Names...)
+		types = append(types, results.Types...)
+	}
+	for i, ident := range names {
+		if first, ok := seen[ident.Value]; ok && !ast.IsBlank(ident.Value) {
+			c.diags = append(c.diags, redeclared(ident, first, "", true))
+		} else {
+			seen[ident.Value] = declaration{name: ident}
+		}
+		c.define(fn, ident, false)
+		fn.setType(ident.Value, types[i])
+	}
+
+	function, outer := c.function, c.result
+	c.function, c.result = name, result
+	c.statements(body.Statements, fn)
+	c.function, c.result = function, outer
+}
+
+// returnStatement reports a return whose values do not match the results
+// of the enclosing function: a value where there is no result, too few or
+// too many values, a literal of a kind its result type cannot hold, or a
+// value without the methods of its interface result type. A single call
+// may return all the results, and a return without values returns the
+// named results as they are.
+func (c *checker) returnStatement(stmt *ast.ReturnStatement, s *scope) {
+	results, values := resultTypes(c.result), returnValues(stmt.ReturnValue)
+	list, named := c.result.(*ast.ResultList)
+	named = named && len(list.Names) > 0
+	switch {
+	case c.function == "":
+		return
+	case c.result == nil && stmt.ReturnValue != nil:
+		c.diags = append(c.diags, diagnostic.AtNode(diagnostic.ReturnMismatch, stmt,
+			"too many return values: %s has no result", c.function))
+	case named && stmt.ReturnValue == nil:
+		return
+	case c.result != nil && stmt.ReturnValue == nil:
+		c.diags = append(c.diags, diagnostic.AtNode(diagnostic.ReturnMismatch, stmt,
+			"not enough return values: %s returns %s", c.function, ast.Sprint(c.result)))
+	case len(values) == 1 && len(results) > 1 && isCall(values[0]):
+		return
+	case len(values) < len(results):
+		c.diags = append(c.diags, diagnostic.AtNode(diagnostic.ReturnMismatch, stmt,
+			"not enough return values: %s returns %s", c.function, ast.Sprint(c.result)))
+	case len(values) > len(results):
+		c.diags = append(c.diags, diagnostic.AtNode(diagnostic.ReturnMismatch, stmt,
+			"too many return values: %s returns %s", c.function, ast.Sprint(c.result)))
+	default:
+		for i, value := range values {
+			c.implements(value, results[i], s, "return statement")
+			kind := literalKind(value)
+			want, ok := results[i].(*ast.Identifier)
+			if kind == "" || !ok || basicTypes[want.Value] == "" || accepts(basicTypes[want.Value], kind) {
+				continue
+			}
+			c.diags = append(c.diags, diagnostic.AtNode(diagnostic.ReturnMismatch, stmt,
+				"cannot use %s (%s literal) as %s value in return statement", ast.Sprint(value), kind, want.Value))
+		}
+	}
+}
+
+// resultTypes returns the types of the results of a function with the
+// given return type
+func resultTypes(result ast.Expression) []ast.Expression {
+	switch result := result.(type) {
+	case nil:
+		return nil
+	case *ast.ResultList:
+		return result.Types
+	}
+	return []ast.Expression{result}
+}
+
+// returnValues returns the values of a return statement
+func returnValues(value ast.Expression) []ast.Expression {
+	switch value := value.(type) {
+	case nil:
+		return nil
+	case *ast.ExpressionList:
+		return value.Values
+	}
+	return []ast.Expression{value}
+}
+
+// isCall reports whether expr is a function call
+func isCall(expr ast.Expression) bool {
+	_, ok := expr.(*ast.CallExpression)
+	return ok
+}
+
+// basicTypes maps the names of the basic types to the Go types they are
+var basicTypes = map[string]string{
+	"整数": "int", "浮点": "float64", "字符串": "string", "布尔": "bool",
+	"int": "int", "float64": "float64", "string": "string", "bool": "bool",
+}
+
+// literalKind returns the kind of value of a literal, or "" when expr is
+// not a basic literal
+func literalKind(expr ast.Expression) string {
+	switch expr.(type) {
+	case *ast.IntegerLiteral:
+		return "integer"
+	case *ast.FloatLiteral:
+		return "float"
+	case *ast.StringLiteral:
+		return "string"
+	case *ast.BooleanLiteral:
+		return "bool"
+	}
+	return ""
+}
+
+// accepts reports whether a literal of the given kind can be used as a
+// value of the basic Go type typ. Integer literals are untyped constants,
+// so they are floats as well.
+func accepts(typ, kind string) bool {
+	switch kind {
+	case "integer":
+		return typ == "int" || typ == "float64"
+	case "float":
+		return typ == "float64"
+	}
+	return typ == kind
+}
+
+// option reports an option declared anywhere but the top level of the
+// file declaring 入口, which parses the options
+func (c *checker) option(stmt *ast.OptionStatement, topLevel bool) {
+	if !topLevel {
+		c.diags = append(c.diags, diagnostic.AtNode(diagnostic.MisplacedOption, stmt, "option %s must be declared at the top level", stmt.Name.Value))
+		return
+	}
+	for _, decl := range c.program.Statements {
+		if fn, ok := decl.(*ast.FunctionStatement); ok && fn.Name.Value == "入口" {
+			return
+		}
+	}
+	c.diags = append(c.diags, diagnostic.AtNode(diagnostic.MisplacedOption, stmt, "option %s must be declared in the file declaring 入口", stmt.Name.Value))
+}
+
+// statements checks a list of statements in scope s
+func (c *checker) statements(stmts []ast.Statement, s *scope) {
+	for _, stmt := range stmts {
+		c.statement(stmt, s, false)
+	}
+}
+
+// block checks a block in a new scope nested in s
+func (c *checker) block(block *ast.BlockStatement, s *scope) {
+	if block != nil {
+		c.statements(block.Statements, newScope(s))
+	}
+}
+
+// expression checks the names used by expr
+func (c *checker) expression(expr ast.Expression, s *scope) {
+	switch expr := expr.(type) {
+	case *ast.Identifier:
+		c.resolve(expr, s)
+	case *ast.PrefixExpression:
+		c.expression(expr.Right, s)
+	case *ast.InfixExpression:
+		c.expression(expr.Left, s)
+		c.expression(expr.Right, s)
+		c.operator(expr.Token, expr.Operator, expr.Left, expr.Right, s)
+	case *ast.AssignExpression:
+		c.target(expr.Left, s)
+		c.expression(expr.Value, s)
+		c.implements(expr.Value, c.typeOf(expr.Left, s), s, "assignment")
+	case *ast.CompoundAssignExpression:
+		c.expression(expr.Left, s)
+		c.expression(expr.Value, s)
+		c.assignable(expr.Left, s)
+		c.operator(expr.Token, expr.Operator, expr.Left, expr.Value, s)
+	case *ast.MemberExpression:
+		c.member(expr, s)
+	case *ast.IndexExpression:
+		c.expression(expr.Left, s)
+		c.index(expr.Index, s)
+		c.indexed(expr, s)
+		c.stringOperation(expr, expr.Left, c.info.StringIndex, expr.Token.Offset, s)
+	case *ast.SliceExpression:
+		c.expression(expr.Left, s)
+		for _, index := range []ast.Expression{expr.Low, expr.High, expr.Max} {
+			if index != nil {
+				c.expression(index, s)
+			}
+		}
+		c.stringOperation(expr, expr.Left, c.info.StringSlice, expr.Token.Offset, s)
+	case *ast.CompositeLiteral:
+		c.expression(expr.Type, s)
+		for _, field := range expr.Fields {
+			c.expression(field.Value, s)
+		}
+	case *ast.MapLiteral:
+		for _, pair := range expr.Pairs {
+			c.expression(pair.Key, s)
+			c.expression(pair.Value, s)
+		}
+	case *ast.SliceLiteral:
+		for _, el := range expr.Elements {
+			c.expression(el, s)
+		}
+	case *ast.ArrayLiteral:
+		c.expression(expr.Type.Len, s)
+		for _, el := range expr.Elements {
+			c.expression(el, s)
+		}
+	case *ast.FunctionLiteral:
+		c.functionBody("匿名函数", expr.Parameters, expr.ReturnType, expr.Body, s)
+	case *ast.CallExpression:
+		c.expression(expr.Function, s)
+		for _, arg := range expr.Arguments {
+			c.expression(arg, s)
+		}
+		c.callArguments(expr, s)
+		if fn, ok := expr.Function.(*ast.Identifier); ok && fn.Value == "len" && s.builtin("len") && len(expr.Arguments) == 1 {
+			c.stringOperation(expr, expr.Arguments[0], c.info.StringLen, expr.Token.Offset, s)
+		}
+		c.callTypes(expr, s)
+	case *ast.ExpressionList:
+		for _, value := range expr.Values {
+			c.expression(value, s)
+		}
+	}
+}
+
+// index checks the index of an index expression, which may be the type
+// arguments instantiating a generic function or type. Predeclared types
+// are not declared in any scope.
+func (c *checker) index(index ast.Expression, s *scope) {
+	for _, arg := range ast.TypeArguments(index) {
+		if ident, ok := arg.(*ast.Identifier); ok {
+			if _, declared := s.declarationOf(ident.Value); predeclaredTypes[ident.Value] && !declared {
+				continue
+			}
+		}
+		c.expression(arg, s)
+	}
+}
+
+// constantValue checks the value of a constant declared in s, where 序号
+// is the number of the constant in its declaration
+func (c *checker) constantValue(stmt *ast.ConstStatement, s *scope) {
+	s = newScope(s)
+	s.declareConstant(codegen.IotaName)
+	s.setType(codegen.IotaName, intType)
+	c.expression(stmt.Value, s)
+	if !c.constant(stmt.Value, s) {
+		c.diags = append(c.diags, diagnostic.AtNode(diagnostic.NonConstantValue, stmt.Value,
+			"%s (value of constant %s) is not constant", ast.Sprint(stmt.Value), stmt.Name.Value))
+	}
+}
+
+// constant reports whether expr is a constant expression, which Go
+// accepts as the value of a const declaration: literals, constants,
+// conversions and operators applied to them. Members of packages may be
+// constants such as 时间.秒, which only Go can tell.
+func (c *checker) constant(expr ast.Expression, s *scope) bool {
+	switch expr := expr.(type) {
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.UnitLiteral, *ast.StringLiteral, *ast.BooleanLiteral:
+		return true
+	case *ast.Identifier:
+		return s.constant(expr.Value)
+	case *ast.PrefixExpression:
+		return expr.Operator != "<-" && c.constant(expr.Right, s)
+	case *ast.InfixExpression:
+		return c.constant(expr.Left, s) && c.constant(expr.Right, s)
+	case *ast.MemberExpression:
+		object, ok := expr.Object.(*ast.Identifier)
+		return ok && c.packages[object.Value] != "" && !s.shadows(object.Value)
+	case *ast.CallExpression:
+		fn, ok := expr.Function.(*ast.Identifier)
+		if !ok || !constantConversions[fn.Value] || s.shadows(fn.Value) || len(expr.Arguments) != 1 {
+			return false
+		}
+		return c.constant(expr.Arguments[0], s)
+	}
+	return false
+}
+
+// constantConversions lists the predeclared types a constant can be
+// converted to in a constant expression
+var constantConversions = map[string]bool{
+	"bool": true, "byte": true, "float32": true, "float64": true, "int": true,
+	"int8": true, "int16": true, "int32": true, "int64": true, "rune": true,
+	"string": true, "uint": true, "uint8": true, "uint16": true, "uint32": true,
+	"uint64": true, "uintptr": true,
+}
+
+// assignable reports an assignment to a constant
+// target checks the target of an assignment. Assigning to the blank
+// identifier discards the value.
+func (c *checker) target(target ast.Expression, s *scope) {
+	if ident, ok := target.(*ast.Identifier); !ok || !ast.IsBlank(ident.Value) {
+		c.expression(target, s)
+	}
+	c.assignable(target, s)
+}
+
+func (c *checker) assignable(target ast.Expression, s *scope) {
+	if index, ok := target.(*ast.IndexExpression); ok {
+		c.assignableElement(index, s)
+		return
+	}
+	ident, ok := target.(*ast.Identifier)
+	if !ok || !s.constant(ident.Value) {
+		return
+	}
+	c.diags = append(c.diags, diagnostic.AtNode(diagnostic.AssignToConstant, ident, "cannot assign to %s (constant)", ident.Value))
+}
+
+// member checks a member expression. Members of translated packages used
+// through their Chinese name must be known translations or Go names;
+// other members are left for Go to check.
+func (c *checker) member(expr *ast.MemberExpression, s *scope) {
+	c.expression(expr.Object, s)
+	c.changedReceiver(expr, s)
+
+	object, ok := expr.Object.(*ast.Identifier)
+	if !ok || s.shadows(object.Value) {
+		return
+	}
+	pkg, ok := c.translated[object.Value]
+	if !ok {
+		return
+	}
+	property, ok := expr.Property.(*ast.Identifier)
+	if !ok {
+		return
+	}
+	if _, ok := pkg.Member(property.Value); ok {
+		return
+	}
+
+	d := diagnostic.AtNode(diagnostic.UnknownMember, property, "%s has no member %s", object.Value, property.Value)
+	c.suggest(d, property, pkg.MemberNames())
+	c.diags = append(c.diags, d)
+}
+
+// resolve reports ident if it is not declared in s or an enclosing scope,
+// or if it is the blank identifier, which has no value. An undefined
+// name may have been meant to be one of the visible names or keywords.
+func (c *checker) resolve(ident *ast.Identifier, s *scope, keywords ...string) {
+	if ast.IsBlank(ident.Value) {
+		c.diags = append(c.diags, diagnostic.AtNode(diagnostic.UndefinedName, ident, "cannot use %s as value", ident.Value))
+		return
+	}
+	if s.lookup(ident.Value) {
+		if d, ok := s.declarationOf(ident.Value); ok && c.references != nil {
+			*c.references = append(*c.references, Reference{File: c.file, Ident: ident, DeclFile: d.file, Declaration: d.name})
+		}
+		return
+	}
+	if pkg, ok := stdlib.LookupPackage(ident.Value); ok {
+		d := diagnostic.AtNode(diagnostic.MissingImport, ident, "%s used without importing %q", ident.Value, pkg.Path)
+		d.WithFix(fmt.Sprintf("import %q", pkg.Path), c.importEdit(pkg.Path))
+		c.diags = append(c.diags, d)
+		return
+	}
+	d := diagnostic.AtNode(diagnostic.UndefinedName, ident, "undefined: %s", ident.Value)
+	c.suggest(d, ident, append(s.visible(), keywords...))
+	c.diags = append(c.diags, d)
+}
+
+// define declares a local name in s, recording the declaration when
+// references are collected
+func (c *checker) define(s *scope, ident *ast.Identifier, constant bool) {
+	if constant {
+		s.defineConstant(c.file, ident)
+	} else {
+		s.define(c.file, ident)
+	}
+	if c.references != nil {
+		*c.references = append(*c.references, Reference{File: c.file, Ident: ident, DeclFile: c.file, Declaration: ident})
+	}
+}
+
+// suggest appends "did you mean" to d, with a fix, when one of candidates
+// is close to the name of ident
+func (c *checker) suggest(d *diagnostic.Diagnostic, ident *ast.Identifier, candidates []string) {
+	name, ok := suggest.Closest(ident.Value, candidates)
+	if !ok {
+		return
+	}
+	d.Message += fmt.Sprintf("; did you mean %s?", name)
+	d.WithFix(fmt.Sprintf("replace %s with %s", ident.Value, name),
+		diagnostic.Replace(ast.TokenRange(ident.Token), name))
+}
@@ -0,0 +1,137 @@
+package checker
+
+import (
+	"path"
+	"reflect"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/stdlib"
+)
+
+// packageMember returns the type of expr when it selects a function,
+// variable or constant of an imported standard library package whose type
+// is known, as 字符串库.转大写 or strings.ToUpper, and nil otherwise
+func (c *checker) packageMember(expr *ast.MemberExpression, s *scope) ast.Expression {
+	object, ok := expr.Object.(*ast.Identifier)
+	property, isIdent := expr.Property.(*ast.Identifier)
+	if !ok || !isIdent || c.packages[object.Value] == "" || s.shadows(object.Value) {
+		return nil
+	}
+	name := property.Value
+	if pkg, ok := c.translated[object.Value]; ok {
+		if goName, ok := pkg.Member(name); ok {
+			name = goName
+		}
+	}
+	if t, ok := stdlib.ValueType(c.packages[object.Value], name); ok {
+		return goType(t)
+	}
+	return nil
+}
+
+// goMethod returns the type of the method name of typ, a named type of a
+// standard library package such as time.Time, without its receiver, or
+// nil when it is not known
+func (c *checker) goMethod(typ *ast.MemberExpression, name string) ast.Expression {
+	pkg, ok := typ.Object.(*ast.Identifier)
+	typeName, isIdent := typ.Property.(*ast.Identifier)
+	if !ok || !isIdent {
+		return nil
+	}
+	goPath := c.packages[pkg.Value]
+	if translated, ok := stdlib.LookupPackage(pkg.Value); ok && goPath == "" {
+		goPath = translated.Path
+	}
+	t, ok := stdlib.NamedType(goPath, typeName.Value)
+	if !ok {
+		return nil
+	}
+	method, ok := t.MethodByName(name)
+	if !ok {
+		return nil
+	}
+	fn, ok := goType(method.Type).(*ast.FuncType)
+	if !ok {
+		return nil
+	}
+	fn.Parameters = fn.Parameters[1:]
+	return fn
+}
+
+// goType returns the Saika type of the Go type t, or nil when it has none.
+// A named type of a Go package is written as Go writes it, and pointers,
+// which Saika does not have, are not known.
+func goType(t reflect.Type) ast.Expression {
+	if t.PkgPath() != "" {
+		return &ast.MemberExpression{
+			Object:   &ast.Identifier{Value: path.Base(t.PkgPath())},
+			Property: &ast.Identifier{Value: t.Name()},
+		}
+	}
+	if t.Name() != "" {
+		return &ast.Identifier{Value: t.Name()}
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return &ast.Identifier{Value: "any"}
+		}
+	case reflect.Slice:
+		if elem := goType(t.Elem()); elem != nil {
+			return &ast.SliceType{Elem: elem}
+		}
+	case reflect.Array:
+		if elem := goType(t.Elem()); elem != nil {
+			return &ast.ArrayType{Len: &ast.IntegerLiteral{Value: int64(t.Len())}, Elem: elem}
+		}
+	case reflect.Chan:
+		if elem := goType(t.Elem()); elem != nil {
+			return &ast.ChanType{Elem: elem}
+		}
+	case reflect.Map:
+		key, value := goType(t.Key()), goType(t.Elem())
+		if key != nil && value != nil {
+			return &ast.MapType{Key: key, Value: value}
+		}
+	case reflect.Func:
+		return goFuncType(t)
+	}
+	return nil
+}
+
+// goFuncType returns the Saika type of the Go function type t. A parameter
+// or result whose type is not known is written as 任意, so that the types
+// of the others are still known.
+func goFuncType(t reflect.Type) *ast.FuncType {
+	fn := &ast.FuncType{Variadic: t.IsVariadic()}
+	for i := range t.NumIn() {
+		in := t.In(i)
+		if fn.Variadic && i == t.NumIn()-1 {
+			in = in.Elem()
+		}
+		fn.Parameters = append(fn.Parameters, knownType(in))
+	}
+
+	switch t.NumOut() {
+	case 0:
+	case 1:
+		fn.ReturnType = goType(t.Out(0))
+	default:
+		results := &ast.ResultList{}
+		for i := range t.NumOut() {
+			results.Types = append(results.Types, goType(t.Out(i)))
+		}
+		fn.ReturnType = results
+	}
+	return fn
+}
+
+// knownType returns the Saika type of the Go type t, or 任意 when it has
+// none
+func knownType(t reflect.Type) ast.Expression {
+	if typ := goType(t); typ != nil {
+		return typ
+	}
+	return anyType
+}
@@ -0,0 +1,122 @@
+package checker
+
+import (
+	"math"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/diagnostic"
+)
+
+// integerTypes lists the predeclared integer types
+var integerTypes = map[string]bool{
+	"整数": true, "int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+	"byte": true, "rune": true,
+}
+
+// floatTypes lists the predeclared floating-point types
+var floatTypes = map[string]bool{"浮点": true, "float32": true, "float64": true}
+
+// isInteger reports whether the values of typ are integers
+func (c *checker) isInteger(typ ast.Expression) bool {
+	ident, ok := c.underlying(typ).(*ast.Identifier)
+	return ok && integerTypes[ident.Value]
+}
+
+// indexed checks an element of a slice, array or string that expr reads
+// or assigns to, as Go does when compiling it: the index must be an
+// integer, and a constant one must not be negative or, for an array,
+// past its end. Values that cannot be indexed are reported.
+func (c *checker) indexed(expr *ast.IndexExpression, s *scope) {
+	typ := c.typeOf(expr.Left, s)
+	length := int64(-1)
+	switch u := c.underlying(typ).(type) {
+	case *ast.SliceType:
+	case *ast.ArrayType:
+		if n, ok := u.Len.(*ast.IntegerLiteral); ok {
+			length = n.Value
+		}
+	case *ast.Identifier:
+		if integerTypes[u.Value] || floatTypes[u.Value] || isBool(u) {
+			c.diags = append(c.diags, diagnostic.AtNode(diagnostic.InvalidIndex, expr.Left,
+				"cannot index %s (type %s)", ast.Sprint(expr.Left), ast.Sprint(typ)))
+			return
+		}
+		if str, _ := c.isString(u); !str {
+			return
+		}
+	default:
+		return
+	}
+
+	index, isConst := constantIndex(expr.Index)
+	switch {
+	case !isConst:
+		if t := c.typeOf(expr.Index, s); c.nonInteger(t, expr.Index, s) {
+			c.diags = append(c.diags, diagnostic.AtNode(diagnostic.InvalidIndex, expr.Index,
+				"invalid index %s (type %s): the index of %s must be an integer", ast.Sprint(expr.Index), ast.Sprint(t), ast.Sprint(expr.Left)))
+		}
+	case index < 0:
+		c.diags = append(c.diags, diagnostic.AtNode(diagnostic.InvalidIndex, expr.Index,
+			"invalid index %s of %s: an index must not be negative", ast.Sprint(expr.Index), ast.Sprint(expr.Left)))
+	case length >= 0 && index >= length:
+		c.diags = append(c.diags, diagnostic.AtNode(diagnostic.InvalidIndex, expr.Index,
+			"invalid index %s of %s: out of bounds for its %d elements", ast.Sprint(expr.Index), ast.Sprint(expr.Left), length))
+	}
+}
+
+// nonInteger reports whether index, of type typ, is known not to be an
+// integer. Only predeclared types are known: a type parameter may stand
+// for an integer type. A constant float expression may have an integer
+// value, which Go accepts, but a float literal does not.
+func (c *checker) nonInteger(typ, index ast.Expression, s *scope) bool {
+	ident, ok := c.underlying(typ).(*ast.Identifier)
+	if !ok || !predeclaredTypes[ident.Value] || integerTypes[ident.Value] {
+		return false
+	}
+	if _, literal := index.(*ast.FloatLiteral); !literal && floatTypes[ident.Value] && c.constant(index, s) {
+		return false
+	}
+	return true
+}
+
+// constantIndex returns the value of index when it is an integer literal,
+// negated or not, or a float literal with an integer value, and ok is
+// false otherwise
+func constantIndex(index ast.Expression) (value int64, ok bool) {
+	switch index := index.(type) {
+	case *ast.IntegerLiteral:
+		return index.Value, true
+	case *ast.FloatLiteral:
+		if index.Value == math.Trunc(index.Value) {
+			return int64(index.Value), true
+		}
+	case *ast.PrefixExpression:
+		if index.Operator == "-" {
+			if n, ok := constantIndex(index.Right); ok {
+				return -n, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// assignableElement checks that the element target assigns to can be
+// changed: the characters of a string cannot, and neither can the
+// elements of an array that is not itself stored in a variable, such as
+// an array held in a map or returned by a call
+func (c *checker) assignableElement(target *ast.IndexExpression, s *scope) {
+	typ := c.typeOf(target.Left, s)
+	switch c.underlying(typ).(type) {
+	case *ast.ArrayType:
+		if !c.addressable(target.Left, s) {
+			c.diags = append(c.diags, diagnostic.AtNode(diagnostic.InvalidAssignTarget, target,
+				"cannot assign to %s: %s is not a variable, so its elements cannot be changed", ast.Sprint(target), ast.Sprint(target.Left)))
+		}
+	case *ast.Identifier:
+		if str, _ := c.isString(typ); str {
+			c.diags = append(c.diags, diagnostic.AtNode(diagnostic.InvalidAssignTarget, target,
+				"cannot assign to %s: the characters of a string cannot be changed; build a new string instead", ast.Sprint(target)))
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package checker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/codegen"
+	"github.com/saika-m/saika-lang/internal/diagnostic"
+)
+
+// packageTypes holds the types, functions and methods declared at the top
+// level of the files of a package, for checking the values given to
+// interfaces
+type packageTypes struct {
+	interfaces map[string]*ast.InterfaceStatement
+	types      map[string]*ast.TypeStatement
+	functions  map[string]*ast.FunctionStatement
+	methods    map[string]map[string]*ast.FunctionStatement // by the name of the receiver type
+	changing   map[string]bool                              // methods changing their receiver, as 计数器.增
+}
+
+// collectTypes returns the types and functions declared in files
+func collectTypes(files []*ast.Program) *packageTypes {
+	t := &packageTypes{
+		interfaces: map[string]*ast.InterfaceStatement{},
+		types:      map[string]*ast.TypeStatement{},
+		functions:  map[string]*ast.FunctionStatement{},
+		methods:    map[string]map[string]*ast.FunctionStatement{},
+		changing:   map[string]bool{},
+	}
+	for _, file := range files {
+		for _, stmt := range file.Statements {
+			switch stmt := stmt.(type) {
+			case *ast.InterfaceStatement:
+				t.interfaces[stmt.Name.Value] = stmt
+			case *ast.TypeStatement:
+				t.types[stmt.Name.Value] = stmt
+			case *ast.FunctionStatement:
+				if stmt.Receiver == nil {
+					t.functions[stmt.Name.Value] = stmt
+					break
+				}
+				// The first of methods declared twice is the one used
+				typ, _ := codegen.ReceiverType(stmt)
+				if typ == "" {
+					break
+				}
+				if t.methods[typ] == nil {
+					t.methods[typ] = map[string]*ast.FunctionStatement{}
+				}
+				if t.methods[typ][stmt.Name.Value] == nil {
+					t.methods[typ][stmt.Name.Value] = stmt
+				}
+			}
+		}
+	}
+	return t
+}
+
+// errorMethods is the method set of the predeclared error interface
+var errorMethods = []string{"Error"}
+
+// interfaceMethods returns the methods of the interface typ names, and
+// whether it names one: an interface declared in the package or 错误
+func (c *checker) interfaceMethods(typ ast.Expression, s *scope) ([]string, bool) {
+	ident, ok := typ.(*ast.Identifier)
+	if !ok {
+		return nil, false
+	}
+	if ident.Value == "错误" || ident.Value == "error" && !s.shadows("error") {
+		if _, declared := s.declarationOf(ident.Value); !declared {
+			return errorMethods, true
+		}
+	}
+	decl, ok := c.types.interfaces[ident.Value]
+	if !ok || !c.declares(s, decl.Name) {
+		return nil, false
+	}
+	methods := make([]string, len(decl.Methods))
+	for i, method := range decl.Methods {
+		methods[i] = method.Name.Value
+	}
+	return methods, true
+}
+
+// declares reports whether the name of the package-level declaration
+// name is the one visible from s
+func (c *checker) declares(s *scope, name *ast.Identifier) bool {
+	d, ok := s.declarationOf(name.Value)
+	return ok && d.name == name
+}
+
+// valueMethods returns the name of the type of value and the methods a
+// value of it has, when they are known, as they are for the values of
+// predeclared, composite and instantiated types and of types declared in
+// Saika. It returns "" for any other value, such as one of a type declared
+// from a Go type, which is left for Go to check.
+func (c *checker) valueMethods(value ast.Expression, s *scope) (string, map[string]*ast.FunctionStatement) {
+	switch typ := c.typeOf(value, s).(type) {
+	case *ast.Identifier:
+		if methods, known := c.methodSet(typ, s, 0); known {
+			return typ.Value, methods
+		}
+	case *ast.IndexExpression:
+		if ident, ok := typ.Left.(*ast.Identifier); ok {
+			if methods, known := c.methodSet(ident, s, 0); known {
+				return ast.Sprint(typ), methods
+			}
+		}
+	case *ast.StructType, *ast.MapType, *ast.SliceType, *ast.ArrayType, *ast.ChanType, *ast.FuncType:
+		return ast.Sprint(typ), nil
+	}
+	return "", nil
+}
+
+// methodSet returns the methods declared on the type named by ident, and
+// whether they are all known: a basic type has none, and a type declared
+// in the package as a struct, map, slice, array, channel or function type,
+// or as another such type, has those declared in Saika. A type declared
+// from a Go type is not known: an alias keeps the methods of its type.
+func (c *checker) methodSet(ident *ast.Identifier, s *scope, depth int) (map[string]*ast.FunctionStatement, bool) {
+	if basicTypes[ident.Value] != "" {
+		return nil, true
+	}
+	decl, ok := c.types.types[ident.Value]
+	if !ok || !c.declares(s, decl.Name) || depth > len(c.types.types) {
+		return nil, false
+	}
+	switch typ := decl.Type.(type) {
+	case *ast.StructType, *ast.MapType, *ast.SliceType, *ast.ArrayType, *ast.ChanType, *ast.FuncType:
+		return c.types.methods[ident.Value], true
+	case *ast.Identifier:
+		methods, known := c.methodSet(typ, s, depth+1)
+		if !decl.Alias {
+			methods = c.types.methods[ident.Value]
+		}
+		return methods, known
+	}
+	return nil, false
+}
+
+// implements reports value, given as a value of typ, when typ is an
+// interface with methods that the type of value is known not to have.
+// A method that changes its receiver only belongs to a pointer to the
+// value in Go, so the value does not have it either. Context says where
+// the value is used, as in "return statement".
+func (c *checker) implements(value, typ ast.Expression, s *scope, context string) {
+	if value == nil || typ == nil {
+		return
+	}
+	methods, ok := c.interfaceMethods(typ, s)
+	if !ok || len(methods) == 0 {
+		return
+	}
+	name, has := c.valueMethods(value, s)
+	if name == "" {
+		return
+	}
+
+	var missing, changing []string
+	for _, method := range methods {
+		fn, ok := has[method]
+		switch {
+		case !ok:
+			missing = append(missing, method)
+		case c.types.changesReceiver(fn):
+			changing = append(changing, method)
+		}
+	}
+	var reason string
+	switch {
+	case len(missing) == 1:
+		reason = "missing method " + missing[0]
+	case len(missing) > 1:
+		reason = "missing methods " + strings.Join(missing, ", ")
+	case len(changing) > 0:
+		reason = "method " + changing[0] + " changes its receiver"
+	default:
+		return
+	}
+	c.diags = append(c.diags, diagnostic.AtNode(diagnostic.MissingMethod, value,
+		"cannot use %s (value of type %s) as %s value in %s: %s does not implement %s (%s)",
+		ast.Sprint(value), name, ast.Sprint(typ), context, name, ast.Sprint(typ), reason))
+}
+
+// callArguments checks the arguments of a call of a function declared in
+// the package against the interfaces its parameters take
+func (c *checker) callArguments(expr *ast.CallExpression, s *scope) {
+	ident, ok := expr.Function.(*ast.Identifier)
+	if !ok || expr.Ellipsis {
+		return
+	}
+	fn, ok := c.types.functions[ident.Value]
+	if !ok || !c.declares(s, fn.Name) || len(fn.TypeParams) > 0 {
+		return
+	}
+	for i, arg := range expr.Arguments {
+		if i >= len(fn.Parameters) || fn.Parameters[i].Variadic {
+			return
+		}
+		c.implements(arg, fn.Parameters[i].Type, s, fmt.Sprintf("argument to %s", ident.Value))
+	}
+}
@@ -0,0 +1,60 @@
+package checker
+
+import (
+	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/diagnostic"
+)
+
+// Methods of the iterator protocol: 下一个() 布尔 moves an iterator to its
+// next value and reports whether there is one, and 值() returns the value
+const (
+	nextMethod  = "下一个"
+	valueMethod = "值"
+)
+
+// rangeElement returns the type of the values a 范围 loop binds, or nil
+// when the type of what it ranges over is not known. The type of the
+// values of an iterator is recorded for lowering the loop. Values that
+// 范围 cannot range over are reported.
+func (c *checker) rangeElement(stmt *ast.RangeStatement, s *scope) ast.Expression {
+	typ := c.typeOf(stmt.Value, s)
+	switch u := c.underlying(typ).(type) {
+	case nil:
+		return nil
+	case *ast.SliceType:
+		return u.Elem
+	case *ast.ArrayType:
+		return u.Elem
+	case *ast.Identifier:
+		if u.Value == "字符串" || u.Value == "string" {
+			c.diags = append(c.diags, diagnostic.AtNode(diagnostic.InvalidRange, stmt.Value,
+				"cannot range over %s (type %s): range over the characters of a string with 逐字符",
+				ast.Sprint(stmt.Value), ast.Sprint(typ)))
+			return nil
+		}
+	}
+
+	next, value := c.method(typ, nextMethod), c.method(typ, valueMethod)
+	if next == nil || value == nil {
+		c.diags = append(c.diags, diagnostic.AtNode(diagnostic.InvalidRange, stmt.Value,
+			"cannot range over %s (type %s): 范围 ranges over slices, arrays and iterators, which have the methods %s() 布尔 and %s()",
+			ast.Sprint(stmt.Value), ast.Sprint(typ), nextMethod, valueMethod))
+		return nil
+	}
+	results := resultTypes(next.ReturnType)
+	if len(next.Parameters) > 0 || len(results) != 1 || !isBool(results[0]) {
+		c.diags = append(c.diags, diagnostic.AtNode(diagnostic.InvalidRange, stmt.Value,
+			"cannot range over %s (type %s): its method %s must be %s() 布尔",
+			ast.Sprint(stmt.Value), ast.Sprint(typ), nextMethod, nextMethod))
+		return nil
+	}
+	results = resultTypes(value.ReturnType)
+	if len(value.Parameters) > 0 || len(results) != 1 {
+		c.diags = append(c.diags, diagnostic.AtNode(diagnostic.InvalidRange, stmt.Value,
+			"cannot range over %s (type %s): its method %s must take nothing and return one value",
+			ast.Sprint(stmt.Value), ast.Sprint(typ), valueMethod))
+		return nil
+	}
+	c.info.Iterators[stmt.Token.Offset] = results[0]
+	return results[0]
+}
@@ -0,0 +1,334 @@
+package checker
+
+import (
+	"strings"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/codegen"
+	"github.com/saika-m/saika-lang/internal/diagnostic"
+)
+
+// Features holds the language features that a package may use besides
+// those of every language version
+type Features struct {
+	// Overloading has the operators +, -, *, / and == on two values of a
+	// struct type call the methods 加, 减, 乘, 除 and 等于 of the type
+	Overloading bool
+}
+
+// operatorMethods gives the method each operator that can be overloaded
+// calls; != is the negation of 等于
+var operatorMethods = map[string]string{
+	"+": "加", "-": "减", "*": "乘", "/": "除", "==": "等于", "!=": "等于",
+}
+
+// receiver reports a method declared on a type that cannot have methods,
+// with type parameters or with the name of a field, and an operator
+// method that does not have the form its operator needs. The receiver of
+// a method on a generic type names the type parameters, as in (s 栈[T]).
+func (c *checker) receiver(fn *ast.FunctionStatement, s *scope) {
+	name, params := codegen.ReceiverType(fn)
+	var st *ast.StructType
+	decl, declared := c.types.types[name]
+	if declared && !decl.Alias && c.declares(s, decl.Name) {
+		st, _ = c.underlying(decl.Name).(*ast.StructType)
+	}
+	if st == nil {
+		c.diags = append(c.diags, diagnostic.AtNode(diagnostic.InvalidReceiver, fn.Receiver.Type,
+			"cannot declare method %s on %s: methods can only be declared on struct types of the package",
+			fn.Name.Value, ast.Sprint(fn.Receiver.Type)))
+		return
+	}
+	if len(decl.TypeParams) == 0 && len(params) > 0 {
+		c.diags = append(c.diags, diagnostic.AtNode(diagnostic.InvalidReceiver, fn.Receiver.Type,
+			"cannot declare method %s on %s: %s is not a generic type", fn.Name.Value, ast.Sprint(fn.Receiver.Type), name))
+		return
+	}
+	if len(params) != len(decl.TypeParams) {
+		names := make([]string, len(decl.TypeParams))
+		for i, tp := range decl.TypeParams {
+			names[i] = tp.Name.Value
+		}
+		c.diags = append(c.diags, diagnostic.AtNode(diagnostic.InvalidReceiver, fn.Receiver.Type,
+			"cannot declare method %s on %s: the receiver must name the type parameters of %s, as in (%s %s[%s])",
+			fn.Name.Value, ast.Sprint(fn.Receiver.Type), name, fn.Receiver.Name.Value, name, strings.Join(names, ", ")))
+		return
+	}
+	if len(fn.TypeParams) > 0 {
+		c.diags = append(c.diags, diagnostic.AtNode(diagnostic.InvalidReceiver, fn.TypeParams[0].Name,
+			"method %s.%s cannot have type parameters", name, fn.Name.Value))
+	}
+	for _, field := range st.Fields {
+		if field.Name.Value == fn.Name.Value {
+			c.diags = append(c.diags, diagnostic.AtNode(diagnostic.InvalidReceiver, fn.Name,
+				"type %s has both field and method named %s", name, fn.Name.Value))
+		}
+	}
+	if c.features.Overloading {
+		c.operatorMethod(fn, ast.Sprint(fn.Receiver.Type))
+	}
+}
+
+// operatorMethod reports a method that an operator calls, named as in
+// operatorMethods, unless it takes one value of its receiver type typ,
+// leaves its receiver as it is and, for 等于, returns 布尔
+func (c *checker) operatorMethod(fn *ast.FunctionStatement, typ string) {
+	operator := ""
+	for op, method := range operatorMethods {
+		if method == fn.Name.Value && op != "!=" {
+			operator = op
+		}
+	}
+	if operator == "" {
+		return
+	}
+
+	report := func(format string, args ...any) {
+		c.diags = append(c.diags, diagnostic.AtNode(diagnostic.InvalidOperator, fn.Name,
+			"method %s.%s overloads %s, so it "+format, append([]any{typ, fn.Name.Value, operator}, args...)...))
+	}
+	param := fn.Parameters
+	if len(param) != 1 || param[0].Variadic || ast.Sprint(param[0].Type) != typ {
+		report("must take one parameter of type %s", typ)
+	}
+	results := resultTypes(fn.ReturnType)
+	switch {
+	case operator == "==" && (len(results) != 1 || !isBool(results[0])):
+		report("must return 布尔")
+	case len(results) != 1:
+		report("must return one value")
+	}
+	if c.types.changesReceiver(fn) {
+		report("must not change its receiver")
+	}
+}
+
+// isBool reports whether typ is the predeclared boolean type
+func isBool(typ ast.Expression) bool {
+	ident, ok := typ.(*ast.Identifier)
+	return ok && (ident.Value == "布尔" || ident.Value == "bool")
+}
+
+// method returns the method called name declared on the type typ names,
+// following aliases, or nil when there is none. The method of an
+// instantiated generic type has the type arguments in its signature.
+func (c *checker) method(typ ast.Expression, name string) *ast.FunctionStatement {
+	for depth := 0; depth <= len(c.types.types); depth++ {
+		var args []ast.Expression
+		if inst, ok := typ.(*ast.IndexExpression); ok {
+			typ, args = inst.Left, ast.TypeArguments(inst.Index)
+		}
+		ident, ok := typ.(*ast.Identifier)
+		if !ok {
+			return nil
+		}
+		if fn := c.types.methods[ident.Value][name]; fn != nil {
+			_, params := codegen.ReceiverType(fn)
+			types := ast.BindTypeParams(params, args)
+			if types == nil {
+				return fn
+			}
+			inst := *fn
+			inst.Parameters = make([]*ast.TypedParam, len(fn.Parameters))
+			for i, param := range fn.Parameters {
+				inst.Parameters[i] = &ast.TypedParam{Name: param.Name, Type: ast.Substitute(param.Type, types), Variadic: param.Variadic}
+			}
+			inst.ReturnType = ast.Substitute(fn.ReturnType, types)
+			return &inst
+		}
+		decl, ok := c.types.types[ident.Value]
+		if !ok || !decl.Alias {
+			return nil
+		}
+		typ = decl.Type
+	}
+	return nil
+}
+
+// operator records an operator applied to two values of a struct type
+// declaring the method it calls, given by op, the token of the operator,
+// so that it is lowered to a call of the method. Overloading operators
+// needs the feature, and both operands to be of the same type.
+func (c *checker) operator(op ast.Token, operator string, left, right ast.Expression, s *scope) {
+	name, ok := operatorMethods[operator]
+	if !ok {
+		return
+	}
+	typ := c.typeOf(left, s)
+	if c.method(typ, name) == nil {
+		return
+	}
+	if !c.features.Overloading {
+		c.diags = append(c.diags, diagnostic.New(diagnostic.Error, diagnostic.InvalidOperator, ast.TokenRange(op),
+			"operator %s on %s calls its method %s only from language version 1.1; set \"language\": \"1.1\" in %s",
+			operator, ast.Sprint(typ), name, projectFile))
+		return
+	}
+	if other := c.typeOf(right, s); other != nil && ast.Sprint(other) != ast.Sprint(typ) {
+		c.diags = append(c.diags, diagnostic.New(diagnostic.Error, diagnostic.InvalidOperator, ast.TokenRange(op),
+			"invalid operation: operator %s on %s and %s: both operands of an overloaded operator must be %s",
+			operator, ast.Sprint(typ), ast.Sprint(other), ast.Sprint(typ)))
+		return
+	}
+	c.info.Operators[op.Offset] = name
+}
+
+// projectFile is the file that sets the language version of a project
+const projectFile = "saika.json"
+
+// changedReceiver reports a call of a method that changes its receiver,
+// or a method value of one, on a value that is not a variable or a part
+// of one, whose changes Go could not store
+func (c *checker) changedReceiver(expr *ast.MemberExpression, s *scope) {
+	property, ok := expr.Property.(*ast.Identifier)
+	if !ok {
+		return
+	}
+	fn := c.method(c.typeOf(expr.Object, s), property.Value)
+	if fn == nil || !c.types.changesReceiver(fn) || c.addressable(expr.Object, s) {
+		return
+	}
+	c.diags = append(c.diags, diagnostic.AtNode(diagnostic.InvalidReceiver, expr.Object,
+		"cannot call %s on %s: %s changes its receiver, which must be a variable, field or element",
+		property.Value, ast.Sprint(expr.Object), property.Value))
+}
+
+// addressable reports whether expr is a variable or a part of one that Go
+// can take the address of: a field of one, or an element of a slice or of
+// an array that is
+func (c *checker) addressable(expr ast.Expression, s *scope) bool {
+	switch expr := expr.(type) {
+	case *ast.Identifier:
+		return s.lookup(expr.Value) && !s.constant(expr.Value) && c.namedType(expr, s) == nil
+	case *ast.MemberExpression:
+		return c.addressable(expr.Object, s)
+	case *ast.IndexExpression:
+		switch c.underlying(c.typeOf(expr.Left, s)).(type) {
+		case *ast.SliceType:
+			return true
+		case *ast.ArrayType:
+			return c.addressable(expr.Left, s)
+		}
+	}
+	return false
+}
+
+// changingMethods works out which methods of the package change their
+// receiver: those assigning to it or to a part of it, and, until no more
+// are found, those selecting a method that does on it or on a field or
+// array element of it, to call it or as a method value
+func (c *checker) changingMethods(pkg *scope) {
+	var others []*ast.FunctionStatement
+	for typ, methods := range c.types.methods {
+		for name, fn := range methods {
+			if assignsReceiver(fn) {
+				c.types.changing[typ+"."+name] = true
+			} else {
+				others = append(others, fn)
+			}
+		}
+	}
+	for found := true; found; {
+		found = false
+		for _, fn := range others {
+			if !c.types.changesReceiver(fn) && c.callsChanging(fn, pkg) {
+				typ, _ := codegen.ReceiverType(fn)
+				c.types.changing[typ+"."+fn.Name.Value] = true
+				found = true
+			}
+		}
+	}
+}
+
+// changesReceiver reports whether the method fn, or the method of a
+// generic type it instantiates, changes its receiver
+func (t *packageTypes) changesReceiver(fn *ast.FunctionStatement) bool {
+	typ, _ := codegen.ReceiverType(fn)
+	return t.changing[typ+"."+fn.Name.Value]
+}
+
+// callsChanging reports whether the body of the method fn selects a
+// method known to change its receiver on the receiver of fn, or on a
+// field or array element of it
+func (c *checker) callsChanging(fn *ast.FunctionStatement, pkg *scope) bool {
+	s := newScope(pkg)
+	_, params := codegen.ReceiverType(fn)
+	for _, param := range params {
+		s.declare(param.Value)
+	}
+	name := fn.Receiver.Name.Value
+	s.declare(name)
+	s.setType(name, fn.Receiver.Type)
+
+	calls := false
+	ast.Inspect(fn.Body, func(node ast.Node) bool {
+		expr, ok := node.(*ast.MemberExpression)
+		if !ok || calls {
+			return !calls
+		}
+		property, ok := expr.Property.(*ast.Identifier)
+		if ok && c.partOf(expr.Object, name, s) {
+			method := c.method(c.typeOf(expr.Object, s), property.Value)
+			calls = method != nil && c.types.changesReceiver(method)
+		}
+		return !calls
+	})
+	return calls
+}
+
+// partOf reports whether expr is the variable name, or a field or array
+// element of it, which a method changing it would change too. An element
+// of a slice is shared with the slice instead.
+func (c *checker) partOf(expr ast.Expression, name string, s *scope) bool {
+	switch expr := expr.(type) {
+	case *ast.Identifier:
+		return expr.Value == name
+	case *ast.MemberExpression:
+		return c.partOf(expr.Object, name, s)
+	case *ast.IndexExpression:
+		_, array := c.underlying(c.typeOf(expr.Left, s)).(*ast.ArrayType)
+		return array && c.partOf(expr.Left, name, s)
+	}
+	return false
+}
+
+// assignsReceiver reports whether the method fn assigns to its receiver,
+// or to a field or element of it. It only looks at the syntax of the
+// body: a local variable shadowing the receiver counts as the receiver.
+func assignsReceiver(fn *ast.FunctionStatement) bool {
+	name := fn.Receiver.Name.Value
+	assigns := false
+	ast.Inspect(fn.Body, func(node ast.Node) bool {
+		var targets []ast.Expression
+		switch node := node.(type) {
+		case *ast.AssignExpression:
+			targets = []ast.Expression{node.Left}
+		case *ast.CompoundAssignExpression:
+			targets = []ast.Expression{node.Left}
+		case *ast.AssignListStatement:
+			targets = node.Targets
+		}
+		for _, target := range targets {
+			assigns = assigns || rootName(target) == name
+		}
+		return !assigns
+	})
+	return assigns
+}
+
+// rootName returns the variable a target of an assignment is a part of,
+// as 位置 is of 位置.X[0], or "" when it is no part of a variable
+func rootName(target ast.Expression) string {
+	for {
+		switch t := target.(type) {
+		case *ast.Identifier:
+			return t.Value
+		case *ast.MemberExpression:
+			target = t.Object
+		case *ast.IndexExpression:
+			target = t.Left
+		default:
+			return ""
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package checker
+
+import (
+	"sort"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/codegen"
+	"github.com/saika-m/saika-lang/internal/ir"
+)
+
+// Scope depths; anything deeper than fileDepth is local to a function
+const (
+	universeDepth = iota
+	packageDepth
+	fileDepth
+)
+
+// scope is a set of declared names nested in an enclosing scope
+type scope struct {
+	parent    *scope
+	depth     int
+	names     map[string]bool
+	constants map[string]bool           // names declared with 常量
+	types     map[string]ast.Expression // the types of names, when known
+
+	// declarations records where the names declared in the source are
+	declarations map[string]declaration
+}
+
+// newScope creates a scope nested in parent, which may be nil
+func newScope(parent *scope) *scope {
+	s := &scope{
+		parent:       parent,
+		names:        map[string]bool{},
+		constants:    map[string]bool{},
+		types:        map[string]ast.Expression{},
+		declarations: map[string]declaration{},
+	}
+	if parent != nil {
+		s.depth = parent.depth + 1
+	}
+	return s
+}
+
+// declare adds name to the scope
+func (s *scope) declare(name string) {
+	s.names[name] = true
+}
+
+// declareConstant adds name to the scope as a constant
+func (s *scope) declareConstant(name string) {
+	s.names[name] = true
+	s.constants[name] = true
+}
+
+// define declares the name of ident, found in the file with index file
+func (s *scope) define(file int, ident *ast.Identifier) {
+	s.declare(ident.Value)
+	s.declarations[ident.Value] = declaration{file: file, name: ident}
+}
+
+// defineConstant declares the name of ident as a constant
+func (s *scope) defineConstant(file int, ident *ast.Identifier) {
+	s.define(file, ident)
+	s.constants[ident.Value] = true
+}
+
+// declarationOf returns the innermost declaration of name visible from
+// s. It reports false for names without one in the source, such as
+// builtins and imported packages.
+func (s *scope) declarationOf(name string) (declaration, bool) {
+	for ; s != nil; s = s.parent {
+		if s.names[name] {
+			d, ok := s.declarations[name]
+			return d, ok
+		}
+	}
+	return declaration{}, false
+}
+
+// constant reports whether name refers to a constant, looking through
+// enclosing scopes up to the innermost declaration of name
+func (s *scope) constant(name string) bool {
+	for ; s != nil; s = s.parent {
+		if s.names[name] {
+			return s.constants[name]
+		}
+	}
+	return false
+}
+
+// setType records typ as the type of name, declared in s
+func (s *scope) setType(name string, typ ast.Expression) {
+	if typ != nil {
+		s.types[name] = typ
+	}
+}
+
+// typeOf returns the type of the innermost declaration of name, or nil
+// when it is not known
+func (s *scope) typeOf(name string) ast.Expression {
+	for ; s != nil; s = s.parent {
+		if s.names[name] {
+			return s.types[name]
+		}
+	}
+	return nil
+}
+
+// lookup reports whether name is declared in s or an enclosing scope
+func (s *scope) lookup(name string) bool {
+	for ; s != nil; s = s.parent {
+		if s.names[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// builtin reports whether name refers to a name of the universe scope
+func (s *scope) builtin(name string) bool {
+	for ; s != nil; s = s.parent {
+		if s.names[name] {
+			return s.depth == universeDepth
+		}
+	}
+	return false
+}
+
+// shadows reports whether name is declared in a function-local scope,
+// hiding any package or import of the same name
+func (s *scope) shadows(name string) bool {
+	for ; s != nil && s.depth > fileDepth; s = s.parent {
+		if s.names[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// visible returns every name visible from s, innermost scope first and
+// sorted within each scope, for use as suggestions
+func (s *scope) visible() []string {
+	var out []string
+	for ; s != nil; s = s.parent {
+		names := make([]string, 0, len(s.names))
+		for name := range s.names {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		out = append(out, names...)
+	}
+	return out
+}
+
+// universe holds the names every Saika program can use: Go's predeclared
+// identifiers and the Saika builtins
+var universe = newScope(nil)
+
+func init() {
+	for _, name := range []string{
+		// Types
+		"any", "bool", "byte", "comparable", "complex64", "complex128", "error",
+		"float32", "float64", "int", "int8", "int16", "int32", "int64", "rune",
+		"string", "uint", "uint8", "uint16", "uint32", "uint64", "uintptr",
+		// Zero value
+		"nil",
+		// Functions
+		"append", "cap", "clear", "close", "complex", "copy", "delete", "imag",
+		"len", "make", "max", "min", "new", "panic", "print", "println", "real",
+		"recover",
+		// Saika builtins
+		codegen.BuildInfoName, codegen.MakeName, codegen.PanicName, codegen.RecoverName,
+		codegen.OpenDatabaseName, codegen.RenderTemplateName, codegen.WriteTemplateName,
+		codegen.BytesName, codegen.CharCountName, codegen.SubstringName, ir.NewErrorName,
+		codegen.StaticFilesName,
+	} {
+		universe.declare(name)
+	}
+	for _, name := range []string{"true", "false", "iota"} {
+		universe.declareConstant(name)
+	}
+	universe.setType("true", boolType)
+	universe.setType("false", boolType)
+	universe.setType("iota", intType)
+}
@@ -0,0 +1,40 @@
+package checker
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/codegen"
+	"github.com/saika-m/saika-lang/internal/diagnostic"
+)
+
+// CheckTemplates reports template files named by a string literal in a
+// call to 渲染模板 or 输出模板 that do not exist relative to dir, the
+// directory of the source file, or that lie outside it, since the program
+// embeds them
+func CheckTemplates(program *ast.Program, dir string) diagnostic.List {
+	var diags diagnostic.List
+	ast.Inspect(program, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpression)
+		if !ok {
+			return true
+		}
+		file, ok := codegen.TemplateFile(call)
+		if !ok {
+			return true
+		}
+
+		name, ok := codegen.EmbeddedTemplate(file.Text())
+		if !ok {
+			diags = append(diags, diagnostic.AtNode(diagnostic.MissingTemplate, file,
+				"template file %q is not in the directory of the source file, so it cannot be embedded", file.Text()))
+			return true
+		}
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			diags = append(diags, diagnostic.AtNode(diagnostic.MissingTemplate, file, "template file %q not found", file.Text()))
+		}
+		return true
+	})
+	return diags
+}
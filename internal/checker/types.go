@@ -0,0 +1,593 @@
+package checker
+
+import (
+	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/codegen"
+	"github.com/saika-m/saika-lang/internal/diagnostic"
+	"github.com/saika-m/saika-lang/internal/ir"
+)
+
+// The checker works out the types of expressions as far as the package
+// declares them, as the type expressions they would be written with, such
+// as 字符串 or 映射[字符串]整数. It needs them to tell the operations on
+// strings, which Saika performs by character, from those on other values.
+// The types of the members of Go packages, of the results of the methods
+// of Go types and of values of type parameters are not known.
+
+// Types of literals and of the results of builtins
+var (
+	stringType = &ast.Identifier{Value: "字符串"}
+	intType    = &ast.Identifier{Value: "整数"}
+	floatType  = &ast.Identifier{Value: "浮点"}
+	boolType   = &ast.Identifier{Value: "布尔"}
+	errorType  = &ast.Identifier{Value: "错误"}
+	anyType    = &ast.Identifier{Value: "任意"}
+	bytesType  = &ast.SliceType{Elem: &ast.Identifier{Value: "byte"}}
+)
+
+// predeclaredTypes lists the types every program can use by name
+var predeclaredTypes = map[string]bool{
+	"整数": true, "浮点": true, "字符串": true, "布尔": true, "错误": true, "任意": true, "可比较": true,
+	"any": true, "bool": true, "byte": true, "comparable": true, "complex64": true, "complex128": true,
+	"error": true, "float32": true, "float64": true, "int": true, "int8": true, "int16": true,
+	"int32": true, "int64": true, "rune": true, "string": true, "uint": true, "uint8": true,
+	"uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+}
+
+// topLevelTypes records the types of the names declared at the top level
+// of files in pkg. Variables and constants declared without a type have
+// the type of their value, which may use names declared after them, in
+// any of the files, so their types are worked out until no more are found.
+func (c *checker) topLevelTypes(pkg *scope, files []*ast.Program) {
+	type untyped struct {
+		names []*ast.Identifier
+		value ast.Expression
+	}
+	var pending []untyped
+	for _, file := range files {
+		for _, stmt := range file.Statements {
+			switch stmt := stmt.(type) {
+			case *ast.FunctionStatement:
+				if stmt.Receiver == nil {
+					pkg.setType(stmt.Name.Value, funcType(stmt.Parameters, stmt.ReturnType))
+				}
+			case *ast.VarStatement:
+				if stmt.Type != nil {
+					pkg.setType(stmt.Name.Value, stmt.Type)
+				} else {
+					pending = append(pending, untyped{[]*ast.Identifier{stmt.Name}, stmt.Value})
+				}
+			case *ast.VarListStatement:
+				pending = append(pending, untyped{stmt.Names, stmt.Value})
+			case *ast.ConstStatement:
+				pending = append(pending, untyped{[]*ast.Identifier{stmt.Name}, stmt.Value})
+			case *ast.ConstGroupStatement:
+				// A constant without a value repeats the one before it
+				var value ast.Expression
+				for _, constant := range stmt.Consts {
+					if constant.Value != nil {
+						value = constant.Value
+					}
+					pending = append(pending, untyped{[]*ast.Identifier{constant.Name}, value})
+				}
+			case *ast.OptionStatement:
+				pkg.setType(stmt.Name.Value, stmt.Type)
+			case *ast.EnumStatement:
+				for _, member := range stmt.Members {
+					pkg.setType(member.Value, stmt.Name)
+				}
+			}
+		}
+	}
+
+	for found := true; found; {
+		found = false
+		for _, p := range pending {
+			for i, typ := range c.valueTypes(p.value, len(p.names), pkg) {
+				if name := p.names[i].Value; typ != nil && pkg.types[name] == nil {
+					pkg.setType(name, typ)
+					found = true
+				}
+			}
+		}
+	}
+}
+
+// typeOf returns the type of expr in scope s, or nil when it is not known
+func (c *checker) typeOf(expr ast.Expression, s *scope) ast.Expression {
+	switch expr := expr.(type) {
+	case *ast.StringLiteral:
+		return stringType
+	case *ast.IntegerLiteral, *ast.UnitLiteral:
+		return intType
+	case *ast.FloatLiteral:
+		return floatType
+	case *ast.BooleanLiteral:
+		return boolType
+	case *ast.Identifier:
+		return s.typeOf(expr.Value)
+	case *ast.PrefixExpression:
+		switch expr.Operator {
+		case "!":
+			return boolType
+		case "<-":
+			if ch, ok := c.underlying(c.typeOf(expr.Right, s)).(*ast.ChanType); ok {
+				return ch.Elem
+			}
+			return nil
+		}
+		return c.typeOf(expr.Right, s)
+	case *ast.InfixExpression:
+		switch expr.Operator {
+		case "==", "!=", "<", "<=", ">", ">=", "&&", "||":
+			return boolType
+		case "<<", ">>":
+			return c.typeOf(expr.Left, s)
+		}
+		// An overloaded operator has the result of its method, and a
+		// literal operand takes the type of the other one
+		left, right := c.typeOf(expr.Left, s), c.typeOf(expr.Right, s)
+		if fn := c.method(left, operatorMethods[expr.Operator]); fn != nil {
+			if results := resultTypes(fn.ReturnType); len(results) == 1 {
+				return results[0]
+			}
+			return nil
+		}
+		if left == nil || literalKind(expr.Left) != "" && right != nil {
+			return right
+		}
+		return left
+	case *ast.IndexExpression:
+		// Type arguments instantiate a generic function declared in the
+		// package
+		if ident, ok := expr.Left.(*ast.Identifier); ok {
+			if fn, ok := c.types.functions[ident.Value]; ok && len(fn.TypeParams) > 0 && c.declares(s, fn.Name) {
+				params := make([]*ast.Identifier, len(fn.TypeParams))
+				for i, tp := range fn.TypeParams {
+					params[i] = tp.Name
+				}
+				if types := ast.BindTypeParams(params, ast.TypeArguments(expr.Index)); types != nil {
+					return ast.Substitute(funcType(fn.Parameters, fn.ReturnType), types)
+				}
+			}
+		}
+		return c.elemType(c.typeOf(expr.Left, s))
+	case *ast.SliceExpression:
+		typ := c.typeOf(expr.Left, s)
+		if array, ok := c.underlying(typ).(*ast.ArrayType); ok {
+			return &ast.SliceType{Elem: array.Elem}
+		}
+		return typ
+	case *ast.MemberExpression:
+		if typ := c.packageMember(expr, s); typ != nil {
+			return typ
+		}
+		return c.fieldType(expr, s)
+	case *ast.CallExpression:
+		if results := c.callResults(expr, s); len(results) == 1 {
+			return results[0]
+		}
+	case *ast.CompositeLiteral:
+		return expr.Type
+	case *ast.MapLiteral:
+		return expr.Type
+	case *ast.SliceLiteral:
+		return expr.Type
+	case *ast.ArrayLiteral:
+		return expr.Type
+	case *ast.FunctionLiteral:
+		return funcType(expr.Parameters, expr.ReturnType)
+	}
+	return nil
+}
+
+// valueTypes returns the types of the n values that value gives a
+// declaration of n variables, each nil when it is not known. Two
+// variables take a map index or a receive and whether it succeeded.
+func (c *checker) valueTypes(value ast.Expression, n int, s *scope) []ast.Expression {
+	types := make([]ast.Expression, n)
+	switch v := value.(type) {
+	case nil:
+	case *ast.ExpressionList:
+		if len(v.Values) == n {
+			for i, value := range v.Values {
+				types[i] = c.typeOf(value, s)
+			}
+		}
+	case *ast.CallExpression:
+		if results := c.callResults(v, s); len(results) == n {
+			copy(types, results)
+		}
+	default:
+		if n == 1 {
+			types[0] = c.typeOf(value, s)
+		} else if n == 2 {
+			types[0], types[1] = c.typeOf(value, s), boolType
+		}
+	}
+	return types
+}
+
+// elemType returns the type of an index of a value of typ: a character of
+// a string, or an element of a map, slice or array. Indexing a generic
+// function instantiates it, keeping its type.
+func (c *checker) elemType(typ ast.Expression) ast.Expression {
+	switch u := c.underlying(typ).(type) {
+	case *ast.MapType:
+		return u.Value
+	case *ast.SliceType:
+		return u.Elem
+	case *ast.ArrayType:
+		return u.Elem
+	case *ast.FuncType:
+		return typ
+	}
+	if str, _ := c.isString(typ); str {
+		return stringType
+	}
+	return nil
+}
+
+// fieldType returns the type of the field of a struct that expr selects,
+// or of the method, a function without its receiver
+func (c *checker) fieldType(expr *ast.MemberExpression, s *scope) ast.Expression {
+	typ := c.typeOf(expr.Object, s)
+	property, isIdent := expr.Property.(*ast.Identifier)
+	if goType, ok := typ.(*ast.MemberExpression); ok && isIdent {
+		return c.goMethod(goType, property.Value)
+	}
+	st, ok := c.underlying(typ).(*ast.StructType)
+	if !ok || !isIdent {
+		return nil
+	}
+	for _, field := range st.Fields {
+		if field.Name.Value == property.Value {
+			return field.Type
+		}
+	}
+	if fn := c.method(typ, property.Value); fn != nil {
+		return funcType(fn.Parameters, fn.ReturnType)
+	}
+	return nil
+}
+
+// callResults returns the types of the results of a call of a function
+// declared in the package or in a standard library package, a function
+// value, a builtin or a conversion, or nil when they are not known
+func (c *checker) callResults(call *ast.CallExpression, s *scope) []ast.Expression {
+	switch fn := call.Function.(type) {
+	case *ast.Identifier:
+		if typ := c.namedType(fn, s); typ != nil {
+			return []ast.Expression{typ}
+		}
+		if s.builtin(fn.Value) {
+			return c.builtinResults(fn.Value, call.Arguments, s)
+		}
+		// A generic function called without type arguments returns the
+		// types it is instantiated with
+		if generic, ok := c.types.functions[fn.Value]; ok && len(generic.TypeParams) > 0 && c.declares(s, generic.Name) {
+			args := c.inferTypes(generic, call.Arguments, s)
+			if args == nil {
+				return nil
+			}
+			params := make([]*ast.Identifier, len(generic.TypeParams))
+			for i, tp := range generic.TypeParams {
+				params[i] = tp.Name
+			}
+			return resultTypes(ast.Substitute(generic.ReturnType, ast.BindTypeParams(params, args)))
+		}
+	case *ast.SliceType, *ast.MapType, *ast.ArrayType, *ast.ChanType, *ast.FuncType:
+		return []ast.Expression{fn}
+	case *ast.IndexExpression:
+		if ident, ok := fn.Left.(*ast.Identifier); ok && c.namedType(ident, s) != nil {
+			return []ast.Expression{fn}
+		}
+	}
+	if fn, ok := c.underlying(c.typeOf(call.Function, s)).(*ast.FuncType); ok {
+		return resultTypes(fn.ReturnType)
+	}
+	return nil
+}
+
+// builtinResults returns the types of the results of a call of the builtin
+// name with the given arguments, or nil when they are not known
+func (c *checker) builtinResults(name string, args []ast.Expression, s *scope) []ast.Expression {
+	var typ ast.Expression
+	switch name {
+	case "len", "cap", "copy", codegen.CharCountName:
+		typ = intType
+	case codegen.SubstringName:
+		typ = stringType
+	case codegen.BytesName:
+		typ = bytesType
+	case ir.NewErrorName:
+		typ = errorType
+	case "recover", codegen.RecoverName:
+		typ = anyType
+	case "append", "max", "min":
+		if len(args) > 0 {
+			typ = c.typeOf(args[0], s)
+		}
+	case "make", codegen.MakeName:
+		if len(args) > 0 {
+			typ = args[0]
+		}
+	}
+	if typ == nil {
+		return nil
+	}
+	return []ast.Expression{typ}
+}
+
+// namedType returns ident when it names a predeclared type or one
+// declared in the package, as the function of a conversion does, and nil
+// otherwise
+func (c *checker) namedType(ident *ast.Identifier, s *scope) ast.Expression {
+	if _, declared := s.declarationOf(ident.Value); predeclaredTypes[ident.Value] && !declared {
+		return ident
+	}
+	if decl, ok := c.types.types[ident.Value]; ok && c.declares(s, decl.Name) {
+		return ident
+	}
+	if decl, ok := c.types.interfaces[ident.Value]; ok && c.declares(s, decl.Name) {
+		return ident
+	}
+	return nil
+}
+
+// underlying returns the type that typ is declared as, following the
+// types declared in the package to a predeclared, interface or composite
+// type. An instantiated generic type is declared as its type with the
+// type arguments in place of the type parameters. It returns nil when
+// that is not known, as for the types of Go packages and type parameters.
+func (c *checker) underlying(typ ast.Expression) ast.Expression {
+	for depth := 0; depth <= len(c.types.types); depth++ {
+		if inst, ok := typ.(*ast.IndexExpression); ok {
+			typ = c.instantiate(inst)
+			continue
+		}
+		ident, ok := typ.(*ast.Identifier)
+		if !ok {
+			if _, ok := typ.(*ast.MemberExpression); ok {
+				return nil
+			}
+			return typ
+		}
+		if predeclaredTypes[ident.Value] || c.types.interfaces[ident.Value] != nil {
+			return ident
+		}
+		decl, ok := c.types.types[ident.Value]
+		if !ok {
+			return nil
+		}
+		typ = decl.Type
+	}
+	return nil
+}
+
+// instantiate returns the type that the generic type inst instantiates
+// is declared as, with the type arguments of inst in place of its type
+// parameters, or nil when inst instantiates no such type
+func (c *checker) instantiate(inst *ast.IndexExpression) ast.Expression {
+	ident, ok := inst.Left.(*ast.Identifier)
+	if !ok {
+		return nil
+	}
+	decl, ok := c.types.types[ident.Value]
+	if !ok {
+		return nil
+	}
+	params := make([]*ast.Identifier, len(decl.TypeParams))
+	for i, tp := range decl.TypeParams {
+		params[i] = tp.Name
+	}
+	types := ast.BindTypeParams(params, ast.TypeArguments(inst.Index))
+	if types == nil {
+		return nil
+	}
+	return ast.Substitute(decl.Type, types)
+}
+
+// isString reports whether the values of typ are strings, and whether
+// that is known at all
+func (c *checker) isString(typ ast.Expression) (str, known bool) {
+	switch u := c.underlying(typ).(type) {
+	case nil:
+		return false, false
+	case *ast.Identifier:
+		return u.Value == "字符串" || u.Value == "string", true
+	}
+	return false, true
+}
+
+// stringOperation records op, an index, slice or len of operand, in ops
+// when operand is a string. When the type of operand is not known, op is
+// reported rather than left to work on bytes if it is one.
+func (c *checker) stringOperation(op, operand ast.Expression, ops map[int]bool, offset int, s *scope) {
+	// Undefined names are reported already, and types are indexed to
+	// instantiate them
+	name := operand
+	if call, ok := operand.(*ast.CallExpression); ok {
+		name = call.Function
+	}
+	if ident, ok := name.(*ast.Identifier); ok && !s.lookup(ident.Value) {
+		return
+	}
+	if ident, ok := operand.(*ast.Identifier); ok && c.namedType(ident, s) != nil {
+		return
+	}
+	switch str, known := c.isString(c.typeOf(operand, s)); {
+	case str:
+		ops[offset] = true
+	case !known:
+		c.diags = append(c.diags, diagnostic.New(diagnostic.Error, diagnostic.UnknownStringType, ast.NodeRange(op),
+			"cannot tell whether %s is a string, so %s could work on its bytes rather than its characters; give it a declared type",
+			ast.Sprint(operand), ast.Sprint(op)))
+	}
+}
+
+// funcType returns the type of a function with the given parameters and
+// result type
+func funcType(params []*ast.TypedParam, result ast.Expression) *ast.FuncType {
+	fn := &ast.FuncType{ReturnType: result}
+	for _, param := range params {
+		fn.Parameters = append(fn.Parameters, param.Type)
+		fn.Variadic = param.Variadic
+	}
+	return fn
+}
+
+// paramType returns the type of a parameter in the body of its function:
+// a variadic parameter is a slice of the arguments
+func paramType(param *ast.TypedParam) ast.Expression {
+	if param.Variadic {
+		return &ast.SliceType{Elem: param.Type}
+	}
+	return param.Type
+}
+
+// callTypes records a call whose Go type depends on the type 整数 stands
+// for: that of a builtin returning 整数, which is an int in Go, and that of
+// a generic function declared in the package without type arguments,
+// whose inferred type arguments an untyped constant may make int
+func (c *checker) callTypes(call *ast.CallExpression, s *scope) {
+	ident, ok := call.Function.(*ast.Identifier)
+	if !ok {
+		return
+	}
+	if s.builtin(ident.Value) {
+		results := c.builtinResults(ident.Value, call.Arguments, s)
+		if len(results) == 1 && isInt(results[0]) {
+			c.info.IntResults[call.Token.Offset] = true
+		}
+		return
+	}
+	fn, ok := c.types.functions[ident.Value]
+	if !ok || len(fn.TypeParams) == 0 || !c.declares(s, fn.Name) {
+		return
+	}
+	if args := c.inferTypes(fn, call.Arguments, s); args != nil {
+		c.info.Inferred[call.Token.Offset] = args
+	}
+}
+
+// isInt reports whether typ is the predeclared integer type
+func isInt(typ ast.Expression) bool {
+	ident, ok := typ.(*ast.Identifier)
+	return ok && (ident.Value == "整数" || ident.Value == "int")
+}
+
+// inferTypes returns the type arguments that a call of the generic
+// function fn with args and without type arguments instantiates it with,
+// as Go infers them from the types of the arguments, or nil when one is
+// not known. An untyped constant gives its type parameter 整数 or 浮点
+// only when no typed argument gives it a type.
+func (c *checker) inferTypes(fn *ast.FunctionStatement, args []ast.Expression, s *scope) []ast.Expression {
+	types := map[string]ast.Expression{}
+	for _, tp := range fn.TypeParams {
+		types[tp.Name.Value] = nil
+	}
+	untyped := map[string]ast.Expression{}
+	for i, arg := range args {
+		if i >= len(fn.Parameters) && (len(fn.Parameters) == 0 || !fn.Parameters[len(fn.Parameters)-1].Variadic) {
+			break
+		}
+		param := fn.Parameters[min(i, len(fn.Parameters)-1)]
+		if ident, ok := param.Type.(*ast.Identifier); ok {
+			if _, isParam := types[ident.Value]; isParam {
+				if typ := untypedNumber(arg); typ != nil {
+					if untyped[ident.Value] != floatType {
+						untyped[ident.Value] = typ
+					}
+					continue
+				}
+			}
+		}
+		c.unify(param.Type, c.typeOf(arg, s), types)
+	}
+
+	inferred := make([]ast.Expression, len(fn.TypeParams))
+	for i, tp := range fn.TypeParams {
+		if inferred[i] = types[tp.Name.Value]; inferred[i] == nil {
+			inferred[i] = untyped[tp.Name.Value]
+		}
+		if inferred[i] == nil {
+			return nil
+		}
+	}
+	return inferred
+}
+
+// unify binds the type parameters in types that typ, the type of a
+// parameter, names to the types in the same places of arg, the type of
+// the argument given for it, unless they are bound already
+func (c *checker) unify(typ, arg ast.Expression, types map[string]ast.Expression) {
+	if arg == nil {
+		return
+	}
+	if ident, ok := typ.(*ast.Identifier); ok {
+		if bound, isParam := types[ident.Value]; isParam && bound == nil {
+			types[ident.Value] = arg
+		}
+		return
+	}
+	// A value of a type declared as a composite type is given for one
+	// written as that type
+	if _, ok := arg.(*ast.Identifier); ok {
+		arg = c.underlying(arg)
+	}
+	switch typ := typ.(type) {
+	case *ast.SliceType:
+		if arg, ok := arg.(*ast.SliceType); ok {
+			c.unify(typ.Elem, arg.Elem, types)
+		}
+	case *ast.ArrayType:
+		if arg, ok := arg.(*ast.ArrayType); ok {
+			c.unify(typ.Elem, arg.Elem, types)
+		}
+	case *ast.ChanType:
+		if arg, ok := arg.(*ast.ChanType); ok {
+			c.unify(typ.Elem, arg.Elem, types)
+		}
+	case *ast.MapType:
+		if arg, ok := arg.(*ast.MapType); ok {
+			c.unify(typ.Key, arg.Key, types)
+			c.unify(typ.Value, arg.Value, types)
+		}
+	}
+}
+
+// untypedNumber returns the type that Go gives an untyped numeric
+// constant made of literals and byte sizes by default, 整数 or 浮点, or
+// nil when expr is not one
+func untypedNumber(expr ast.Expression) ast.Expression {
+	switch expr := expr.(type) {
+	case *ast.IntegerLiteral:
+		return intType
+	case *ast.FloatLiteral:
+		return floatType
+	case *ast.UnitLiteral:
+		if !ast.Units[expr.Unit].Duration {
+			return intType
+		}
+	case *ast.PrefixExpression:
+		if expr.Operator == "-" || expr.Operator == "+" {
+			return untypedNumber(expr.Right)
+		}
+	case *ast.InfixExpression:
+		left, right := untypedNumber(expr.Left), untypedNumber(expr.Right)
+		if left == nil || right == nil {
+			return nil
+		}
+		switch expr.Operator {
+		case "+", "-", "*", "/":
+			if left == floatType || right == floatType {
+				return floatType
+			}
+			return intType
+		case "%", "<<", ">>", "&", "|", "^":
+			return left
+		}
+	}
+	return nil
+}
@@ -2,21 +2,88 @@ package codegen
 
 import (
 	"fmt"
+	"math"
+	"sort"
 	"strings"
 
 	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/stdlib"
 )
 
 // Generator represents a code generator for Saika
 type Generator struct {
-	program *ast.Program
+	// Readable puts a comment before the code of each statement relating
+	// the Saika it was written in to the Go it became, for learners
+	Readable bool
+
+	program   *ast.Program
+	features  map[string]bool   // support features used by the generated code
+	templates map[string]bool   // template files embedded by the generated code
+	functions []FunctionMapping // where the code of each function went
+
+	// header is set while generating the header of an if or for
+	// statement, where Go takes a '{' after a type name as the block
+	header bool
+
+	// result is the return type of the function being generated
+	result ast.Expression
+}
+
+// FunctionMapping relates a Saika function to the lines of Go code
+// generated for it
+type FunctionMapping struct {
+	Name    string    // name of the function in the Saika source
+	GoName  string    // name of the generated Go function
+	Source  ast.Range // the function declaration in the Saika source
+	GoStart int       // first line of the generated Go code, from 1
+	GoEnd   int       // last line of the generated Go code
 }
 
-// New creates a new Generator
+// New creates a new Generator for a program lowered by ir.Lower
 func New(program *ast.Program) *Generator {
 	return &Generator{
-		program: program,
+		program:   program,
+		features:  map[string]bool{},
+		templates: map[string]bool{},
+	}
+}
+
+// Features returns the support features the generated code relies on, in
+// sorted order. They are provided once per package by SupportSource.
+func (g *Generator) Features() []string {
+	features := make([]string, 0, len(g.features))
+	for feature := range g.features {
+		features = append(features, feature)
 	}
+	sort.Strings(features)
+	return features
+}
+
+// Templates returns the template files the generated code embeds, named
+// relative to the directory of the source file, in sorted order
+func (g *Generator) Templates() []string {
+	templates := make([]string, 0, len(g.templates))
+	for name := range g.templates {
+		templates = append(templates, name)
+	}
+	sort.Strings(templates)
+	return templates
+}
+
+// Functions returns where the code of each top-level function went in
+// the output of Generate, in source order
+func (g *Generator) Functions() []FunctionMapping {
+	return g.functions
+}
+
+// PackageName returns the package declared by the program, or main
+func (g *Generator) PackageName() string {
+	for _, stmt := range g.program.Statements {
+		if pkg, ok := stmt.(*ast.PackageStatement); ok {
+			return pkg.Name
+		}
+	}
+	return "main"
 }
 
 // Generate generates Go code from the AST
@@ -24,8 +91,31 @@ func (g *Generator) Generate() string {
 	var out strings.Builder
 
 	// Process all statements
+	g.functions = nil
+	line := 1
 	for _, stmt := range g.program.Statements {
-		out.WriteString(g.generateStatement(stmt))
+		if comment := g.explain(stmt); comment != "" {
+			out.WriteString(comment)
+			line += strings.Count(comment, "\n")
+		}
+		code := g.generateStatement(stmt)
+		lines := strings.Count(code, "\n") + 1
+		if fn, ok := stmt.(*ast.FunctionStatement); ok {
+			name, goName := fn.Name.Value, goFunctionName(fn.Name.Value)
+			if fn.Receiver != nil {
+				typ, _ := ReceiverType(fn)
+				name, goName = typ+"."+name, MethodName(fn)
+			}
+			g.functions = append(g.functions, FunctionMapping{
+				Name:    name,
+				GoName:  goName,
+				Source:  ast.NodeRange(fn),
+				GoStart: line,
+				GoEnd:   line + lines - 1,
+			})
+		}
+		line += lines
+		out.WriteString(code)
 		out.WriteString("\n")
 	}
 
@@ -41,16 +131,50 @@ func (g *Generator) generateStatement(stmt ast.Statement) string {
 		return g.generateImportStatement(stmt)
 	case *ast.FunctionStatement:
 		return g.generateFunctionStatement(stmt)
+	case *ast.InterfaceStatement:
+		return g.generateInterfaceStatement(stmt)
+	case *ast.TypeStatement:
+		if stmt.Alias {
+			return fmt.Sprintf("type %s = %s", stmt.Name.Value, g.generateType(stmt.Type))
+		}
+		return fmt.Sprintf("type %s%s %s", stmt.Name.Value, g.generateTypeParams(stmt.TypeParams), g.generateType(stmt.Type))
 	case *ast.VarStatement:
 		return g.generateVarStatement(stmt)
+	case *ast.VarListStatement:
+		return g.generateVarListStatement(stmt)
+	case *ast.AssignListStatement:
+		return g.generateAssignListStatement(stmt)
 	case *ast.ConstStatement:
 		return g.generateConstStatement(stmt)
+	case *ast.ConstGroupStatement:
+		return g.generateConstGroupStatement(stmt)
+	case *ast.OptionStatement:
+		return g.generateOptionStatement(stmt)
 	case *ast.ReturnStatement:
 		return g.generateReturnStatement(stmt)
 	case *ast.IfStatement:
 		return g.generateIfStatement(stmt)
 	case *ast.ForStatement:
 		return g.generateForStatement(stmt)
+	case *ast.CharLoopStatement:
+		return g.generateCharLoopStatement(stmt)
+	case *ast.RangeStatement:
+		return g.generateRangeStatement(stmt)
+	case *ast.QueryStatement:
+		return g.generateQueryStatement(stmt)
+	case *ast.SignalStatement:
+		g.features[FeatureSignals] = true
+		return fmt.Sprintf("saikaOnSignal(func() %s)", g.generateFunctionBody(nil, stmt.Body))
+	case *ast.DeferStatement:
+		return "defer " + g.generateExpression(stmt.Call)
+	case *ast.GoStatement:
+		return "go " + g.generateExpression(stmt.Call)
+	case *ast.SendStatement:
+		return g.generateExpression(stmt.Channel) + " <- " + g.generateExpression(stmt.Value)
+	case *ast.SelectStatement:
+		return g.generateSelectStatement(stmt)
+	case *ast.SwitchStatement:
+		return g.generateSwitchStatement(stmt)
 	case *ast.ExpressionStatement:
 		return g.generateExpressionStatement(stmt)
 	default:
@@ -67,10 +191,14 @@ func (g *Generator) generatePackageStatement(stmt *ast.PackageStatement) string
 func (g *Generator) generateImportStatement(stmt *ast.ImportStatement) string {
 	// Make sure the path has quotes around it
 	// The Path field might already contain quotes from the parser
-	path := stmt.Path
+	path := stdlib.GoPath(stmt.Path)
 	if !strings.HasPrefix(path, "\"") {
 		path = "\"" + path + "\""
 	}
+	// Name the package when Go would not know it by its import path
+	if pkg, ok := stdlib.LookupPath(strings.Trim(path, "\"")); ok && pkg.Ident != "" {
+		return fmt.Sprintf("import %s %s", pkg.Ident, path)
+	}
 	return fmt.Sprintf("import %s", path)
 }
 
@@ -85,15 +213,162 @@ func (g *Generator) translateTypeName(typeName string) string {
 		return "float64"
 	case "布尔":
 		return "bool"
+	case "错误":
+		return "error"
+	case "任意":
+		return "any"
+	case "可比较":
+		return "comparable"
 	default:
 		return typeName
 	}
 }
 
+// generateType generates a Go type from a Saika type expression
+func (g *Generator) generateType(expr ast.Expression) string {
+	switch expr := expr.(type) {
+	case *ast.Identifier:
+		return g.translateTypeName(expr.Value)
+	case *ast.MapType:
+		return fmt.Sprintf("map[%s]%s",
+			g.generateType(expr.Key),
+			g.generateType(expr.Value))
+	case *ast.SliceType:
+		return "[]" + g.generateType(expr.Elem)
+	case *ast.ArrayType:
+		return fmt.Sprintf("[%s]%s",
+			g.generateExpression(expr.Len),
+			g.generateType(expr.Elem))
+	case *ast.ChanType:
+		return "chan " + g.generateType(expr.Elem)
+	case *ast.FuncType:
+		params := make([]string, len(expr.Parameters))
+		for i, param := range expr.Parameters {
+			params[i] = g.generateType(param)
+		}
+		if expr.Variadic {
+			params[len(params)-1] = "..." + params[len(params)-1]
+		}
+		out := "func(" + strings.Join(params, ", ") + ")"
+		if expr.ReturnType != nil {
+			out += " " + g.generateType(expr.ReturnType)
+		}
+		return out
+	case *ast.ResultList:
+		types := make([]string, len(expr.Types))
+		for i, t := range expr.Types {
+			types[i] = g.generateType(t)
+			if len(expr.Names) > 0 {
+				types[i] = expr.Names[i].Value + " " + types[i]
+			}
+		}
+		return "(" + strings.Join(types, ", ") + ")"
+	case *ast.UnionType:
+		types := make([]string, len(expr.Types))
+		for i, t := range expr.Types {
+			types[i] = g.generateType(t)
+		}
+		return strings.Join(types, " | ")
+	case *ast.StructType:
+		var out strings.Builder
+		out.WriteString("struct {\n")
+		for _, field := range expr.Fields {
+			out.WriteString(fmt.Sprintf("%s %s\n", field.Name.Value, g.generateType(field.Type)))
+		}
+		out.WriteString("}")
+		return out.String()
+	case *ast.MemberExpression:
+		// A type of another package
+		return g.generateExpression(expr)
+	case *ast.IndexExpression:
+		// An instantiated generic type
+		return g.generateType(expr.Left) + "[" + g.generateTypeArguments(expr.Index) + "]"
+	default:
+		return ""
+	}
+}
+
+// generateTypeParams generates the bracketed type parameters of a generic
+// function or type, or nothing when there are none
+func (g *Generator) generateTypeParams(typeParams []*ast.TypeParam) string {
+	if len(typeParams) == 0 {
+		return ""
+	}
+	params := make([]string, len(typeParams))
+	for i, tp := range typeParams {
+		params[i] = tp.Name.Value + " " + g.generateType(tp.Constraint)
+	}
+	return "[" + strings.Join(params, ", ") + "]"
+}
+
+// generateTypeArguments generates the type arguments of an instantiated
+// generic type, which an expression list gives when there are several
+func (g *Generator) generateTypeArguments(index ast.Expression) string {
+	args := ast.TypeArguments(index)
+	out := make([]string, len(args))
+	for i, arg := range args {
+		out[i] = g.generateType(arg)
+	}
+	return strings.Join(out, ", ")
+}
+
+// generateIndex generates the index of an index expression, which may be
+// the type arguments instantiating a generic function or type, as in
+// 首[整数]; predeclared and composite types are generated as types
+func (g *Generator) generateIndex(index ast.Expression) string {
+	args := ast.TypeArguments(index)
+	out := make([]string, len(args))
+	for i, arg := range args {
+		switch arg := arg.(type) {
+		case *ast.Identifier:
+			if name := g.translateTypeName(arg.Value); name != arg.Value {
+				out[i] = name
+				continue
+			}
+		case *ast.SliceType, *ast.MapType, *ast.ArrayType, *ast.ChanType, *ast.FuncType:
+			out[i] = g.generateType(arg)
+			continue
+		}
+		out[i] = g.generateExpression(arg)
+	}
+	return strings.Join(out, ", ")
+}
+
 // generateVarStatement generates code for a variable statement
 func (g *Generator) generateVarStatement(stmt *ast.VarStatement) string {
-	return fmt.Sprintf("var %s = %s",
+	switch {
+	case stmt.Type == nil:
+		return fmt.Sprintf("var %s = %s", stmt.Name.Value, g.generateExpression(stmt.Value))
+	case stmt.Value == nil:
+		return fmt.Sprintf("var %s %s", stmt.Name.Value, g.generateType(stmt.Type))
+	}
+	return fmt.Sprintf("var %s %s = %s",
 		stmt.Name.Value,
+		g.generateType(stmt.Type),
+		g.generateExpression(stmt.Value))
+}
+
+// generateVarListStatement generates code for the declaration of several
+// variables taking the results of a call
+func (g *Generator) generateVarListStatement(stmt *ast.VarListStatement) string {
+	names := make([]string, len(stmt.Names))
+	for i, name := range stmt.Names {
+		names[i] = name.Value
+	}
+	return fmt.Sprintf("var %s = %s",
+		strings.Join(names, ", "),
+		g.generateExpression(stmt.Value))
+}
+
+// generateAssignListStatement generates code for assigning the results of
+// a call to several targets
+func (g *Generator) generateAssignListStatement(stmt *ast.AssignListStatement) string {
+	targets := make([]string, len(stmt.Targets))
+	for i, target := range stmt.Targets {
+		targets[i] = g.generateExpression(target)
+	}
+	return fmt.Sprintf("%s = %s",
+		strings.Join(targets, ", "),
 		g.generateExpression(stmt.Value))
 }
 
@@ -104,6 +379,62 @@ func (g *Generator) generateConstStatement(stmt *ast.ConstStatement) string {
 		g.generateExpression(stmt.Value))
 }
 
+// generateConstGroupStatement generates a parenthesized const declaration,
+// where a constant without a value repeats the one before as in Go
+func (g *Generator) generateConstGroupStatement(stmt *ast.ConstGroupStatement) string {
+	var out strings.Builder
+
+	out.WriteString("const (\n")
+	for _, c := range stmt.Consts {
+		out.WriteString(c.Name.Value)
+		if c.Value != nil {
+			out.WriteString(" = " + g.generateExpression(c.Value))
+		}
+		out.WriteString("\n")
+	}
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// optionFlagFuncs maps the types of options to the flag functions
+// defining them
+var optionFlagFuncs = map[string]string{
+	"整数":  "IntVar",
+	"字符串": "StringVar",
+	"浮点":  "Float64Var",
+	"布尔":  "BoolVar",
+}
+
+// generateOptionStatement generates a package variable for an option and
+// an init function defining its flag, which 入口 parses when it starts
+func (g *Generator) generateOptionStatement(stmt *ast.OptionStatement) string {
+	g.features[FeatureOptions] = true
+
+	usage := "\"\""
+	if stmt.Usage != nil {
+		usage = g.generateExpression(stmt.Usage)
+	}
+	return fmt.Sprintf("var %s %s\nfunc init() {\nsaikaFlags.%s(&%s, %q, %s, %s)\n}",
+		stmt.Name.Value,
+		g.translateTypeName(stmt.Type.Value),
+		optionFlagFuncs[stmt.Type.Value],
+		stmt.Name.Value,
+		stmt.Name.Value,
+		g.generateExpression(stmt.Value),
+		usage)
+}
+
+// hasOptions reports whether the program declares options
+func (g *Generator) hasOptions() bool {
+	for _, stmt := range g.program.Statements {
+		if _, ok := stmt.(*ast.OptionStatement); ok {
+			return true
+		}
+	}
+	return false
+}
+
 // generateReturnStatement generates code for a return statement
 func (g *Generator) generateReturnStatement(stmt *ast.ReturnStatement) string {
 	if stmt.ReturnValue != nil {
@@ -114,27 +445,74 @@ func (g *Generator) generateReturnStatement(stmt *ast.ReturnStatement) string {
 
 // generateFunctionStatement generates code for a function statement
 func (g *Generator) generateFunctionStatement(stmt *ast.FunctionStatement) string {
+	if stmt.Receiver != nil {
+		return g.generateMethod(stmt)
+	}
+
 	var out strings.Builder
 
 	// Replace 數 with func
 	out.WriteString("func ")
 
-	// Special case for main function (入口 -> main)
-	if stmt.Name.Value == "入口" {
-		out.WriteString("main")
-	} else {
-		out.WriteString(stmt.Name.Value)
+	out.WriteString(goFunctionName(stmt.Name.Value))
+	out.WriteString(g.generateTypeParams(stmt.TypeParams))
+
+	out.WriteString(g.generateSignature(stmt.Parameters, stmt.ReturnType))
+
+	// Generate function body; 入口 starts by parsing the options
+	out.WriteString(" ")
+	body := g.generateFunctionBody(stmt.ReturnType, stmt.Body)
+	if stmt.Name.Value == "入口" && g.hasOptions() {
+		body = "{\nsaikaParseFlags()" + strings.TrimPrefix(body, "{")
+	}
+	out.WriteString(body)
+
+	return out.String()
+}
+
+// generateMethod generates code for a method. A method that changes its
+// receiver takes a pointer to it and works on a copy named as the
+// receiver, which a deferred function stores back; see ReceiverName.
+func (g *Generator) generateMethod(stmt *ast.FunctionStatement) string {
+	receiver := stmt.Receiver.Name.Value + " " + g.generateType(stmt.Receiver.Type)
+	changes := stmt.ChangesReceiver
+	if changes {
+		receiver = ReceiverName + " *" + g.generateType(stmt.Receiver.Type)
+	}
+	body := g.generateFunctionBody(stmt.ReturnType, stmt.Body)
+	if changes {
+		name := stmt.Receiver.Name.Value
+		body = fmt.Sprintf("{\n%s := *%s\ndefer func() { *%s = %s }()\n", name, ReceiverName, ReceiverName, name) + strings.TrimPrefix(body, "{\n")
+	}
+	return fmt.Sprintf("func (%s) %s%s %s", receiver, stmt.Name.Value, g.generateSignature(stmt.Parameters, stmt.ReturnType), body)
+}
+
+// goFunctionName returns the Go name of a top-level function; 入口
+// becomes main
+func goFunctionName(name string) string {
+	if name == "入口" {
+		return "main"
 	}
+	return name
+}
+
+// generateSignature generates a parameter list and return type
+func (g *Generator) generateSignature(parameters []*ast.TypedParam, returnType ast.Expression) string {
+	var out strings.Builder
 
 	out.WriteString("(")
 
 	// Generate parameters
 	params := []string{}
-	for _, p := range stmt.Parameters {
-		if p.Type != nil {
+	for _, p := range parameters {
+		if p.Variadic {
+			params = append(params, fmt.Sprintf("%s ...%s",
+				p.Name.Value,
+				g.generateType(p.Type)))
+		} else if p.Type != nil {
 			params = append(params, fmt.Sprintf("%s %s",
 				p.Name.Value,
-				g.translateTypeName(p.Type.Value)))
+				g.generateType(p.Type)))
 		} else {
 			params = append(params, p.Name.Value)
 		}
@@ -143,14 +521,25 @@ func (g *Generator) generateFunctionStatement(stmt *ast.FunctionStatement) strin
 	out.WriteString(")")
 
 	// Generate return type if any
-	if stmt.ReturnType != nil {
+	if returnType != nil {
 		out.WriteString(" ")
-		out.WriteString(g.translateTypeName(stmt.ReturnType.Value))
+		out.WriteString(g.generateType(returnType))
 	}
 
-	// Generate function body
-	out.WriteString(" ")
-	out.WriteString(g.generateBlockStatement(stmt.Body))
+	return out.String()
+}
+
+// generateInterfaceStatement generates code for an interface declaration
+func (g *Generator) generateInterfaceStatement(stmt *ast.InterfaceStatement) string {
+	var out strings.Builder
+
+	out.WriteString(fmt.Sprintf("type %s interface {\n", stmt.Name.Value))
+	for _, method := range stmt.Methods {
+		out.WriteString(method.Name.Value)
+		out.WriteString(g.generateSignature(method.Parameters, method.ReturnType))
+		out.WriteString("\n")
+	}
+	out.WriteString("}")
 
 	return out.String()
 }
@@ -160,7 +549,7 @@ func (g *Generator) generateIfStatement(stmt *ast.IfStatement) string {
 	var out strings.Builder
 
 	out.WriteString("if ")
-	out.WriteString(g.generateExpression(stmt.Condition))
+	out.WriteString(g.generateHeader(stmt.Condition))
 	out.WriteString(" ")
 	out.WriteString(g.generateBlockStatement(stmt.Consequence))
 
@@ -178,13 +567,27 @@ func (g *Generator) generateForStatement(stmt *ast.ForStatement) string {
 
 	out.WriteString("for ")
 
+	// A loop with only a condition needs no semicolons
+	if stmt.Init == nil && stmt.Update == nil && stmt.Condition != nil {
+		out.WriteString(g.generateHeader(stmt.Condition))
+		out.WriteString(" ")
+		out.WriteString(g.generateBlockStatement(stmt.Body))
+		return out.String()
+	}
+
+	header := g.header
+	g.header = true
+
 	// Special handling for variable declarations in the initializer
 	if stmt.Init != nil {
 		if varStmt, ok := stmt.Init.(*ast.VarStatement); ok {
-			// Use short declaration (:=) syntax instead of var
-			out.WriteString(fmt.Sprintf("%s := %s",
-				varStmt.Name.Value,
-				g.generateExpression(varStmt.Value)))
+			// Use short declaration (:=) syntax instead of var, which
+			// takes a declared type as a conversion of the value
+			value := g.generateExpression(varStmt.Value)
+			if varStmt.Type != nil {
+				value = fmt.Sprintf("(%s)(%s)", g.generateType(varStmt.Type), value)
+			}
+			out.WriteString(fmt.Sprintf("%s := %s", varStmt.Name.Value, value))
 		} else {
 			// For other statement types, generate normally
 			out.WriteString(g.generateStatement(stmt.Init))
@@ -207,6 +610,7 @@ func (g *Generator) generateForStatement(stmt *ast.ForStatement) string {
 		}
 		out.WriteString(updateStmt)
 	}
+	g.header = header
 
 	out.WriteString(" ")
 	out.WriteString(g.generateBlockStatement(stmt.Body))
@@ -214,18 +618,155 @@ func (g *Generator) generateForStatement(stmt *ast.ForStatement) string {
 	return out.String()
 }
 
+// generateHeader generates code for the condition of an if or for
+// statement
+func (g *Generator) generateHeader(expr ast.Expression) string {
+	header := g.header
+	g.header = true
+	defer func() { g.header = header }()
+
+	return g.generateExpression(expr)
+}
+
+// generateCharLoopStatement generates code for a loop over the characters
+// of a string, ranging over its runes so that the index counts characters
+// as s[i] does
+func (g *Generator) generateCharLoopStatement(stmt *ast.CharLoopStatement) string {
+	var out strings.Builder
+
+	index := "_"
+	if stmt.Index != nil {
+		index = stmt.Index.Value
+	}
+	value := g.generateExpression(stmt.Value)
+	switch {
+	case stmt.Char.Value != "_":
+		out.WriteString(fmt.Sprintf("for %s, saikaRune := range []rune(%s) {\n", index, value))
+		out.WriteString(fmt.Sprintf("%s := string(saikaRune)\n", stmt.Char.Value))
+	case index != "_":
+		out.WriteString(fmt.Sprintf("for %s := range []rune(%s) {\n", index, value))
+	default:
+		out.WriteString(fmt.Sprintf("for range []rune(%s) {\n", value))
+	}
+
+	// The body is a block of its own so that it may redeclare the character
+	out.WriteString(g.generateBlockStatement(stmt.Body))
+	out.WriteString("\n}")
+
+	return out.String()
+}
+
+// generateRangeStatement generates code for a loop over the elements of a
+// slice or array, or over the values of an iterator, ranging over the
+// function ValuesName returns for it
+func (g *Generator) generateRangeStatement(stmt *ast.RangeStatement) string {
+	var out strings.Builder
+
+	value := g.generateExpression(stmt.Value)
+	if stmt.Element != nil {
+		g.features[FeatureIterators] = true
+		value = fmt.Sprintf("%s[%s](%s)", ValuesName, g.generateType(stmt.Element), value)
+	}
+	switch {
+	case stmt.Name.Value == "_":
+		out.WriteString(fmt.Sprintf("for range %s {\n", value))
+	case stmt.Element != nil:
+		out.WriteString(fmt.Sprintf("for %s := range %s {\n", stmt.Name.Value, value))
+	default:
+		out.WriteString(fmt.Sprintf("for _, %s := range %s {\n", stmt.Name.Value, value))
+	}
+
+	// The body is a block of its own so that it may redeclare the value
+	out.WriteString(g.generateBlockStatement(stmt.Body))
+	out.WriteString("\n}")
+
+	return out.String()
+}
+
+// generateQueryStatement generates code for a query statement. The rows
+// are closed by a deferred call as well as after the loop, so that they
+// are released when the body returns early.
+func (g *Generator) generateQueryStatement(stmt *ast.QueryStatement) string {
+	var out strings.Builder
+
+	g.features[FeatureQuery] = true
+
+	args := []string{g.generateExpression(stmt.Call.Function)}
+	for _, arg := range stmt.Call.Arguments {
+		args = append(args, g.generateExpression(arg))
+	}
+	failed := g.queryFailed()
+	out.WriteString("{\n")
+	out.WriteString(fmt.Sprintf("saikaRows, saikaErr := saikaQuery(%s)\n", strings.Join(args, ", ")))
+	out.WriteString("if saikaErr != nil {\n" + failed + "\n}\n")
+	out.WriteString("defer saikaRows.Close()\n")
+	out.WriteString("for saikaRows.Next() {\n")
+
+	dest := []string{}
+	for _, column := range stmt.Columns {
+		out.WriteString(fmt.Sprintf("var %s %s\n",
+			column.Name.Value,
+			g.generateType(column.Type)))
+		dest = append(dest, "&"+column.Name.Value)
+	}
+	out.WriteString(fmt.Sprintf("if saikaErr := saikaRows.Scan(%s); saikaErr != nil {\n%s\n}\n",
+		strings.Join(dest, ", "), failed))
+
+	// The body is a block of its own so that it may redeclare a column
+	out.WriteString(g.generateBlockStatement(stmt.Body))
+	out.WriteString("\n}\n")
+	out.WriteString("if saikaErr := saikaRows.Done(); saikaErr != nil {\n" + failed + "\n}\n")
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// queryFailed generates the statement handling saikaErr, the error of a
+// query: returning it, with zero values for the other results, from a
+// function whose last result is an 错误, or else panicking with it
+func (g *Generator) queryFailed() string {
+	if !ast.ReturnsError(g.result) {
+		return "panic(saikaErr)"
+	}
+	results := []string{}
+	if list, ok := g.result.(*ast.ResultList); ok {
+		for _, typ := range list.Types[:len(list.Types)-1] {
+			results = append(results, fmt.Sprintf("*new(%s)", g.generateType(typ)))
+		}
+	}
+	return "return " + strings.Join(append(results, "saikaErr"), ", ")
+}
+
+// generateFunctionBody generates the body of a function returning result
+func (g *Generator) generateFunctionBody(result ast.Expression, body *ast.BlockStatement) string {
+	outer := g.result
+	g.result = result
+	defer func() { g.result = outer }()
+	return g.generateBlockStatement(body)
+}
+
 // generateBlockStatement generates code for a block statement
 func (g *Generator) generateBlockStatement(stmt *ast.BlockStatement) string {
 	var out strings.Builder
 
 	out.WriteString("{\n")
+	out.WriteString(g.generateStatements(stmt.Statements))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// generateStatements generates code for a list of statements, one per line
+func (g *Generator) generateStatements(stmts []ast.Statement) string {
+	var out strings.Builder
 
-	for _, s := range stmt.Statements {
+	for _, s := range stmts {
+		out.WriteString(g.explain(s))
 		out.WriteString(g.generateStatement(s))
 
 		// Add semicolon for certain statement types
 		switch s.(type) {
-		case *ast.ExpressionStatement, *ast.VarStatement, *ast.ConstStatement:
+		case *ast.ExpressionStatement, *ast.VarStatement, *ast.VarListStatement, *ast.AssignListStatement, *ast.ConstStatement:
 			if !strings.HasSuffix(out.String(), ";") {
 				out.WriteString(";")
 			}
@@ -234,6 +775,52 @@ func (g *Generator) generateBlockStatement(stmt *ast.BlockStatement) string {
 		out.WriteString("\n")
 	}
 
+	return out.String()
+}
+
+// generateSelectStatement generates code for a 监听 statement
+func (g *Generator) generateSelectStatement(stmt *ast.SelectStatement) string {
+	var out strings.Builder
+
+	out.WriteString("select {\n")
+	for _, c := range stmt.Cases {
+		switch comm := c.Comm.(type) {
+		case nil:
+			out.WriteString("default:\n")
+		case *ast.VarStatement:
+			// A variable receiving the value is local to the case
+			fmt.Fprintf(&out, "case %s := %s:\n", comm.Name.Value, g.generateExpression(comm.Value))
+		default:
+			fmt.Fprintf(&out, "case %s:\n", g.generateStatement(comm))
+		}
+		out.WriteString(g.generateStatements(c.Body))
+	}
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// generateSwitchStatement generates code for a 选择 statement
+func (g *Generator) generateSwitchStatement(stmt *ast.SwitchStatement) string {
+	var out strings.Builder
+
+	out.WriteString("switch ")
+	if stmt.Tag != nil {
+		out.WriteString(g.generateExpression(stmt.Tag) + " ")
+	}
+	out.WriteString("{\n")
+	for _, c := range stmt.Cases {
+		if len(c.Values) == 0 {
+			out.WriteString("default:\n")
+		} else {
+			values := make([]string, len(c.Values))
+			for i, v := range c.Values {
+				values[i] = g.generateExpression(v)
+			}
+			fmt.Fprintf(&out, "case %s:\n", strings.Join(values, ", "))
+		}
+		out.WriteString(g.generateStatements(c.Body))
+	}
 	out.WriteString("}")
 
 	return out.String()
@@ -244,13 +831,68 @@ func (g *Generator) generateExpressionStatement(stmt *ast.ExpressionStatement) s
 	return g.generateExpression(stmt.Expression)
 }
 
+// generateOperand generates an operand of an operator with the given
+// precedence, keeping the grouping of the source with parentheses
+func (g *Generator) generateOperand(expr ast.Expression, precedence int, right bool) string {
+	if ast.NeedsParens(expr, precedence, right) {
+		return "(" + g.generateExpression(expr) + ")"
+	}
+	return g.generateExpression(expr)
+}
+
 // generateExpression generates code for an expression
 func (g *Generator) generateExpression(expr ast.Expression) string {
 	switch expr := expr.(type) {
 	case *ast.Identifier:
+		switch expr.Value {
+		case BuildInfoName:
+			g.features[FeatureBuildInfo] = true
+		case OpenDatabaseName:
+			g.features[FeatureQuery] = true
+		case RenderTemplateName, WriteTemplateName:
+			g.features[FeatureTemplate] = true
+		case StaticFilesName:
+			g.features[FeatureStatic] = true
+		case MakeName:
+			return "make"
+		case IotaName:
+			return "iota"
+		case PanicName:
+			return "panic"
+		case RecoverName:
+			return "recover"
+		case RuneAtName, RuneCountName, RuneSliceName, RunesFromName:
+			g.features[FeatureRunes] = true
+		case CharCountName:
+			g.features[FeatureRunes] = true
+			return RuneCountName
+		case SubstringName:
+			g.features[FeatureRunes] = true
+			return RuneSliceName
+		case BytesName:
+			return "[]byte"
+		}
 		return expr.Value
 	case *ast.IntegerLiteral:
+		// Keep the original spelling so hex and other forms survive
+		if expr.Token.Raw != "" {
+			return expr.Token.Raw
+		}
 		return fmt.Sprintf("%d", expr.Value)
+	case *ast.FloatLiteral:
+		return expr.Token.Literal
+	case *ast.UnitLiteral:
+		g.features[FeatureUnits] = true
+		unit := ast.Units[expr.Unit]
+		if unit.Duration {
+			return fmt.Sprintf("saikaDuration(%s * %s)", expr.Number(), unitNames[expr.Unit])
+		}
+		// A byte size is an untyped integer constant, usable as any
+		// integer type; a fraction of a unit is written out in bytes
+		if expr.Value != math.Trunc(expr.Value) {
+			return fmt.Sprintf("(%d * %s)", int64(expr.Value*float64(unit.Size)), unitNames["字节"])
+		}
+		return fmt.Sprintf("(%d * %s)", int64(expr.Value), unitNames[expr.Unit])
 	case *ast.StringLiteral:
 		return fmt.Sprintf("\"%s\"", expr.Value)
 	case *ast.BooleanLiteral:
@@ -259,34 +901,157 @@ func (g *Generator) generateExpression(expr ast.Expression) string {
 		}
 		return "false"
 	case *ast.PrefixExpression:
+		// A nested prefix operand is parenthesized so that - -x does not
+		// become Go's -- operator
 		return fmt.Sprintf("%s%s",
 			expr.Operator,
-			g.generateExpression(expr.Right))
+			g.generateOperand(expr.Right, ast.Precedence(expr), true))
 	case *ast.InfixExpression:
 		// Special case for modulo operator (% -> %)
 		operator := expr.Operator
 
 		return fmt.Sprintf("%s %s %s",
-			g.generateExpression(expr.Left),
+			g.generateOperand(expr.Left, ast.Precedence(expr), false),
 			operator,
-			g.generateExpression(expr.Right))
+			g.generateOperand(expr.Right, ast.Precedence(expr), true))
 	case *ast.AssignExpression:
 		return fmt.Sprintf("%s = %s",
 			g.generateExpression(expr.Left),
 			g.generateExpression(expr.Value))
+	case *ast.CompoundAssignExpression:
+		return fmt.Sprintf("%s %s= %s",
+			g.generateExpression(expr.Left),
+			expr.Operator,
+			g.generateExpression(expr.Value))
 	case *ast.MemberExpression:
+		if translated, ok := g.translateMember(expr); ok {
+			return translated
+		}
 		return fmt.Sprintf("%s.%s",
 			g.generateExpression(expr.Object),
 			g.generateExpression(expr.Property))
+	case *ast.IndexExpression:
+		return fmt.Sprintf("%s[%s]",
+			g.generateExpression(expr.Left),
+			g.generateIndex(expr.Index))
+	case *ast.SliceExpression:
+		// An omitted index stays empty, as in s[:n]
+		indices := []string{"", ""}
+		if expr.Low != nil {
+			indices[0] = g.generateExpression(expr.Low)
+		}
+		if expr.High != nil {
+			indices[1] = g.generateExpression(expr.High)
+		}
+		if expr.Max != nil {
+			indices = append(indices, g.generateExpression(expr.Max))
+		}
+		return fmt.Sprintf("%s[%s]",
+			g.generateExpression(expr.Left),
+			strings.Join(indices, ":"))
+	case *ast.MapType, *ast.SliceType, *ast.ChanType:
+		// A type given to a builtin, as in 创建(通道 整数)
+		return g.generateType(expr)
+	case *ast.ExpressionList:
+		values := make([]string, len(expr.Values))
+		for i, value := range expr.Values {
+			values[i] = g.generateExpression(value)
+		}
+		return strings.Join(values, ", ")
+	case *ast.SliceLiteral:
+		elements := []string{}
+		for _, el := range expr.Elements {
+			elements = append(elements, g.generateExpression(el))
+		}
+		return fmt.Sprintf("%s{%s}",
+			g.generateType(expr.Type),
+			strings.Join(elements, ", "))
+	case *ast.ArrayLiteral:
+		elements := []string{}
+		for _, el := range expr.Elements {
+			elements = append(elements, g.generateExpression(el))
+		}
+		return fmt.Sprintf("%s{%s}",
+			g.generateType(expr.Type),
+			strings.Join(elements, ", "))
+	case *ast.MapLiteral:
+		pairs := []string{}
+		for _, pair := range expr.Pairs {
+			pairs = append(pairs, fmt.Sprintf("%s: %s",
+				g.generateExpression(pair.Key),
+				g.generateExpression(pair.Value)))
+		}
+		return fmt.Sprintf("%s{%s}",
+			g.generateType(expr.Type),
+			strings.Join(pairs, ", "))
+	case *ast.CompositeLiteral:
+		fields := []string{}
+		for _, field := range expr.Fields {
+			fields = append(fields, fmt.Sprintf("%s: %s",
+				field.Name.Value,
+				g.generateExpression(field.Value)))
+		}
+		lit := fmt.Sprintf("%s{%s}",
+			g.generateType(expr.Type),
+			strings.Join(fields, ", "))
+		// A literal in a header is parenthesized, as Go requires of a
+		// literal of a named type there
+		if g.header {
+			return "(" + lit + ")"
+		}
+		return lit
+	case *ast.FunctionLiteral:
+		return "func" + g.generateSignature(expr.Parameters, expr.ReturnType) + " " + g.generateFunctionBody(expr.ReturnType, expr.Body)
 	case *ast.CallExpression:
+		if file, ok := TemplateFile(expr); ok {
+			if name, ok := EmbeddedTemplate(file.Text()); ok {
+				g.features[FeatureTemplateFiles] = true
+				g.templates[name] = true
+			}
+		}
 		args := []string{}
 		for _, arg := range expr.Arguments {
 			args = append(args, g.generateExpression(arg))
 		}
-		return fmt.Sprintf("%s(%s)",
+		spread := ""
+		if expr.Ellipsis {
+			spread = "..."
+		}
+		return fmt.Sprintf("%s(%s%s)",
 			g.generateExpression(expr.Function),
-			strings.Join(args, ", "))
+			strings.Join(args, ", "),
+			spread)
 	default:
 		return ""
 	}
 }
+
+// translateMember translates a member of a standard library package given
+// by its Chinese name, or a Chinese member of one given by its Go name,
+// e.g. 格式化.打印行 -> fmt.Println
+func (g *Generator) translateMember(expr *ast.MemberExpression) (string, bool) {
+	object, ok := expr.Object.(*ast.Identifier)
+	if !ok {
+		return "", false
+	}
+	property, ok := expr.Property.(*ast.Identifier)
+	if !ok {
+		return "", false
+	}
+
+	pkg, ok := stdlib.Lookup(object.Value)
+	if !ok {
+		if pkg, ok = stdlib.LookupPath(object.Value); !ok {
+			return "", false
+		}
+		if _, translated := pkg.Members[property.Value]; !translated {
+			return "", false
+		}
+	}
+
+	member, ok := pkg.Member(property.Value)
+	if !ok {
+		member = property.Value
+	}
+	return pkg.GoName() + "." + member, true
+}
@@ -0,0 +1,47 @@
+package codegen
+
+import "github.com/saika-m/saika-lang/internal/ast"
+
+// ReceiverName is the Go name of the pointer receiver of a method that
+// changes its receiver. The body works on a copy of the value under the
+// receiver's Saika name, which is stored back through the pointer when
+// the method returns, so that the variable the method was called on
+// sees the changes.
+const ReceiverName = "saikaReceiver"
+
+// ReceiverType returns the name of the type the method fn is declared on,
+// with the type parameters of a generic type, as 栈 and T are of (s
+// 栈[T]). The name is "" when the receiver does not name a type.
+func ReceiverType(fn *ast.FunctionStatement) (string, []*ast.Identifier) {
+	switch typ := fn.Receiver.Type.(type) {
+	case *ast.Identifier:
+		return typ.Value, nil
+	case *ast.IndexExpression:
+		name, ok := typ.Left.(*ast.Identifier)
+		if !ok {
+			return "", nil
+		}
+		args := ast.TypeArguments(typ.Index)
+		params := make([]*ast.Identifier, len(args))
+		for i, arg := range args {
+			if params[i], ok = arg.(*ast.Identifier); !ok {
+				return "", nil
+			}
+		}
+		return name.Value, params
+	}
+	return "", nil
+}
+
+// MethodName returns the name of the lowered method fn as Go reports it,
+// with the type of its receiver, as in 向量.加, (*向量).缩放 or 栈[...].压入
+func MethodName(fn *ast.FunctionStatement) string {
+	typ, params := ReceiverType(fn)
+	if len(params) > 0 {
+		typ += "[...]"
+	}
+	if fn.ChangesReceiver {
+		typ = "(*" + typ + ")"
+	}
+	return typ + "." + fn.Name.Value
+}
@@ -0,0 +1,99 @@
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+)
+
+// explain returns the comment that a Readable generator puts before the
+// code of stmt, relating the Saika construct and keyword it was written
+// with to the Go construct it became:
+//
+//	// 第 12 行 如果 … 否则 → if … else：按条件选择执行的代码块
+//
+// It returns "" for expressions and assignments, whose Go reads as their
+// Saika does, for statements that lowering made up, and when g is not
+// Readable.
+func (g *Generator) explain(stmt ast.Statement) string {
+	if !g.Readable {
+		return ""
+	}
+	keyword := stmt.TokenLiteral()
+	var goForm, meaning string
+	switch stmt := stmt.(type) {
+	case *ast.PackageStatement:
+		goForm, meaning = "package", "声明文件所属的包"
+	case *ast.ImportStatement:
+		goForm, meaning = "import", "导入包 "+stmt.Path
+	case *ast.FunctionStatement:
+		goForm, meaning = "func "+goFunctionName(stmt.Name.Value), "声明函数 "+stmt.Name.Value
+		if stmt.Receiver != nil {
+			typ, _ := ReceiverType(stmt)
+			goForm, meaning = "func ("+stmt.Receiver.Name.Value+" …) "+stmt.Name.Value, "为类型 "+typ+" 声明方法 "+stmt.Name.Value
+		} else if stmt.Name.Value == "入口" {
+			meaning = "程序从这里开始执行"
+		}
+	case *ast.InterfaceStatement:
+		goForm, meaning = "type … interface", "声明接口：一组方法"
+	case *ast.TypeStatement:
+		goForm, meaning = "type", "声明新类型 "+stmt.Name.Value
+		if len(stmt.TypeParams) > 0 {
+			goForm, meaning = "type …[…]", "声明泛型类型 "+stmt.Name.Value+"，使用时给出类型参数"
+		}
+		if stmt.Alias {
+			goForm, meaning = "type … =", "为已有类型起别名 "+stmt.Name.Value
+		}
+	case *ast.EnumStatement:
+		goForm, meaning = "type 与 const ( … iota )", "声明一组依次编号的常量"
+	case *ast.VarStatement, *ast.VarListStatement:
+		goForm, meaning = "var", "声明变量"
+	case *ast.ConstStatement:
+		goForm, meaning = "const", "声明不可改变的常量"
+	case *ast.ConstGroupStatement:
+		goForm, meaning = "const ( … iota )", "声明一组常量，序号从 0 起依次编号"
+	case *ast.OptionStatement:
+		goForm, meaning = "flag", "声明命令行选项"
+	case *ast.ReturnStatement:
+		goForm, meaning = "return", "结束函数并交回结果"
+	case *ast.IfStatement:
+		goForm, meaning = "if", "条件成立时执行代码块"
+		if stmt.Alternative != nil {
+			keyword += " … 否则"
+			goForm, meaning = "if … else", "按条件选择执行的代码块"
+		}
+	case *ast.ForStatement:
+		goForm, meaning = "for", "重复执行代码块"
+		switch {
+		case stmt.Init == nil && stmt.Condition == nil && stmt.Update == nil:
+			meaning = "不停地重复执行代码块，直到跳出"
+		case stmt.Init == nil && stmt.Update == nil:
+			meaning = "条件成立时重复执行代码块"
+		}
+	case *ast.CharLoopStatement:
+		goForm, meaning = "for … range []rune(…)", "逐个字符遍历字符串"
+	case *ast.RangeStatement:
+		goForm, meaning = "for … range", "逐个遍历切片、数组或迭代器的值"
+	case *ast.QueryStatement:
+		goForm, meaning = "database/sql 的 Query 与 rows.Next", "逐行读取查询结果"
+	case *ast.SignalStatement:
+		goForm, meaning = "signal.Notify", "收到中断信号时执行代码块"
+	case *ast.DeferStatement:
+		goForm, meaning = "defer", "函数返回前才执行调用"
+	case *ast.GoStatement:
+		goForm, meaning = "go", "在新的协程中并发执行调用"
+	case *ast.SendStatement:
+		goForm, meaning = "ch <- v", "向通道发送值"
+	case *ast.SelectStatement:
+		goForm, meaning = "select", "等待多个通道操作中先就绪的一个"
+	case *ast.SwitchStatement:
+		goForm, meaning = "switch", "执行第一个值相符的情况，都不相符时执行默认"
+	default:
+		return ""
+	}
+	line := ast.NodeRange(stmt).Start.Line
+	if line == 0 || keyword == "" {
+		return ""
+	}
+	return fmt.Sprintf("// 第 %d 行 %s → %s：%s\n", line, keyword, goForm, meaning)
+}
@@ -0,0 +1,515 @@
+package codegen
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+)
+
+// Support features that generated code can require
+const (
+	// FeatureBuildInfo provides the 构建信息 builtin
+	FeatureBuildInfo = "buildinfo"
+
+	// FeatureQuery provides the helpers behind 查询 and 打开数据库
+	FeatureQuery = "query"
+
+	// FeatureTemplate provides 渲染模板 and 输出模板
+	FeatureTemplate = "template"
+
+	// FeatureTemplateFiles embeds the template files named by the program,
+	// copied to TemplateDirName
+	FeatureTemplateFiles = "templatefiles"
+
+	// FeatureOptions provides the flag set behind 选项 declarations
+	FeatureOptions = "options"
+
+	// FeatureSignals provides the handler registration behind 捕获信号
+	FeatureSignals = "signals"
+
+	// FeatureUnits provides the units of literals such as 500毫秒
+	FeatureUnits = "units"
+
+	// FeatureRunes provides the helpers indexing and measuring strings by
+	// character
+	FeatureRunes = "runes"
+
+	// FeatureStatic provides 静态文件, the embedded static directory
+	FeatureStatic = "static"
+
+	// FeatureIterators provides the function behind 范围 loops over
+	// iterators
+	FeatureIterators = "iterators"
+)
+
+// BuildInfoName is the Saika builtin exposing build information
+const BuildInfoName = "构建信息"
+
+// Variables behind 构建信息, set at link time with -ldflags -X
+const (
+	BuildInfoVersionVar     = "saikaVersion"
+	BuildInfoCommitVar      = "saikaCommit"
+	BuildInfoTimeVar        = "saikaBuildTime"
+	BuildInfoToolVersionVar = "saikaToolVersion"
+)
+
+// MakeName is the Saika builtin creating channels, maps and slices, Go's
+// make: 创建(通道 整数, 10) is make(chan int, 10)
+const MakeName = "创建"
+
+// IotaName is the Saika spelling of Go's iota, which numbers the
+// constants of a 常量 group from 0
+const IotaName = "序号"
+
+// Keywords naming Go's panic and recover builtins: 恐慌(值) panics and
+// 恢复(), called by a deferred function, stops the panic and returns its
+// value. As keywords, they cannot be shadowed.
+const (
+	PanicName   = "恐慌"
+	RecoverName = "恢复"
+)
+
+// Saika indexes and measures strings by character (rune) rather than by
+// byte: s[i] and len(s) of a string s are lowered to calls of the helpers
+// named RuneAtName and RuneCountName, and s[i] is a one-character string.
+// Slices s[i:j] and s[i:] are lowered to RuneSliceName and RunesFromName.
+// 字节(s), Go's []byte(s), gives the bytes of s, which index by byte.
+// The builtins 字符数(s) and 子串(s, i, j) count the characters of s and
+// slice them from index i up to j.
+const (
+	RuneAtName    = "saikaRuneAt"
+	RuneCountName = "saikaRuneCount"
+	RuneSliceName = "saikaSubstring"
+	RunesFromName = "saikaRunesFrom"
+	BytesName     = "字节"
+	CharCountName = "字符数"
+	SubstringName = "子串"
+)
+
+// StaticFilesName is the Saika builtin holding the files of the project's
+// static directory, named StaticDirName, as an io/fs.FS. saika copies the
+// directory next to the generated code, which embeds it in the program.
+const (
+	StaticFilesName = "静态文件"
+	StaticDirName   = "static"
+)
+
+// ValuesName is the helper a 范围 loop over an iterator ranges over. Given
+// the type of the values, it returns a Go 1.23 range-over-func iterator
+// that moves a copy of the iterator along with its method 下一个 and
+// yields what its method 值 returns.
+const ValuesName = "saikaValues"
+
+// OpenDatabaseName is the Saika builtin opening a database/sql database
+const OpenDatabaseName = "打开数据库"
+
+// Saika builtins rendering html/template files: 渲染模板(文件, 数据) returns
+// the result and 输出模板(写入器, 文件, 数据) writes it to an io.Writer
+const (
+	RenderTemplateName = "渲染模板"
+	WriteTemplateName  = "输出模板"
+)
+
+// TemplateFileArgs gives the argument holding the file name for each
+// builtin rendering templates
+var TemplateFileArgs = map[string]int{
+	RenderTemplateName: 0,
+	WriteTemplateName:  1,
+}
+
+// TemplateDirName is the directory, next to the generated code, holding
+// the template files that the program embeds. Files named by a string
+// literal in a call to 渲染模板 or 输出模板 are embedded, named relative to
+// the directory of the source file; others are read when rendered.
+const TemplateDirName = "saika_templates"
+
+// TemplateFile returns the string literal naming the template file in a
+// call to 渲染模板 or 输出模板, if the file is given by one
+func TemplateFile(call *ast.CallExpression) (*ast.StringLiteral, bool) {
+	fn, ok := call.Function.(*ast.Identifier)
+	if !ok {
+		return nil, false
+	}
+	arg, ok := TemplateFileArgs[fn.Value]
+	if !ok || arg >= len(call.Arguments) {
+		return nil, false
+	}
+	file, ok := call.Arguments[arg].(*ast.StringLiteral)
+	return file, ok
+}
+
+// EmbeddedTemplate returns the slash-separated name under which a program
+// embeds the template file named file, which must be a relative path
+// within the directory of the source file
+func EmbeddedTemplate(file string) (string, bool) {
+	name := path.Clean(file)
+	return name, fs.ValidPath(name) && name != "."
+}
+
+// supportImports lists the packages each support feature imports
+var supportImports = map[string][]string{
+	FeatureQuery:         {"context", "database/sql", "fmt"},
+	FeatureTemplate:      {"fmt", "html/template", "io", "io/fs", "path", "strings", "sync"},
+	FeatureTemplateFiles: {"embed", "io/fs"},
+	FeatureOptions:       {"flag"},
+	FeatureSignals:       {"context", "os", "os/signal", "syscall"},
+	FeatureUnits:         {"time"},
+	FeatureRunes:         {"unicode/utf8"},
+	FeatureStatic:        {"embed", "io/fs"},
+}
+
+// SupportImports returns the packages the support code of a feature
+// imports
+func SupportImports(feature string) []string {
+	return supportImports[feature]
+}
+
+// SupportFileName is the name of the Go file holding package support code
+const SupportFileName = "saika_support.go"
+
+// SupportSource returns the Go source providing the given support features
+// for a package. It is written once per package, however many files use them.
+func SupportSource(pkg string, features []string) string {
+	var out strings.Builder
+
+	out.WriteString("// Code generated by saika. DO NOT EDIT.\n\n")
+	out.WriteString(fmt.Sprintf("package %s\n", pkg))
+
+	imports := map[string]bool{}
+	for _, feature := range features {
+		for _, path := range supportImports[feature] {
+			imports[path] = true
+		}
+	}
+	if len(imports) > 0 {
+		paths := make([]string, 0, len(imports))
+		for path := range imports {
+			paths = append(paths, fmt.Sprintf("\t%q\n", path))
+		}
+		sort.Strings(paths)
+		out.WriteString("\nimport (\n" + strings.Join(paths, "") + ")\n")
+	}
+
+	for _, feature := range features {
+		switch feature {
+		case FeatureBuildInfo:
+			out.WriteString(buildInfoSource)
+		case FeatureQuery:
+			out.WriteString(querySource)
+		case FeatureTemplate:
+			out.WriteString(templateSource)
+		case FeatureTemplateFiles:
+			out.WriteString(templateFilesSource)
+		case FeatureOptions:
+			out.WriteString(optionsSource)
+		case FeatureSignals:
+			out.WriteString(signalsSource)
+		case FeatureUnits:
+			out.WriteString(unitsSource)
+		case FeatureRunes:
+			out.WriteString(runesSource)
+		case FeatureStatic:
+			out.WriteString(staticSource)
+		case FeatureIterators:
+			out.WriteString(iteratorsSource)
+		}
+	}
+
+	return out.String()
+}
+
+// buildInfoSource declares 构建信息 and the variables saika build fills in
+var buildInfoSource = fmt.Sprintf(`
+// Overridden with -ldflags -X by saika build and saika run
+var (
+	%[1]s     = "dev"
+	%[2]s      = ""
+	%[3]s   = ""
+	%[4]s = ""
+)
+
+// %[5]s reports the program version, source commit, build time and saika version
+var %[5]s = struct {
+	版本   string
+	提交   string
+	时间   string
+	工具版本 string
+}{%[1]s, %[2]s, %[3]s, %[4]s}
+`, BuildInfoVersionVar, BuildInfoCommitVar, BuildInfoTimeVar, BuildInfoToolVersionVar, BuildInfoName)
+
+// querySource declares the helpers 查询 statements are generated with,
+// which prepare each query, scan its rows and close them even when the
+// body returns early, and 打开数据库. Their errors are returned to the
+// generated code, which returns or panics with them.
+var querySource = fmt.Sprintf(`
+// saikaContext is the context database queries run in
+var saikaContext = context.Background()
+
+// saikaPreparer is implemented by *sql.DB, *sql.Conn and *sql.Tx
+type saikaPreparer interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// saikaQueryRows iterates over the rows of a 查询 statement
+type saikaQueryRows struct {
+	stmt *sql.Stmt
+	rows *sql.Rows
+}
+
+// saikaQuery prepares query and runs it with args
+func saikaQuery(db saikaPreparer, query string, args ...any) (*saikaQueryRows, error) {
+	stmt, err := db.PrepareContext(saikaContext, query)
+	if err != nil {
+		return nil, fmt.Errorf("查询: %%w", err)
+	}
+	rows, err := stmt.QueryContext(saikaContext, args...)
+	if err != nil {
+		stmt.Close()
+		return nil, fmt.Errorf("查询: %%w", err)
+	}
+	return &saikaQueryRows{stmt, rows}, nil
+}
+
+// Next advances to the next row, reporting whether there is one
+func (r *saikaQueryRows) Next() bool {
+	return r.rows.Next()
+}
+
+// Scan copies the columns of the current row into dest
+func (r *saikaQueryRows) Scan(dest ...any) error {
+	if err := r.rows.Scan(dest...); err != nil {
+		return fmt.Errorf("查询: %%w", err)
+	}
+	return nil
+}
+
+// Close releases the rows and the prepared statement; it may be called
+// more than once
+func (r *saikaQueryRows) Close() {
+	r.rows.Close()
+	r.stmt.Close()
+}
+
+// Done closes the rows once they are all read, failing if reading them
+// stopped on an error
+func (r *saikaQueryRows) Done() error {
+	err := r.rows.Err()
+	r.Close()
+	if err != nil {
+		return fmt.Errorf("查询: %%w", err)
+	}
+	return nil
+}
+
+// %[1]s opens a database with a registered database/sql driver
+func %[1]s(driver, source string) *sql.DB {
+	db, err := sql.Open(driver, source)
+	if err != nil {
+		panic(fmt.Errorf("%[1]s: %%w", err))
+	}
+	return db
+}
+`, OpenDatabaseName)
+
+// templateSource declares the builtins rendering templates. Each file is
+// parsed once; html/template escapes the data for the context it is used in.
+var templateSource = fmt.Sprintf(`
+// saikaTemplateFiles holds the template files embedded in the program
+var saikaTemplateFiles fs.FS
+
+// saikaTemplates caches the parsed templates by file name
+var saikaTemplates sync.Map
+
+// saikaTemplate returns the parsed template in file: the embedded file of
+// that name, or else the file read relative to the working directory
+func saikaTemplate(file string) *template.Template {
+	if t, ok := saikaTemplates.Load(file); ok {
+		return t.(*template.Template)
+	}
+	var t *template.Template
+	var err error
+	if name := path.Clean(file); saikaTemplateFiles != nil && fs.ValidPath(name) {
+		if _, statErr := fs.Stat(saikaTemplateFiles, name); statErr == nil {
+			t, err = template.ParseFS(saikaTemplateFiles, name)
+		}
+	}
+	if t == nil && err == nil {
+		t, err = template.ParseFiles(file)
+	}
+	if err != nil {
+		panic(fmt.Errorf("模板: %%w", err))
+	}
+	cached, _ := saikaTemplates.LoadOrStore(file, t)
+	return cached.(*template.Template)
+}
+
+// %[1]s renders the template in file with data and returns the result
+func %[1]s(file string, data any) string {
+	var out strings.Builder
+	%[2]s(&out, file, data)
+	return out.String()
+}
+
+// %[2]s renders the template in file with data to w
+func %[2]s(w io.Writer, file string, data any) {
+	if err := saikaTemplate(file).Execute(w, data); err != nil {
+		panic(fmt.Errorf("模板: %%w", err))
+	}
+}
+`, RenderTemplateName, WriteTemplateName)
+
+// templateFilesSource embeds the template files copied next to the
+// generated code
+var templateFilesSource = fmt.Sprintf(`
+//go:embed %[1]s
+var saikaTemplateEmbed embed.FS
+
+func init() {
+	saikaTemplateFiles, _ = fs.Sub(saikaTemplateEmbed, %[1]q)
+}
+`, TemplateDirName)
+
+// optionsSource declares the flag set 选项 declarations define their flags
+// in, and the function 入口 parses the command line with
+const optionsSource = `
+// saikaFlags holds the flags of the 选项 declarations
+var saikaFlags = flag.CommandLine
+
+// saikaParseFlags sets the 选项 of the program from its command line
+func saikaParseFlags() {
+	flag.Parse()
+}
+`
+
+// signalsSource declares the function 捕获信号 statements register their
+// handlers with
+const signalsSource = `
+// saikaOnSignal runs handler once the program receives an interrupt or
+// termination signal. Signals are then no longer caught, so a second one
+// stops the program.
+func saikaOnSignal(handler func()) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+		handler()
+	}()
+}
+`
+
+// unitNames maps the suffixes of unit literals to the constants declared
+// by unitsSource
+var unitNames = map[string]string{
+	"纳秒":  "saikaNanosecond",
+	"微秒":  "saikaMicrosecond",
+	"毫秒":  "saikaMillisecond",
+	"秒":   "saikaSecond",
+	"分钟":  "saikaMinute",
+	"小时":  "saikaHour",
+	"字节":  "saikaByte",
+	"千字节": "saikaKilobyte",
+	"兆字节": "saikaMegabyte",
+	"吉字节": "saikaGigabyte",
+	"太字节": "saikaTerabyte",
+}
+
+// unitsSource declares the units of unit literals. They are untyped so
+// that 1.5秒 is a constant expression; a literal n单位 is generated as
+// saikaDuration(n * constant) for a duration, or as the untyped constant
+// (n * constant) for a byte size, which any integer type can hold.
+const unitsSource = `
+// saikaDuration is the type of duration literals
+type saikaDuration = time.Duration
+
+const (
+	saikaNanosecond  = 1
+	saikaMicrosecond = 1000 * saikaNanosecond
+	saikaMillisecond = 1000 * saikaMicrosecond
+	saikaSecond      = 1000 * saikaMillisecond
+	saikaMinute      = 60 * saikaSecond
+	saikaHour        = 60 * saikaMinute
+)
+
+const (
+	saikaByte     = 1
+	saikaKilobyte = 1 << 10
+	saikaMegabyte = 1 << 20
+	saikaGigabyte = 1 << 30
+	saikaTerabyte = 1 << 40
+)
+`
+
+// runesSource declares the helpers strings are indexed and measured with.
+// They take strings of any type whose underlying type is string, and
+// indexes of any integer type, as Go's own indexing does.
+var runesSource = fmt.Sprintf(`
+// saikaIndex is the type of an index into the characters of a string
+type saikaIndex interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// %[1]s returns the character at index i of s, counting in runes
+func %[1]s[S ~string, I saikaIndex](s S, i I) string {
+	return string([]rune(string(s))[i])
+}
+
+// %[2]s returns the number of characters in s
+func %[2]s[S ~string](s S) int {
+	return utf8.RuneCountInString(string(s))
+}
+
+// %[3]s returns the characters of s from index i up to j
+func %[3]s[S ~string, I, J saikaIndex](s S, i I, j J) S {
+	return S([]rune(string(s))[i:j])
+}
+
+// %[4]s returns the characters of s from index i on
+func %[4]s[S ~string, I saikaIndex](s S, i I) S {
+	return S([]rune(string(s))[i:])
+}
+`, RuneAtName, RuneCountName, RuneSliceName, RunesFromName)
+
+// iteratorsSource declares the iterator protocol and ValuesName. The
+// pointer type parameter P lets 下一个 change the copy of the iterator.
+var iteratorsSource = fmt.Sprintf(`
+// saikaIterator is an iterator of values of type T, which 范围 ranges over
+type saikaIterator[T any] interface {
+	下一个() bool
+	值() T
+}
+
+// %[1]s returns the values of it, moving a copy of it along
+func %[1]s[T any, I any, P interface {
+	*I
+	saikaIterator[T]
+}](it I) func(yield func(T) bool) {
+	return func(yield func(T) bool) {
+		p := P(&it)
+		for p.下一个() {
+			if !yield(p.值()) {
+				return
+			}
+		}
+	}
+}
+`, ValuesName)
+
+// staticSource embeds the static directory copied next to the generated
+// code and declares 静态文件
+var staticSource = fmt.Sprintf(`
+//go:embed %[2]s
+var saikaStatic embed.FS
+
+// %[1]s holds the files of the static directory, named relative to it
+var %[1]s = func() fs.FS {
+	files, err := fs.Sub(saikaStatic, %[2]q)
+	if err != nil {
+		panic(err)
+	}
+	return files
+}()
+`, StaticFilesName, StaticDirName)
@@ -0,0 +1,1200 @@
+package diagnostic
+
+import "sort"
+
+// Code is a stable identifier for a kind of diagnostic, such as SK0001.
+// Codes are never reused once assigned, so tools and documentation can
+// refer to them.
+type Code string
+
+// Diagnostic codes
+const (
+	UnexpectedToken      Code = "SK0001"
+	ExpectedExpression   Code = "SK0002"
+	InvalidInteger       Code = "SK0003"
+	InvalidImportPath    Code = "SK0004"
+	UnexpectedIdentifier Code = "SK0005"
+	UnusedImport         Code = "SK0006"
+	UndefinedName        Code = "SK0007"
+	UnknownMember        Code = "SK0008"
+	MissingImport        Code = "SK0009"
+	DuplicateImport      Code = "SK0010"
+	MissingTemplate      Code = "SK0011"
+	MisplacedOption      Code = "SK0012"
+	NonConstantValue     Code = "SK0013"
+	AssignToConstant     Code = "SK0014"
+	InvalidAssignTarget  Code = "SK0015"
+	Redeclared           Code = "SK0016"
+	ReturnMismatch       Code = "SK0017"
+	CallRequired         Code = "SK0018"
+	ChannelOpRequired    Code = "SK0019"
+	DeferInLoop          Code = "SK0020"
+	_                    Code = "SK0021" // retired: loop variables captured by goroutines
+	MissingMethod        Code = "SK0022"
+	NamingConvention     Code = "SK0023"
+	InvalidString        Code = "SK0024"
+	UnknownStringType    Code = "SK0025"
+	InvalidReceiver      Code = "SK0026"
+	InvalidOperator      Code = "SK0027"
+	InvalidRange         Code = "SK0028"
+	MissingEnumCase      Code = "SK0029"
+	InvalidIndex         Code = "SK0030"
+)
+
+// Entry describes a diagnostic code for saika explain
+type Entry struct {
+	Code  Code
+	Title string
+	// Explanation is a detailed description with examples, in Chinese
+	// followed by English
+	Explanation string
+}
+
+// catalog holds every assigned diagnostic code
+var catalog = map[Code]*Entry{
+	UnexpectedToken: {
+		Code:  UnexpectedToken,
+		Title: "unexpected token",
+		Explanation: `此处需要的记号与实际出现的不同，通常是缺少括号、花括号或分隔符。
+
+错误示例：
+
+    数 入口( {
+    }
+
+函数的参数列表缺少右括号 ")"。修正后：
+
+    数 入口() {
+    }
+
+The parser expected a particular token, such as a closing parenthesis or
+brace, but found a different one. This usually means a delimiter is
+missing or misplaced.
+
+Erroneous example:
+
+    数 入口( {
+    }
+
+The parameter list is missing its closing ")". Corrected:
+
+    数 入口() {
+    }
+`,
+	},
+	ExpectedExpression: {
+		Code:  ExpectedExpression,
+		Title: "expected an expression",
+		Explanation: `此处需要一个表达式，但出现的记号不能作为表达式的开头，例如多余的运算符或右括号。
+
+错误示例：
+
+    变量 x = * 2
+
+"*" 不能作为表达式的开头。修正后：
+
+    变量 x = 3 * 2
+
+The token found cannot start an expression. A value, identifier, call or
+parenthesised expression was expected, but the parser found something
+like a stray operator or closing bracket.
+
+Erroneous example:
+
+    变量 x = * 2
+
+"*" cannot begin an expression. Corrected:
+
+    变量 x = 3 * 2
+`,
+	},
+	InvalidInteger: {
+		Code:  InvalidInteger,
+		Title: "invalid number literal",
+		Explanation: `数字字面量无法解析，通常是数值超出 64 位整数的范围，或进制前缀后没有数字。
+浮点字面量超出 64 位浮点数的范围（如 1e999）时也会报告此错误。
+带单位的字面量（如 0.3纳秒）不是整数个纳秒或字节时同样报告此错误。
+
+错误示例：
+
+    变量 x = 99999999999999999999
+    变量 y = 0x
+
+修正后：
+
+    变量 x = 999999999
+    变量 y = 0xff
+
+An integer literal could not be parsed. Either its value does not fit in a
+64-bit integer, or a radix prefix such as 0x, 0o or 0b is not followed by
+any digits. A float literal out of the range of a 64-bit float, such as
+1e999, is reported the same way, as is a unit literal such as 0.3纳秒
+that is not a whole number of nanoseconds or bytes.
+
+Erroneous example:
+
+    变量 x = 99999999999999999999
+    变量 y = 0x
+
+Corrected:
+
+    变量 x = 999999999
+    变量 y = 0xff
+`,
+	},
+	InvalidImportPath: {
+		Code:  InvalidImportPath,
+		Title: "import path must be a string",
+		Explanation: `导入路径必须写成字符串字面量。
+
+错误示例：
+
+    导入 (
+        fmt
+    )
+
+修正后：
+
+    导入 (
+        "fmt"
+    )
+
+The path of an import must be a string literal.
+
+Erroneous example:
+
+    导入 (
+        fmt
+    )
+
+Corrected:
+
+    导入 (
+        "fmt"
+    )
+`,
+	},
+	UnexpectedIdentifier: {
+		Code:  UnexpectedIdentifier,
+		Title: "unexpected identifier",
+		Explanation: `一个单独的标识符后面在同一行紧跟着另一个标识符或字面量。这通常是关键字拼写错误，
+编译器会给出最接近的关键字作为建议。
+
+错误示例：
+
+    变亮 x = 1
+
+"变亮" 不是关键字，应为 "变量"。修正后：
+
+    变量 x = 1
+
+An identifier on its own is followed by another identifier or literal on
+the same line. This is usually a misspelled keyword; the closest keyword is
+suggested when there is one, and saika fix --apply can replace it.
+
+Erroneous example:
+
+    变亮 x = 1
+
+"变亮" is not a keyword; "变量" was meant. Corrected:
+
+    变量 x = 1
+`,
+	},
+	UnusedImport: {
+		Code:  UnusedImport,
+		Title: "imported package is not used",
+		Explanation: `导入的包在文件中从未使用。Go 不允许未使用的导入，因此需要删除它，
+可以运行 saika fix --apply 自动删除。
+
+错误示例：
+
+    包 main
+    导入 "os"
+
+    数 入口() {
+    }
+
+删除未使用的导入后：
+
+    包 main
+
+    数 入口() {
+    }
+
+A package is imported but never referenced in the file. Go rejects unused
+imports, so the import must be removed; saika fix --apply removes it.
+
+Erroneous example:
+
+    包 main
+    导入 "os"
+
+    数 入口() {
+    }
+
+Corrected by removing the import:
+
+    包 main
+
+    数 入口() {
+    }
+`,
+	},
+	UndefinedName: {
+		Code:  UndefinedName,
+		Title: "undefined name",
+		Explanation: `使用的名称没有在当前作用域、包或导入中声明。常见原因是拼写错误，
+或者在声明之前就使用了局部变量。若有相近的名称，编译器会给出建议。
+
+错误示例：
+
+    数 入口() {
+        变量 总数 = 1
+        格式化.打印行(总树)
+    }
+
+"总树" 未声明，应为 "总数"。修正后：
+
+    数 入口() {
+        变量 总数 = 1
+        格式化.打印行(总数)
+    }
+
+A name is used that is not declared in the enclosing scopes, the package
+or the file's imports. This is usually a typo, or a local variable used
+before its declaration. When a declared name is close, it is suggested and
+saika fix --apply can replace it.
+
+Erroneous example:
+
+    数 入口() {
+        变量 总数 = 1
+        格式化.打印行(总树)
+    }
+
+"总树" is not declared; "总数" was meant. Corrected:
+
+    数 入口() {
+        变量 总数 = 1
+        格式化.打印行(总数)
+    }
+`,
+	},
+	UnknownMember: {
+		Code:  UnknownMember,
+		Title: "unknown member of a translated package",
+		Explanation: `通过中文包名（如 格式化）访问的成员既不是已知的中文译名，也不是已知的 Go 名称。
+若有相近的名称，编译器会给出建议。通过 Go 包名（如 fmt）访问的成员不做此检查。
+
+错误示例：
+
+    导入 "fmt"
+
+    数 入口() {
+        格式化.打印航("你好")
+    }
+
+修正后：
+
+    导入 "fmt"
+
+    数 入口() {
+        格式化.打印行("你好")
+    }
+
+A member used through the Chinese name of a translated standard library
+package, such as 格式化, is neither a known translation nor a known Go
+name. The closest member is suggested when there is one. Members used
+through the Go package name, such as fmt, are not checked.
+
+Erroneous example:
+
+    导入 "fmt"
+
+    数 入口() {
+        格式化.打印航("你好")
+    }
+
+Corrected:
+
+    导入 "fmt"
+
+    数 入口() {
+        格式化.打印行("你好")
+    }
+`,
+	},
+	MissingImport: {
+		Code:  MissingImport,
+		Title: "package used without importing it",
+		Explanation: `代码使用了一个标准库包（中文名或 Go 名均可），但文件没有导入它。
+saika fix --apply 会在包声明或最后一个导入之后添加导入。
+
+错误示例：
+
+    包 main
+
+    数 入口() {
+        格式化.打印行("你好")
+    }
+
+修正后：
+
+    包 main
+    导入 "fmt"
+
+    数 入口() {
+        格式化.打印行("你好")
+    }
+
+A standard library package is used, by its Chinese or Go name, but the
+file does not import it. saika fix --apply adds the import after the
+package clause or the last import.
+
+Erroneous example:
+
+    包 main
+
+    数 入口() {
+        格式化.打印行("你好")
+    }
+
+Corrected:
+
+    包 main
+    导入 "fmt"
+
+    数 入口() {
+        格式化.打印行("你好")
+    }
+`,
+	},
+	DuplicateImport: {
+		Code:  DuplicateImport,
+		Title: "package imported more than once",
+		Explanation: `同一个包被导入了两次，例如同时导入 "fmt" 和它的中文名 "格式化"。
+两种写法导入的是同一个包，只需保留一个。
+
+错误示例：
+
+    导入 "fmt"
+    导入 "格式化"
+
+修正后：
+
+    导入 "fmt"
+
+The same package is imported twice, for example as both "fmt" and its
+Chinese name "格式化". Both spellings import the same package, so only one
+import is needed and the package can still be used by either name.
+
+Erroneous example:
+
+    导入 "fmt"
+    导入 "格式化"
+
+Corrected:
+
+    导入 "fmt"
+`,
+	},
+	MissingTemplate: {
+		Code:  MissingTemplate,
+		Title: "template file not found",
+		Explanation: `渲染模板 或 输出模板 引用的模板文件不存在，或不在源文件所在的目录中。
+文件名是字符串字面量时，转译时会相对于源文件所在的目录检查该文件，并把它
+嵌入程序，因此程序在任何工作目录下都能找到它。
+
+错误示例：
+
+    格式化.打印行(渲染模板("页面.html", 数据))
+
+源文件旁边没有 页面.html。请创建该文件或修正文件名。以 .. 开头的名字和
+绝对路径无法嵌入，请把模板移到源文件所在的目录或其子目录中。
+
+A template file passed to 渲染模板 or 输出模板 does not exist, or is not
+in the directory of the source file. When the file name is a string
+literal, it is checked at transpile time relative to the directory of the
+source file and embedded in the program, which finds it whatever its
+working directory.
+
+Erroneous example:
+
+    格式化.打印行(渲染模板("页面.html", 数据))
+
+There is no 页面.html next to the source file. Create the file or fix its
+name. Names starting with .. and absolute paths cannot be embedded; move
+the template into the directory of the source file or below it.
+`,
+	},
+	MisplacedOption: {
+		Code:  MisplacedOption,
+		Title: "misplaced option",
+		Explanation: `选项 声明的命令行选项在 入口 开始时解析，因此只能在声明 入口 的文件顶层声明。
+
+错误示例：
+
+    数 入口() {
+        选项 端口 整数 = 8080 "监听端口"
+    }
+
+修正后：
+
+    选项 端口 整数 = 8080 "监听端口"
+
+    数 入口() {
+    }
+
+Command-line options declared with 选项 are parsed when 入口 starts, so
+they may only be declared at the top level of the file that declares
+入口.
+
+Erroneous example:
+
+    数 入口() {
+        选项 端口 整数 = 8080 "监听端口"
+    }
+
+Corrected:
+
+    选项 端口 整数 = 8080 "监听端口"
+
+    数 入口() {
+    }
+`,
+	},
+	NonConstantValue: {
+		Code:  NonConstantValue,
+		Title: "value of constant is not constant",
+		Explanation: `常量 的值必须在编译时确定：字面量、其他常量、包中的常量，以及对它们的运算和
+类型转换。函数调用、映射、切片和变量都不是常量。需要在运行时计算的值请用 变量 声明。
+
+错误示例：
+
+    常量 开始 = 时间.现在()
+
+修正后：
+
+    变量 开始 = 时间.现在()
+
+The value of a constant declared with 常量 must be known at compile time:
+literals, other constants, constants of packages, and operators and
+conversions applied to them. Function calls, maps, slices and variables are
+not constant. Declare values computed at run time with 变量.
+
+Erroneous example:
+
+    常量 开始 = 时间.现在()
+
+Corrected:
+
+    变量 开始 = 时间.现在()
+`,
+	},
+	AssignToConstant: {
+		Code:  AssignToConstant,
+		Title: "assignment to constant",
+		Explanation: `常量 声明后不能再赋值，包括 += 等复合赋值。需要修改的值请用 变量 声明。
+
+错误示例：
+
+    常量 上限 = 10
+    上限 = 20
+
+修正后：
+
+    变量 上限 = 10
+    上限 = 20
+
+A constant declared with 常量 cannot be assigned to, including with
+compound assignments such as +=. Declare values that change with 变量.
+
+Erroneous example:
+
+    常量 上限 = 10
+    上限 = 20
+
+Corrected:
+
+    变量 上限 = 10
+    上限 = 20
+`,
+	},
+	InvalidAssignTarget: {
+		Code:  InvalidAssignTarget,
+		Title: "invalid assignment target",
+		Explanation: `赋值号左边必须是可以存储值的位置：变量、成员（如 点.x）或元素（如 表["键"]）。
+字面量、函数调用和运算结果都不能被赋值。这通常是把比较运算 == 误写成了 =。
+
+错误示例：
+
+    如果 5 = x {
+    }
+    取值() = 3
+
+修正后：
+
+    如果 5 == x {
+    }
+    变量 值 = 取值()
+    值 = 3
+
+The left side of an assignment must be a location that can hold a value: a
+variable, a member such as 点.x, or an element such as 表["键"]. Literals,
+function calls and the results of operators cannot be assigned to. Often
+== was meant instead of =.
+
+Erroneous example:
+
+    如果 5 = x {
+    }
+    取值() = 3
+
+Corrected:
+
+    如果 5 == x {
+    }
+    变量 值 = 取值()
+    值 = 3
+`,
+	},
+	Redeclared: {
+		Code:  Redeclared,
+		Title: "name redeclared",
+		Explanation: `同一个包的顶层（包括包内的其他文件）不能两次声明同一个名称，同一个函数的参数也不能同名。
+诊断信息给出了另一处声明的位置。请重命名或删除其中一个声明。
+
+错误示例：
+
+    数 相加(a 整数, a 整数) 整数 {
+        返回 a + a
+    }
+
+    数 相加(a 整数) 整数 {
+        返回 a
+    }
+
+修正后：
+
+    数 相加(a 整数, b 整数) 整数 {
+        返回 a + b
+    }
+
+    数 加一(a 整数) 整数 {
+        返回 a + 1
+    }
+
+A name may be declared only once at the top level of a package, across all
+of its files, and the parameters of a function must have distinct names.
+The message gives the location of the other declaration. Rename or remove
+one of them.
+
+Erroneous example:
+
+    数 相加(a 整数, a 整数) 整数 {
+        返回 a + a
+    }
+
+    数 相加(a 整数) 整数 {
+        返回 a
+    }
+
+Corrected:
+
+    数 相加(a 整数, b 整数) 整数 {
+        返回 a + b
+    }
+
+    数 加一(a 整数) 整数 {
+        返回 a + 1
+    }
+`,
+	},
+	ReturnMismatch: {
+		Code:  ReturnMismatch,
+		Title: "return does not match function result",
+		Explanation: `返回 语句必须与所在函数声明的结果一致：没有声明结果类型的函数（以及 捕获信号
+的处理代码）只能使用不带值的 返回，声明了结果类型的函数必须返回一个值；声明了多个结果
+（如 (整数, 错误)）的函数必须返回同样多的值，或者返回一个提供全部结果的函数调用。返回的
+字面量也必须能作为结果类型的值，例如 整数 函数不能返回字符串字面量。
+
+错误示例：
+
+    数 问候() {
+        返回 "你好"
+    }
+
+    数 长度() 整数 {
+        返回
+    }
+
+修正后：
+
+    数 问候() 字符串 {
+        返回 "你好"
+    }
+
+    数 长度() 整数 {
+        返回 0
+    }
+
+A return statement must match the result declared by its function. A
+function without a result type, and the body of 捕获信号, may only use 返回
+without a value; a function with a result type must return a value. A
+function with several results, such as (整数, 错误), must return as many
+values, or one call providing all of them. A returned literal must also be usable as a value of the result type, so a
+function returning 整数 cannot return a string literal.
+
+Erroneous example:
+
+    数 问候() {
+        返回 "你好"
+    }
+
+    数 长度() 整数 {
+        返回
+    }
+
+Corrected:
+
+    数 问候() 字符串 {
+        返回 "你好"
+    }
+
+    数 长度() 整数 {
+        返回 0
+    }
+`,
+	},
+	CallRequired: {
+		Code:  CallRequired,
+		Title: "function call required",
+		Explanation: `推迟 和 协程 之后必须是一次函数调用。被推迟或在新协程中运行的是这次调用：
+函数和参数立即求值，调用本身在所在函数返回时或在新协程中才执行。要推迟多条语句，
+请把它们写进一个立即调用的匿名函数。
+
+错误示例：
+
+    推迟 文件.关闭
+    推迟 计数 = 0
+
+修正后：
+
+    推迟 文件.关闭()
+    推迟 数() {
+        计数 = 0
+    }()
+
+推迟 and 协程 must be followed by a function call. What is deferred, or
+started in a goroutine, is the call: the function and its arguments are
+evaluated at once, and the call runs when the surrounding function
+returns, or in the new goroutine. To defer several statements, put them
+in a function literal and call it.
+
+Erroneous example:
+
+    推迟 文件.关闭
+    推迟 计数 = 0
+
+Corrected:
+
+    推迟 文件.关闭()
+    推迟 数() {
+        计数 = 0
+    }()
+`,
+	},
+	ChannelOpRequired: {
+		Code:  ChannelOpRequired,
+		Title: "channel operation required",
+		Explanation: `监听 的每个 情况 必须是一次通道操作：发送（通道 <- 值）、接收（<-通道），
+或者把接收到的值赋给变量（变量 值 = <-通道 或 值 = <-通道）。监听 会等到其中一个操作
+可以进行，然后执行那个 情况。
+
+错误示例：
+
+    监听 {
+    情况 计数 > 0:
+        fmt.Println("大于零")
+    }
+
+修正后：
+
+    监听 {
+    情况 变量 值 = <-结果:
+        fmt.Println(值)
+    情况 <-完成:
+        返回
+    }
+
+Each 情况 of a 监听 statement must be a channel operation: a send
+(通道 <- 值), a receive (<-通道), or a receive whose value is stored in a
+variable (变量 值 = <-通道 or 值 = <-通道). 监听 waits until one of the
+operations can proceed and then runs that case.
+
+Erroneous example:
+
+    监听 {
+    情况 计数 > 0:
+        fmt.Println("大于零")
+    }
+
+Corrected:
+
+    监听 {
+    情况 变量 值 = <-结果:
+        fmt.Println(值)
+    情况 <-完成:
+        返回
+    }
+`,
+	},
+	DeferInLoop: {
+		Code:  DeferInLoop,
+		Title: "defer in loop",
+		Explanation: `推迟 的调用在所在函数返回时才执行，而不是在每次循环结束时执行。在循环中推迟
+关闭文件之类的清理操作，会让所有资源一直保持打开，直到函数返回。这是一条 saika vet
+警告。
+
+警告示例：
+
+    循环 变量 i = 0; i < len(文件名); i += 1 {
+        变量 f = 打开(文件名[i])
+        推迟 f.Close()
+        处理(f)
+    }
+
+把循环体移到一个函数中，使每次调用结束时执行清理：
+
+    数 处理文件(名 字符串) {
+        变量 f = 打开(名)
+        推迟 f.Close()
+        处理(f)
+    }
+
+    循环 变量 i = 0; i < len(文件名); i += 1 {
+        处理文件(文件名[i])
+    }
+
+A call deferred with 推迟 runs when the enclosing function returns, not at
+the end of each loop iteration. Deferring cleanup such as closing a file
+in a loop keeps every resource open until the function returns. This is a
+saika vet warning.
+
+Example:
+
+    循环 变量 i = 0; i < len(文件名); i += 1 {
+        变量 f = 打开(文件名[i])
+        推迟 f.Close()
+        处理(f)
+    }
+
+Move the body of the loop into a function, so that the cleanup runs at
+the end of each call:
+
+    数 处理文件(名 字符串) {
+        变量 f = 打开(名)
+        推迟 f.Close()
+        处理(f)
+    }
+
+    循环 变量 i = 0; i < len(文件名); i += 1 {
+        处理文件(文件名[i])
+    }
+`,
+	},
+	MissingMethod: {
+		Code:  MissingMethod,
+		Title: "type does not implement interface",
+		Explanation: `接口类型的变量、参数和结果只能保存具有接口全部方法的值。报告会列出缺少的方法。
+会修改接收者的方法在 Go 中属于指向值的指针，而不属于值本身，所以只有这样的方法的
+类型也不能作为该接口的值。
+
+错误示例：
+
+    接口 形状 {
+        面积() 浮点
+    }
+
+    类型 圆 结构 {
+        半径 浮点
+    }
+
+    变量 s 形状 = 圆{半径: 1.0}
+
+圆 没有 面积 方法，不能作为 形状 的值。修正后，为 圆 声明这个方法：
+
+    数 (c 圆) 面积() 浮点 {
+        返回 3.14 * c.半径 * c.半径
+    }
+
+A variable, parameter or result of an interface type only holds values
+that have every method of the interface. The missing methods are
+listed. A method that changes its receiver belongs to a pointer to the
+value in Go rather than to the value, so a type with such a method
+cannot be used as the interface either.
+
+Erroneous example:
+
+    接口 形状 {
+        面积() 浮点
+    }
+
+    类型 圆 结构 {
+        半径 浮点
+    }
+
+    变量 s 形状 = 圆{半径: 1.0}
+
+圆 has no method 面积, so it cannot be used as a 形状. Corrected, by
+declaring the method on 圆:
+
+    数 (c 圆) 面积() 浮点 {
+        返回 3.14 * c.半径 * c.半径
+    }
+`,
+	},
+	NamingConvention: {
+		Code:  NamingConvention,
+		Title: "name breaks the project's naming convention",
+		Explanation: `项目文件 saika.json 的 "naming" 对象规定了函数、类型、常量和变量的名字可以使用的
+写法：chinese（汉字和数字）、camelCase、PascalCase 或 snake_case，以及是否禁止在一个
+名字中混用汉字和拉丁字母。saika vet 对不符合规定的声明给出警告。入口、init、main 和 _
+不受限制。
+
+配置示例：
+
+    "naming": {
+        "functions": ["chinese", "camelCase"],
+        "forbidMixedScripts": true
+    }
+
+警告示例：
+
+    数 Get用户() {
+    }
+
+"Get用户" 混用了汉字和拉丁字母。修正后：
+
+    数 获取用户() {
+    }
+
+The "naming" object of the project file, saika.json, lists the styles
+the names of functions, types, constants and variables may be written
+in: chinese (Chinese characters and digits), camelCase, PascalCase or
+snake_case, and whether a name may mix Chinese and Latin letters.
+saika vet warns about the declarations that break them. 入口, init,
+main and _ are exempt.
+
+Example configuration:
+
+    "naming": {
+        "functions": ["chinese", "camelCase"],
+        "forbidMixedScripts": true
+    }
+
+Example:
+
+    数 Get用户() {
+    }
+
+"Get用户" mixes Chinese and Latin letters. Corrected:
+
+    数 获取用户() {
+    }
+`,
+	},
+	InvalidString: {
+		Code:  InvalidString,
+		Title: "invalid string literal",
+		Explanation: `字符串字面量中的转义序列与 Go 相同：\n 换行、\t 制表符、\" 引号、\\ 反斜杠、
+\u4e2d 等 Unicode 码点。其他跟在反斜杠后的字符不是转义序列。字符串也不能跨行，
+换行要写成 \n。
+
+错误示例：
+
+    变量 路径 = "C:\新建"
+
+修正后：
+
+    变量 路径 = "C:\\新建"
+
+String literals take the escape sequences of Go: \n for a line break, \t
+for a tab, \" for a quote, \\ for a backslash and code points such as
+\u4e2d. A backslash followed by any other character is an error, as is a
+string that runs on to the next line; write \n for a line break instead.
+
+Erroneous example:
+
+    变量 路径 = "C:\新建"
+
+Corrected:
+
+    变量 路径 = "C:\\新建"
+`,
+	},
+	UnknownStringType: {
+		Code:  UnknownStringType,
+		Title: "operand of unknown type",
+		Explanation: `Saika 按字符而不是按字节对字符串取下标、切片和求长度，因此需要知道操作数
+是不是字符串。标准库函数和泛型函数的结果有已知的类型；其他 Go 包中的值等
+类型未知的值，如果是字符串就会按字节处理，所以这是错误。先把值赋给声明了
+类型的变量即可。
+
+错误示例：
+
+    变量 路径 = url.PathEscape(名)
+    fmt.Println(len(路径))
+
+修正后：
+
+    变量 路径 字符串 = url.PathEscape(名)
+    fmt.Println(len(路径))
+
+Saika indexes, slices and measures strings by character rather than by
+byte, so it needs to know whether the operand is a string. Results of
+standard library and generic functions have known types; values whose
+type is unknown, such as other members of Go packages, would be handled
+by byte if they were strings, so this is an error. Assign the value to a
+variable declared with a type.
+
+Example:
+
+    变量 路径 = url.PathEscape(名)
+    fmt.Println(len(路径))
+
+Corrected:
+
+    变量 路径 字符串 = url.PathEscape(名)
+    fmt.Println(len(路径))
+`,
+	},
+	InvalidReceiver: {
+		Code:  InvalidReceiver,
+		Title: "invalid method receiver",
+		Explanation: `方法只能声明在本包的结构类型上，且方法名不能与字段或其他方法重名。
+给接收者或它的字段赋值的方法会修改调用它的变量，所以只能在变量、字段或
+元素上调用，不能在函数结果等临时值上调用。
+
+错误示例：
+
+    数 (n 整数) 翻倍() 整数 {
+        返回 n * 2
+    }
+
+修正后：
+
+    类型 计数 结构 {
+        值 整数
+    }
+
+    数 (n 计数) 翻倍() 整数 {
+        返回 n.值 * 2
+    }
+
+Methods can only be declared on struct types of the same package, and a
+method cannot share its name with a field or another method of the type.
+A method that assigns to its receiver or a field of it changes the
+variable it is called on, so it can only be called on a variable, field
+or element, not on a temporary value such as the result of a call.
+
+Erroneous example:
+
+    数 (n 整数) 翻倍() 整数 {
+        返回 n * 2
+    }
+
+Corrected:
+
+    类型 计数 结构 {
+        值 整数
+    }
+
+    数 (n 计数) 翻倍() 整数 {
+        返回 n.值 * 2
+    }
+`,
+	},
+	InvalidOperator: {
+		Code:  InvalidOperator,
+		Title: "invalid overloaded operator",
+		Explanation: `语言版本 1.1 起，结构类型可以用方法 加、减、乘、除、等于 重载运算符
++、-、*、/、== 和 !=。两个操作数必须是同一结构类型，运算符方法只能有一个
+同类型的参数，等于 必须返回 布尔。在 saika.json 中设置 "language": "1.1"
+启用重载。
+
+错误示例：
+
+    变量 和 = 向量{X: 1} + 向量{X: 2}
+
+修正后（saika.json）：
+
+    {
+        "language": "1.1"
+    }
+
+From language version 1.1, struct types can overload the operators +, -,
+*, /, == and != with the methods 加, 减, 乘, 除 and 等于. Both operands
+must be of the same struct type, an operator method takes exactly one
+parameter of that type, and 等于 must return 布尔. Set "language": "1.1"
+in saika.json to enable overloading.
+
+Erroneous example:
+
+    变量 和 = 向量{X: 1} + 向量{X: 2}
+
+Corrected (saika.json):
+
+    {
+        "language": "1.1"
+    }
+`,
+	},
+	InvalidRange: {
+		Code:  InvalidRange,
+		Title: "invalid range",
+		Explanation: `范围 循环遍历切片或数组的元素，或迭代器的值。迭代器是有方法 下一个() 布尔
+和 值() 的结构类型：下一个 移到下一个值并报告是否还有值，值 返回当前的值。字符串的字符用 逐字符 遍历。
+
+错误示例：
+
+    范围 (字) = "你好" {
+        fmt.Println(字)
+    }
+
+修正后：
+
+    逐字符 (字) = "你好" {
+        fmt.Println(字)
+    }
+
+A 范围 loop ranges over the elements of a slice or array, or the values
+of an iterator: a struct type with the methods 下一个() 布尔, which moves
+to the next value and reports whether there is one, and 值(), which
+returns it. Range over the characters of a string with 逐字符.
+
+Erroneous example:
+
+    范围 (字) = "你好" {
+        fmt.Println(字)
+    }
+
+Corrected:
+
+    逐字符 (字) = "你好" {
+        fmt.Println(字)
+    }
+`,
+	},
+	MissingEnumCase: {
+		Code:  MissingEnumCase,
+		Title: "选择 misses members of an enumeration",
+		Explanation: `选择 一个枚举类型的值时，没有 默认 的 选择 应当为每个成员写一个 情况。漏掉的成员
+不会执行任何情况，给枚举添加成员后也容易忘记处理它。这是一条 saika vet 警告，
+saika fix 会为漏掉的成员补上空的 情况。
+
+警告示例：
+
+    枚举 颜色 { 红, 绿, 蓝 }
+
+    选择 c {
+    情况 红:
+        fmt.Println("停")
+    情况 绿:
+        fmt.Println("行")
+    }
+
+修正后：
+
+    选择 c {
+    情况 红:
+        fmt.Println("停")
+    情况 绿:
+        fmt.Println("行")
+    情况 蓝:
+    }
+
+若确实只需处理部分成员，用 默认 说明其余成员的处理方式。
+
+A 选择 on a value of an enumeration without a 默认 clause should have a
+情况 for every member: a missing member runs no case, and a member added
+to the enumeration later is easily left unhandled. This is a saika vet
+warning; saika fix adds an empty 情况 for each missing member.
+
+Example:
+
+    枚举 颜色 { 红, 绿, 蓝 }
+
+    选择 c {
+    情况 红:
+        fmt.Println("停")
+    情况 绿:
+        fmt.Println("行")
+    }
+
+Corrected:
+
+    选择 c {
+    情况 红:
+        fmt.Println("停")
+    情况 绿:
+        fmt.Println("行")
+    情况 蓝:
+    }
+
+When only some members need handling, say what the others do with 默认.
+`,
+	},
+	InvalidIndex: {
+		Code:  InvalidIndex,
+		Title: "invalid index",
+		Explanation: `切片、数组和字符串的下标必须是整数，常量下标不能是负数，也不能超出数组的长度。
+整数、浮点数和布尔值不能取下标。
+
+错误示例：
+
+    变量 分数 数组[3]整数
+    分数[3] = 100
+    fmt.Println(分数["一"])
+
+修正后：
+
+    变量 分数 数组[3]整数
+    分数[2] = 100
+    fmt.Println(分数[0])
+
+The index of a slice, an array or a string must be an integer, and a
+constant index must not be negative or past the end of an array.
+Integers, floats and booleans cannot be indexed.
+
+Erroneous example:
+
+    变量 分数 数组[3]整数
+    分数[3] = 100
+    fmt.Println(分数["一"])
+
+Corrected:
+
+    变量 分数 数组[3]整数
+    分数[2] = 100
+    fmt.Println(分数[0])
+`,
+	},
+}
+
+// Lookup returns the catalog entry for code
+func Lookup(code Code) (*Entry, bool) {
+	entry, ok := catalog[code]
+	return entry, ok
+}
+
+// Codes returns every assigned code in order
+func Codes() []Code {
+	codes := make([]Code, 0, len(catalog))
+	for code := range catalog {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	return codes
+}
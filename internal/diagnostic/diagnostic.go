@@ -0,0 +1,121 @@
+package diagnostic
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+)
+
+// Severity represents how serious a diagnostic is
+type Severity int
+
+// Severity levels
+const (
+	Error Severity = iota
+	Warning
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Error:
+		return "error"
+	case Warning:
+		return "warning"
+	default:
+		return "unknown"
+	}
+}
+
+// Diagnostic represents a problem found in Saika source code. Every
+// diagnostic carries the source range it refers to and a stable code.
+type Diagnostic struct {
+	Severity Severity
+	Code     Code
+	Range    ast.Range
+	Message  string
+	Fixes    []Fix // machine-applicable fixes, best first
+}
+
+// New creates a diagnostic covering the given range
+func New(severity Severity, code Code, rng ast.Range, format string, args ...interface{}) *Diagnostic {
+	return &Diagnostic{
+		Severity: severity,
+		Code:     code,
+		Range:    rng,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+// AtToken creates an error diagnostic covering the given token
+func AtToken(code Code, tok ast.Token, format string, args ...interface{}) *Diagnostic {
+	return New(Error, code, ast.TokenRange(tok), format, args...)
+}
+
+// AtNode creates an error diagnostic covering the given node
+func AtNode(code Code, node ast.Node, format string, args ...interface{}) *Diagnostic {
+	return New(Error, code, ast.NodeRange(node), format, args...)
+}
+
+// Pos returns the start position of the diagnostic
+func (d *Diagnostic) Pos() ast.Position {
+	return d.Range.Start
+}
+
+// String formats the diagnostic as "Line <line>:<column> [<code>] <message>"
+func (d *Diagnostic) String() string {
+	prefix := fmt.Sprintf("Line %d:%d ", d.Range.Start.Line, d.Range.Start.Column)
+	if d.Severity == Warning {
+		prefix += "warning: "
+	}
+	if d.Code != "" {
+		prefix += "[" + string(d.Code) + "] "
+	}
+	return prefix + d.Message
+}
+
+// Error implements the error interface
+func (d *Diagnostic) Error() string {
+	return d.String()
+}
+
+// List is a list of diagnostics that can be returned as an error
+type List []*Diagnostic
+
+// Error implements the error interface, printing one diagnostic per line
+func (l List) Error() string {
+	lines := make([]string, len(l))
+	for i, d := range l {
+		lines[i] = d.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Strings returns each diagnostic formatted as a string
+func (l List) Strings() []string {
+	out := make([]string, len(l))
+	for i, d := range l {
+		out[i] = d.String()
+	}
+	return out
+}
+
+// HasErrors reports whether the list contains at least one error
+func (l List) HasErrors() bool {
+	for _, d := range l {
+		if d.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
+
+// FirstCode returns the code of the first diagnostic that has one, or ""
+func (l List) FirstCode() Code {
+	for _, d := range l {
+		if d.Code != "" {
+			return d.Code
+		}
+	}
+	return ""
+}
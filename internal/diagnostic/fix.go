@@ -0,0 +1,134 @@
+package diagnostic
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+)
+
+// TextEdit replaces the source covered by Range with NewText. An empty
+// range inserts NewText; an empty NewText deletes the range.
+type TextEdit struct {
+	Range   ast.Range
+	NewText string
+}
+
+// Fix is a machine-applicable change that resolves a diagnostic
+type Fix struct {
+	Title string // short description, e.g. `insert ";"`
+	Edits []TextEdit
+}
+
+// WithFix attaches a fix to the diagnostic and returns it
+func (d *Diagnostic) WithFix(title string, edits ...TextEdit) *Diagnostic {
+	d.Fixes = append(d.Fixes, Fix{Title: title, Edits: edits})
+	return d
+}
+
+// Insert returns an edit inserting text at pos
+func Insert(pos ast.Position, text string) TextEdit {
+	return TextEdit{Range: ast.Range{Start: pos, End: pos}, NewText: text}
+}
+
+// Replace returns an edit replacing the range with text
+func Replace(rng ast.Range, text string) TextEdit {
+	return TextEdit{Range: rng, NewText: text}
+}
+
+// Delete returns an edit removing the range
+func Delete(rng ast.Range) TextEdit {
+	return TextEdit{Range: rng}
+}
+
+// ApplyFixes applies the first fix of every diagnostic that has one to src
+// and returns the result along with the number of fixes applied. A fix whose
+// edits overlap those of an earlier fix is skipped; running the fixer again
+// picks it up once the earlier fix has been applied.
+func ApplyFixes(src []byte, diags List) ([]byte, int, error) {
+	var edits []TextEdit
+	applied := 0
+	for _, d := range diags {
+		if len(d.Fixes) == 0 {
+			continue
+		}
+		fix := d.Fixes[0]
+		if overlapsAny(fix.Edits, edits) {
+			continue
+		}
+		edits = append(edits, fix.Edits...)
+		applied++
+	}
+
+	out, err := ApplyEdits(src, edits)
+	if err != nil {
+		return nil, 0, err
+	}
+	return out, applied, nil
+}
+
+// ApplyEdits applies non-overlapping edits to src. A deletion that leaves
+// its line blank removes the whole line, so deleting a statement does not
+// leave an empty line behind.
+func ApplyEdits(src []byte, edits []TextEdit) ([]byte, error) {
+	sorted := make([]TextEdit, len(edits))
+	copy(sorted, edits)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Range.Start.Offset < sorted[j].Range.Start.Offset
+	})
+
+	out := make([]byte, 0, len(src))
+	last := 0
+	for _, edit := range sorted {
+		start, end := edit.Range.Start.Offset, edit.Range.End.Offset
+		if start < last || end < start || end > len(src) {
+			return nil, fmt.Errorf("invalid or overlapping edit at line %d:%d", edit.Range.Start.Line, edit.Range.Start.Column)
+		}
+		if edit.NewText == "" {
+			start, end = expandToLine(src, start, end, last)
+		}
+		out = append(out, src[last:start]...)
+		out = append(out, edit.NewText...)
+		last = end
+	}
+	return append(out, src[last:]...), nil
+}
+
+// expandToLine widens a deletion to its whole line, including the line
+// break, when nothing but blanks would remain on the line
+func expandToLine(src []byte, start, end, floor int) (int, int) {
+	lineStart := start
+	for lineStart > floor && (src[lineStart-1] == ' ' || src[lineStart-1] == '\t') {
+		lineStart--
+	}
+	if lineStart > 0 && src[lineStart-1] != '\n' {
+		return start, end
+	}
+
+	lineEnd := end
+	for lineEnd < len(src) && (src[lineEnd] == ' ' || src[lineEnd] == '\t' || src[lineEnd] == '\r') {
+		lineEnd++
+	}
+	switch {
+	case lineEnd == len(src):
+		return lineStart, lineEnd
+	case src[lineEnd] == '\n':
+		return lineStart, lineEnd + 1
+	}
+	return start, end
+}
+
+// overlapsAny reports whether any edit in a overlaps or touches any edit
+// in b. Touching edits are kept apart because an insertion at the edge of
+// a deletion may be swallowed when the deletion widens to its whole line,
+// and two insertions at one place have no defined order.
+func overlapsAny(a, b []TextEdit) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x.Range.Start.Offset <= y.Range.End.Offset && y.Range.Start.Offset <= x.Range.End.Offset {
+				return true
+			}
+		}
+	}
+	return false
+}
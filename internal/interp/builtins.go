@@ -0,0 +1,283 @@
+package interp
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"math"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/saika-m/saika-lang/internal/codegen"
+	"github.com/saika-m/saika-lang/internal/stdlib"
+)
+
+// builtins holds the predeclared Go functions the interpreter provides
+var builtins = map[string]any{
+	"len":                    reflect.ValueOf(func(v any) int { return reflect.ValueOf(v).Len() }),
+	"cap":                    reflect.ValueOf(func(v any) int { return reflect.ValueOf(v).Cap() }),
+	"close":                  reflect.ValueOf(func(ch any) { reflect.ValueOf(ch).Close() }),
+	codegen.MakeName:         reflect.ValueOf(makeValue),
+	codegen.OpenDatabaseName: reflect.ValueOf(openDatabase),
+	codegen.RuneAtName:       reflect.ValueOf(func(s string, i int) string { return string([]rune(s)[i]) }),
+	codegen.RuneCountName:    reflect.ValueOf(utf8.RuneCountInString),
+	codegen.RuneSliceName:    reflect.ValueOf(func(s string, i, j int) string { return string([]rune(s)[i:j]) }),
+	codegen.RunesFromName:    reflect.ValueOf(func(s string, i int) string { return string([]rune(s)[i:]) }),
+	codegen.BytesName:        reflect.ValueOf(func(s string) []byte { return []byte(s) }),
+	codegen.CharCountName:    reflect.ValueOf(utf8.RuneCountInString),
+	codegen.SubstringName:    reflect.ValueOf(func(s string, i, j int) string { return string([]rune(s)[i:j]) }),
+}
+
+// appendValues appends values to the slice s as Go's append does. With
+// spread, values is a single slice, or a string appended to a byte slice.
+func appendValues(s any, values []any, spread bool) (any, error) {
+	v := reflect.ValueOf(s)
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("first argument must be a slice; have %s", typeName(s))
+	}
+	if spread {
+		if len(values) != 1 {
+			return nil, fmt.Errorf("can only use ... with final argument")
+		}
+		more, err := sliceOf(values[0], v.Type())
+		if err != nil {
+			return nil, err
+		}
+		return reflect.AppendSlice(v, more).Interface(), nil
+	}
+	for _, value := range values {
+		elem, err := convertValue(value, v.Type().Elem())
+		if err != nil {
+			return nil, err
+		}
+		v = reflect.Append(v, elem)
+	}
+	return v.Interface(), nil
+}
+
+// copyValues copies the elements of the slice src, or the bytes of a
+// string, to the slice dst as Go's copy does, returning their number
+func copyValues(dst, src any) (int, error) {
+	d := reflect.ValueOf(dst)
+	if d.Kind() != reflect.Slice {
+		return 0, fmt.Errorf("destination must be a slice; have %s", typeName(dst))
+	}
+	s, err := sliceOf(src, d.Type())
+	if err != nil {
+		return 0, err
+	}
+	return reflect.Copy(d, s), nil
+}
+
+// sliceOf returns value, a slice or a string given for a byte slice, as a
+// slice of type t
+func sliceOf(value any, t reflect.Type) (reflect.Value, error) {
+	if s, ok := value.(string); ok && t.Elem().Kind() == reflect.Uint8 {
+		return reflect.ValueOf([]byte(s)), nil
+	}
+	return convertValue(value, t)
+}
+
+// deleteKey deletes the element of the map m with key, as Go's delete does
+func deleteKey(m, key any) error {
+	v := reflect.ValueOf(m)
+	if v.Kind() != reflect.Map {
+		return fmt.Errorf("first argument must be a map; have %s", typeName(m))
+	}
+	k, err := convertValue(key, v.Type().Key())
+	if err != nil {
+		return err
+	}
+	v.SetMapIndex(k, reflect.Value{})
+	return nil
+}
+
+// goPackage is an imported Go package
+type goPackage struct {
+	pkg     *stdlib.Package // nil for a package without Chinese names
+	names   []string        // names the package is referred to by
+	members map[string]any  // Go member names to values
+}
+
+// member returns a member given by its Chinese or Go name
+func (p *goPackage) member(name string) (any, bool) {
+	if p.pkg != nil {
+		if goName, ok := p.pkg.Member(name); ok {
+			name = goName
+		}
+	}
+	value, ok := p.members[name]
+	return value, ok
+}
+
+// importPackage resolves an import path, given in Go or in Chinese, to
+// one of the packages the interpreter provides
+func (r *run) importPackage(importPath string) (*goPackage, error) {
+	goPath := stdlib.GoPath(importPath)
+	members, ok := r.packages[goPath]
+	if !ok {
+		return nil, fmt.Errorf("package %q is not supported by the interpreter", importPath)
+	}
+
+	p := &goPackage{members: members, names: []string{path.Base(goPath)}}
+	if pkg, ok := stdlib.LookupPath(goPath); ok {
+		p.pkg = pkg
+		p.names = append(p.names, pkg.Name)
+	}
+	return p, nil
+}
+
+// goPackages returns the members of the Go packages available to programs.
+// Functions are held as reflect values and called through callGo, and types
+// as reflect types; output goes to the interpreter's Stdout, and sleeping or
+// exiting stops with the run.
+func goPackages(r *run) map[string]map[string]any {
+	fn := reflect.ValueOf
+	stdout := r.interp.Stdout
+
+	return map[string]map[string]any{
+		"fmt": {
+			"Print":    fn(func(a ...any) (int, error) { return fmt.Fprint(stdout, a...) }),
+			"Println":  fn(func(a ...any) (int, error) { return fmt.Fprintln(stdout, a...) }),
+			"Printf":   fn(func(format string, a ...any) (int, error) { return fmt.Fprintf(stdout, format, a...) }),
+			"Sprintf":  fn(fmt.Sprintf),
+			"Sprint":   fn(fmt.Sprint),
+			"Sprintln": fn(fmt.Sprintln),
+			"Errorf":   fn(fmt.Errorf),
+		},
+		"math": {
+			"Abs":   fn(math.Abs),
+			"Sqrt":  fn(math.Sqrt),
+			"Pow":   fn(math.Pow),
+			"Max":   fn(math.Max),
+			"Min":   fn(math.Min),
+			"Floor": fn(math.Floor),
+			"Ceil":  fn(math.Ceil),
+			"Round": fn(math.Round),
+			"Trunc": fn(math.Trunc),
+			"Pi":    math.Pi,
+			"E":     math.E,
+		},
+		"errors": {
+			"New":    fn(errors.New),
+			"Is":     fn(errors.Is),
+			"Unwrap": fn(errors.Unwrap),
+			"Join":   fn(errors.Join),
+		},
+		"strings": {
+			"Contains":   fn(strings.Contains),
+			"Split":      fn(strings.Split),
+			"Join":       fn(strings.Join),
+			"ReplaceAll": fn(strings.ReplaceAll),
+			"Repeat":     fn(strings.Repeat),
+			"ToUpper":    fn(strings.ToUpper),
+			"ToLower":    fn(strings.ToLower),
+			"TrimSpace":  fn(strings.TrimSpace),
+			"HasPrefix":  fn(strings.HasPrefix),
+			"HasSuffix":  fn(strings.HasSuffix),
+			"Index":      fn(strings.Index),
+			"Fields":     fn(strings.Fields),
+		},
+		"sort": {
+			"Slice":       fn(sort.Slice),
+			"SliceStable": fn(sort.SliceStable),
+			"Ints":        fn(sort.Ints),
+			"Strings":     fn(sort.Strings),
+		},
+		"strconv": {
+			"Atoi":       fn(strconv.Atoi),
+			"Itoa":       fn(strconv.Itoa),
+			"ParseFloat": fn(strconv.ParseFloat),
+			"Quote":      fn(strconv.Quote),
+		},
+		"time": {
+			"Now":   fn(time.Now),
+			"Since": fn(time.Since),
+			"After": fn(time.After),
+			"Tick":  fn(time.Tick),
+			"Sleep": fn(func(d time.Duration) {
+				select {
+				case <-time.After(d):
+				case <-r.ctx.Done():
+				}
+			}),
+			"Nanosecond":  time.Nanosecond,
+			"Microsecond": time.Microsecond,
+			"Millisecond": time.Millisecond,
+			"Second":      time.Second,
+			"Minute":      time.Minute,
+			"Hour":        time.Hour,
+			"Duration":    reflect.TypeOf(time.Duration(0)),
+			"Time":        reflect.TypeOf(time.Time{}),
+		},
+		"os": {
+			"Exit":   fn(func(code int) { panic(exitPanic(code)) }),
+			"Args":   r.interp.Args,
+			"Getenv": fn(os.Getenv),
+			"Stdout": stdout,
+			"Stderr": os.Stderr,
+		},
+		"io/fs": {
+			"ReadFile": fn(fs.ReadFile),
+			"ReadDir":  fn(fs.ReadDir),
+			"Glob":     fn(fs.Glob),
+			"Sub":      fn(fs.Sub),
+		},
+		"log/slog": slogMembers(),
+	}
+}
+
+// slogMembers returns the members of log/slog. The default logger belongs
+// to the run, so that 日志.设置默认 does not change the interpreter's own.
+func slogMembers() map[string]any {
+	fn := reflect.ValueOf
+	logger := slog.Default()
+
+	return map[string]any{
+		"Debug":          fn(func(msg string, args ...any) { logger.Debug(msg, args...) }),
+		"Info":           fn(func(msg string, args ...any) { logger.Info(msg, args...) }),
+		"Warn":           fn(func(msg string, args ...any) { logger.Warn(msg, args...) }),
+		"Error":          fn(func(msg string, args ...any) { logger.Error(msg, args...) }),
+		"Group":          fn(slog.Group),
+		"With":           fn(func(args ...any) *slog.Logger { return logger.With(args...) }),
+		"New":            fn(slog.New),
+		"Default":        fn(func() *slog.Logger { return logger }),
+		"SetDefault":     fn(func(l *slog.Logger) { logger = l }),
+		"NewTextHandler": fn(slog.NewTextHandler),
+		"NewJSONHandler": fn(slog.NewJSONHandler),
+	}
+}
+
+// makeValue creates a channel, map or slice of type t as 创建 does; sizes
+// gives the buffer of a channel, or the length and capacity of a slice
+func makeValue(t reflect.Type, sizes ...int) any {
+	size := func(i int) int {
+		if i < len(sizes) {
+			return sizes[i]
+		}
+		return 0
+	}
+	switch t.Kind() {
+	case reflect.Chan:
+		return reflect.MakeChan(t, size(0)).Interface()
+	case reflect.Map:
+		return reflect.MakeMapWithSize(t, size(0)).Interface()
+	case reflect.Slice:
+		if len(sizes) < 1 {
+			panic(fmt.Sprintf("invalid operation: %s expects 2 or 3 arguments for %s", codegen.MakeName, t))
+		}
+		capacity := size(0)
+		if len(sizes) > 1 {
+			capacity = size(1)
+		}
+		return reflect.MakeSlice(t, size(0), capacity).Interface()
+	}
+	panic(fmt.Sprintf("invalid argument: cannot make %s", t))
+}
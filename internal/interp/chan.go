@@ -0,0 +1,164 @@
+package interp
+
+import (
+	"reflect"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+)
+
+// receive receives a value from a channel, and whether it was sent rather
+// than the zero value of a closed channel. A receive that blocks gives up
+// once the run ends, as when it is interrupted; unlike a compiled program,
+// the interpreter does not detect that every goroutine is blocked.
+func (r *run) receive(ch any, file *fileEnv, at ast.Position) (any, bool, error) {
+	v := reflect.ValueOf(ch)
+	if v.Kind() != reflect.Chan {
+		return nil, false, r.errorf(file, at, "invalid operation: cannot receive from non-channel %s", typeName(ch))
+	}
+	chosen, value, ok := reflect.Select([]reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: v},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(r.ctx.Done())},
+	})
+	if chosen == 1 {
+		return nil, false, r.ctx.Err()
+	}
+	return value.Interface(), ok, nil
+}
+
+// send sends value on a channel, giving up once the run ends
+func (r *run) send(ch, value any, file *fileEnv, at ast.Position) (err error) {
+	v := reflect.ValueOf(ch)
+	if v.Kind() != reflect.Chan {
+		return r.errorf(file, at, "invalid operation: cannot send to non-channel %s", typeName(ch))
+	}
+	elem, err := convertValue(value, v.Type().Elem())
+	if err != nil {
+		return r.errorf(file, at, "%v", err)
+	}
+
+	// Sending on a closed channel panics, as in Go
+	defer func() {
+		if p := recover(); p != nil {
+			err = r.errorf(file, at, "panic: %v", p)
+		}
+	}()
+	chosen, _, _ := reflect.Select([]reflect.SelectCase{
+		{Dir: reflect.SelectSend, Chan: v, Send: elem},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(r.ctx.Done())},
+	})
+	if chosen == 1 {
+		return r.ctx.Err()
+	}
+	return nil
+}
+
+// selectStatement runs a 监听 statement: it waits until the channel
+// operation of one of its cases can proceed, picking one at random when
+// several can, performs it and runs the statements of that case. The
+// channels and the values sent are evaluated first, in source order.
+func (r *run) selectStatement(stmt *ast.SelectStatement, e *env, file *fileEnv) (*returned, error) {
+	var (
+		cases       []reflect.SelectCase
+		clauses     []*ast.SelectCase
+		defaultCase *ast.SelectCase
+	)
+	for _, clause := range stmt.Cases {
+		if clause.Comm == nil {
+			defaultCase = clause
+			continue
+		}
+		c, err := r.selectCase(clause, e, file)
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, c)
+		clauses = append(clauses, clause)
+	}
+	// Without 默认, waiting gives up once the run ends
+	if defaultCase != nil {
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectDefault})
+	} else {
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(r.ctx.Done())})
+	}
+
+	// Sending on a closed channel panics, as in Go
+	chosen, value, err := func() (chosen int, value reflect.Value, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = r.errorf(file, stmt.Token.Position, "panic: %v", p)
+			}
+		}()
+		chosen, value, _ = reflect.Select(cases)
+		return chosen, value, nil
+	}()
+	if err != nil {
+		return nil, err
+	}
+
+	scope := newEnv(e)
+	clause := defaultCase
+	if chosen < len(clauses) {
+		clause = clauses[chosen]
+		if err := r.received(clause.Comm, value, scope, file); err != nil {
+			return nil, err
+		}
+	} else if defaultCase == nil {
+		return nil, r.ctx.Err()
+	}
+	return r.block(&ast.BlockStatement{Statements: clause.Body}, scope, file)
+}
+
+// selectCase evaluates the channel, and the value for a send, of the
+// channel operation of a 情况 clause
+func (r *run) selectCase(clause *ast.SelectCase, e *env, file *fileEnv) (reflect.SelectCase, error) {
+	if send, ok := clause.Comm.(*ast.SendStatement); ok {
+		ch, err := r.channel(send.Channel, e, file)
+		if err != nil {
+			return reflect.SelectCase{}, err
+		}
+		value, err := r.eval(send.Value, e, file)
+		if err != nil {
+			return reflect.SelectCase{}, err
+		}
+		elem, err := convertValue(value, ch.Type().Elem())
+		if err != nil {
+			return reflect.SelectCase{}, r.errorf(file, send.Token.Position, "%v", err)
+		}
+		return reflect.SelectCase{Dir: reflect.SelectSend, Chan: ch, Send: elem}, nil
+	}
+
+	ch, err := r.channel(ast.Receive(clause.Comm).Right, e, file)
+	if err != nil {
+		return reflect.SelectCase{}, err
+	}
+	return reflect.SelectCase{Dir: reflect.SelectRecv, Chan: ch}, nil
+}
+
+// channel evaluates an expression that must give a channel
+func (r *run) channel(expr ast.Expression, e *env, file *fileEnv) (reflect.Value, error) {
+	value, err := r.eval(expr, e, file)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Chan {
+		return reflect.Value{}, r.errorf(file, positionOf(expr), "invalid operation: %s (%s) is not a channel", expr.String(), typeName(value))
+	}
+	return v, nil
+}
+
+// received stores the value received by the channel operation of a 情况
+// clause in the variable it declares or assigns, if any
+func (r *run) received(comm ast.Statement, value reflect.Value, scope *env, file *fileEnv) error {
+	switch comm := comm.(type) {
+	case *ast.VarStatement:
+		scope.define(comm.Name.Value, value.Interface(), false)
+	case *ast.ExpressionStatement:
+		assign, ok := comm.Expression.(*ast.AssignExpression)
+		if !ok {
+			return nil
+		}
+		return r.store(assign.Left, value.Interface(), assign.Value, scope, file)
+	}
+	return nil
+}
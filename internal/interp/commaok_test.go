@@ -0,0 +1,53 @@
+package interp_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/saika-m/saika-lang/internal/interp"
+	"github.com/saika-m/saika-lang/internal/ir"
+	"github.com/saika-m/saika-lang/internal/lexer"
+	"github.com/saika-m/saika-lang/internal/parser"
+)
+
+// TestCommaOk declares the value of a map index or a receive together
+// with whether it was there, as Go does
+func TestCommaOk(t *testing.T) {
+	source := `包 main
+
+导入 "fmt"
+
+变量 表 = 映射[字符串]整数{"一": 1}
+变量 一, 在 = 表["一"]
+
+数 入口() {
+	变量 二, ok = 表["二"]
+	fmt.Println(一, 在, 二, ok)
+	变量 ch = 创建(通道 整数, 1)
+	ch <- 3
+	close(ch)
+	变量 v, 有 = <-ch
+	fmt.Println(v, 有)
+	v, 有 = <-ch
+	fmt.Println(v, 有)
+	变量 w, 还有 = <-ch
+	fmt.Println(w, 还有)
+}
+`
+	p := parser.New(lexer.New(source))
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("parse: %v", errs)
+	}
+
+	var stdout bytes.Buffer
+	in := interp.New()
+	in.Stdout = &stdout
+	if err := in.Run(context.Background(), []interp.File{{Path: "commaok.saika", Program: ir.Lower(program, nil)}}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := stdout.String(), "1 true 0 false\n3 true\n0 false\n0 false\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,120 @@
+package interp
+
+import (
+	"go/constant"
+	"go/token"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+)
+
+// constantOf returns the exact value of expr when it is an untyped numeric
+// constant expression: numeric literals, byte sizes, constants and 序号
+// combined by arithmetic and comparison. Go computes such an expression
+// exactly before giving it a type, so 0.1 + 0.2 is 0.3 rather than the sum
+// of two float64s; ok is false for any other expression.
+func constantOf(expr ast.Expression, e *env) (value constant.Value, ok bool) {
+	switch expr := expr.(type) {
+	case *ast.IntegerLiteral:
+		return constant.MakeInt64(expr.Value), true
+	case *ast.FloatLiteral:
+		if v := constant.MakeFromLiteral(expr.Token.Literal, token.FLOAT, 0); v.Kind() == constant.Float {
+			return v, true
+		}
+		return constant.MakeFloat64(expr.Value), true
+	case *ast.UnitLiteral:
+		// Durations are typed constants, which leave untyped ones
+		unit := ast.Units[expr.Unit]
+		if unit.Duration {
+			return nil, false
+		}
+		v := constant.BinaryOp(constant.MakeFloat64(expr.Value), token.MUL, constant.MakeInt64(unit.Size))
+		return constant.ToInt(v), true
+	case *ast.Identifier:
+		b, found := e.lookup(expr.Value)
+		if !found || !b.constant {
+			return nil, false
+		}
+		if b.exact != nil {
+			return b.exact, true
+		}
+		switch v := b.value.(type) {
+		case int:
+			return constant.MakeInt64(int64(v)), true
+		case float64:
+			return constant.MakeFloat64(v), true
+		}
+	case *ast.PrefixExpression:
+		x, ok := constantOf(expr.Right, e)
+		if !ok || expr.Operator != "-" && expr.Operator != "+" {
+			return nil, false
+		}
+		return constant.UnaryOp(constantTokens[expr.Operator], x, 0), true
+	case *ast.InfixExpression:
+		op, ok := constantTokens[expr.Operator]
+		if !ok {
+			return nil, false
+		}
+		x, ok := constantOf(expr.Left, e)
+		if !ok {
+			return nil, false
+		}
+		y, ok := constantOf(expr.Right, e)
+		if !ok {
+			return nil, false
+		}
+		return constantOp(op, x, y)
+	}
+	return nil, false
+}
+
+// constantTokens maps the operators of constant expressions to the Go
+// tokens go/constant computes them by
+var constantTokens = map[string]token.Token{
+	"+": token.ADD, "-": token.SUB, "*": token.MUL, "/": token.QUO, "%": token.REM,
+	"<<": token.SHL, ">>": token.SHR,
+	"==": token.EQL, "!=": token.NEQ, "<": token.LSS, "<=": token.LEQ, ">": token.GTR, ">=": token.GEQ,
+}
+
+// constantOp applies the binary operator op to the constants x and y as Go
+// does, dividing integers as integers. ok is false when Go would reject
+// the operation, which is then left to fail as it does at run time.
+func constantOp(op token.Token, x, y constant.Value) (constant.Value, bool) {
+	isInt := x.Kind() == constant.Int && y.Kind() == constant.Int
+	switch op {
+	case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+		return constant.MakeBool(constant.Compare(x, op, y)), true
+	case token.SHL, token.SHR:
+		s, exact := constant.Uint64Val(y)
+		if !isInt || !exact || s > 64 {
+			return nil, false
+		}
+		return constant.Shift(x, op, uint(s)), true
+	case token.REM:
+		if !isInt || constant.Sign(y) == 0 {
+			return nil, false
+		}
+	case token.QUO:
+		if constant.Sign(y) == 0 {
+			return nil, false
+		}
+		if isInt {
+			op = token.QUO_ASSIGN
+		}
+	}
+	return constant.BinaryOp(x, op, y), true
+}
+
+// constantValue returns the value Go gives the untyped constant v by
+// default: an int, a float64 or a bool
+func constantValue(v constant.Value) any {
+	switch v.Kind() {
+	case constant.Int:
+		if n, exact := constant.Int64Val(v); exact {
+			return int(n)
+		}
+	case constant.Bool:
+		return constant.BoolVal(v)
+	}
+	f, _ := constant.Float64Val(v)
+	return f
+}
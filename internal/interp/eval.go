@@ -0,0 +1,1121 @@
+package interp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/codegen"
+)
+
+// tuple holds the results of a Go function returning several values. Like
+// Go, it may only be passed straight on as the arguments of another call.
+type tuple []any
+
+// eval evaluates an expression in environment e of file
+func (r *run) eval(expr ast.Expression, e *env, file *fileEnv) (any, error) {
+	switch expr := expr.(type) {
+	case *ast.Identifier:
+		return r.identifier(expr, e, file)
+	case *ast.IntegerLiteral:
+		return int(expr.Value), nil
+	case *ast.FloatLiteral:
+		return expr.Value, nil
+	case *ast.UnitLiteral:
+		unit := ast.Units[expr.Unit]
+		n := int64(expr.Value * float64(unit.Size))
+		if unit.Duration {
+			return time.Duration(n), nil
+		}
+		// Byte sizes are untyped integer constants in Go, ints by default
+		return int(n), nil
+	case *ast.StringLiteral:
+		return expr.Text(), nil
+	case *ast.BooleanLiteral:
+		return expr.Value, nil
+	case *ast.PrefixExpression:
+		if v, ok := constantOf(expr, e); ok {
+			return constantValue(v), nil
+		}
+		right, err := r.eval(expr.Right, e, file)
+		if err != nil {
+			return nil, err
+		}
+		if expr.Operator == "<-" {
+			value, _, err := r.receive(right, file, expr.Token.Position)
+			return value, err
+		}
+		value, err := unary(expr.Operator, right)
+		if err != nil {
+			return nil, r.errorf(file, expr.Token.Position, "%v", err)
+		}
+		return value, nil
+	case *ast.InfixExpression:
+		if v, ok := constantOf(expr, e); ok {
+			return constantValue(v), nil
+		}
+		left, err := r.eval(expr.Left, e, file)
+		if err != nil {
+			return nil, err
+		}
+		right, err := r.eval(expr.Right, e, file)
+		if err != nil {
+			return nil, err
+		}
+		value, err := binary(expr.Operator, left, right)
+		if err != nil {
+			return nil, r.errorf(file, expr.Token.Position, "%v", err)
+		}
+		return value, nil
+	case *ast.AssignExpression:
+		return nil, r.assign(expr, e, file)
+	case *ast.CompoundAssignExpression:
+		return nil, r.compoundAssign(expr, e, file)
+	case *ast.MemberExpression:
+		return r.member(expr, e, file)
+	case *ast.CallExpression:
+		return r.callExpression(expr, e, file)
+	case *ast.IndexExpression:
+		return r.index(expr, e, file)
+	case *ast.SliceExpression:
+		return r.slice(expr, e, file)
+	case *ast.CompositeLiteral:
+		return r.compositeLiteral(expr, e, file)
+	case *ast.MapLiteral:
+		return r.mapLiteral(expr, e, file)
+	case *ast.SliceLiteral:
+		return r.sliceLiteral(expr, e, file)
+	case *ast.ExpressionList:
+		// The results of a 返回 with several values
+		values := make(tuple, len(expr.Values))
+		for i, value := range expr.Values {
+			v, err := r.eval(value, e, file)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		return values, nil
+	case *ast.ArrayLiteral:
+		return r.arrayLiteral(expr, e, file)
+	case *ast.MapType, *ast.SliceType, *ast.ChanType:
+		// A type given to a builtin, as in 创建(通道 整数)
+		return reflectType(resolveType(expr, e)), nil
+	case *ast.FunctionLiteral:
+		return &function{
+			name:       "匿名函数",
+			parameters: expr.Parameters,
+			returnType: expr.ReturnType,
+			body:       expr.Body,
+			pos:        expr.Token.Position,
+			file:       file,
+			env:        e,
+			run:        r,
+		}, nil
+	default:
+		return nil, r.errorf(file, positionOf(expr), "%T is not supported by the interpreter", expr)
+	}
+}
+
+// identifier evaluates a name, which may be a builtin of the universe
+func (r *run) identifier(ident *ast.Identifier, e *env, file *fileEnv) (any, error) {
+	if b, ok := e.lookup(ident.Value); ok {
+		return b.value, nil
+	}
+	switch ident.Value {
+	case codegen.BuildInfoName:
+		info := r.interp.BuildInfo
+		return record{
+			"版本":   info.Version,
+			"提交":   info.Commit,
+			"时间":   info.Time,
+			"工具版本": info.ToolVersion,
+		}, nil
+	case codegen.RenderTemplateName:
+		dir := filepath.Dir(file.path)
+		return reflect.ValueOf(func(name string, data any) string {
+			return r.renderTemplate(dir, name, data)
+		}), nil
+	case codegen.WriteTemplateName:
+		dir := filepath.Dir(file.path)
+		return reflect.ValueOf(func(w io.Writer, name string, data any) {
+			r.writeTemplate(w, dir, name, data)
+		}), nil
+	case codegen.StaticFilesName:
+		if r.interp.StaticDir == "" {
+			return nil, r.errorf(file, ident.Token.Position, "%s is used, but the program has no %s directory", ident.Value, codegen.StaticDirName)
+		}
+		return os.DirFS(r.interp.StaticDir), nil
+	case "nil":
+		return nil, nil
+	case codegen.PanicName, codegen.RecoverName, "append", "copy", "delete":
+		return builtin(ident.Value), nil
+	}
+	if builtin, ok := builtins[ident.Value]; ok {
+		return builtin, nil
+	}
+	return nil, r.errorf(file, ident.Token.Position, "%s is not supported by the interpreter", ident.Value)
+}
+
+// assign stores a value in a variable, an element or a field
+func (r *run) assign(expr *ast.AssignExpression, e *env, file *fileEnv) error {
+	value, err := r.eval(expr.Value, e, file)
+	if err != nil {
+		return err
+	}
+	return r.store(expr.Left, value, expr.Value, e, file)
+}
+
+// store stores an evaluated value, given by valueExpr, in the variable,
+// element or field that target denotes, discarding it for the blank
+// identifier
+func (r *run) store(target ast.Expression, value any, valueExpr ast.Expression, e *env, file *fileEnv) error {
+	switch target := target.(type) {
+	case *ast.IndexExpression:
+		return r.storeIndex(target, value, valueExpr, e, file)
+	case *ast.MemberExpression:
+		return r.storeField(target, value, valueExpr, e, file)
+	case *ast.Identifier:
+		if ast.IsBlank(target.Value) {
+			return nil
+		}
+	}
+	b, err := r.variable(target, e, file)
+	if err != nil {
+		return err
+	}
+	b.value = assignable(value, b.value)
+	return nil
+}
+
+// compoundAssign applies an operator to a variable or an element of a map,
+// slice or array and stores the result back
+func (r *run) compoundAssign(expr *ast.CompoundAssignExpression, e *env, file *fileEnv) error {
+	current, err := r.eval(expr.Left, e, file)
+	if err != nil {
+		return err
+	}
+	operand, err := r.eval(expr.Value, e, file)
+	if err != nil {
+		return err
+	}
+	value, err := binary(expr.Operator, current, operand)
+	if err != nil {
+		return r.errorf(file, expr.Token.Position, "%v", err)
+	}
+
+	return r.store(expr.Left, value, expr.Value, e, file)
+}
+
+// variable returns the binding of a variable that can be assigned to
+func (r *run) variable(expr ast.Expression, e *env, file *fileEnv) (*binding, error) {
+	target, ok := expr.(*ast.Identifier)
+	if !ok {
+		return nil, r.errorf(file, positionOf(expr), "cannot assign to %s", expr.String())
+	}
+	b, ok := e.lookup(target.Value)
+	if !ok {
+		return nil, r.errorf(file, target.Token.Position, "undefined: %s", target.Value)
+	}
+	if b.constant {
+		return nil, r.errorf(file, target.Token.Position, "cannot assign to %s (constant)", target.Value)
+	}
+	return b, nil
+}
+
+// storeIndex stores an evaluated value, given by valueExpr, in an element
+// of a map, slice or array
+func (r *run) storeIndex(expr *ast.IndexExpression, value any, valueExpr ast.Expression, e *env, file *fileEnv) error {
+	container, err := r.eval(expr.Left, e, file)
+	if err != nil {
+		return err
+	}
+	key, err := r.eval(expr.Index, e, file)
+	if err != nil {
+		return err
+	}
+
+	m := reflect.ValueOf(container)
+	switch m.Kind() {
+	case reflect.Slice:
+		return r.assignElement(expr, m, key, value, valueExpr, file)
+	case reflect.Array:
+		// Arrays are values, so the changed copy is stored back where
+		// the array came from
+		array := reflect.New(m.Type()).Elem()
+		array.Set(m)
+		if err := r.assignElement(expr, array, key, value, valueExpr, file); err != nil {
+			return err
+		}
+		return r.store(expr.Left, array.Interface(), valueExpr, e, file)
+	}
+	if m.Kind() != reflect.Map {
+		return r.errorf(file, expr.Token.Position, "cannot assign to %s (%s)", expr.String(), typeName(container))
+	}
+	if m.IsNil() {
+		return r.errorf(file, expr.Token.Position, "assignment to entry in nil map")
+	}
+	k, err := convertValue(key, m.Type().Key())
+	if err != nil {
+		return r.errorf(file, positionOf(expr.Index), "%v", err)
+	}
+	v, err := convertValue(value, m.Type().Elem())
+	if err != nil {
+		return r.errorf(file, positionOf(valueExpr), "%v", err)
+	}
+	m.SetMapIndex(k, v)
+	return nil
+}
+
+// storeField stores an evaluated value in a field of a struct. Structs are
+// values, so the changed copy is stored back where the struct came from.
+func (r *run) storeField(expr *ast.MemberExpression, value any, valueExpr ast.Expression, e *env, file *fileEnv) error {
+	property, ok := expr.Property.(*ast.Identifier)
+	if !ok {
+		return r.errorf(file, positionOf(expr.Property), "invalid member %s", expr.Property.String())
+	}
+	object, err := r.eval(expr.Object, e, file)
+	if err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(object)
+	if v.Kind() != reflect.Struct {
+		return r.errorf(file, expr.Token.Position, "cannot assign to %s (%s)", expr.String(), typeName(object))
+	}
+	s := reflect.New(v.Type()).Elem()
+	s.Set(v)
+	field := s.FieldByName(fieldName(property.Value))
+	if !field.IsValid() {
+		return r.errorf(file, property.Token.Position, "%s has no field %s", expr.Object.String(), property.Value)
+	}
+	converted, err := convertValue(value, field.Type())
+	if err != nil {
+		return r.errorf(file, positionOf(valueExpr), "%v", err)
+	}
+	field.Set(converted)
+	return r.store(expr.Object, s.Interface(), valueExpr, e, file)
+}
+
+// assignElement stores a value in an element of a slice, which shares its
+// elements with every copy of the slice, or of an addressable array
+func (r *run) assignElement(expr *ast.IndexExpression, s reflect.Value, key, value any, valueExpr ast.Expression, file *fileEnv) error {
+	i, ok := key.(int)
+	if !ok {
+		return r.errorf(file, positionOf(expr.Index), "invalid index %v (%s)", key, typeName(key))
+	}
+	if i < 0 || i >= s.Len() {
+		return r.errorf(file, expr.Token.Position, "runtime error: index out of range [%d] with length %d", i, s.Len())
+	}
+	v, err := convertValue(value, s.Type().Elem())
+	if err != nil {
+		return r.errorf(file, positionOf(valueExpr), "%v", err)
+	}
+	s.Index(i).Set(v)
+	return nil
+}
+
+// index evaluates an element of a map, string or slice, or instantiates a
+// generic function or type with type arguments. A missing map key gives
+// the zero value of the element type, as in Go.
+func (r *run) index(expr *ast.IndexExpression, e *env, file *fileEnv) (any, error) {
+	container, err := r.eval(expr.Left, e, file)
+	if err != nil {
+		return nil, err
+	}
+	switch generic := container.(type) {
+	case *function:
+		if len(generic.typeParams) > 0 {
+			return generic.instantiate(r.typeArguments(expr.Index, e)), nil
+		}
+	case namedType:
+		if len(generic.params) > 0 {
+			return r.instantiate(generic, r.typeArguments(expr.Index, e)), nil
+		}
+	}
+	key, err := r.eval(expr.Index, e, file)
+	if err != nil {
+		return nil, err
+	}
+	value, _, err := r.lookupIndex(expr, container, key, file)
+	return value, err
+}
+
+// indexOperands evaluates what an index expression indexes and its index
+func (r *run) indexOperands(expr *ast.IndexExpression, e *env, file *fileEnv) (container, key any, err error) {
+	if container, err = r.eval(expr.Left, e, file); err != nil {
+		return nil, nil, err
+	}
+	if key, err = r.eval(expr.Index, e, file); err != nil {
+		return nil, nil, err
+	}
+	return container, key, nil
+}
+
+// lookupIndex returns the element of container at key, and whether a map
+// holds the key; the zero value of its elements when it does not
+func (r *run) lookupIndex(expr *ast.IndexExpression, container, key any, file *fileEnv) (any, bool, error) {
+	v := reflect.ValueOf(container)
+	switch v.Kind() {
+	case reflect.Map:
+		k, err := convertValue(key, v.Type().Key())
+		if err != nil {
+			return nil, false, r.errorf(file, positionOf(expr.Index), "%v", err)
+		}
+		elem := v.MapIndex(k)
+		if !elem.IsValid() {
+			return reflect.Zero(v.Type().Elem()).Interface(), false, nil
+		}
+		return elem.Interface(), true, nil
+	case reflect.String, reflect.Slice, reflect.Array:
+		i, ok := key.(int)
+		if !ok {
+			return nil, false, r.errorf(file, positionOf(expr.Index), "invalid index %v (%s)", key, typeName(key))
+		}
+		if i < 0 || i >= v.Len() {
+			return nil, false, r.errorf(file, expr.Token.Position, "runtime error: index out of range [%d] with length %d", i, v.Len())
+		}
+		return v.Index(i).Interface(), true, nil
+	}
+	return nil, false, r.errorf(file, expr.Token.Position, "cannot index %s (%s)", expr.Left.String(), typeName(container))
+}
+
+// slice evaluates a slice of a string, slice or array. Strings known to
+// the lowering are sliced by character before they get here.
+func (r *run) slice(expr *ast.SliceExpression, e *env, file *fileEnv) (any, error) {
+	container, err := r.eval(expr.Left, e, file)
+	if err != nil {
+		return nil, err
+	}
+	v := reflect.ValueOf(container)
+	switch v.Kind() {
+	case reflect.String, reflect.Slice:
+	case reflect.Array:
+		// Only an addressable array can be sliced
+		addressable := reflect.New(v.Type()).Elem()
+		addressable.Set(v)
+		v = addressable
+	default:
+		return nil, r.errorf(file, expr.Token.Position, "cannot slice %s (%s)", expr.Left.String(), typeName(container))
+	}
+
+	capacity := v.Len()
+	if v.Kind() != reflect.String {
+		capacity = v.Cap()
+	}
+	indices := []int{0, v.Len(), capacity}
+	for i, index := range []ast.Expression{expr.Low, expr.High, expr.Max} {
+		if index == nil {
+			continue
+		}
+		value, err := r.eval(index, e, file)
+		if err != nil {
+			return nil, err
+		}
+		n, ok := value.(int)
+		if !ok {
+			return nil, r.errorf(file, positionOf(index), "invalid slice index %v (%s)", value, typeName(value))
+		}
+		indices[i] = n
+	}
+	low, high, max := indices[0], indices[1], indices[2]
+	if low < 0 || low > high || high > max || max > capacity {
+		return nil, r.errorf(file, expr.Token.Position, "runtime error: slice bounds out of range [%d:%d] with capacity %d", low, high, capacity)
+	}
+	if expr.Max != nil {
+		return v.Slice3(low, high, max).Interface(), nil
+	}
+	return v.Slice(low, high).Interface(), nil
+}
+
+// compositeLiteral evaluates a struct value given by the values of its
+// fields
+func (r *run) compositeLiteral(expr *ast.CompositeLiteral, e *env, file *fileEnv) (any, error) {
+	named := namedType{typ: expr.Type}
+	if _, ok := expr.Type.(*ast.StructType); !ok {
+		typ, err := r.eval(expr.Type, e, file)
+		if err != nil {
+			return nil, err
+		}
+		named, _ = typ.(namedType)
+		if _, ok := named.typ.(*ast.StructType); !ok {
+			return nil, r.errorf(file, expr.Token.Position, "invalid composite literal type %s", expr.Type.String())
+		}
+	}
+
+	s := reflect.New(named.reflectType()).Elem()
+	for _, field := range expr.Fields {
+		f := s.FieldByName(fieldName(field.Name.Value))
+		if !f.IsValid() {
+			return nil, r.errorf(file, field.Name.Token.Position, "unknown field %s in struct literal of type %s", field.Name.Value, expr.Type.String())
+		}
+		value, err := r.eval(field.Value, e, file)
+		if err != nil {
+			return nil, err
+		}
+		converted, err := convertValue(value, f.Type())
+		if err != nil {
+			return nil, r.errorf(file, positionOf(field.Value), "%v", err)
+		}
+		f.Set(converted)
+	}
+	return s.Interface(), nil
+}
+
+// mapLiteral evaluates a map literal
+func (r *run) mapLiteral(expr *ast.MapLiteral, e *env, file *fileEnv) (any, error) {
+	t := reflectType(resolveType(expr.Type, e))
+	m := reflect.MakeMapWithSize(t, len(expr.Pairs))
+	for _, pair := range expr.Pairs {
+		key, err := r.eval(pair.Key, e, file)
+		if err != nil {
+			return nil, err
+		}
+		value, err := r.eval(pair.Value, e, file)
+		if err != nil {
+			return nil, err
+		}
+
+		k, err := convertValue(key, t.Key())
+		if err != nil {
+			return nil, r.errorf(file, positionOf(pair.Key), "%v", err)
+		}
+		if m.MapIndex(k).IsValid() {
+			return nil, r.errorf(file, positionOf(pair.Key), "duplicate key %v in map literal", key)
+		}
+		v, err := convertValue(value, t.Elem())
+		if err != nil {
+			return nil, r.errorf(file, positionOf(pair.Value), "%v", err)
+		}
+		m.SetMapIndex(k, v)
+	}
+	return m.Interface(), nil
+}
+
+// sliceLiteral evaluates a slice literal
+func (r *run) sliceLiteral(expr *ast.SliceLiteral, e *env, file *fileEnv) (any, error) {
+	t := reflectType(resolveType(expr.Type, e))
+	s := reflect.MakeSlice(t, len(expr.Elements), len(expr.Elements))
+	for i, el := range expr.Elements {
+		value, err := r.eval(el, e, file)
+		if err != nil {
+			return nil, err
+		}
+		v, err := convertValue(value, t.Elem())
+		if err != nil {
+			return nil, r.errorf(file, positionOf(el), "%v", err)
+		}
+		s.Index(i).Set(v)
+	}
+	return s.Interface(), nil
+}
+
+// arrayLiteral evaluates an array literal; elements it leaves out are zero
+func (r *run) arrayLiteral(expr *ast.ArrayLiteral, e *env, file *fileEnv) (any, error) {
+	length, err := r.eval(expr.Type.Len, e, file)
+	if err != nil {
+		return nil, err
+	}
+	n, ok := length.(int)
+	if !ok || n < 0 {
+		return nil, r.errorf(file, positionOf(expr.Type.Len), "invalid array length %s", expr.Type.Len.String())
+	}
+	t := reflect.ArrayOf(n, reflectType(resolveType(expr.Type.Elem, e)))
+	if len(expr.Elements) > t.Len() {
+		return nil, r.errorf(file, positionOf(expr.Elements[t.Len()]), "index %d out of bounds [0:%d]", t.Len(), t.Len())
+	}
+	a := reflect.New(t).Elem()
+	for i, el := range expr.Elements {
+		value, err := r.eval(el, e, file)
+		if err != nil {
+			return nil, err
+		}
+		v, err := convertValue(value, t.Elem())
+		if err != nil {
+			return nil, r.errorf(file, positionOf(el), "%v", err)
+		}
+		a.Index(i).Set(v)
+	}
+	return a.Interface(), nil
+}
+
+// member evaluates a member of an imported package or of a record, or a
+// field or method of a Go value
+func (r *run) member(expr *ast.MemberExpression, e *env, file *fileEnv) (any, error) {
+	property, ok := expr.Property.(*ast.Identifier)
+	if !ok {
+		return nil, r.errorf(file, positionOf(expr.Property), "invalid member %s", expr.Property.String())
+	}
+	object, err := r.eval(expr.Object, e, file)
+	if err != nil {
+		return nil, err
+	}
+
+	switch object := object.(type) {
+	case *goPackage:
+		value, ok := object.member(property.Value)
+		if !ok {
+			return nil, r.errorf(file, property.Token.Position, "%s.%s is not supported by the interpreter",
+				expr.Object.String(), property.Value)
+		}
+		return value, nil
+	case record:
+		if value, ok := object[property.Value]; ok {
+			return value, nil
+		}
+	case reflect.Type:
+		// A method expression, as in time.Duration.String, taking the
+		// receiver as its first argument
+		if method, ok := object.MethodByName(property.Value); ok {
+			return method.Func, nil
+		}
+	case namedType:
+		// So does one of a Saika type, as in 点.双
+		if fn := r.typeMethod(object.name, property.Value); fn != nil {
+			method := *fn
+			method.parameters = append([]*ast.TypedParam{{Name: fn.receiver.Name}}, fn.parameters...)
+			method.receiver = nil
+			return &method, nil
+		}
+	default:
+		v := reflect.ValueOf(object)
+		if v.Kind() == reflect.Struct {
+			if field := v.FieldByName(fieldName(property.Value)); field.IsValid() {
+				return field.Interface(), nil
+			}
+		}
+		// A method of a Saika struct, bound to the value; one that
+		// changes its receiver stores it back where it came from
+		if fn := r.method(v, property.Value); fn != nil {
+			bound := *fn
+			bound.self = object
+			if fn.changes {
+				bound.store = func(value any) error {
+					return r.store(expr.Object, value, expr.Object, e, file)
+				}
+				bound.load = func() (any, error) {
+					return r.eval(expr.Object, e, file)
+				}
+			}
+			return &bound, nil
+		}
+		// A method of a Go value, bound to it: called at once, as in
+		// t.Format(...), or kept as a method value to be called later
+		if v.IsValid() {
+			if method := v.MethodByName(property.Value); method.IsValid() {
+				return method, nil
+			}
+		}
+	}
+	return nil, r.errorf(file, property.Token.Position, "%s has no member %s", expr.Object.String(), property.Value)
+}
+
+// callExpression evaluates a call of a Saika or Go function
+func (r *run) callExpression(expr *ast.CallExpression, e *env, file *fileEnv) (any, error) {
+	callee, args, err := r.callOperands(expr, e, file)
+	if err != nil {
+		return nil, err
+	}
+	return r.invoke(expr, callee, args, file)
+}
+
+// callOperands evaluates the function and the arguments of a call
+func (r *run) callOperands(expr *ast.CallExpression, e *env, file *fileEnv) (any, []any, error) {
+	callee, err := r.eval(expr.Function, e, file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	args := make([]any, 0, len(expr.Arguments))
+	for _, arg := range expr.Arguments {
+		value, err := r.eval(arg, e, file)
+		if err != nil {
+			return nil, nil, err
+		}
+		args = append(args, value)
+	}
+	// f(g()) passes all results of g to f
+	if len(args) == 1 {
+		if results, ok := args[0].(tuple); ok {
+			args = results
+		}
+	}
+	for i, arg := range args {
+		if _, ok := arg.(tuple); ok {
+			return nil, nil, r.errorf(file, positionOf(expr.Arguments[i]), "multiple-value %s in single-value context", expr.Arguments[i].String())
+		}
+	}
+	return callee, args, nil
+}
+
+// invoke calls the evaluated function of a call with evaluated arguments
+func (r *run) invoke(expr *ast.CallExpression, callee any, args []any, file *fileEnv) (any, error) {
+	switch callee := callee.(type) {
+	case *function:
+		return r.call(callee, args, expr.Ellipsis, expr.Token.Position)
+	case builtin:
+		return r.callBuiltin(expr, callee, args, file)
+	case namedType:
+		if len(args) != 1 {
+			return nil, r.errorf(file, expr.Token.Position, "wrong number of arguments in conversion to %s: have %d, want 1", expr.Function.String(), len(args))
+		}
+		return convertToType(args[0], callee.typ), nil
+	case reflect.Type:
+		if len(args) != 1 {
+			return nil, r.errorf(file, expr.Token.Position, "wrong number of arguments in conversion to %s: have %d, want 1", expr.Function.String(), len(args))
+		}
+		v, err := convertValue(args[0], callee)
+		if err != nil {
+			return nil, r.errorf(file, expr.Token.Position, "%v", err)
+		}
+		return v.Interface(), nil
+	case reflect.Value:
+		value, err := callGo(callee, r.goArgs(callee.Type(), args, expr.Ellipsis), expr.Ellipsis)
+		var callback callbackError
+		if errors.As(err, &callback) {
+			return nil, callback.err
+		}
+		if err != nil {
+			return nil, r.errorf(file, expr.Token.Position, "%s: %v", expr.Function.String(), err)
+		}
+		return value, nil
+	default:
+		// A function held in a Go value, such as an element of a slice
+		// of functions, is called as Go functions are
+		if v := reflect.ValueOf(callee); v.Kind() == reflect.Func {
+			return r.invoke(expr, v, args, file)
+		}
+		return nil, r.errorf(file, expr.Token.Position, "cannot call non-function %s (%s)", expr.Function.String(), typeName(callee))
+	}
+}
+
+// callGo calls a Go function through reflection, converting the arguments
+// to its parameter types the way Go converts untyped constants. With
+// spread set, the last argument is the slice of variadic arguments.
+func callGo(fn reflect.Value, args []any, spread bool) (result any, err error) {
+	t := fn.Type()
+	if spread && !t.IsVariadic() {
+		return nil, fmt.Errorf("have (...) arguments in call to non-variadic function")
+	}
+	if len(args) < t.NumIn()-1 || ((!t.IsVariadic() || spread) && len(args) != t.NumIn()) {
+		return nil, fmt.Errorf("wrong number of arguments: have %d, want %d", len(args), t.NumIn())
+	}
+
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		var param reflect.Type
+		if t.IsVariadic() && !spread && i >= t.NumIn()-1 {
+			param = t.In(t.NumIn() - 1).Elem()
+		} else {
+			param = t.In(i)
+		}
+		v, err := convertValue(arg, param)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %v", i+1, err)
+		}
+		in[i] = v
+	}
+
+	// A Go function panicking, e.g. strings.Repeat with a negative count,
+	// is a runtime error of the program
+	defer func() {
+		if v := recover(); v != nil {
+			if _, ok := v.(exitPanic); ok {
+				panic(v)
+			}
+			if callback, ok := v.(callbackError); ok {
+				err = callback
+				return
+			}
+			err = fmt.Errorf("panic: %v", v)
+		}
+	}()
+
+	var out []reflect.Value
+	if spread {
+		out = fn.CallSlice(in)
+	} else {
+		out = fn.Call(in)
+	}
+	switch len(out) {
+	case 0:
+		return nil, nil
+	case 1:
+		return out[0].Interface(), nil
+	}
+	results := make(tuple, len(out))
+	for i, v := range out {
+		results[i] = v.Interface()
+	}
+	return results, nil
+}
+
+// goArgs returns the arguments of a call of a Go function of type t, with
+// each struct value given as an interface that has a String or Error
+// method in Saika wrapped in a Go value with the method, so that Go finds
+// it as it does in the compiled program, as fmt does
+func (r *run) goArgs(t reflect.Type, args []any, spread bool) []any {
+	wrapped := args
+	for i, arg := range args {
+		var param reflect.Type
+		switch {
+		case t.IsVariadic() && !spread && i >= t.NumIn()-1:
+			param = t.In(t.NumIn() - 1).Elem()
+		case i < t.NumIn():
+			param = t.In(i)
+		default:
+			return wrapped
+		}
+		if param.Kind() != reflect.Interface {
+			continue
+		}
+		if value, ok := r.withMethods(arg); ok {
+			if &wrapped[0] == &args[0] {
+				wrapped = append([]any(nil), args...)
+			}
+			wrapped[i] = value
+		}
+	}
+	return wrapped
+}
+
+// withMethods returns value, a struct of a Saika type, as a Go value with
+// its Error or String method, and false when it has neither. A method
+// that changes its receiver belongs to a pointer in Go, which the value
+// is not.
+func (r *run) withMethods(value any) (any, bool) {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() {
+		return nil, false
+	}
+	for _, name := range []string{"Error", "String"} {
+		fn := r.method(v, name)
+		if fn == nil || fn.changes || len(fn.parameters) != 0 {
+			continue
+		}
+		bound := *fn
+		bound.self = value
+		if name == "Error" {
+			return saikaError{&bound}, true
+		}
+		return saikaStringer{&bound}, true
+	}
+	return nil, false
+}
+
+// saikaStringer is a Saika value with a String method, given to Go
+type saikaStringer struct {
+	method *function
+}
+
+func (s saikaStringer) String() string { return s.method.callString() }
+
+// saikaError is a Saika value with an Error method, given to Go
+type saikaError struct {
+	method *function
+}
+
+func (e saikaError) Error() string { return e.method.callString() }
+
+// callString calls a method returning a string, bound to its receiver,
+// for Go code. An error panics out of the Go code to callGo.
+func (fn *function) callString() string {
+	result, err := fn.run.call(fn, nil, false, fn.pos)
+	if err != nil {
+		panic(callbackError{err})
+	}
+	str, _ := result.(string)
+	return str
+}
+
+// callbackError carries the error of a Saika function called back by Go
+// code, such as the less function of sort.Slice, out of the Go call
+type callbackError struct {
+	err error
+}
+
+func (e callbackError) Error() string { return e.err.Error() }
+
+// goFunc wraps fn as a Go function of type t, so that it can be passed
+// to Go code. An error of fn panics out of the Go code to callGo.
+func (fn *function) goFunc(t reflect.Type) reflect.Value {
+	return reflect.MakeFunc(t, func(in []reflect.Value) []reflect.Value {
+		args := make([]any, len(in))
+		for i, v := range in {
+			args[i] = v.Interface()
+		}
+		result, err := fn.run.call(fn, args, t.IsVariadic(), fn.pos)
+		if err != nil {
+			panic(callbackError{err})
+		}
+
+		results, ok := result.(tuple)
+		if !ok {
+			results = tuple{result}
+		}
+		if t.NumOut() == 0 {
+			return nil
+		}
+		if len(results) != t.NumOut() {
+			panic(callbackError{fn.run.errorf(fn.file, fn.pos, "%s returns %d values, want %d", fn.name, len(results), t.NumOut())})
+		}
+		out := make([]reflect.Value, t.NumOut())
+		for i := range out {
+			v, err := convertValue(results[i], t.Out(i))
+			if err != nil {
+				panic(callbackError{fn.run.errorf(fn.file, fn.pos, "%s: %v", fn.name, err)})
+			}
+			out[i] = v
+		}
+		return out
+	})
+}
+
+// convertValue converts a value to the Go type t
+func convertValue(value any, t reflect.Type) (reflect.Value, error) {
+	if value == nil {
+		switch t.Kind() {
+		case reflect.Interface, reflect.Ptr, reflect.Slice, reflect.Map, reflect.Func, reflect.Chan:
+			return reflect.Zero(t), nil
+		}
+		return reflect.Value{}, fmt.Errorf("cannot use nil as %s value", t)
+	}
+	if fn, ok := value.(*function); ok && t.Kind() == reflect.Func {
+		return fn.goFunc(t), nil
+	}
+	// A declared type given to a builtin, as in 创建(分数表)
+	if named, ok := value.(namedType); ok && t == typeType {
+		return reflect.ValueOf(reflectType(named.typ)), nil
+	}
+
+	// Go functions and method values are held as reflect values
+	v, ok := value.(reflect.Value)
+	if !ok {
+		v = reflect.ValueOf(value)
+	}
+	if v.Type().AssignableTo(t) {
+		return v, nil
+	}
+	// Plain integers stand in for Go's untyped constants, which convert
+	// to any numeric type
+	if _, untyped := value.(int); untyped && isNumeric(t.Kind()) {
+		return v.Convert(t), nil
+	}
+	return reflect.Value{}, fmt.Errorf("cannot use %v (%s) as %s value", value, typeName(value), t)
+}
+
+// assignable converts a plain integer assigned to a variable holding
+// another numeric type, e.g. a float, to that type
+func assignable(value, current any) any {
+	if _, ok := value.(int); !ok || current == nil {
+		return value
+	}
+	t := reflect.TypeOf(current)
+	if !isNumeric(t.Kind()) {
+		return value
+	}
+	return reflect.ValueOf(value).Convert(t).Interface()
+}
+
+// convertToType converts a plain integer to a Saika numeric type
+func convertToType(value any, typ ast.Expression) any {
+	if results, ok := typ.(*ast.ResultList); ok {
+		values, ok := value.(tuple)
+		if !ok || len(values) != len(results.Types) {
+			return value
+		}
+		converted := make(tuple, len(values))
+		for i, v := range values {
+			converted[i] = convertToType(v, results.Types[i])
+		}
+		return converted
+	}
+	if n, ok := value.(int); ok && reflectType(typ) == reflect.TypeOf(0.0) {
+		return float64(n)
+	}
+	return value
+}
+
+// anyType is the type of values whose Saika type the interpreter does not
+// model, such as interfaces
+var anyType = reflect.TypeOf((*any)(nil)).Elem()
+
+// typeType is the type of the type arguments of builtins such as 创建
+var typeType = reflect.TypeOf((*reflect.Type)(nil)).Elem()
+
+// errorType is the type of 错误 values
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// zeroValue returns the zero value of a Saika type, or the tuple of zero
+// values of a result list
+func zeroValue(typ ast.Expression) any {
+	if results, ok := typ.(*ast.ResultList); ok {
+		zeros := make(tuple, len(results.Types))
+		for i, t := range results.Types {
+			zeros[i] = zeroValue(t)
+		}
+		return zeros
+	}
+	return reflect.Zero(reflectType(typ)).Interface()
+}
+
+// reflectType returns the Go type of a Saika type expression
+func reflectType(expr ast.Expression) reflect.Type {
+	switch expr := expr.(type) {
+	case *ast.Identifier:
+		switch expr.Value {
+		case "整数":
+			return reflect.TypeOf(0)
+		case "字符串":
+			return reflect.TypeOf("")
+		case "浮点":
+			return reflect.TypeOf(0.0)
+		case "布尔":
+			return reflect.TypeOf(false)
+		case "错误":
+			return errorType
+		}
+	case *ast.MapType:
+		return reflect.MapOf(reflectType(expr.Key), reflectType(expr.Value))
+	case *ast.SliceType:
+		return reflect.SliceOf(reflectType(expr.Elem))
+	case *ast.ChanType:
+		return reflect.ChanOf(reflect.BothDir, reflectType(expr.Elem))
+	case *ast.ArrayType:
+		if n, ok := expr.Len.(*ast.IntegerLiteral); ok {
+			return reflect.ArrayOf(int(n.Value), reflectType(expr.Elem))
+		}
+	case *ast.FuncType:
+		params := make([]reflect.Type, len(expr.Parameters))
+		for i, param := range expr.Parameters {
+			params[i] = reflectType(param)
+		}
+		if expr.Variadic {
+			params[len(params)-1] = reflect.SliceOf(params[len(params)-1])
+		}
+		var results []reflect.Type
+		switch result := expr.ReturnType.(type) {
+		case nil:
+		case *ast.ResultList:
+			for _, t := range result.Types {
+				results = append(results, reflectType(t))
+			}
+		default:
+			results = append(results, reflectType(result))
+		}
+		return reflect.FuncOf(params, results, expr.Variadic)
+	case *ast.StructType:
+		fields := make([]reflect.StructField, len(expr.Fields))
+		for i, field := range expr.Fields {
+			fields[i] = reflect.StructField{Name: fieldName(field.Name.Value), Type: reflectType(field.Type)}
+		}
+		return reflect.StructOf(fields)
+	}
+	return anyType
+}
+
+// fieldName returns the name a field of a Saika struct has in the Go
+// struct standing for it. reflect.StructOf only makes exported fields,
+// which Chinese names are not, so the names get an upper-case prefix.
+func fieldName(name string) string {
+	return "F" + name
+}
+
+// namedType is a type declared with 类型. Its values are those of the type
+// it is declared as; calling it converts a value to it. A type declared at
+// the package level has its name, so that the values of a struct type
+// find its methods: the fields of their Go structs are tagged with it.
+type namedType struct {
+	typ    ast.Expression
+	name   string
+	params []*ast.TypeParam // of a generic type
+}
+
+// typeTag is the key of the struct tag naming the type of a struct value
+const typeTag = "saika"
+
+// reflectType returns the Go type of the values of the type
+func (n namedType) reflectType() reflect.Type {
+	t := reflectType(n.typ)
+	if t.Kind() != reflect.Struct || n.name == "" {
+		return t
+	}
+	fields := make([]reflect.StructField, t.NumField())
+	for i := range fields {
+		fields[i] = t.Field(i)
+		fields[i].Tag = reflect.StructTag(fmt.Sprintf("%s:%q", typeTag, n.name))
+	}
+	return reflect.StructOf(fields)
+}
+
+// method returns the method called name of the struct value v, bound to
+// it, or nil when it has none. An empty struct has no field telling its
+// type, so its methods are looked up among the empty struct types.
+func (r *run) method(v reflect.Value, name string) *function {
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	if v.NumField() > 0 {
+		return r.typeMethod(v.Type().Field(0).Tag.Get(typeTag), name)
+	}
+	var found *function
+	for typ, methods := range r.methods {
+		fn, ok := methods[name]
+		b, _ := r.pkg.lookup(typ)
+		named, _ := b.value.(namedType)
+		if st, isStruct := named.typ.(*ast.StructType); ok && isStruct && len(st.Fields) == 0 {
+			if found != nil {
+				return nil
+			}
+			found = fn
+		}
+	}
+	return found
+}
+
+// typeMethod returns the method called name of the package-level type
+// named typ, or nil when it has none. The methods of an instance of a
+// generic type are those of the generic type, instantiated.
+func (r *run) typeMethod(typ, name string) *function {
+	if inst, ok := r.instances.Load(typ); ok {
+		inst := inst.(instance)
+		if fn := r.methods[inst.generic][name]; fn != nil {
+			return fn.instantiate(inst.args)
+		}
+		return nil
+	}
+	return r.methods[typ][name]
+}
+
+// numbered returns a scope nested in e for the value of the constant with
+// index i in its declaration, where 序号 is i
+func numbered(e *env, i int) *env {
+	n := newEnv(e)
+	n.define(codegen.IotaName, i, true)
+	return n
+}
+
+// record is a value with named fields, such as 构建信息
+type record map[string]any
+
+// typeName returns the Go name of a value's type for messages
+func typeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "nil"
+	case *function:
+		return "func"
+	case *goPackage:
+		return "package"
+	case record:
+		return "struct"
+	case namedType:
+		return "type"
+	}
+	return reflect.TypeOf(value).String()
+}
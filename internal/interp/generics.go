@@ -0,0 +1,242 @@
+package interp
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+)
+
+// instance is a generic package-level type instantiated with type
+// arguments, whose values find the methods of the generic type
+type instance struct {
+	generic string
+	args    []ast.Expression
+}
+
+// instantiate returns the generic function or method fn with the type
+// arguments args in place of its type parameters, which its body sees as
+// declared types, so that their values start as the compiled program's do
+func (fn *function) instantiate(args []ast.Expression) *function {
+	types := ast.BindTypeParams(fn.typeParams, args)
+	if types == nil {
+		return fn
+	}
+	inst := *fn
+	inst.typeParams = nil
+	inst.parameters = make([]*ast.TypedParam, len(fn.parameters))
+	for i, param := range fn.parameters {
+		inst.parameters[i] = &ast.TypedParam{Name: param.Name, Type: ast.Substitute(param.Type, types), Variadic: param.Variadic}
+	}
+	inst.returnType = ast.Substitute(fn.returnType, types)
+	inst.env = newEnv(fn.env)
+	for name, typ := range types {
+		inst.env.define(name, namedType{typ: typ}, true)
+	}
+	return &inst
+}
+
+// instantiate returns the generic type n instantiated with the type
+// arguments args: its type with them in place of its type parameters. An
+// instance of a package-level type is named with its type arguments, so
+// that the methods of its values see them too.
+func (r *run) instantiate(n namedType, args []ast.Expression) namedType {
+	params := make([]*ast.Identifier, len(n.params))
+	for i, tp := range n.params {
+		params[i] = tp.Name
+	}
+	types := ast.BindTypeParams(params, args)
+	if types == nil {
+		return n
+	}
+	inst := namedType{typ: ast.Substitute(n.typ, types)}
+	if n.name != "" {
+		names := make([]string, len(args))
+		for i, arg := range args {
+			names[i] = ast.Sprint(arg)
+		}
+		inst.name = n.name + "[" + strings.Join(names, ", ") + "]"
+		r.instances.LoadOrStore(inst.name, instance{n.name, args})
+	}
+	return inst
+}
+
+// typeArguments returns the type arguments that the index of an
+// instantiation gives in e, where the type parameters and local types
+// they name stand for the types they are bound to
+func (r *run) typeArguments(index ast.Expression, e *env) []ast.Expression {
+	args := ast.TypeArguments(index)
+	resolved := make([]ast.Expression, len(args))
+	for i, arg := range args {
+		resolved[i] = resolveType(arg, e)
+	}
+	return resolved
+}
+
+// declaredType returns the type declared with 类型 that typ names, if it
+// names one: by its name, or by instantiating a generic one
+func (r *run) declaredType(typ ast.Expression, e *env) (namedType, bool) {
+	var args []ast.Expression
+	if inst, ok := typ.(*ast.IndexExpression); ok {
+		typ, args = inst.Left, r.typeArguments(inst.Index, e)
+	}
+	ident, ok := typ.(*ast.Identifier)
+	if !ok {
+		return namedType{}, false
+	}
+	b, ok := e.lookup(ident.Value)
+	if !ok {
+		return namedType{}, false
+	}
+	named, ok := b.value.(namedType)
+	if ok && len(named.params) > 0 {
+		named = r.instantiate(named, args)
+	}
+	return named, ok
+}
+
+// resolveType returns typ with the types that the type parameters and
+// local types it names are bound to in e in place of them, as in the body
+// of an instantiated generic function, so that its values have the types
+// the compiled program gives them
+func resolveType(typ ast.Expression, e *env) ast.Expression {
+	types := map[string]ast.Expression{}
+	ast.Inspect(typ, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Identifier); ok {
+			if b, ok := e.lookup(ident.Value); ok {
+				if named, ok := b.value.(namedType); ok && named.name == "" && len(named.params) == 0 {
+					types[ident.Value] = named.typ
+				}
+			}
+		}
+		return true
+	})
+	if len(types) == 0 {
+		return typ
+	}
+	return ast.Substitute(typ, types)
+}
+
+// inferTypes returns the type arguments of a call of the generic function
+// fn with args, inferred from the Go types of the arguments as Go infers
+// them from their types. A type parameter that no argument tells is 任意.
+func (r *run) inferTypes(fn *function, args []any, spread bool) []ast.Expression {
+	types := map[string]ast.Expression{}
+	for _, param := range fn.typeParams {
+		types[param.Value] = nil
+	}
+	for i, arg := range args {
+		if len(fn.parameters) == 0 {
+			break
+		}
+		param := fn.parameters[min(i, len(fn.parameters)-1)]
+		typ := param.Type
+		if param.Variadic && spread {
+			typ = &ast.SliceType{Elem: typ}
+		}
+		if arg != nil {
+			r.unify(typ, reflect.TypeOf(arg), types)
+		}
+	}
+	inferred := make([]ast.Expression, len(fn.typeParams))
+	for i, param := range fn.typeParams {
+		if inferred[i] = types[param.Value]; inferred[i] == nil {
+			inferred[i] = &ast.Identifier{Value: "任意"}
+		}
+	}
+	return inferred
+}
+
+// unify binds the type parameters in types that typ, the type of a
+// parameter, names where t, the Go type of its argument, has a type
+func (r *run) unify(typ ast.Expression, t reflect.Type, types map[string]ast.Expression) {
+	switch typ := typ.(type) {
+	case *ast.Identifier:
+		if bound, ok := types[typ.Value]; ok && bound == nil {
+			types[typ.Value] = r.typeExpression(t)
+		}
+	case *ast.SliceType:
+		if t.Kind() == reflect.Slice {
+			r.unify(typ.Elem, t.Elem(), types)
+		}
+	case *ast.ArrayType:
+		if t.Kind() == reflect.Array {
+			r.unify(typ.Elem, t.Elem(), types)
+		}
+	case *ast.ChanType:
+		if t.Kind() == reflect.Chan {
+			r.unify(typ.Elem, t.Elem(), types)
+		}
+	case *ast.MapType:
+		if t.Kind() == reflect.Map {
+			r.unify(typ.Key, t.Key(), types)
+			r.unify(typ.Value, t.Elem(), types)
+		}
+	case *ast.IndexExpression:
+		// An instance of a generic type gives its type arguments
+		generic, ok := typ.Left.(*ast.Identifier)
+		if !ok || t.Kind() != reflect.Struct || t.NumField() == 0 {
+			return
+		}
+		inst, ok := r.instances.Load(t.Field(0).Tag.Get(typeTag))
+		if !ok || inst.(instance).generic != generic.Value {
+			return
+		}
+		args := inst.(instance).args
+		for i, param := range ast.TypeArguments(typ.Index) {
+			ident, ok := param.(*ast.Identifier)
+			if !ok || i >= len(args) {
+				continue
+			}
+			if bound, isParam := types[ident.Value]; isParam && bound == nil {
+				types[ident.Value] = args[i]
+			}
+		}
+	}
+}
+
+// typeExpression returns the Saika type of the values of the Go type t,
+// or nil when it has none that the interpreter can tell
+func (r *run) typeExpression(t reflect.Type) ast.Expression {
+	switch t.Kind() {
+	case reflect.Int:
+		return &ast.Identifier{Value: "整数"}
+	case reflect.String:
+		return &ast.Identifier{Value: "字符串"}
+	case reflect.Float64:
+		return &ast.Identifier{Value: "浮点"}
+	case reflect.Bool:
+		return &ast.Identifier{Value: "布尔"}
+	case reflect.Slice:
+		if elem := r.typeExpression(t.Elem()); elem != nil {
+			return &ast.SliceType{Elem: elem}
+		}
+	case reflect.Array:
+		if elem := r.typeExpression(t.Elem()); elem != nil {
+			return &ast.ArrayType{Len: &ast.IntegerLiteral{Value: int64(t.Len())}, Elem: elem}
+		}
+	case reflect.Map:
+		key, value := r.typeExpression(t.Key()), r.typeExpression(t.Elem())
+		if key != nil && value != nil {
+			return &ast.MapType{Key: key, Value: value}
+		}
+	case reflect.Chan:
+		if elem := r.typeExpression(t.Elem()); elem != nil {
+			return &ast.ChanType{Elem: elem}
+		}
+	case reflect.Struct:
+		// A struct of a package-level type is tagged with its name
+		if t.NumField() == 0 {
+			return nil
+		}
+		name := t.Field(0).Tag.Get(typeTag)
+		if inst, ok := r.instances.Load(name); ok {
+			inst := inst.(instance)
+			return &ast.IndexExpression{Left: &ast.Identifier{Value: inst.generic}, Index: &ast.ExpressionList{Values: inst.args}}
+		}
+		if name != "" {
+			return &ast.Identifier{Value: name}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,1067 @@
+// Package interp runs Saika programs by walking their lowered syntax tree,
+// without generating Go code or needing the Go toolchain. Values are held
+// as the Go values the compiled program would use, so a program prints the
+// same output whichever way it is run.
+package interp
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"go/constant"
+	"html/template"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/codegen"
+)
+
+// maxCallDepth bounds recursion, so a runaway program reports a stack
+// overflow instead of crashing the interpreter
+const maxCallDepth = 10000
+
+// File is one lowered source file of a program
+type File struct {
+	Path    string
+	Program *ast.Program
+}
+
+// BuildInfo holds the values the 构建信息 builtin reports
+type BuildInfo struct {
+	Version     string
+	Commit      string
+	Time        string
+	ToolVersion string
+}
+
+// Interpreter runs lowered Saika programs
+type Interpreter struct {
+	Stdout    io.Writer
+	Args      []string // os.Args of the program, starting with its name
+	BuildInfo BuildInfo
+	StaticDir string // directory 静态文件 reads its files from, if any
+}
+
+// New creates an Interpreter writing to standard output
+func New() *Interpreter {
+	return &Interpreter{
+		Stdout:    os.Stdout,
+		Args:      []string{"saika-program"},
+		BuildInfo: BuildInfo{Version: "dev"},
+	}
+}
+
+// RuntimeError is an error raised while running a program
+type RuntimeError struct {
+	Path string
+	Pos  ast.Position
+	Msg  string
+}
+
+func (e *RuntimeError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("Line %d:%d: %s", e.Pos.Line, e.Pos.Column, e.Msg)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.Path, e.Pos.Line, e.Pos.Column, e.Msg)
+}
+
+// ExitError reports that the program exited with a non-zero status
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// exitPanic unwinds the interpreter when the program calls os.Exit
+type exitPanic int
+
+// Run runs the program formed by files, which must be package main,
+// initializing the package variables and then calling 入口. It stops with
+// ctx's error when ctx is done.
+func (in *Interpreter) Run(ctx context.Context, files []File) (err error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	r := &run{ctx: ctx, cancel: cancel, interp: in, pkg: newEnv(nil), templates: map[string]*template.Template{}, methods: map[string]map[string]*function{}, instances: &sync.Map{}}
+	r.packages = goPackages(r)
+
+	defer func() {
+		if v := recover(); v != nil {
+			code, ok := v.(exitPanic)
+			if !ok {
+				panic(v)
+			}
+			if code != 0 {
+				err = &ExitError{Code: int(code)}
+			} else {
+				err = nil
+			}
+		}
+	}()
+
+	if err := r.declare(files); err != nil {
+		return err
+	}
+	if err := r.initialize(); err != nil {
+		return err
+	}
+	if err := r.parseOptions(); err != nil {
+		return err
+	}
+
+	entry, ok := r.pkg.lookup("入口")
+	if !ok {
+		return fmt.Errorf("program has no 入口 function")
+	}
+	fn, ok := entry.value.(*function)
+	if !ok {
+		return fmt.Errorf("入口 is not a function")
+	}
+	_, err = r.call(fn, nil, false, fn.pos)
+	if cause := context.Cause(ctx); errors.Is(err, context.Canceled) && !errors.Is(cause, context.Canceled) {
+		// A goroutine failed or exited the program
+		var exit *ExitError
+		if errors.As(cause, &exit) && exit.Code == 0 {
+			return nil
+		}
+		return cause
+	}
+	if errors.Is(err, context.Canceled) && len(r.signalHandlers) > 0 {
+		return r.handleSignal(ctx)
+	}
+	return err
+}
+
+// goStatement starts the call of a 协程 statement in a goroutine, with
+// its function and arguments evaluated now. The goroutine has a call
+// stack of its own. As in Go, it ends with the program, and a goroutine
+// that fails or exits ends the program.
+func (r *run) goStatement(stmt *ast.GoStatement, e *env, file *fileEnv) error {
+	callee, args, err := r.callOperands(stmt.Call, e, file)
+	if err != nil {
+		return err
+	}
+
+	g := *r
+	g.depth = 0
+	g.deferred = nil
+	g.recoverable = nil
+	g.templates = map[string]*template.Template{}
+	go func() {
+		defer func() {
+			if v := recover(); v != nil {
+				code, ok := v.(exitPanic)
+				if !ok {
+					panic(v)
+				}
+				r.cancel(&ExitError{Code: int(code)})
+			}
+		}()
+		if _, err := g.invoke(stmt.Call, callee, args, file); err != nil && !errors.Is(err, context.Canceled) {
+			r.cancel(err)
+		}
+	}()
+	return nil
+}
+
+// signalHandler is the body of a 捕获信号 statement that has run, with the
+// scope it ran in
+type signalHandler struct {
+	body *ast.BlockStatement
+	env  *env
+	file *fileEnv
+}
+
+// handleSignal runs the signal handlers once the run was canceled, as by
+// an interrupt. Unlike a compiled program, whose handlers run alongside
+// it, the interpreted program has stopped by then.
+func (r *run) handleSignal(ctx context.Context) error {
+	r.ctx = context.WithoutCancel(ctx)
+	for _, h := range r.signalHandlers {
+		_, err := r.withDeferred(func() (*returned, error) {
+			return r.block(h.body, functionEnv(h.env, nil), h.file)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run holds the state of one program run
+type run struct {
+	ctx      context.Context
+	cancel   context.CancelCauseFunc // ends the run, as when a goroutine fails
+	interp   *Interpreter
+	pkg      *env
+	packages map[string]map[string]any // Go import path to member values
+	depth    int
+
+	// templates caches the templates parsed by 渲染模板 and 输出模板
+	templates map[string]*template.Template
+
+	// vars lists the package variables and constants in source order,
+	// with the file environment their values are evaluated in
+	vars []packageVar
+
+	// options lists the 选项 declarations of the program
+	options []*ast.OptionStatement
+
+	// signalHandlers lists the 捕获信号 handlers registered so far
+	signalHandlers []signalHandler
+
+	// methods holds the methods declared on each package-level type, by
+	// the name of the type
+	methods map[string]map[string]*function
+
+	// instances holds the instances of generic package-level types made
+	// so far, by their names with their type arguments
+	instances *sync.Map
+
+	// deferred holds the calls deferred with 推迟 by each running
+	// function, innermost last
+	deferred [][]func() error
+
+	// recoverable holds the panics the running deferred calls may
+	// recover with 恢复, innermost last
+	recoverable []recoverable
+}
+
+// packageVar is a package-level variable or constant awaiting its value,
+// or several variables declared together from the results of one call
+type packageVar struct {
+	file  *fileEnv
+	names []string
+	value ast.Expression
+	typ   ast.Expression // declared type of a single variable, if any
+}
+
+// fileEnv is the environment of one file, holding its imports
+type fileEnv struct {
+	*env
+	path string
+}
+
+// numbered returns the environment of the file for the value of the
+// constant with index i in its declaration, as numbered returns for env
+func (f *fileEnv) numbered(i int) *fileEnv {
+	return &fileEnv{env: numbered(f.env, i), path: f.path}
+}
+
+// function is a Saika function value: a declared function or a function
+// literal, which keeps the scope it was created in, or a method, bound to
+// a receiver when it is selected from one
+type function struct {
+	name       string
+	parameters []*ast.TypedParam
+	returnType ast.Expression
+	body       *ast.BlockStatement
+	pos        ast.Position
+	file       *fileEnv
+	env        *env
+	run        *run
+
+	// receiver is the receiver of a method, and changes whether the
+	// method changes it; see ast.FunctionStatement.ChangesReceiver
+	receiver *ast.TypedParam
+	changes  bool
+
+	// typeParams are the type parameters of a generic function, or of the
+	// generic type of a method, which type arguments instantiate
+	typeParams []*ast.Identifier
+
+	// self is the receiver a method is bound to, and store stores it
+	// back into the variable it came from once a call of a method that
+	// changes it returns, as the compiled program does through a pointer.
+	// Through that pointer, each call sees the changes made before it,
+	// so load, when set, loads the receiver anew for each call.
+	self  any
+	store func(any) error
+	load  func() (any, error)
+}
+
+// declare binds the imports of each file and the package-level names of
+// all files
+func (r *run) declare(files []File) error {
+	for _, f := range files {
+		file := &fileEnv{env: newEnv(r.pkg), path: f.Path}
+		for _, stmt := range f.Program.Statements {
+			switch stmt := stmt.(type) {
+			case *ast.PackageStatement:
+				if stmt.Name != "main" {
+					return r.errorf(file, stmt.Token.Position, "package %s is not a main package", stmt.Name)
+				}
+			case *ast.ImportStatement:
+				pkg, err := r.importPackage(stmt.Path)
+				if err != nil {
+					return r.errorf(file, stmt.Token.Position, "%v", err)
+				}
+				for _, name := range pkg.names {
+					file.define(name, pkg, false)
+				}
+			case *ast.FunctionStatement:
+				fn := &function{
+					name:       stmt.Name.Value,
+					parameters: stmt.Parameters,
+					returnType: stmt.ReturnType,
+					body:       stmt.Body,
+					pos:        stmt.Token.Position,
+					file:       file,
+					env:        file.env,
+					run:        r,
+				}
+				if stmt.Receiver == nil {
+					for _, tp := range stmt.TypeParams {
+						fn.typeParams = append(fn.typeParams, tp.Name)
+					}
+					r.pkg.define(stmt.Name.Value, fn, true)
+					break
+				}
+				typ, params := codegen.ReceiverType(stmt)
+				fn.name = typ + "." + fn.name
+				fn.receiver, fn.changes, fn.typeParams = stmt.Receiver, stmt.ChangesReceiver, params
+				if r.methods[typ] == nil {
+					r.methods[typ] = map[string]*function{}
+				}
+				r.methods[typ][stmt.Name.Value] = fn
+			case *ast.VarStatement:
+				r.pkg.define(stmt.Name.Value, nil, false)
+				r.vars = append(r.vars, packageVar{file, []string{stmt.Name.Value}, stmt.Value, stmt.Type})
+			case *ast.VarListStatement:
+				names := make([]string, len(stmt.Names))
+				for i, name := range stmt.Names {
+					names[i] = name.Value
+					if !ast.IsBlank(name.Value) {
+						r.pkg.define(name.Value, nil, false)
+					}
+				}
+				r.vars = append(r.vars, packageVar{file, names, stmt.Value, nil})
+			case *ast.ConstStatement:
+				r.pkg.define(stmt.Name.Value, nil, true)
+				r.vars = append(r.vars, packageVar{file.numbered(0), []string{stmt.Name.Value}, stmt.Value, nil})
+			case *ast.ConstGroupStatement:
+				var value ast.Expression
+				for i, c := range stmt.Consts {
+					if c.Value != nil {
+						value = c.Value
+					}
+					r.pkg.define(c.Name.Value, nil, true)
+					r.vars = append(r.vars, packageVar{file.numbered(i), []string{c.Name.Value}, value, nil})
+				}
+			case *ast.TypeStatement:
+				named := namedType{typ: stmt.Type, params: stmt.TypeParams}
+				if !stmt.Alias {
+					named.name = stmt.Name.Value
+				}
+				r.pkg.define(stmt.Name.Value, named, true)
+			case *ast.OptionStatement:
+				r.pkg.define(stmt.Name.Value, nil, false)
+				r.vars = append(r.vars, packageVar{file, []string{stmt.Name.Value}, stmt.Value, nil})
+				r.options = append(r.options, stmt)
+			}
+		}
+	}
+	return nil
+}
+
+// initialize evaluates the package variables and constants in the order
+// they appear in the source
+func (r *run) initialize() error {
+	for _, v := range r.vars {
+		var values []any
+		if len(v.names) > 1 {
+			var err error
+			if values, err = r.values(v.value, len(v.names), v.file.env, v.file); err != nil {
+				return err
+			}
+		} else {
+			value, err := r.declaredValue(v.typ, v.value, v.file.env, v.file)
+			if err != nil {
+				return err
+			}
+			values = []any{value}
+		}
+		for i, name := range v.names {
+			if b, ok := r.pkg.lookup(name); ok && !ast.IsBlank(name) {
+				b.value = values[i]
+				if b.constant {
+					b.exact, _ = constantOf(v.value, v.file.env)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// declaredValue evaluates the value of a variable declared with type typ,
+// or with none when typ is nil: value converted to the type, or the zero
+// value of the type when there is no value
+func (r *run) declaredValue(typ, value ast.Expression, e *env, file *fileEnv) (any, error) {
+	if named, ok := r.declaredType(typ, e); ok {
+		if value == nil {
+			return reflect.Zero(named.reflectType()).Interface(), nil
+		}
+		typ = named.typ
+	}
+	typ = resolveType(typ, e)
+	if value == nil {
+		return zeroValue(typ), nil
+	}
+	v, err := r.eval(value, e, file)
+	if err != nil || typ == nil {
+		return v, err
+	}
+	return convertToType(v, typ), nil
+}
+
+// values evaluates expr for declaring or assigning n values. With two, a map
+// index or a receive gives the value and whether it was there, as the
+// comma-ok forms do in Go; anything else is unpacked.
+func (r *run) values(expr ast.Expression, n int, e *env, file *fileEnv) ([]any, error) {
+	if n == 2 {
+		switch expr := expr.(type) {
+		case *ast.IndexExpression:
+			container, key, err := r.indexOperands(expr, e, file)
+			if err != nil {
+				return nil, err
+			}
+			if reflect.ValueOf(container).Kind() != reflect.Map {
+				return nil, r.errorf(file, positionOf(expr), "assignment mismatch: 2 variables but %s returns 1 value", expr.String())
+			}
+			value, found, err := r.lookupIndex(expr, container, key, file)
+			return []any{value, found}, err
+		case *ast.PrefixExpression:
+			if expr.Operator == "<-" {
+				ch, err := r.eval(expr.Right, e, file)
+				if err != nil {
+					return nil, err
+				}
+				value, ok, err := r.receive(ch, file, expr.Token.Position)
+				return []any{value, ok}, err
+			}
+		}
+	}
+	value, err := r.eval(expr, e, file)
+	if err != nil {
+		return nil, err
+	}
+	return r.unpack(value, n, expr, file)
+}
+
+// unpack returns the n values that value, the result of expr, holds: the
+// value itself when n is 1, or else the results of a call
+func (r *run) unpack(value any, n int, expr ast.Expression, file *fileEnv) ([]any, error) {
+	results, ok := value.(tuple)
+	if n == 1 {
+		if ok {
+			return nil, r.errorf(file, positionOf(expr), "multiple-value %s in single-value context", expr.String())
+		}
+		return []any{value}, nil
+	}
+	if !ok || len(results) != n {
+		have := 1
+		if ok {
+			have = len(results)
+		}
+		return nil, r.errorf(file, positionOf(expr), "assignment mismatch: %d variables but %s returns %d values", n, expr.String(), have)
+	}
+	return results, nil
+}
+
+// parseOptions sets the options from the program's arguments the way the
+// flag package does for compiled programs, exiting after printing the
+// usage for -h or a bad argument
+func (r *run) parseOptions() error {
+	if len(r.options) == 0 {
+		return nil
+	}
+
+	name := "saika"
+	var args []string
+	if len(r.interp.Args) > 0 {
+		name, args = r.interp.Args[0], r.interp.Args[1:]
+	}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+
+	values := make([]any, len(r.options))
+	for i, opt := range r.options {
+		b, _ := r.pkg.lookup(opt.Name.Value)
+		usage := ""
+		if opt.Usage != nil {
+			usage = opt.Usage.Text()
+		}
+		switch value := convertToType(b.value, opt.Type).(type) {
+		case int:
+			values[i] = fs.Int(opt.Name.Value, value, usage)
+		case string:
+			values[i] = fs.String(opt.Name.Value, value, usage)
+		case float64:
+			values[i] = fs.Float64(opt.Name.Value, value, usage)
+		case bool:
+			values[i] = fs.Bool(opt.Name.Value, value, usage)
+		default:
+			return fmt.Errorf("option %s has a %s value, want %s", opt.Name.Value, typeName(value), opt.Type.Value)
+		}
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			panic(exitPanic(0))
+		}
+		panic(exitPanic(2))
+	}
+	for i, opt := range r.options {
+		b, _ := r.pkg.lookup(opt.Name.Value)
+		b.value = reflect.ValueOf(values[i]).Elem().Interface()
+	}
+	return nil
+}
+
+// call calls a Saika function with evaluated arguments. Unless spread is
+// set, the arguments for a variadic parameter are collected into a slice.
+func (r *run) call(fn *function, args []any, spread bool, at ast.Position) (any, error) {
+	if err := r.ctx.Err(); err != nil {
+		return nil, err
+	}
+	// A generic function called without type arguments infers them
+	if len(fn.typeParams) > 0 && fn.receiver == nil {
+		fn = fn.instantiate(r.inferTypes(fn, args, spread))
+	}
+	params := fn.parameters
+	variadic := len(params) > 0 && params[len(params)-1].Variadic
+	if spread && !variadic {
+		return nil, r.errorf(fn.file, at, "have (...) arguments in call to non-variadic %s", fn.name)
+	}
+	if variadic && !spread && len(args) >= len(params)-1 {
+		last := len(params) - 1
+		rest, err := variadicSlice(args[last:], params[last].Type)
+		if err != nil {
+			return nil, r.errorf(fn.file, at, "in call to %s: %v", fn.name, err)
+		}
+		args = append(args[:last:last], rest)
+	}
+	if len(args) != len(params) {
+		return nil, r.errorf(fn.file, at, "wrong number of arguments in call to %s: have %d, want %d",
+			fn.name, len(args), len(params))
+	}
+	if r.depth >= maxCallDepth {
+		return nil, r.errorf(fn.file, at, "stack overflow in %s", fn.name)
+	}
+	r.depth++
+	defer func() { r.depth-- }()
+
+	scope := functionEnv(fn.env, fn.returnType)
+	if fn.receiver != nil {
+		self := fn.self
+		if fn.load != nil {
+			var err error
+			if self, err = fn.load(); err != nil {
+				return nil, err
+			}
+		}
+		scope.define(fn.receiver.Name.Value, self, false)
+	}
+	for i, param := range params {
+		arg := args[i]
+		if param.Type != nil {
+			arg = convertToType(arg, param.Type)
+		}
+		scope.define(param.Name.Value, arg, false)
+	}
+	results, named := fn.returnType.(*ast.ResultList)
+	if named = named && len(results.Names) > 0; named {
+		for i, name := range results.Names {
+			scope.define(name.Value, zeroValue(results.Types[i]), false)
+		}
+	}
+
+	result, err := r.withDeferred(func() (*returned, error) {
+		ret, err := r.block(fn.body, scope, fn.file)
+		if named && ret != nil && ret.value != nil {
+			// The values returned are given to the named results, which
+			// deferred calls may change before the function returns
+			values, ok := ret.value.(tuple)
+			if len(results.Names) == 1 || !ok {
+				values = tuple{ret.value}
+			}
+			for i, name := range results.Names {
+				if i < len(values) {
+					b, _ := scope.lookup(name.Value)
+					b.value = convertToType(values[i], results.Types[i])
+				}
+			}
+		}
+		return ret, err
+	})
+	if fn.store != nil {
+		// The changes stay even when the method panics, as they do
+		// through a pointer
+		b, _ := scope.lookup(fn.receiver.Name.Value)
+		if storeErr := fn.store(b.value); err == nil {
+			err = storeErr
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if named {
+		values := make(tuple, len(results.Names))
+		for i, name := range results.Names {
+			b, _ := scope.lookup(name.Value)
+			values[i] = b.value
+		}
+		if len(values) == 1 {
+			return values[0], nil
+		}
+		return values, nil
+	}
+	if result == nil {
+		if fn.returnType != nil {
+			// Only a recovered panic ends a function with results this way
+			return zeroValue(fn.returnType), nil
+		}
+		return nil, nil
+	}
+	if fn.returnType != nil {
+		return convertToType(result.value, fn.returnType), nil
+	}
+	return result.value, nil
+}
+
+// variadicSlice collects the arguments passed to a variadic parameter of
+// type ...typ into a slice, which is nil when there are none, as in Go
+func variadicSlice(args []any, typ ast.Expression) (any, error) {
+	t := reflect.SliceOf(reflectType(typ))
+	if len(args) == 0 {
+		return reflect.Zero(t).Interface(), nil
+	}
+	s := reflect.MakeSlice(t, 0, len(args))
+	for _, arg := range args {
+		v, err := convertValue(convertToType(arg, typ), t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		s = reflect.Append(s, v)
+	}
+	return s.Interface(), nil
+}
+
+// withDeferred runs body, the body of a function, and then the calls it
+// deferred, last first. Deferred calls run after an error too, unless the
+// run was canceled; the first error is returned, except that a panic in a
+// deferred call replaces the one before it, as in Go. A panic recovered by
+// a deferred call makes the function return its zero value.
+func (r *run) withDeferred(body func() (*returned, error)) (*returned, error) {
+	r.deferred = append(r.deferred, nil)
+	ret, err := body()
+	calls := r.deferred[len(r.deferred)-1]
+	r.deferred = r.deferred[:len(r.deferred)-1]
+
+	for i := len(calls) - 1; i >= 0 && r.ctx.Err() == nil; i-- {
+		recovered, callErr := r.runDeferred(calls[i], err)
+		if recovered {
+			ret, err = nil, nil
+		}
+		if err == nil || asPanic(callErr) != nil {
+			err = callErr
+		}
+	}
+	return ret, err
+}
+
+// returned carries the value of a return statement out of nested blocks
+type returned struct {
+	value any
+}
+
+// block runs the statements of a block in a new scope nested in e
+func (r *run) block(block *ast.BlockStatement, e *env, file *fileEnv) (*returned, error) {
+	if block == nil {
+		return nil, nil
+	}
+	scope := newEnv(e)
+	for _, stmt := range block.Statements {
+		if ret, err := r.statement(stmt, scope, file); ret != nil || err != nil {
+			return ret, err
+		}
+	}
+	return nil, nil
+}
+
+// statement runs a statement, returning non-nil when it returns from the
+// enclosing function
+func (r *run) statement(stmt ast.Statement, e *env, file *fileEnv) (*returned, error) {
+	switch stmt := stmt.(type) {
+	case *ast.VarStatement:
+		value, err := r.declaredValue(stmt.Type, stmt.Value, e, file)
+		if err != nil {
+			return nil, err
+		}
+		e.define(stmt.Name.Value, value, false)
+	case *ast.VarListStatement:
+		values, err := r.values(stmt.Value, len(stmt.Names), e, file)
+		if err != nil {
+			return nil, err
+		}
+		for i, name := range stmt.Names {
+			if !ast.IsBlank(name.Value) {
+				e.define(name.Value, values[i], false)
+			}
+		}
+	case *ast.ConstStatement:
+		if err := r.defineConstant(stmt.Name, stmt.Value, numbered(e, 0), e, file); err != nil {
+			return nil, err
+		}
+	case *ast.ConstGroupStatement:
+		var valueExpr ast.Expression
+		for i, c := range stmt.Consts {
+			if c.Value != nil {
+				valueExpr = c.Value
+			}
+			if err := r.defineConstant(c.Name, valueExpr, numbered(e, i), e, file); err != nil {
+				return nil, err
+			}
+		}
+	case *ast.TypeStatement:
+		e.define(stmt.Name.Value, namedType{typ: stmt.Type, params: stmt.TypeParams}, true)
+	case *ast.ReturnStatement:
+		if stmt.ReturnValue == nil {
+			return &returned{}, nil
+		}
+		value, err := r.eval(stmt.ReturnValue, e, file)
+		if err != nil {
+			return nil, err
+		}
+		return &returned{value: value}, nil
+	case *ast.IfStatement:
+		cond, err := r.condition(stmt.Condition, e, file)
+		if err != nil {
+			return nil, err
+		}
+		if cond {
+			return r.block(stmt.Consequence, e, file)
+		}
+		return r.block(stmt.Alternative, e, file)
+	case *ast.ForStatement:
+		return r.forStatement(stmt, e, file)
+	case *ast.SignalStatement:
+		r.signalHandlers = append(r.signalHandlers, signalHandler{stmt.Body, e, file})
+	case *ast.DeferStatement:
+		if len(r.deferred) == 0 {
+			return nil, r.errorf(file, stmt.Token.Position, "%s outside a function", stmt.Token.Literal)
+		}
+		// The function and arguments are evaluated now, the call later
+		callee, args, err := r.callOperands(stmt.Call, e, file)
+		if err != nil {
+			return nil, err
+		}
+		top := len(r.deferred) - 1
+		r.deferred[top] = append(r.deferred[top], func() error {
+			_, err := r.invoke(stmt.Call, callee, args, file)
+			return err
+		})
+	case *ast.GoStatement:
+		return nil, r.goStatement(stmt, e, file)
+	case *ast.SelectStatement:
+		return r.selectStatement(stmt, e, file)
+	case *ast.SwitchStatement:
+		return r.switchStatement(stmt, e, file)
+	case *ast.SendStatement:
+		ch, err := r.eval(stmt.Channel, e, file)
+		if err != nil {
+			return nil, err
+		}
+		value, err := r.eval(stmt.Value, e, file)
+		if err != nil {
+			return nil, err
+		}
+		return nil, r.send(ch, value, file, stmt.Token.Position)
+	case *ast.CharLoopStatement:
+		return r.charLoopStatement(stmt, e, file)
+	case *ast.RangeStatement:
+		return r.rangeStatement(stmt, e, file)
+	case *ast.QueryStatement:
+		return r.queryStatement(stmt, e, file)
+	case *ast.BlockStatement:
+		return r.block(stmt, e, file)
+	case *ast.AssignListStatement:
+		values, err := r.values(stmt.Value, len(stmt.Targets), e, file)
+		if err != nil {
+			return nil, err
+		}
+		for i, target := range stmt.Targets {
+			if err := r.store(target, values[i], stmt.Value, e, file); err != nil {
+				return nil, err
+			}
+		}
+	case *ast.ExpressionStatement:
+		_, err := r.eval(stmt.Expression, e, file)
+		return nil, err
+	default:
+		return nil, r.errorf(file, positionOf(stmt), "%T is not supported by the interpreter", stmt)
+	}
+	return nil, nil
+}
+
+// forStatement runs a loop; its init statement is scoped to the loop
+func (r *run) forStatement(stmt *ast.ForStatement, e *env, file *fileEnv) (*returned, error) {
+	loop := newEnv(e)
+	if stmt.Init != nil {
+		if _, err := r.statement(stmt.Init, loop, file); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		if err := r.ctx.Err(); err != nil {
+			return nil, err
+		}
+		if stmt.Condition != nil {
+			cond, err := r.condition(stmt.Condition, loop, file)
+			if err != nil {
+				return nil, err
+			}
+			if !cond {
+				return nil, nil
+			}
+		}
+		if ret, err := r.block(stmt.Body, loop, file); ret != nil || err != nil {
+			return ret, err
+		}
+		if stmt.Update != nil {
+			if _, err := r.statement(stmt.Update, loop, file); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// charLoopStatement runs a loop over the characters of a string, binding
+// the index and character of each in a new scope
+func (r *run) charLoopStatement(stmt *ast.CharLoopStatement, e *env, file *fileEnv) (*returned, error) {
+	value, err := r.eval(stmt.Value, e, file)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := value.(string)
+	if !ok {
+		return nil, r.errorf(file, positionOf(stmt.Value), "cannot range over characters of %s", typeName(value))
+	}
+
+	for i, c := range []rune(s) {
+		if err := r.ctx.Err(); err != nil {
+			return nil, err
+		}
+		loop := newEnv(e)
+		if stmt.Index != nil {
+			loop.define(stmt.Index.Value, i, false)
+		}
+		loop.define(stmt.Char.Value, string(c), false)
+		if ret, err := r.block(stmt.Body, loop, file); ret != nil || err != nil {
+			return ret, err
+		}
+	}
+	return nil, nil
+}
+
+// rangeStatement runs a loop over the elements of a slice or array, or
+// the values of an iterator, binding each in a new scope
+func (r *run) rangeStatement(stmt *ast.RangeStatement, e *env, file *fileEnv) (*returned, error) {
+	value, err := r.eval(stmt.Value, e, file)
+	if err != nil {
+		return nil, err
+	}
+	body := func(value any) (*returned, error) {
+		if err := r.ctx.Err(); err != nil {
+			return nil, err
+		}
+		loop := newEnv(e)
+		loop.define(stmt.Name.Value, value, false)
+		return r.block(stmt.Body, loop, file)
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if ret, err := body(v.Index(i).Interface()); ret != nil || err != nil {
+				return ret, err
+			}
+		}
+		return nil, nil
+	}
+	next, current := r.method(v, "下一个"), r.method(v, "值")
+	if next == nil || current == nil {
+		return nil, r.errorf(file, positionOf(stmt.Value), "cannot range over %s", typeName(value))
+	}
+
+	// The methods work on a copy of the iterator, which 下一个 moves along
+	// when it changes its receiver
+	it := value
+	bind := func(fn *function) *function {
+		bound := *fn
+		bound.self = it
+		if fn.changes {
+			bound.store = func(value any) error {
+				it = value
+				return nil
+			}
+		}
+		return &bound
+	}
+	for {
+		more, err := r.call(bind(next), nil, false, stmt.Token.Position)
+		if err != nil {
+			return nil, err
+		}
+		if more, _ := more.(bool); !more {
+			return nil, nil
+		}
+		value, err := r.call(bind(current), nil, false, stmt.Token.Position)
+		if err != nil {
+			return nil, err
+		}
+		if ret, err := body(value); ret != nil || err != nil {
+			return ret, err
+		}
+	}
+}
+
+// switchStatement runs a 选择 statement: it evaluates the tag, then the
+// values of its cases in order until one equals the tag, or is true when
+// there is no tag, and runs the statements of that case, or of 默认 when
+// none does
+func (r *run) switchStatement(stmt *ast.SwitchStatement, e *env, file *fileEnv) (*returned, error) {
+	var tag any = true
+	if stmt.Tag != nil {
+		var err error
+		if tag, err = r.eval(stmt.Tag, e, file); err != nil {
+			return nil, err
+		}
+	}
+
+	var defaultCase *ast.SwitchCase
+	for _, clause := range stmt.Cases {
+		if len(clause.Values) == 0 {
+			defaultCase = clause
+			continue
+		}
+		for _, expr := range clause.Values {
+			value, err := r.eval(expr, e, file)
+			if err != nil {
+				return nil, err
+			}
+			equal, err := binary("==", tag, value)
+			if err != nil {
+				return nil, r.errorf(file, positionOf(expr), "%v", err)
+			}
+			if equal.(bool) {
+				return r.block(&ast.BlockStatement{Statements: clause.Body}, e, file)
+			}
+		}
+	}
+	if defaultCase == nil {
+		return nil, nil
+	}
+	return r.block(&ast.BlockStatement{Statements: defaultCase.Body}, e, file)
+}
+
+// condition evaluates a condition, which must be a boolean
+func (r *run) condition(expr ast.Expression, e *env, file *fileEnv) (bool, error) {
+	value, err := r.eval(expr, e, file)
+	if err != nil {
+		return false, err
+	}
+	cond, ok := value.(bool)
+	if !ok {
+		return false, r.errorf(file, positionOf(expr), "non-boolean condition (%s)", typeName(value))
+	}
+	return cond, nil
+}
+
+// errorf returns a runtime error at pos in file
+func (r *run) errorf(file *fileEnv, pos ast.Position, format string, args ...any) error {
+	err := &RuntimeError{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+	if file != nil {
+		err.Path = file.path
+	}
+	return err
+}
+
+// positionOf returns where node starts, for error positions
+func positionOf(node ast.Node) ast.Position {
+	return ast.NodeRange(node).Start
+}
+
+// binding is a named value in an environment
+type binding struct {
+	value    any
+	constant bool
+	exact    constant.Value // of a numeric constant, as Go computes it
+}
+
+// env is a scope of named values
+type env struct {
+	vars   map[string]*binding
+	parent *env
+
+	// function is set on the scope of a function's parameters, and
+	// result to the function's return type
+	function bool
+	result   ast.Expression
+}
+
+// newEnv creates a scope nested in parent
+func newEnv(parent *env) *env {
+	return &env{vars: map[string]*binding{}, parent: parent}
+}
+
+// defineConstant defines the local constant name in e with the value of
+// expr, evaluated in scope, keeping its exact value when it is numeric
+func (r *run) defineConstant(name *ast.Identifier, expr ast.Expression, scope, e *env, file *fileEnv) error {
+	value, err := r.eval(expr, scope, file)
+	if err != nil {
+		return err
+	}
+	e.define(name.Value, value, true)
+	e.vars[name.Value].exact, _ = constantOf(expr, scope)
+	return nil
+}
+
+// define binds name in this scope
+func (e *env) define(name string, value any, constant bool) {
+	e.vars[name] = &binding{value: value, constant: constant}
+}
+
+// functionEnv creates the scope of the parameters of a function returning
+// result, nested in parent
+func functionEnv(parent *env, result ast.Expression) *env {
+	e := newEnv(parent)
+	e.function, e.result = true, result
+	return e
+}
+
+// resultType returns the return type of the function whose body e is in
+func (e *env) resultType() ast.Expression {
+	for s := e; s != nil; s = s.parent {
+		if s.function {
+			return s.result
+		}
+	}
+	return nil
+}
+
+// lookup finds name in this scope or an enclosing one
+func (e *env) lookup(name string) (*binding, bool) {
+	for s := e; s != nil; s = s.parent {
+		if b, ok := s.vars[name]; ok {
+			return b, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,256 @@
+package interp
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// errDivideByZero is the runtime error of an integer division by zero
+var errDivideByZero = errors.New("runtime error: integer divide by zero")
+
+// errNegativeShift is the runtime error of a shift by a negative count
+var errNegativeShift = errors.New("runtime error: negative shift amount")
+
+// isNumeric reports whether values of kind k support arithmetic
+func isNumeric(k reflect.Kind) bool {
+	return isInteger(k) || k == reflect.Float32 || k == reflect.Float64
+}
+
+// isInteger reports whether k is a signed or unsigned integer kind
+func isInteger(k reflect.Kind) bool {
+	return reflect.Int <= k && k <= reflect.Uintptr
+}
+
+// unary applies a prefix operator
+func unary(op string, right any) (any, error) {
+	v := reflect.ValueOf(right)
+	switch {
+	case op == "!" && v.Kind() == reflect.Bool:
+		return !v.Bool(), nil
+	case op == "-" && v.IsValid() && isNumeric(v.Kind()):
+		zero := reflect.Zero(v.Type()).Interface()
+		return binary("-", zero, right)
+	case op == "^" && v.IsValid() && isInteger(v.Kind()):
+		result := reflect.New(v.Type()).Elem()
+		if v.Kind() >= reflect.Uint {
+			result.SetUint(^v.Uint())
+		} else {
+			result.SetInt(^v.Int())
+		}
+		return result.Interface(), nil
+	}
+	return nil, fmt.Errorf("invalid operation: operator %s not defined on %v (%s)", op, right, typeName(right))
+}
+
+// binary applies an infix operator. Operands must have the same type,
+// except that a plain integer converts to the type of the other operand,
+// as an untyped constant would in Go.
+func binary(op string, left, right any) (any, error) {
+	l, r := reflect.ValueOf(left), reflect.ValueOf(right)
+	if !l.IsValid() || !r.IsValid() {
+		if op == "==" || op == "!=" {
+			return (isNil(l) && isNil(r)) == (op == "=="), nil
+		}
+		return nil, fmt.Errorf("invalid operation: operator %s not defined on nil", op)
+	}
+
+	// The count of a shift may have any integer type
+	if op == "<<" || op == ">>" {
+		return shift(op, l, r)
+	}
+
+	if l.Type() != r.Type() {
+		_, leftInt := left.(int)
+		_, rightInt := right.(int)
+		switch {
+		case leftInt && isNumeric(r.Kind()):
+			l = l.Convert(r.Type())
+		case rightInt && isNumeric(l.Kind()):
+			r = r.Convert(l.Type())
+		default:
+			return nil, fmt.Errorf("invalid operation: %v %s %v (mismatched types %s and %s)",
+				left, op, right, typeName(left), typeName(right))
+		}
+	}
+
+	switch op {
+	case "==", "!=":
+		if !l.Type().Comparable() {
+			return nil, fmt.Errorf("invalid operation: %s cannot be compared", typeName(left))
+		}
+		return l.Equal(r) == (op == "=="), nil
+	case "<", ">", "<=", ">=":
+		return compare(op, l, r)
+	}
+	return arithmetic(op, l, r)
+}
+
+// isNil reports whether v is nil or the nil value of a slice, map,
+// pointer, function or channel
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Slice, reflect.Map, reflect.Pointer, reflect.Func, reflect.Chan, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// compare applies an ordering operator
+func compare(op string, l, r reflect.Value) (any, error) {
+	var c int
+	switch k := l.Kind(); {
+	case isInteger(k) && k >= reflect.Uint:
+		c = cmp(l.Uint(), r.Uint())
+	case isInteger(k):
+		c = cmp(l.Int(), r.Int())
+	case k == reflect.Float32 || k == reflect.Float64:
+		c = cmp(l.Float(), r.Float())
+	case k == reflect.String:
+		c = cmp(l.String(), r.String())
+	default:
+		return nil, fmt.Errorf("invalid operation: operator %s not defined on %s", op, l.Type())
+	}
+
+	switch op {
+	case "<":
+		return c < 0, nil
+	case ">":
+		return c > 0, nil
+	case "<=":
+		return c <= 0, nil
+	default:
+		return c >= 0, nil
+	}
+}
+
+// cmp compares two ordered values
+func cmp[T int64 | uint64 | float64 | string](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// arithmetic applies an arithmetic operator, keeping the operands' type
+func arithmetic(op string, l, r reflect.Value) (any, error) {
+	result := reflect.New(l.Type()).Elem()
+	switch k := l.Kind(); {
+	case k == reflect.String && op == "+":
+		result.SetString(l.String() + r.String())
+	case isInteger(k) && k >= reflect.Uint:
+		a, b := l.Uint(), r.Uint()
+		if (op == "/" || op == "%") && b == 0 {
+			return nil, errDivideByZero
+		}
+		switch op {
+		case "+":
+			result.SetUint(a + b)
+		case "-":
+			result.SetUint(a - b)
+		case "*":
+			result.SetUint(a * b)
+		case "/":
+			result.SetUint(a / b)
+		case "%":
+			result.SetUint(a % b)
+		case "&":
+			result.SetUint(a & b)
+		case "|":
+			result.SetUint(a | b)
+		case "^":
+			result.SetUint(a ^ b)
+		case "&^":
+			result.SetUint(a &^ b)
+		default:
+			return nil, undefined(op, l)
+		}
+	case isInteger(k):
+		a, b := l.Int(), r.Int()
+		if (op == "/" || op == "%") && b == 0 {
+			return nil, errDivideByZero
+		}
+		switch op {
+		case "+":
+			result.SetInt(a + b)
+		case "-":
+			result.SetInt(a - b)
+		case "*":
+			result.SetInt(a * b)
+		case "/":
+			result.SetInt(a / b)
+		case "%":
+			result.SetInt(a % b)
+		case "&":
+			result.SetInt(a & b)
+		case "|":
+			result.SetInt(a | b)
+		case "^":
+			result.SetInt(a ^ b)
+		case "&^":
+			result.SetInt(a &^ b)
+		default:
+			return nil, undefined(op, l)
+		}
+	case k == reflect.Float32 || k == reflect.Float64:
+		a, b := l.Float(), r.Float()
+		switch op {
+		case "+":
+			result.SetFloat(a + b)
+		case "-":
+			result.SetFloat(a - b)
+		case "*":
+			result.SetFloat(a * b)
+		case "/":
+			result.SetFloat(a / b)
+		default:
+			return nil, undefined(op, l)
+		}
+	default:
+		return nil, undefined(op, l)
+	}
+	return result.Interface(), nil
+}
+
+// shift applies a shift operator, keeping the type of the left operand.
+// Setting the result truncates it to the width of that type.
+func shift(op string, l, r reflect.Value) (any, error) {
+	if !isInteger(l.Kind()) || !isInteger(r.Kind()) {
+		return nil, fmt.Errorf("invalid operation: shift of %s by %s", l.Type(), r.Type())
+	}
+
+	var count uint64
+	if r.Kind() >= reflect.Uint {
+		count = r.Uint()
+	} else if r.Int() < 0 {
+		return nil, errNegativeShift
+	} else {
+		count = uint64(r.Int())
+	}
+
+	result := reflect.New(l.Type()).Elem()
+	if l.Kind() >= reflect.Uint {
+		if op == "<<" {
+			result.SetUint(l.Uint() << count)
+		} else {
+			result.SetUint(l.Uint() >> count)
+		}
+	} else {
+		if op == "<<" {
+			result.SetInt(l.Int() << count)
+		} else {
+			result.SetInt(l.Int() >> count)
+		}
+	}
+	return result.Interface(), nil
+}
+
+// undefined reports an operator that the operand type does not support
+func undefined(op string, operand reflect.Value) error {
+	return fmt.Errorf("invalid operation: operator %s not defined on %s", op, operand.Type())
+}
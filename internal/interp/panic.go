@@ -0,0 +1,120 @@
+package interp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/codegen"
+)
+
+// builtin is a Saika builtin that is not a Go function: 恐慌 and 恢复,
+// which work on the state of the run, and append, copy and delete, which
+// take slices and maps of any type
+type builtin string
+
+// panicked is the error a 恐慌 call unwinds the interpreted call stack
+// with, until a deferred call recovers it with 恢复
+type panicked struct {
+	*RuntimeError
+	value any
+}
+
+// runtimeError is the value 恢复 returns for a runtime error such as an
+// integer division by zero, which panics in Go; it implements
+// runtime.Error
+type runtimeError string
+
+func (e runtimeError) Error() string { return string(e) }
+func (e runtimeError) RuntimeError() {}
+
+// asPanic returns err as a panic that a deferred call may recover: that
+// of a 恐慌 call or of a runtime error. It returns nil for other errors.
+func asPanic(err error) *panicked {
+	switch err := err.(type) {
+	case *panicked:
+		return err
+	case *RuntimeError:
+		if strings.HasPrefix(err.Msg, "runtime error: ") {
+			return &panicked{RuntimeError: err, value: runtimeError(err.Msg)}
+		}
+	}
+	return nil
+}
+
+// recoverable is a panic that the deferred function called at depth may
+// recover, or nil once recovered or when the deferring function did not
+// panic
+type recoverable struct {
+	panic *panicked
+	depth int
+}
+
+// callBuiltin calls 恐慌, 恢复, append, copy or delete
+func (r *run) callBuiltin(expr *ast.CallExpression, b builtin, args []any, file *fileEnv) (any, error) {
+	switch b {
+	case codegen.PanicName:
+		if len(args) != 1 {
+			return nil, r.errorf(file, expr.Token.Position, "wrong number of arguments in call to %s: have %d, want 1", b, len(args))
+		}
+		return nil, &panicked{
+			RuntimeError: r.errorf(file, expr.Token.Position, "panic: %v", args[0]).(*RuntimeError),
+			value:        args[0],
+		}
+	case codegen.RecoverName:
+		if len(args) != 0 {
+			return nil, r.errorf(file, expr.Token.Position, "too many arguments in call to %s", b)
+		}
+		// As in Go, only a function deferred by the panicking function
+		// recovers, and only when it calls 恢复 itself
+		if len(r.recoverable) == 0 {
+			return nil, nil
+		}
+		top := &r.recoverable[len(r.recoverable)-1]
+		if top.panic == nil || top.depth != r.depth {
+			return nil, nil
+		}
+		value := top.panic.value
+		top.panic = nil
+		return value, nil
+	case "append":
+		if len(args) == 0 {
+			return nil, r.errorf(file, expr.Token.Position, "not enough arguments in call to append")
+		}
+		value, err := appendValues(args[0], args[1:], expr.Ellipsis)
+		if err != nil {
+			return nil, r.errorf(file, expr.Token.Position, "append: %v", err)
+		}
+		return value, nil
+	case "copy":
+		if len(args) != 2 {
+			return nil, r.errorf(file, expr.Token.Position, "wrong number of arguments in call to copy: have %d, want 2", len(args))
+		}
+		n, err := copyValues(args[0], args[1])
+		if err != nil {
+			return nil, r.errorf(file, expr.Token.Position, "copy: %v", err)
+		}
+		return n, nil
+	case "delete":
+		if len(args) != 2 {
+			return nil, r.errorf(file, expr.Token.Position, "wrong number of arguments in call to delete: have %d, want 2", len(args))
+		}
+		if err := deleteKey(args[0], args[1]); err != nil {
+			return nil, r.errorf(file, expr.Token.Position, "delete: %v", err)
+		}
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown builtin %s", b)
+}
+
+// runDeferred runs a call deferred by the function at the current depth,
+// which returned err. It reports whether the call recovered err, a panic.
+func (r *run) runDeferred(call func() error, err error) (recovered bool, callErr error) {
+	p := asPanic(err)
+	r.recoverable = append(r.recoverable, recoverable{panic: p, depth: r.depth + 1})
+	defer func() {
+		recovered = p != nil && r.recoverable[len(r.recoverable)-1].panic == nil
+		r.recoverable = r.recoverable[:len(r.recoverable)-1]
+	}()
+	return false, call()
+}
@@ -0,0 +1,100 @@
+package interp
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+)
+
+// preparer is implemented by *sql.DB, *sql.Conn and *sql.Tx
+type preparer interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// openDatabase implements 打开数据库. Only drivers linked into saika are
+// available, so programs needing others must be compiled.
+func openDatabase(driver, source string) *sql.DB {
+	db, err := sql.Open(driver, source)
+	if err != nil {
+		panic(err)
+	}
+	return db
+}
+
+// queryStatement runs a query statement, running its body with the columns
+// of each row bound in a new scope. The query stops with the program. A
+// query that fails returns its error from the enclosing function when that
+// function's last result is an 错误, and stops the program otherwise.
+func (r *run) queryStatement(stmt *ast.QueryStatement, e *env, file *fileEnv) (*returned, error) {
+	at := stmt.Call.Token.Position
+	callee, err := r.eval(stmt.Call.Function, e, file)
+	if err != nil {
+		return nil, err
+	}
+	db, ok := callee.(preparer)
+	if !ok {
+		return nil, r.errorf(file, at, "cannot query %s (%s)", stmt.Call.Function.String(), typeName(callee))
+	}
+
+	args := make([]any, 0, len(stmt.Call.Arguments))
+	for _, arg := range stmt.Call.Arguments {
+		value, err := r.eval(arg, e, file)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, value)
+	}
+	query, ok := args[0].(string)
+	if !ok {
+		return nil, r.errorf(file, positionOf(stmt.Call.Arguments[0]), "query must be a string, got %s", typeName(args[0]))
+	}
+
+	failed := func(err error) (*returned, error) {
+		result := e.resultType()
+		if !ast.ReturnsError(result) {
+			return nil, r.errorf(file, at, "查询: %v", err)
+		}
+		err = fmt.Errorf("查询: %w", err)
+		if results, ok := zeroValue(result).(tuple); ok {
+			results[len(results)-1] = err
+			return &returned{value: results}, nil
+		}
+		return &returned{value: err}, nil
+	}
+
+	prepared, err := db.PrepareContext(r.ctx, query)
+	if err != nil {
+		return failed(err)
+	}
+	defer prepared.Close()
+	rows, err := prepared.QueryContext(r.ctx, args[1:]...)
+	if err != nil {
+		return failed(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		dest := make([]any, len(stmt.Columns))
+		for i, column := range stmt.Columns {
+			dest[i] = reflect.New(reflectType(column.Type)).Interface()
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return failed(err)
+		}
+
+		row := newEnv(e)
+		for i, column := range stmt.Columns {
+			row.define(column.Name.Value, reflect.ValueOf(dest[i]).Elem().Interface(), false)
+		}
+		if ret, err := r.block(stmt.Body, row, file); ret != nil || err != nil {
+			return ret, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return failed(err)
+	}
+	return nil, nil
+}
@@ -0,0 +1,84 @@
+package interp_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/saika-m/saika-lang/internal/interp"
+	"github.com/saika-m/saika-lang/internal/ir"
+	"github.com/saika-m/saika-lang/internal/lexer"
+	"github.com/saika-m/saika-lang/internal/parser"
+)
+
+// failingDriver opens connections on which every query fails to prepare
+type failingDriver struct{}
+
+func (failingDriver) Open(string) (driver.Conn, error) { return failingConn{}, nil }
+
+type failingConn struct{}
+
+func (failingConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("表不存在") }
+func (failingConn) Close() error                        { return nil }
+func (failingConn) Begin() (driver.Tx, error)           { return nil, errors.New("no transactions") }
+
+func init() {
+	sql.Register("saika-failing", failingDriver{})
+}
+
+// TestQueryError returns the error of a failed 查询 from functions whose
+// last result is an 错误, and stops the program elsewhere
+func TestQueryError(t *testing.T) {
+	source := `包 main
+
+导入 "fmt"
+
+变量 库 = 打开数据库("saika-failing", "")
+
+数 计数() (整数, 错误) {
+	变量 总数 = 0
+	查询 (名 字符串) = 库("SELECT name FROM users") {
+		总数 += 1
+	}
+	返回 总数, nil
+}
+
+数 名单() 错误 {
+	查询 (名 字符串) = 库("SELECT name FROM users") {
+		fmt.Println(名)
+	}
+	返回 nil
+}
+
+数 入口() {
+	fmt.Println(计数())
+	fmt.Println(名单())
+	查询 (名 字符串) = 库("SELECT name FROM users") {
+		fmt.Println(名)
+	}
+	fmt.Println("不会运行")
+}
+`
+	p := parser.New(lexer.New(source))
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("parse: %v", errs)
+	}
+
+	var stdout bytes.Buffer
+	in := interp.New()
+	in.Stdout = &stdout
+	err := in.Run(context.Background(), []interp.File{{Path: "query.saika", Program: ir.Lower(program, nil)}})
+
+	want := "0 查询: 表不存在\n查询: 表不存在\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	if err == nil || !strings.Contains(err.Error(), "query.saika:25:16: 查询: 表不存在") {
+		t.Errorf("error = %v, want the failed query in 入口", err)
+	}
+}
@@ -0,0 +1,48 @@
+package interp
+
+import (
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/saika-m/saika-lang/internal/codegen"
+)
+
+// renderTemplate implements 渲染模板 called from a source in dir
+func (r *run) renderTemplate(dir, file string, data any) string {
+	var out strings.Builder
+	r.writeTemplate(&out, dir, file, data)
+	return out.String()
+}
+
+// writeTemplate implements 输出模板 called from a source in dir. Like a
+// compiled program, which embeds the template files next to its sources,
+// it looks for file in dir before the working directory. Templates are
+// parsed once per run.
+func (r *run) writeTemplate(w io.Writer, dir, file string, data any) {
+	path := file
+	if name, ok := codegen.EmbeddedTemplate(file); ok {
+		if next := filepath.Join(dir, filepath.FromSlash(name)); fileExists(next) {
+			path = next
+		}
+	}
+	t, ok := r.templates[path]
+	if !ok {
+		var err error
+		if t, err = template.ParseFiles(path); err != nil {
+			panic(err)
+		}
+		r.templates[path] = t
+	}
+	if err := t.Execute(w, data); err != nil {
+		panic(err)
+	}
+}
+
+// fileExists reports whether path names a regular file
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
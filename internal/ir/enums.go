@@ -0,0 +1,56 @@
+package ir
+
+import (
+	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/codegen"
+)
+
+// lowerEnums replaces each enumeration in stmts by the declarations it
+// stands for: 枚举 颜色 { 红, 绿 } declares the type 颜色 as an integer and
+// its members as constants of it numbered by 序号,
+//
+//	类型 颜色 整数
+//	常量 (
+//		红 = 颜色(序号)
+//		绿
+//	)
+func lowerEnums(stmts []ast.Statement) []ast.Statement {
+	lowered := stmts[:0:0]
+	for _, stmt := range stmts {
+		enum, ok := stmt.(*ast.EnumStatement)
+		if !ok {
+			lowered = append(lowered, stmt)
+			continue
+		}
+		lowered = append(lowered, enumType(enum), enumConsts(enum))
+	}
+	return lowered
+}
+
+// enumType returns the declaration of the type of enum
+func enumType(enum *ast.EnumStatement) *ast.TypeStatement {
+	return &ast.TypeStatement{
+		Token: enum.Token,
+		Name:  enum.Name,
+		Type:  &ast.Identifier{Token: enum.Name.Token, Value: "整数"},
+	}
+}
+
+// enumConsts returns the declaration of the members of enum, the first of
+// which gives the value the others repeat
+func enumConsts(enum *ast.EnumStatement) *ast.ConstGroupStatement {
+	group := &ast.ConstGroupStatement{Token: enum.Token, Rparen: enum.Rbrace}
+	for i, member := range enum.Members {
+		c := &ast.ConstStatement{Name: member}
+		if i == 0 {
+			c.Value = &ast.CallExpression{
+				Token:     member.Token,
+				Function:  &ast.Identifier{Token: member.Token, Value: enum.Name.Value},
+				Arguments: []ast.Expression{&ast.Identifier{Token: member.Token, Value: codegen.IotaName}},
+				Rparen:    member.Token,
+			}
+		}
+		group.Consts = append(group.Consts, c)
+	}
+	return group
+}
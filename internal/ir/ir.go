@@ -0,0 +1,135 @@
+// Package ir lowers checked Saika programs to the normalized form that
+// backends generate code from. The normalized form is a subset of the AST:
+// Saika-specific sugar is rewritten into the plainer constructs it stands
+// for, so a backend only has to handle those. Lowering runs after checking,
+// so diagnostics still refer to the program as it was written.
+//
+// Lowered constructs:
+//
+//	当 cond { ... }  ->  循环 ; cond; { ... }
+//	新错误(text)      ->  errors.New(text), importing errors
+//	s[i], len(s)     ->  saikaRuneAt(s, i), saikaRuneCount(s) for a string s
+//	s[i:j], s[i:]    ->  saikaSubstring(s, i, j), saikaRunesFrom(s, i)
+//	v + w, v != w    ->  v.加(w), !v.等于(w) for structs declaring them
+//	范围 (v) = it     ->  the same loop, knowing the type of the values of it
+//	数 (c T) 名()     ->  the same method, knowing whether it changes c
+//	忽略              ->  _
+//	枚举 T { A, B }   ->  类型 T 整数 and 常量 ( A = T(序号); B )
+package ir
+
+import (
+	"strings"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+)
+
+// NewErrorName is the Saika builtin creating an error with a message, Go's
+// errors.New
+const NewErrorName = "新错误"
+
+// errorsPath is the Go package NewErrorName is lowered to a function of
+const errorsPath = "errors"
+
+// Lower returns the normalized form of program, given what checking it
+// found. The program itself is left untouched. With a nil info, no
+// operation is known to be on a string.
+func Lower(program *ast.Program, info *Info) *ast.Program {
+	if info == nil {
+		info = NewInfo()
+	}
+	l := &lowering{info: info}
+	return ast.Rewrite(program, l.lower).(*ast.Program)
+}
+
+// lowering holds what lowering a program found that the program as a whole
+// has to account for
+type lowering struct {
+	usesErrors bool // a 新错误 call was lowered to errors.New
+	info       *Info
+}
+
+// lower rewrites a single node whose children are already lowered
+func (l *lowering) lower(node ast.Node) ast.Node {
+	switch node := node.(type) {
+	case *ast.Identifier:
+		if node.Value == ast.BlankName {
+			node.Value = "_"
+		}
+	case *ast.WhileStatement:
+		return lowerWhile(node)
+	case *ast.IndexExpression:
+		if l.info.StringIndex[node.Token.Offset] {
+			return lowerIndex(node)
+		}
+	case *ast.SliceExpression:
+		if l.info.StringSlice[node.Token.Offset] {
+			return lowerSlice(node)
+		}
+	case *ast.InfixExpression:
+		if method, ok := l.info.Operators[node.Token.Offset]; ok {
+			return lowerOperator(node, method)
+		}
+	case *ast.CompoundAssignExpression:
+		if method, ok := l.info.Operators[node.Token.Offset]; ok {
+			return lowerCompoundOperator(node, method)
+		}
+	case *ast.RangeStatement:
+		node.Element = l.info.Iterators[node.Token.Offset]
+	case *ast.FunctionStatement:
+		node.ChangesReceiver = node.Receiver != nil && l.info.ChangedReceivers[node.Token.Offset]
+	case *ast.CallExpression:
+		if ident, ok := node.Function.(*ast.Identifier); ok && ident.Value == NewErrorName {
+			l.usesErrors = true
+			node.Function = lowerNewError(ident)
+		}
+		if ident, ok := node.Function.(*ast.Identifier); ok && ident.Value == "len" && l.info.StringLen[node.Token.Offset] {
+			return lowerLen(node)
+		}
+	case *ast.BlockStatement:
+		node.Statements = lowerEnums(node.Statements)
+	case *ast.Program:
+		node.Statements = lowerEnums(node.Statements)
+		if l.usesErrors {
+			importPackage(node, errorsPath)
+		}
+	}
+	return node
+}
+
+// lowerWhile rewrites a while loop as a for loop with only a condition
+func lowerWhile(stmt *ast.WhileStatement) ast.Node {
+	return &ast.ForStatement{
+		Token:     stmt.Token,
+		Condition: stmt.Condition,
+		Body:      stmt.Body,
+	}
+}
+
+// lowerNewError rewrites the 新错误 builtin called as errors.New
+func lowerNewError(ident *ast.Identifier) ast.Expression {
+	return &ast.MemberExpression{
+		Token:    ident.Token,
+		Object:   &ast.Identifier{Token: ident.Token, Value: errorsPath},
+		Property: &ast.Identifier{Token: ident.Token, Value: "New"},
+	}
+}
+
+// importPackage adds an import of path after the package clause and the
+// imports of program, unless it imports path already
+func importPackage(program *ast.Program, path string) {
+	at := 0
+	for i, stmt := range program.Statements {
+		switch stmt := stmt.(type) {
+		case *ast.PackageStatement:
+		case *ast.ImportStatement:
+			if strings.Trim(stmt.Path, `"`) == path {
+				return
+			}
+		default:
+			continue
+		}
+		at = i + 1
+	}
+	imp := &ast.ImportStatement{Path: path}
+	program.Statements = append(program.Statements[:at], append([]ast.Statement{imp}, program.Statements[at:]...)...)
+}
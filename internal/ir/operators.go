@@ -0,0 +1,38 @@
+package ir
+
+import "github.com/saika-m/saika-lang/internal/ast"
+
+// lowerOperator rewrites an overloaded operator as a call of the method
+// of its left operand taking the right one; != negates the call of 等于
+func lowerOperator(expr *ast.InfixExpression, method string) ast.Node {
+	call := methodCall(expr.Token, expr.Left, method, expr.Right)
+	if expr.Operator == "!=" {
+		return &ast.PrefixExpression{Token: ast.Token{Type: ast.BANG, Literal: "!", Position: expr.Token.Position}, Operator: "!", Right: call}
+	}
+	return call
+}
+
+// lowerCompoundOperator rewrites an overloaded compound assignment, as
+// v += w, as the assignment of the result of the method: v = v.加(w)
+func lowerCompoundOperator(expr *ast.CompoundAssignExpression, method string) ast.Node {
+	return &ast.AssignExpression{
+		Token: ast.Token{Type: ast.ASSIGN, Literal: "=", Position: expr.Token.Position},
+		Left:  expr.Left,
+		Value: methodCall(expr.Token, expr.Left, method, expr.Value),
+	}
+}
+
+// methodCall returns the call of the method of receiver with arg, placed
+// at tok
+func methodCall(tok ast.Token, receiver ast.Expression, method string, arg ast.Expression) *ast.CallExpression {
+	return &ast.CallExpression{
+		Token: tok,
+		Function: &ast.MemberExpression{
+			Token:    tok,
+			Object:   receiver,
+			Property: &ast.Identifier{Token: tok, Value: method},
+		},
+		Arguments: []ast.Expression{arg},
+		Rparen:    tok,
+	}
+}
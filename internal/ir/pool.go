@@ -0,0 +1,89 @@
+package ir
+
+import (
+	"fmt"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+)
+
+// minPooledLength is the length in bytes below which a string literal is
+// left in place however often it occurs: a constant would not be shorter
+const minPooledLength = 4
+
+// PoolStrings hoists the string literals that a lowered program uses more
+// than once into package-level constants, declared after its imports in
+// the order the literals first occur, and refers to them by name:
+//
+//	fmt.Println("欢迎光临")      const saikaStr1a2b3c4d_1 = "欢迎光临"
+//	fmt.Println("欢迎光临")  ->  fmt.Println(saikaStr1a2b3c4d_1)
+//	                            fmt.Println(saikaStr1a2b3c4d_1)
+//
+// The constants are untyped like the literals, so every use keeps its
+// meaning. Tag tells apart the constants of the files of one package; it
+// must be an identifier suffix that no other file of the package uses,
+// such as a prefix of the hash of the file. The program itself is left
+// untouched.
+func PoolStrings(program *ast.Program, tag string) *ast.Program {
+	// The usage of an option is printed as it is, not evaluated
+	usages := map[int]bool{}
+	counts := map[string]int{}
+	var order []string
+	ast.Inspect(program, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.OptionStatement:
+			if node.Usage != nil {
+				usages[node.Usage.Token.Offset] = true
+			}
+		case *ast.StringLiteral:
+			if len(node.Value) < minPooledLength || usages[node.Token.Offset] {
+				return true
+			}
+			if counts[node.Value] == 0 {
+				order = append(order, node.Value)
+			}
+			counts[node.Value]++
+		}
+		return true
+	})
+
+	names := map[string]string{}
+	var consts []ast.Statement
+	for _, value := range order {
+		if counts[value] < 2 {
+			continue
+		}
+		name := fmt.Sprintf("saikaStr%s_%d", tag, len(consts)+1)
+		names[value] = name
+		consts = append(consts, &ast.ConstStatement{
+			Token: ast.Token{Type: ast.CONST, Literal: "常量"},
+			Name:  &ast.Identifier{Token: ast.Token{Type: ast.IDENT, Literal: name}, Value: name},
+			Value: &ast.StringLiteral{Token: ast.Token{Type: ast.STRING, Literal: value}, Value: value},
+		})
+	}
+	if len(consts) == 0 {
+		return program
+	}
+
+	pooled := ast.Rewrite(program, func(node ast.Node) ast.Node {
+		lit, ok := node.(*ast.StringLiteral)
+		if !ok || usages[lit.Token.Offset] {
+			return node
+		}
+		if name, ok := names[lit.Value]; ok {
+			return &ast.Identifier{Token: lit.Token, Value: name}
+		}
+		return node
+	}).(*ast.Program)
+
+	at := 0
+	for i, stmt := range pooled.Statements {
+		switch stmt.(type) {
+		case *ast.PackageStatement, *ast.ImportStatement:
+			at = i + 1
+		}
+	}
+	statements := append([]ast.Statement{}, pooled.Statements[:at]...)
+	statements = append(statements, consts...)
+	pooled.Statements = append(statements, pooled.Statements[at:]...)
+	return pooled
+}
@@ -0,0 +1,124 @@
+package ir
+
+import (
+	"go/token"
+	"path"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/stdlib"
+)
+
+// Prune removes the functions of a package that are never reachable from
+// its roots, along with the imports only they used. The roots are 入口 and
+// init, the values of package-level variables, constants and options, and
+// in a package other than main, the exported functions. Methods are kept,
+// as Go may call them through an interface, and are roots too. Any use of a name
+// counts as a reference to the function of that name, even where a local
+// shadows it, so a function that is used is never removed.
+//
+// The programs are the files of one checked package; they are left
+// untouched, and the pruned files are returned in the same order.
+func Prune(programs []*ast.Program) []*ast.Program {
+	functions := map[string]*ast.FunctionStatement{}
+	library := false
+	for _, program := range programs {
+		for _, stmt := range program.Statements {
+			switch stmt := stmt.(type) {
+			case *ast.PackageStatement:
+				library = stmt.Name != "main"
+			case *ast.FunctionStatement:
+				if stmt.Receiver == nil {
+					functions[stmt.Name.Value] = stmt
+				}
+			}
+		}
+	}
+
+	reachable := map[string]bool{}
+	var queue []ast.Node
+	reach := func(name string) {
+		if fn, ok := functions[name]; ok && !reachable[name] {
+			reachable[name] = true
+			queue = append(queue, fn.Body)
+		}
+	}
+
+	for _, program := range programs {
+		for _, stmt := range program.Statements {
+			switch stmt := stmt.(type) {
+			case *ast.FunctionStatement:
+				name := stmt.Name.Value
+				if stmt.Receiver != nil {
+					queue = append(queue, stmt.Body)
+				} else if name == "入口" || name == "init" || (library && token.IsExported(name)) {
+					reach(name)
+				}
+			case *ast.ImportStatement, *ast.PackageStatement:
+			default:
+				queue = append(queue, stmt)
+			}
+		}
+	}
+
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		ast.Inspect(node, func(n ast.Node) bool {
+			if ident, ok := n.(*ast.Identifier); ok {
+				reach(ident.Value)
+			}
+			return true
+		})
+	}
+
+	pruned := make([]*ast.Program, len(programs))
+	for i, program := range programs {
+		pruned[i] = pruneFile(program, reachable)
+	}
+	return pruned
+}
+
+// pruneFile returns program without its unreachable functions and the
+// imports that no remaining statement uses
+func pruneFile(program *ast.Program, reachable map[string]bool) *ast.Program {
+	kept := []ast.Statement{}
+	for _, stmt := range program.Statements {
+		if fn, ok := stmt.(*ast.FunctionStatement); ok && fn.Receiver == nil && !reachable[fn.Name.Value] {
+			continue
+		}
+		kept = append(kept, stmt)
+	}
+	if len(kept) == len(program.Statements) {
+		return program
+	}
+
+	used := map[string]bool{}
+	for _, stmt := range kept {
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			if member, ok := n.(*ast.MemberExpression); ok {
+				if ident, ok := member.Object.(*ast.Identifier); ok {
+					used[ident.Value] = true
+				}
+			}
+			return true
+		})
+	}
+
+	statements := []ast.Statement{}
+	for _, stmt := range kept {
+		if imp, ok := stmt.(*ast.ImportStatement); ok && !importUsed(imp, used) {
+			continue
+		}
+		statements = append(statements, stmt)
+	}
+	return &ast.Program{Statements: statements}
+}
+
+// importUsed reports whether one of the names an import binds, its Go
+// name or its Chinese one, is used
+func importUsed(imp *ast.ImportStatement, used map[string]bool) bool {
+	if pkg, ok := stdlib.LookupPath(imp.Path); ok {
+		return used[pkg.GoName()] || used[pkg.Name]
+	}
+	return used[path.Base(imp.Path)]
+}
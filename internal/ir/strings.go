@@ -0,0 +1,135 @@
+package ir
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/codegen"
+)
+
+// Info is what checking a program found that lowering it depends on: the
+// index, slice and len operations whose operand is a string, which Saika
+// performs by character, the operators that call a method of their
+// operands, the 范围 loops over iterators and the methods changing their
+// receiver, as well as the calls whose Go types depend on the type 整数
+// stands for. The checker tells them from the types it works out across
+// the package. Each is known by the offset of its '[', '(', operator,
+// '范围' or '数' token, which survives the copy Lower makes of the program.
+type Info struct {
+	StringIndex map[int]bool
+	StringSlice map[int]bool
+	StringLen   map[int]bool
+
+	// Operators gives the method each overloaded operator calls, as 加
+	// for + on two 向量 declaring it
+	Operators map[int]string
+
+	// Iterators gives the type of the values of each 范围 loop over an
+	// iterator, the result of its method 值
+	Iterators map[int]ast.Expression
+
+	// ChangedReceivers holds the methods that change their receiver, by
+	// the offset of their 数 token
+	ChangedReceivers map[int]bool
+
+	// IntResults holds the calls of builtins returning 整数, which Go
+	// gives the type int
+	IntResults map[int]bool
+
+	// Inferred gives the type arguments of each call of a generic function
+	// made without them, as Go infers them
+	Inferred map[int][]ast.Expression
+}
+
+// NewInfo returns an Info recording no operation on strings
+func NewInfo() *Info {
+	return &Info{StringIndex: map[int]bool{}, StringSlice: map[int]bool{}, StringLen: map[int]bool{}, Operators: map[int]string{}, Iterators: map[int]ast.Expression{}, ChangedReceivers: map[int]bool{}, IntResults: map[int]bool{}, Inferred: map[int][]ast.Expression{}}
+}
+
+// Key returns a string that differs between infos recording different
+// operations, for telling whether the code generated for a program
+// depends on more than its source
+func (info *Info) Key() string {
+	var b strings.Builder
+	for _, ops := range []map[int]bool{info.StringIndex, info.StringSlice, info.StringLen, info.ChangedReceivers, info.IntResults} {
+		offsets := make([]int, 0, len(ops))
+		for offset, ok := range ops {
+			if ok {
+				offsets = append(offsets, offset)
+			}
+		}
+		sort.Ints(offsets)
+		fmt.Fprintf(&b, "%v;", offsets)
+	}
+	offsets := make([]int, 0, len(info.Operators))
+	for offset := range info.Operators {
+		offsets = append(offsets, offset)
+	}
+	sort.Ints(offsets)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%d:%s,", offset, info.Operators[offset])
+	}
+	b.WriteString(";")
+	offsets = offsets[:0]
+	for offset := range info.Iterators {
+		offsets = append(offsets, offset)
+	}
+	sort.Ints(offsets)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%d:%s,", offset, ast.Sprint(info.Iterators[offset]))
+	}
+	b.WriteString(";")
+	offsets = offsets[:0]
+	for offset := range info.Inferred {
+		offsets = append(offsets, offset)
+	}
+	sort.Ints(offsets)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%d:", offset)
+		for _, typ := range info.Inferred[offset] {
+			fmt.Fprintf(&b, "%s,", ast.Sprint(typ))
+		}
+	}
+	return b.String()
+}
+
+// lowerIndex rewrites the index of a string as a call of the helper
+// returning the character at the index
+func lowerIndex(expr *ast.IndexExpression) ast.Node {
+	return &ast.CallExpression{
+		Token:     expr.Token,
+		Function:  &ast.Identifier{Token: expr.Token, Value: codegen.RuneAtName},
+		Arguments: []ast.Expression{expr.Left, expr.Index},
+	}
+}
+
+// lowerSlice rewrites the slice of a string as a call of the helper
+// returning the characters from its low index up to its high one, or to
+// the end
+func lowerSlice(expr *ast.SliceExpression) ast.Node {
+	low := expr.Low
+	if low == nil {
+		low = &ast.IntegerLiteral{Token: ast.Token{Type: ast.INT, Literal: "0", Position: expr.Token.Position}}
+	}
+	if expr.High == nil {
+		return &ast.CallExpression{
+			Token:     expr.Token,
+			Function:  &ast.Identifier{Token: expr.Token, Value: codegen.RunesFromName},
+			Arguments: []ast.Expression{expr.Left, low},
+		}
+	}
+	return &ast.CallExpression{
+		Token:     expr.Token,
+		Function:  &ast.Identifier{Token: expr.Token, Value: codegen.RuneSliceName},
+		Arguments: []ast.Expression{expr.Left, low, expr.High},
+	}
+}
+
+// lowerLen rewrites len of a string as a call of the helper counting its
+// characters
+func lowerLen(call *ast.CallExpression) ast.Node {
+	call.Function = &ast.Identifier{Token: call.Token, Value: codegen.RuneCountName}
+	return call
+}
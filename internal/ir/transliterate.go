@@ -0,0 +1,137 @@
+package ir
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/pinyin"
+)
+
+// PinyinNames returns the Go names that the top-level names written with
+// Chinese characters in the files of a package are given, spelled in
+// pinyin by pinyin.Exported so that other Go packages can use them:
+//
+//	数 问好(名字 字符串) 字符串       func WenHao(名字 字符串) 字符串
+//	类型 用户 结构 { ... }     ->    type YongHu struct { ... }
+//
+// 入口 and 忽略 keep their names, as do options, whose names are those of their
+// command-line flags. A spelling that another name of the package already
+// has is followed by _2, _3 and so on, given in the order of the Chinese
+// names, so the same package always gets the same names.
+func PinyinNames(programs []*ast.Program) map[string]string {
+	taken := map[string]bool{}
+	var chinese []string
+	for _, program := range programs {
+		ast.Inspect(program, func(node ast.Node) bool {
+			if ident, ok := node.(*ast.Identifier); ok {
+				taken[ident.Value] = true
+			}
+			return true
+		})
+		for _, name := range declaredNames(program) {
+			if name != "入口" && !ast.IsBlank(name) && strings.ContainsFunc(name, func(r rune) bool { return unicode.Is(unicode.Han, r) }) {
+				chinese = append(chinese, name)
+			}
+		}
+	}
+	sort.Strings(chinese)
+
+	names := map[string]string{}
+	for _, name := range chinese {
+		if _, ok := names[name]; ok {
+			continue
+		}
+		spelled := pinyin.Exported(name)
+		goName := spelled
+		for n := 2; taken[goName]; n++ {
+			goName = fmt.Sprintf("%s_%d", spelled, n)
+		}
+		taken[goName] = true
+		names[name] = goName
+	}
+	return names
+}
+
+// declaredNames returns the names declared at the top level of program
+// that PinyinNames may rename
+func declaredNames(program *ast.Program) []string {
+	var names []string
+	for _, stmt := range program.Statements {
+		switch stmt := stmt.(type) {
+		case *ast.FunctionStatement:
+			if stmt.Receiver == nil {
+				names = append(names, stmt.Name.Value)
+			}
+		case *ast.VarStatement:
+			names = append(names, stmt.Name.Value)
+		case *ast.VarListStatement:
+			for _, name := range stmt.Names {
+				names = append(names, name.Value)
+			}
+		case *ast.ConstStatement:
+			names = append(names, stmt.Name.Value)
+		case *ast.ConstGroupStatement:
+			for _, c := range stmt.Consts {
+				names = append(names, c.Name.Value)
+			}
+		case *ast.InterfaceStatement:
+			names = append(names, stmt.Name.Value)
+		case *ast.TypeStatement:
+			names = append(names, stmt.Name.Value)
+		case *ast.EnumStatement:
+			names = append(names, stmt.Name.Value)
+			for _, member := range stmt.Members {
+				names = append(names, member.Value)
+			}
+		}
+	}
+	return names
+}
+
+// Transliterate renames every use of the names given in names, as
+// returned by PinyinNames, in a checked program. A local that has one of
+// the names is renamed along with it, which keeps every use referring to
+// what it did, since the new names are used nowhere else. Field names,
+// methods and the members selected from a value or package are
+// not package-level names and keep theirs. The program itself is left
+// untouched.
+func Transliterate(program *ast.Program, names map[string]string) *ast.Program {
+	if len(names) == 0 {
+		return program
+	}
+	renamed := ast.DeepCopy(program)
+	kept := map[*ast.Identifier]bool{}
+	ast.Inspect(renamed, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.MemberExpression:
+			if property, ok := node.Property.(*ast.Identifier); ok {
+				kept[property] = true
+			}
+		case *ast.StructType:
+			for _, field := range node.Fields {
+				kept[field.Name] = true
+			}
+		case *ast.CompositeLiteral:
+			for _, field := range node.Fields {
+				kept[field.Name] = true
+			}
+		case *ast.InterfaceStatement:
+			for _, method := range node.Methods {
+				kept[method.Name] = true
+			}
+		case *ast.FunctionStatement:
+			if node.Receiver != nil {
+				kept[node.Name] = true
+			}
+		case *ast.Identifier:
+			if goName, ok := names[node.Value]; ok && !kept[node] {
+				node.Value = goName
+			}
+		}
+		return true
+	})
+	return renamed
+}
@@ -1,28 +1,49 @@
 package lexer
 
 import (
+	"strings"
 	"unicode"
 	"unicode/utf8"
 
 	"github.com/saika-m/saika-lang/internal/ast"
 )
 
+// DefaultTabWidth is the tab width used for display columns when none is configured
+const DefaultTabWidth = 4
+
 // Lexer represents a lexical analyzer for Saika
 type Lexer struct {
-	input        string
-	position     int  // current position in input (points to current char)
-	readPosition int  // current reading position in input (after current char)
-	ch           rune // current char under examination
-	line         int  // current line
-	column       int  // current column
+	input         string
+	position      int  // current position in input (points to current char)
+	readPosition  int  // current reading position in input (after current char)
+	ch            rune // current char under examination
+	line          int  // line of the current char
+	column        int  // rune column of the current char
+	displayColumn int  // tab-expanded column of the current char
+	runeOffset    int  // rune offset of the current char
+	tabWidth      int  // number of columns a tab stop spans
+	atEOF         bool // whether the end of input has been reached
+
+	comments []ast.Comment // the comments skipped so far
 }
 
 // New creates a new Lexer
 func New(input string) *Lexer {
+	return NewWithTabWidth(input, DefaultTabWidth)
+}
+
+// NewWithTabWidth creates a new Lexer that expands tabs to the given width
+// when computing display columns
+func NewWithTabWidth(input string, tabWidth int) *Lexer {
+	if tabWidth < 1 {
+		tabWidth = DefaultTabWidth
+	}
+
 	l := &Lexer{
-		input:  input,
-		line:   1,
-		column: 0,
+		input:      input,
+		line:       1,
+		runeOffset: -1,
+		tabWidth:   tabWidth,
 	}
 	l.readChar()
 	return l
@@ -30,20 +51,46 @@ func New(input string) *Lexer {
 
 // readChar reads the next character and advances the position in the input string
 func (l *Lexer) readChar() {
+	if l.atEOF {
+		return
+	}
+
+	// Advance the counters past the character being consumed
+	switch l.ch {
+	case '\n':
+		l.line++
+		l.column = 1
+		l.displayColumn = 1
+	case '\t':
+		l.column++
+		l.displayColumn += l.tabWidth - (l.displayColumn-1)%l.tabWidth
+	default:
+		l.column++
+		l.displayColumn++
+	}
+	l.runeOffset++
+
 	if l.readPosition >= len(l.input) {
 		l.ch = 0 // EOF
+		l.position = len(l.input)
+		l.readPosition = len(l.input)
+		l.atEOF = true
 	} else {
 		r, size := utf8.DecodeRuneInString(l.input[l.readPosition:])
 		l.ch = r
 		l.position = l.readPosition
 		l.readPosition += size
 	}
+}
 
-	if l.ch == '\n' {
-		l.line++
-		l.column = 0
-	} else {
-		l.column++
+// currentPosition returns the position of the current char
+func (l *Lexer) currentPosition() ast.Position {
+	return ast.Position{
+		Line:          l.line,
+		Column:        l.column,
+		DisplayColumn: l.displayColumn,
+		Offset:        l.position,
+		RuneOffset:    l.runeOffset,
 	}
 }
 
@@ -56,6 +103,24 @@ func (l *Lexer) peekChar() rune {
 	return r
 }
 
+// peekCharAt returns the character n characters ahead without advancing
+// the position; peekCharAt(1) is peekChar()
+func (l *Lexer) peekCharAt(n int) rune {
+	offset := l.position
+	for i := 0; i < n; i++ {
+		if offset >= len(l.input) {
+			return 0
+		}
+		_, size := utf8.DecodeRuneInString(l.input[offset:])
+		offset += size
+	}
+	if offset >= len(l.input) {
+		return 0
+	}
+	r, _ := utf8.DecodeRuneInString(l.input[offset:])
+	return r
+}
+
 // NextToken returns the next token
 func (l *Lexer) NextToken() ast.Token {
 	var tok ast.Token
@@ -63,8 +128,7 @@ func (l *Lexer) NextToken() ast.Token {
 	l.skipWhitespace()
 
 	// Track token position
-	tok.Line = l.line
-	tok.Column = l.column
+	start := l.currentPosition()
 
 	switch l.ch {
 	case '=':
@@ -76,9 +140,9 @@ func (l *Lexer) NextToken() ast.Token {
 			tok = newToken(ast.ASSIGN, l.ch)
 		}
 	case '+':
-		tok = newToken(ast.PLUS, l.ch)
+		tok = l.withAssign(newToken(ast.PLUS, l.ch))
 	case '-':
-		tok = newToken(ast.MINUS, l.ch)
+		tok = l.withAssign(newToken(ast.MINUS, l.ch))
 	case '!':
 		if l.peekChar() == '=' {
 			ch := l.ch
@@ -88,27 +152,59 @@ func (l *Lexer) NextToken() ast.Token {
 			tok = newToken(ast.BANG, l.ch)
 		}
 	case '*':
-		tok = newToken(ast.ASTERISK, l.ch)
+		tok = l.withAssign(newToken(ast.ASTERISK, l.ch))
 	case '/':
 		// Check for comments
 		if l.peekChar() == '/' {
 			l.skipSingleLineComment()
+			l.addComment(start)
 			return l.NextToken()
 		} else if l.peekChar() == '*' {
 			l.skipMultiLineComment()
+			l.addComment(start)
 			return l.NextToken()
 		} else {
-			tok = newToken(ast.SLASH, l.ch)
+			tok = l.withAssign(newToken(ast.SLASH, l.ch))
 		}
 	case '%':
-		tok = newToken(ast.PERCENT, l.ch)
+		tok = l.withAssign(newToken(ast.PERCENT, l.ch))
+	case '&':
+		if l.peekChar() == '^' {
+			ch := l.ch
+			l.readChar()
+			tok = l.withAssign(ast.Token{Type: ast.AND_NOT, Literal: string(ch) + string(l.ch)})
+		} else {
+			tok = l.withAssign(newToken(ast.AMPERSAND, l.ch))
+		}
+	case '|':
+		tok = l.withAssign(newToken(ast.PIPE, l.ch))
+	case '^':
+		tok = l.withAssign(newToken(ast.CARET, l.ch))
 	case '.':
-		tok = newToken(ast.DOT, l.ch)
+		if l.peekChar() == '.' && l.peekCharAt(2) == '.' {
+			l.readChar()
+			l.readChar()
+			tok = ast.Token{Type: ast.ELLIPSIS, Literal: "..."}
+		} else {
+			tok = newToken(ast.DOT, l.ch)
+		}
+	case '…':
+		tok = ast.Token{Type: ast.ELLIPSIS, Literal: "..."}
 	case '<':
 		if l.peekChar() == '=' {
 			ch := l.ch
 			l.readChar()
 			tok = ast.Token{Type: ast.LTE, Literal: string(ch) + string(l.ch)}
+		} else if l.peekChar() == '<' {
+			ch := l.ch
+			l.readChar()
+			tok = l.withAssign(ast.Token{Type: ast.SHIFT_LEFT, Literal: string(ch) + string(l.ch)})
+		} else if l.peekChar() == '-' {
+			// As in Go, x<-1 is a send; a comparison with a negative
+			// number needs a space, as in x < -1
+			ch := l.ch
+			l.readChar()
+			tok = ast.Token{Type: ast.ARROW, Literal: string(ch) + string(l.ch)}
 		} else {
 			tok = newToken(ast.LT, l.ch)
 		}
@@ -117,6 +213,10 @@ func (l *Lexer) NextToken() ast.Token {
 			ch := l.ch
 			l.readChar()
 			tok = ast.Token{Type: ast.GTE, Literal: string(ch) + string(l.ch)}
+		} else if l.peekChar() == '>' {
+			ch := l.ch
+			l.readChar()
+			tok = l.withAssign(ast.Token{Type: ast.SHIFT_RIGHT, Literal: string(ch) + string(l.ch)})
 		} else {
 			tok = newToken(ast.GT, l.ch)
 		}
@@ -124,6 +224,8 @@ func (l *Lexer) NextToken() ast.Token {
 		tok = newToken(ast.COMMA, l.ch)
 	case ';':
 		tok = newToken(ast.SEMICOLON, l.ch)
+	case ':':
+		tok = newToken(ast.COLON, l.ch)
 	case '(':
 		tok = newToken(ast.LPAREN, l.ch)
 	case ')':
@@ -139,6 +241,11 @@ func (l *Lexer) NextToken() ast.Token {
 	case '"':
 		tok.Type = ast.STRING
 		tok.Literal = l.readString()
+		// A string running to the end of the input is not a literal
+		if l.ch == 0 {
+			tok.Type = ast.ILLEGAL
+			tok.Literal = `"` + tok.Literal
+		}
 	case 0:
 		tok.Literal = ""
 		tok.Type = ast.EOF
@@ -146,10 +253,15 @@ func (l *Lexer) NextToken() ast.Token {
 		if isLetter(l.ch) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = LookupIdent(tok.Literal)
+			l.finishToken(&tok, start)
 			return tok
 		} else if isDigit(l.ch) {
-			tok.Literal = l.readNumber()
-			tok.Type = ast.INT
+			tok.Literal, tok.Type = l.readNumber()
+			if unit := l.readUnit(); unit != "" {
+				tok.Literal += unit
+				tok.Type = ast.UNIT
+			}
+			l.finishToken(&tok, start)
 			return tok
 		} else {
 			tok = newToken(ast.ILLEGAL, l.ch)
@@ -157,9 +269,30 @@ func (l *Lexer) NextToken() ast.Token {
 	}
 
 	l.readChar()
+	l.finishToken(&tok, start)
 	return tok
 }
 
+// withAssign turns an operator token into the compound assignment token
+// when the operator is followed by '=', as in +=. The type of a compound
+// assignment token is its literal, like the types of operators.
+func (l *Lexer) withAssign(tok ast.Token) ast.Token {
+	if l.peekChar() != '=' {
+		return tok
+	}
+	l.readChar()
+	literal := tok.Literal + string(l.ch)
+	return ast.Token{Type: ast.TokenType(literal), Literal: literal}
+}
+
+// finishToken records the range and original source text of a token
+// that started at start and ends at the current char
+func (l *Lexer) finishToken(tok *ast.Token, start ast.Position) {
+	tok.Position = start
+	tok.End = l.currentPosition()
+	tok.Raw = l.input[start.Offset:tok.End.Offset]
+}
+
 // skipWhitespace skips whitespace characters
 func (l *Lexer) skipWhitespace() {
 	for unicode.IsSpace(l.ch) {
@@ -167,6 +300,21 @@ func (l *Lexer) skipWhitespace() {
 	}
 }
 
+// addComment records the comment from start to the current char
+func (l *Lexer) addComment(start ast.Position) {
+	text := strings.TrimRight(l.input[start.Offset:l.position], " \t\r")
+	l.comments = append(l.comments, ast.Comment{
+		Text:  text,
+		Range: ast.Range{Start: start, End: l.currentPosition()},
+	})
+}
+
+// Comments returns the comments skipped so far, in source order; once
+// the parser has read every token, these are all the comments of the input
+func (l *Lexer) Comments() []ast.Comment {
+	return l.comments
+}
+
 // skipSingleLineComment skips a single-line comment (// ...)
 func (l *Lexer) skipSingleLineComment() {
 	l.readChar() // Skip the first '/'
@@ -206,13 +354,73 @@ func (l *Lexer) readIdentifier() string {
 	return l.input[position:l.position]
 }
 
-// readNumber reads a number
-func (l *Lexer) readNumber() string {
+// readNumber reads an integer, including 0x, 0o and 0b prefixed forms, or
+// a decimal float such as 3.14 or 1e9, returning its literal and type.
+// Underscores between digits are kept in the literal, which Go and
+// strconv accept as they are.
+func (l *Lexer) readNumber() (string, ast.TokenType) {
 	position := l.position
-	for isDigit(l.ch) {
+
+	if l.ch == '0' && isRadixPrefix(l.peekChar()) {
+		l.readChar() // Skip the '0'
+		l.readChar() // Skip the radix letter
+		for isHexDigit(l.ch) || l.ch == '_' && isHexDigit(l.peekChar()) {
+			l.readChar()
+		}
+		return l.input[position:l.position], ast.INT
+	}
+
+	tokenType := ast.TokenType(ast.INT)
+	l.readDigits()
+
+	// A fraction needs a digit after the dot, so 1.x stays a member access
+	if l.ch == '.' && isDigit(l.peekChar()) {
+		tokenType = ast.FLOAT
+		l.readChar() // Skip the '.'
+		l.readDigits()
+	}
+
+	if (l.ch == 'e' || l.ch == 'E') && (isDigit(l.peekChar()) || l.peekChar() == '+' || l.peekChar() == '-') {
+		tokenType = ast.FLOAT
+		l.readChar() // Skip the 'e'
+		if l.ch == '+' || l.ch == '-' {
+			l.readChar()
+		}
+		l.readDigits()
+	}
+
+	return l.input[position:l.position], tokenType
+}
+
+// readDigits reads a run of decimal digits, which underscores may
+// separate as in 1_000_000
+func (l *Lexer) readDigits() {
+	for isDigit(l.ch) || l.ch == '_' && isDigit(l.peekChar()) {
 		l.readChar()
 	}
-	return l.input[position:l.position]
+}
+
+// readUnit reads the unit suffix directly following a number, as in
+// 500毫秒, returning "" and reading nothing when the letters that follow
+// do not name a unit
+func (l *Lexer) readUnit() string {
+	end := l.position
+	for end < len(l.input) {
+		r, size := utf8.DecodeRuneInString(l.input[end:])
+		if !isLetter(r) && !isDigit(r) {
+			break
+		}
+		end += size
+	}
+
+	unit := l.input[l.position:end]
+	if _, ok := ast.Units[unit]; !ok {
+		return ""
+	}
+	for l.position < end {
+		l.readChar()
+	}
+	return unit
 }
 
 // readString reads a string literal
@@ -225,8 +433,8 @@ func (l *Lexer) readString() string {
 			break
 		}
 
-		// Handle escape sequences
-		if l.ch == '\\' && l.peekChar() == '"' {
+		// Handle escape sequences; the escaped char is kept verbatim
+		if l.ch == '\\' && l.peekChar() != 0 {
 			l.readChar() // Skip the backslash
 		}
 
@@ -246,6 +454,21 @@ func isDigit(ch rune) bool {
 	return unicode.IsDigit(ch)
 }
 
+// isRadixPrefix returns whether the given rune follows a leading 0 in a
+// hexadecimal, octal or binary literal
+func isRadixPrefix(ch rune) bool {
+	switch ch {
+	case 'x', 'X', 'o', 'O', 'b', 'B':
+		return true
+	}
+	return false
+}
+
+// isHexDigit returns whether the given rune is an ASCII hexadecimal digit
+func isHexDigit(ch rune) bool {
+	return ('0' <= ch && ch <= '9') || ('a' <= ch && ch <= 'f') || ('A' <= ch && ch <= 'F')
+}
+
 // newToken creates a new token
 func newToken(tokenType ast.TokenType, ch rune) ast.Token {
 	return ast.Token{Type: tokenType, Literal: string(ch)}
@@ -2,10 +2,14 @@ package parser
 
 import (
 	"fmt"
+	"math"
 	"strconv"
+	"strings"
 
 	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/diagnostic"
 	"github.com/saika-m/saika-lang/internal/lexer"
+	"github.com/saika-m/saika-lang/internal/suggest"
 )
 
 // Parser represents a parser for Saika
@@ -13,10 +17,22 @@ type Parser struct {
 	l         *lexer.Lexer
 	curToken  ast.Token
 	peekToken ast.Token
-	errors    []string
+	errors    diagnostic.List
 
 	prefixParseFns map[ast.TokenType]prefixParseFn
 	infixParseFns  map[ast.TokenType]infixParseFn
+
+	// exprLev is the nesting level of the expression being parsed, as in
+	// go/parser. It is negative in the header of a statement with a block,
+	// as in 如果 x == y { ... }, where a '{' after a type name starts the
+	// block rather than a composite literal; each parenthesis, bracket or
+	// literal brace the expression is nested in adds one, so that literals
+	// are allowed in them again.
+	exprLev int
+
+	// unclosed is set once a block is reported as not closed before the
+	// end of the input
+	unclosed bool
 }
 
 type (
@@ -38,27 +54,45 @@ const (
 
 // Precedences maps token types to their precedence levels
 var precedences = map[ast.TokenType]int{
-	ast.EQ:       EQUALS,
-	ast.NOT_EQ:   EQUALS,
-	ast.ASSIGN:   EQUALS,
-	ast.LT:       LESSGREATER,
-	ast.GT:       LESSGREATER,
-	ast.LTE:      LESSGREATER,
-	ast.GTE:      LESSGREATER,
-	ast.PLUS:     SUM,
-	ast.MINUS:    SUM,
-	ast.SLASH:    PRODUCT,
-	ast.ASTERISK: PRODUCT,
-	ast.PERCENT:  PRODUCT,
-	ast.LPAREN:   CALL,
-	ast.DOT:      CALL,
+	ast.EQ:                 EQUALS,
+	ast.NOT_EQ:             EQUALS,
+	ast.ASSIGN:             EQUALS,
+	ast.PLUS_ASSIGN:        EQUALS,
+	ast.MINUS_ASSIGN:       EQUALS,
+	ast.ASTERISK_ASSIGN:    EQUALS,
+	ast.SLASH_ASSIGN:       EQUALS,
+	ast.PERCENT_ASSIGN:     EQUALS,
+	ast.AMPERSAND_ASSIGN:   EQUALS,
+	ast.PIPE_ASSIGN:        EQUALS,
+	ast.CARET_ASSIGN:       EQUALS,
+	ast.AND_NOT_ASSIGN:     EQUALS,
+	ast.SHIFT_LEFT_ASSIGN:  EQUALS,
+	ast.SHIFT_RIGHT_ASSIGN: EQUALS,
+	ast.LT:                 LESSGREATER,
+	ast.GT:                 LESSGREATER,
+	ast.LTE:                LESSGREATER,
+	ast.GTE:                LESSGREATER,
+	ast.PLUS:               SUM,
+	ast.MINUS:              SUM,
+	ast.PIPE:               SUM,
+	ast.CARET:              SUM,
+	ast.SLASH:              PRODUCT,
+	ast.ASTERISK:           PRODUCT,
+	ast.PERCENT:            PRODUCT,
+	ast.AMPERSAND:          PRODUCT,
+	ast.AND_NOT:            PRODUCT,
+	ast.SHIFT_LEFT:         PRODUCT,
+	ast.SHIFT_RIGHT:        PRODUCT,
+	ast.LPAREN:             CALL,
+	ast.DOT:                CALL,
+	ast.LBRACKET:           INDEX,
 }
 
 // New creates a new Parser
 func New(l *lexer.Lexer) *Parser {
 	p := &Parser{
 		l:      l,
-		errors: []string{},
+		errors: diagnostic.List{},
 	}
 
 	// Initialize prefix parse functions
@@ -66,12 +100,24 @@ func New(l *lexer.Lexer) *Parser {
 	p.prefixParseFns = make(map[ast.TokenType]prefixParseFn)
 	p.registerPrefix(ast.IDENT, p.parseIdentifier)
 	p.registerPrefix(ast.INT, p.parseIntegerLiteral)
+	p.registerPrefix(ast.FLOAT, p.parseFloatLiteral)
+	p.registerPrefix(ast.UNIT, p.parseUnitLiteral)
 	p.registerPrefix(ast.STRING, p.parseStringLiteral)
 	p.registerPrefix(ast.TRUE, p.parseBooleanLiteral)
 	p.registerPrefix(ast.FALSE, p.parseBooleanLiteral)
 	p.registerPrefix(ast.BANG, p.parsePrefixExpression)
 	p.registerPrefix(ast.MINUS, p.parsePrefixExpression)
+	p.registerPrefix(ast.CARET, p.parsePrefixExpression)
 	p.registerPrefix(ast.LPAREN, p.parseGroupedExpression)
+	p.registerPrefix(ast.MAP, p.parseMapLiteral)
+	p.registerPrefix(ast.SLICE, p.parseSliceLiteral)
+	p.registerPrefix(ast.STRUCT, p.parseStructLiteral)
+	p.registerPrefix(ast.ARRAY, p.parseArrayLiteral)
+	p.registerPrefix(ast.FUNC, p.parseFunctionLiteral)
+	p.registerPrefix(ast.CHAN, p.parseChanType)
+	p.registerPrefix(ast.ARROW, p.parsePrefixExpression)
+	p.registerPrefix(ast.PANIC, p.parseIdentifier)
+	p.registerPrefix(ast.RECOVER, p.parseIdentifier)
 
 	// Register infix parse functions
 	p.infixParseFns = make(map[ast.TokenType]infixParseFn)
@@ -80,6 +126,12 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(ast.SLASH, p.parseInfixExpression)
 	p.registerInfix(ast.ASTERISK, p.parseInfixExpression)
 	p.registerInfix(ast.PERCENT, p.parseInfixExpression) // For %
+	p.registerInfix(ast.AMPERSAND, p.parseInfixExpression)
+	p.registerInfix(ast.PIPE, p.parseInfixExpression)
+	p.registerInfix(ast.CARET, p.parseInfixExpression)
+	p.registerInfix(ast.AND_NOT, p.parseInfixExpression)
+	p.registerInfix(ast.SHIFT_LEFT, p.parseInfixExpression)
+	p.registerInfix(ast.SHIFT_RIGHT, p.parseInfixExpression)
 	p.registerInfix(ast.EQ, p.parseInfixExpression)
 	p.registerInfix(ast.NOT_EQ, p.parseInfixExpression)
 	p.registerInfix(ast.LT, p.parseInfixExpression)
@@ -87,8 +139,20 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(ast.LTE, p.parseInfixExpression)
 	p.registerInfix(ast.GTE, p.parseInfixExpression)
 	p.registerInfix(ast.ASSIGN, p.parseAssignExpression)
+	p.registerInfix(ast.PLUS_ASSIGN, p.parseCompoundAssignExpression)
+	p.registerInfix(ast.MINUS_ASSIGN, p.parseCompoundAssignExpression)
+	p.registerInfix(ast.ASTERISK_ASSIGN, p.parseCompoundAssignExpression)
+	p.registerInfix(ast.SLASH_ASSIGN, p.parseCompoundAssignExpression)
+	p.registerInfix(ast.PERCENT_ASSIGN, p.parseCompoundAssignExpression)
+	p.registerInfix(ast.AMPERSAND_ASSIGN, p.parseCompoundAssignExpression)
+	p.registerInfix(ast.PIPE_ASSIGN, p.parseCompoundAssignExpression)
+	p.registerInfix(ast.CARET_ASSIGN, p.parseCompoundAssignExpression)
+	p.registerInfix(ast.AND_NOT_ASSIGN, p.parseCompoundAssignExpression)
+	p.registerInfix(ast.SHIFT_LEFT_ASSIGN, p.parseCompoundAssignExpression)
+	p.registerInfix(ast.SHIFT_RIGHT_ASSIGN, p.parseCompoundAssignExpression)
 	p.registerInfix(ast.DOT, p.parseMemberExpression)
 	p.registerInfix(ast.LPAREN, p.parseCallExpression)
+	p.registerInfix(ast.LBRACKET, p.parseIndexExpression)
 
 	// Read two tokens, so curToken and peekToken are both set
 	p.nextToken()
@@ -107,11 +171,21 @@ func (p *Parser) registerInfix(tokenType ast.TokenType, fn infixParseFn) {
 	p.infixParseFns[tokenType] = fn
 }
 
-// Errors returns parser errors
+// Errors returns parser errors formatted as strings
 func (p *Parser) Errors() []string {
+	return p.errors.Strings()
+}
+
+// Diagnostics returns parser errors with their source ranges
+func (p *Parser) Diagnostics() diagnostic.List {
 	return p.errors
 }
 
+// errorAt records an error diagnostic at the given token
+func (p *Parser) errorAt(tok ast.Token, code diagnostic.Code, format string, args ...interface{}) {
+	p.errors = append(p.errors, diagnostic.AtToken(code, tok, format, args...))
+}
+
 // nextToken advances to the next token
 func (p *Parser) nextToken() {
 	p.curToken = p.peekToken
@@ -125,7 +199,15 @@ func (p *Parser) ParseProgram() *ast.Program {
 	}
 
 	for p.curToken.Type != ast.EOF {
-		stmt := p.parseStatement()
+		var stmt ast.Statement
+		if p.curTokenIs(ast.FUNC) && p.peekTokenIs(ast.LPAREN) {
+			// At the top level, 数 ( starts a method rather than a literal
+			if method := p.parseMethodStatement(); method != nil {
+				stmt = method
+			}
+		} else {
+			stmt = p.parseStatement()
+		}
 		if stmt != nil {
 			program.Statements = append(program.Statements, stmt)
 		}
@@ -143,17 +225,50 @@ func (p *Parser) parseStatement() ast.Statement {
 	case ast.IMPORT:
 		return p.parseImportStatement()
 	case ast.FUNC:
+		// A function literal such as 数() { ... }() is an expression
+		if p.peekTokenIs(ast.LPAREN) {
+			return p.parseExpressionStatement()
+		}
 		return p.parseFunctionStatement()
 	case ast.VAR:
-		return p.parseVarStatement()
+		return p.parseVarDeclaration()
 	case ast.CONST:
+		if p.peekTokenIs(ast.LPAREN) {
+			return p.parseConstGroupStatement()
+		}
 		return p.parseConstStatement()
+	case ast.OPTION:
+		return p.parseOptionStatement()
 	case ast.RETURN:
 		return p.parseReturnStatement()
 	case ast.IF:
 		return p.parseIfStatement()
 	case ast.FOR:
 		return p.parseForStatement()
+	case ast.WHILE:
+		return p.parseWhileStatement()
+	case ast.QUERY:
+		return p.parseQueryStatement()
+	case ast.CHARS:
+		return p.parseCharLoopStatement()
+	case ast.RANGE:
+		return p.parseRangeStatement()
+	case ast.SIGNAL:
+		return p.parseSignalStatement()
+	case ast.DEFER:
+		return p.parseDeferStatement()
+	case ast.GO:
+		return p.parseGoStatement()
+	case ast.SELECT:
+		return p.parseSelectStatement()
+	case ast.SWITCH:
+		return p.parseSwitchStatement()
+	case ast.INTERFACE:
+		return p.parseInterfaceStatement()
+	case ast.TYPE:
+		return p.parseTypeStatement()
+	case ast.ENUM:
+		return p.parseEnumStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -168,6 +283,7 @@ func (p *Parser) parsePackageStatement() *ast.PackageStatement {
 	}
 
 	stmt.Name = p.curToken.Literal
+	stmt.NameToken = p.curToken
 
 	// Expect semicolon or newline
 	if p.peekTokenIs(ast.SEMICOLON) {
@@ -191,13 +307,13 @@ func (p *Parser) parseImportStatement() *ast.ImportStatement {
 
 		// Expect a string literal
 		if !p.curTokenIs(ast.STRING) {
-			p.errors = append(p.errors, fmt.Sprintf("Line %d:%d expected import path to be a string, got %s",
-				p.curToken.Line, p.curToken.Column, p.curToken.Type))
+			p.errorAt(p.curToken, diagnostic.InvalidImportPath, "expected import path to be a string, got %s", p.curToken.Type)
 			return nil
 		}
 
 		// Get the import path
 		stmt.Path = p.curToken.Literal
+		stmt.PathToken = p.curToken
 
 		// Skip to the closing parenthesis
 		for !p.peekTokenIs(ast.RPAREN) && !p.peekTokenIs(ast.EOF) {
@@ -208,6 +324,7 @@ func (p *Parser) parseImportStatement() *ast.ImportStatement {
 		if !p.expectPeek(ast.RPAREN) {
 			return nil
 		}
+		stmt.Rparen = p.curToken
 	} else {
 		// Simple import
 		if !p.expectPeek(ast.STRING) {
@@ -215,6 +332,7 @@ func (p *Parser) parseImportStatement() *ast.ImportStatement {
 		}
 
 		stmt.Path = p.curToken.Literal
+		stmt.PathToken = p.curToken
 	}
 
 	// Expect semicolon or newline
@@ -225,6 +343,39 @@ func (p *Parser) parseImportStatement() *ast.ImportStatement {
 	return stmt
 }
 
+// parseVarDeclaration parses a declaration of one variable or, with several
+// names, of the variables taking the results of a call
+func (p *Parser) parseVarDeclaration() ast.Statement {
+	token := p.curToken
+	if !p.expectPeek(ast.IDENT) {
+		return nil
+	}
+	if !p.peekTokenIs(ast.COMMA) {
+		return p.parseVarValue(&ast.VarStatement{Token: token, Name: p.parseIdentifier().(*ast.Identifier)})
+	}
+
+	stmt := &ast.VarListStatement{Token: token, Names: []*ast.Identifier{p.parseIdentifier().(*ast.Identifier)}}
+	for p.peekTokenIs(ast.COMMA) {
+		p.nextToken()
+		if !p.expectPeek(ast.IDENT) {
+			return nil
+		}
+		stmt.Names = append(stmt.Names, p.parseIdentifier().(*ast.Identifier))
+	}
+	if !p.expectPeek(ast.ASSIGN) {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Value = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(ast.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
 // parseVarStatement parses a variable declaration
 func (p *Parser) parseVarStatement() *ast.VarStatement {
 	stmt := &ast.VarStatement{Token: p.curToken}
@@ -234,12 +385,28 @@ func (p *Parser) parseVarStatement() *ast.VarStatement {
 	}
 
 	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	return p.parseVarValue(stmt)
+}
 
-	if !p.expectPeek(ast.ASSIGN) {
-		return nil
+// parseVarValue parses the type and value of a variable declaration, after
+// its name. Either may be left out, but not both: 变量 x 整数 declares x as
+// the zero value of 整数.
+func (p *Parser) parseVarValue(stmt *ast.VarStatement) *ast.VarStatement {
+	if !p.peekTokenIs(ast.ASSIGN) {
+		p.nextToken()
+		if stmt.Type = p.parseType(); stmt.Type == nil {
+			return nil
+		}
+		if !p.peekTokenIs(ast.ASSIGN) {
+			if p.peekTokenIs(ast.SEMICOLON) {
+				p.nextToken()
+			}
+			return stmt
+		}
 	}
+	p.nextToken() // the '=' token
 
-	p.nextToken() // Skip over the '=' token
+	p.nextToken()
 	stmt.Value = p.parseExpression(LOWEST)
 
 	// Optional semicolon
@@ -274,13 +441,109 @@ func (p *Parser) parseConstStatement() *ast.ConstStatement {
 	return stmt
 }
 
+// parseConstGroupStatement parses constants declared together in
+// parentheses, one to a line. The first has a value, which the others may
+// leave out to repeat it.
+func (p *Parser) parseConstGroupStatement() ast.Statement {
+	stmt := &ast.ConstGroupStatement{Token: p.curToken}
+	p.nextToken()
+
+	for !p.peekTokenIs(ast.RPAREN) {
+		if !p.expectPeek(ast.IDENT) {
+			return nil
+		}
+		c := &ast.ConstStatement{Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}}
+		if p.peekTokenIs(ast.ASSIGN) {
+			p.nextToken()
+			p.nextToken()
+			c.Value = p.parseExpression(LOWEST)
+		} else if len(stmt.Consts) == 0 {
+			p.errorAt(p.peekToken, diagnostic.UnexpectedToken, "expected = after the first constant %s, got %s instead", c.Name.Value, p.peekToken.Type)
+			return nil
+		}
+		stmt.Consts = append(stmt.Consts, c)
+
+		if p.peekTokenIs(ast.SEMICOLON) {
+			p.nextToken()
+		}
+	}
+
+	p.nextToken()
+	stmt.Rparen = p.curToken
+
+	if len(stmt.Consts) == 0 {
+		p.errorAt(stmt.Rparen, diagnostic.UnexpectedToken, "%s () declares no constants", stmt.Token.Literal)
+		return nil
+	}
+
+	return stmt
+}
+
+// parseOptionStatement parses a command-line option declaration, whose
+// type is a built-in type and whose usage string is optional
+func (p *Parser) parseOptionStatement() *ast.OptionStatement {
+	stmt := &ast.OptionStatement{Token: p.curToken}
+
+	if !p.expectPeek(ast.IDENT) {
+		return nil
+	}
+
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.peekTokenIs(ast.TYPE_INT) && !p.peekTokenIs(ast.TYPE_STRING) &&
+		!p.peekTokenIs(ast.TYPE_FLOAT) && !p.peekTokenIs(ast.TYPE_BOOL) {
+		p.errorAt(p.peekToken, diagnostic.UnexpectedToken, "option %s needs a type of 整数, 字符串, 浮点 or 布尔", stmt.Name.Value)
+		return nil
+	}
+	p.nextToken()
+	stmt.Type = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(ast.ASSIGN) {
+		return nil
+	}
+
+	p.nextToken() // Skip over the '=' token
+	stmt.Value = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(ast.STRING) {
+		p.nextToken()
+		stmt.Usage = p.parseStringLiteral().(*ast.StringLiteral)
+	}
+
+	// Optional semicolon
+	if p.peekTokenIs(ast.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
 // parseReturnStatement parses a return statement
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	stmt := &ast.ReturnStatement{Token: p.curToken}
 
+	// A return without a value ends the line, the block or the clause of a
+	// 监听, rather than taking what follows as its value
+	if p.peekTokenIs(ast.RBRACE) || p.peekTokenIs(ast.SEMICOLON) || p.peekTokenIs(ast.EOF) ||
+		p.peekTokenIs(ast.CASE) || p.peekTokenIs(ast.DEFAULT) || p.peekToken.Line != p.curToken.Line {
+		if p.peekTokenIs(ast.SEMICOLON) {
+			p.nextToken()
+		}
+		return stmt
+	}
+
 	p.nextToken()
 
 	stmt.ReturnValue = p.parseExpression(LOWEST)
+	if p.peekTokenIs(ast.COMMA) {
+		list := &ast.ExpressionList{Values: []ast.Expression{stmt.ReturnValue}}
+		for p.peekTokenIs(ast.COMMA) {
+			p.nextToken()
+			p.nextToken()
+			list.Values = append(list.Values, p.parseExpression(LOWEST))
+		}
+		stmt.ReturnValue = list
+	}
 
 	if p.peekTokenIs(ast.SEMICOLON) {
 		p.nextToken()
@@ -299,18 +562,20 @@ func (p *Parser) parseFunctionStatement() *ast.FunctionStatement {
 
 	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
+	if p.peekTokenIs(ast.LBRACKET) {
+		p.nextToken()
+		if stmt.TypeParams = p.parseTypeParams(); stmt.TypeParams == nil {
+			return nil
+		}
+	}
+
 	if !p.expectPeek(ast.LPAREN) {
 		return nil
 	}
 
 	stmt.Parameters = p.parseFunctionParameters()
 
-	// Handle return type
-	if p.peekTokenIs(ast.TYPE_INT) || p.peekTokenIs(ast.TYPE_STRING) ||
-		p.peekTokenIs(ast.TYPE_FLOAT) || p.peekTokenIs(ast.TYPE_BOOL) {
-		p.nextToken()
-		stmt.ReturnType = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-	}
+	stmt.ReturnType = p.parseReturnType()
 
 	if !p.expectPeek(ast.LBRACE) {
 		return nil
@@ -321,168 +586,1027 @@ func (p *Parser) parseFunctionStatement() *ast.FunctionStatement {
 	return stmt
 }
 
-// parseFunctionParameters parses function parameters
-func (p *Parser) parseFunctionParameters() []*ast.TypedParam {
-	typedParams := []*ast.TypedParam{}
-
-	if p.peekTokenIs(ast.RPAREN) {
-		p.nextToken()
-		return typedParams
+// parseMethodStatement parses a method declaration, a function with a
+// receiver before its name, as in 数 (v 向量) 加(w 向量) 向量 { ... }
+func (p *Parser) parseMethodStatement() *ast.FunctionStatement {
+	tok := p.curToken
+	p.nextToken()
+	lparen := p.curToken
+	receiver := p.parseFunctionParameters()
+	if receiver == nil {
+		return nil
+	}
+	// The rest is parsed either way, so that errors do not cascade
+	stmt := p.parseFunctionStatement()
+	if len(receiver) != 1 || receiver[0].Type == nil || receiver[0].Variadic {
+		p.errorAt(lparen, diagnostic.UnexpectedToken, "method needs one receiver with a type, as in (v 向量)")
+		return nil
+	}
+	if stmt == nil {
+		return nil
 	}
+	stmt.Token = tok
+	stmt.Receiver = receiver[0]
+	return stmt
+}
 
-	p.nextToken()
+// parseTypeParams parses the bracketed type parameters of a generic
+// function or type, each with a constraint, as in [K 可比较, V 整数 | 浮点]
+func (p *Parser) parseTypeParams() []*ast.TypeParam {
+	var typeParams []*ast.TypeParam
+	for {
+		if !p.expectPeek(ast.IDENT) {
+			return nil
+		}
+		tp := &ast.TypeParam{Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}}
 
-	// Create parameter with name
-	param := &ast.TypedParam{
-		Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal},
-	}
+		p.nextToken()
+		if tp.Constraint = p.parseConstraint(); tp.Constraint == nil {
+			return nil
+		}
+		typeParams = append(typeParams, tp)
 
-	// Check if there is a type annotation
-	if p.peekTokenIs(ast.TYPE_INT) || p.peekTokenIs(ast.TYPE_STRING) ||
-		p.peekTokenIs(ast.TYPE_FLOAT) || p.peekTokenIs(ast.TYPE_BOOL) {
+		if !p.peekTokenIs(ast.COMMA) {
+			break
+		}
 		p.nextToken()
-		param.Type = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 	}
 
-	typedParams = append(typedParams, param)
+	if !p.expectPeek(ast.RBRACKET) {
+		return nil
+	}
+	return typeParams
+}
+
+// parseConstraint parses the constraint of a type parameter: a type, or a
+// union of types separated by '|'
+func (p *Parser) parseConstraint() ast.Expression {
+	constraint := p.parseType()
+	if constraint == nil || !p.peekTokenIs(ast.PIPE) {
+		return constraint
+	}
 
-	for p.peekTokenIs(ast.COMMA) {
+	union := &ast.UnionType{Types: []ast.Expression{constraint}}
+	for p.peekTokenIs(ast.PIPE) {
 		p.nextToken()
 		p.nextToken()
-
-		// Create parameter with name
-		param := &ast.TypedParam{
-			Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal},
+		t := p.parseType()
+		if t == nil {
+			return nil
 		}
+		union.Types = append(union.Types, t)
+	}
+	return union
+}
 
-		// Check if there is a type annotation
-		if p.peekTokenIs(ast.TYPE_INT) || p.peekTokenIs(ast.TYPE_STRING) ||
-			p.peekTokenIs(ast.TYPE_FLOAT) || p.peekTokenIs(ast.TYPE_BOOL) {
-			p.nextToken()
-			param.Type = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-		}
+// parseFunctionLiteral parses an anonymous function
+func (p *Parser) parseFunctionLiteral() ast.Expression {
+	lit := &ast.FunctionLiteral{Token: p.curToken}
 
-		typedParams = append(typedParams, param)
+	if !p.expectPeek(ast.LPAREN) {
+		return nil
 	}
 
-	if !p.expectPeek(ast.RPAREN) {
+	lit.Parameters = p.parseFunctionParameters()
+
+	lit.ReturnType = p.parseReturnType()
+
+	if !p.expectPeek(ast.LBRACE) {
 		return nil
 	}
 
-	return typedParams
-}
+	lit.Body = p.parseBlockStatement()
 
-// parseIfStatement parses an if statement
-func (p *Parser) parseIfStatement() *ast.IfStatement {
-	stmt := &ast.IfStatement{Token: p.curToken}
+	return lit
+}
 
-	p.nextToken()
-	stmt.Condition = p.parseExpression(LOWEST)
+// parseTypeStatement parses a type declaration, which declares an alias
+// when its name and type are separated by '='
+func (p *Parser) parseTypeStatement() *ast.TypeStatement {
+	stmt := &ast.TypeStatement{Token: p.curToken}
 
-	if !p.expectPeek(ast.LBRACE) {
+	if !p.expectPeek(ast.IDENT) {
 		return nil
 	}
 
-	stmt.Consequence = p.parseBlockStatement()
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
-	if p.peekTokenIs(ast.ELSE) {
+	if p.peekTokenIs(ast.LBRACKET) {
 		p.nextToken()
-
-		if !p.expectPeek(ast.LBRACE) {
+		if stmt.TypeParams = p.parseTypeParams(); stmt.TypeParams == nil {
 			return nil
 		}
+	}
+
+	if p.peekTokenIs(ast.ASSIGN) {
+		p.nextToken()
+		stmt.Alias = true
+		if len(stmt.TypeParams) > 0 {
+			p.errorAt(p.curToken, diagnostic.UnexpectedToken, "generic type cannot be an alias")
+		}
+	}
 
-		stmt.Alternative = p.parseBlockStatement()
+	p.nextToken()
+	if stmt.Type = p.parseType(); stmt.Type == nil {
+		return nil
 	}
 
 	return stmt
 }
 
-// parseForStatement parses a for statement
-func (p *Parser) parseForStatement() *ast.ForStatement {
-	stmt := &ast.ForStatement{Token: p.curToken}
+// parseEnumStatement parses an enumeration, whose members are separated by
+// commas or newlines
+func (p *Parser) parseEnumStatement() *ast.EnumStatement {
+	stmt := &ast.EnumStatement{Token: p.curToken}
 
-	// Skip the "循环" token
-	p.nextToken()
+	if !p.expectPeek(ast.IDENT) {
+		return nil
+	}
 
-	// Parse initialization part
-	if !p.curTokenIs(ast.SEMICOLON) {
-		if p.curTokenIs(ast.VAR) {
-			stmt.Init = p.parseVarStatement()
-		} else {
-			stmt.Init = p.parseExpressionStatement()
-		}
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(ast.LBRACE) {
+		return nil
 	}
 
-	// Skip semicolon after initialization
-	if !p.curTokenIs(ast.SEMICOLON) {
-		if !p.expectPeek(ast.SEMICOLON) {
+	for !p.peekTokenIs(ast.RBRACE) {
+		if !p.expectPeek(ast.IDENT) {
 			return nil
 		}
-	} else {
-		p.nextToken() // Skip semicolon
-	}
+		stmt.Members = append(stmt.Members, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
 
-	// Parse condition part
-	if !p.curTokenIs(ast.SEMICOLON) {
-		stmt.Condition = p.parseExpression(LOWEST)
+		if p.peekTokenIs(ast.COMMA) {
+			p.nextToken()
+		}
 	}
 
-	// Skip semicolon after condition
-	if !p.expectPeek(ast.SEMICOLON) {
+	p.nextToken()
+	stmt.Rbrace = p.curToken
+
+	if len(stmt.Members) == 0 {
+		p.errorAt(stmt.Rbrace, diagnostic.UnexpectedToken, "%s %s has no members", stmt.Token.Literal, stmt.Name.Value)
 		return nil
 	}
 
-	// Parse update part
-	if !p.peekTokenIs(ast.LBRACE) {
-		p.nextToken() // Move past the semicolon
-		stmt.Update = p.parseExpressionStatement()
-	} else {
-		p.nextToken() // Move past the semicolon
-	}
+	return stmt
+}
 
-	// Expect opening brace for the body
-	if !p.expectPeek(ast.LBRACE) {
+// parseInterfaceStatement parses an interface declaration, whose body
+// lists method signatures
+func (p *Parser) parseInterfaceStatement() *ast.InterfaceStatement {
+	stmt := &ast.InterfaceStatement{Token: p.curToken}
+
+	if !p.expectPeek(ast.IDENT) {
 		return nil
 	}
 
-	stmt.Body = p.parseBlockStatement()
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
-	return stmt
-}
+	if !p.expectPeek(ast.LBRACE) {
+		return nil
+	}
 
-// parseBlockStatement parses a block statement
-func (p *Parser) parseBlockStatement() *ast.BlockStatement {
-	block := &ast.BlockStatement{Token: p.curToken}
-	block.Statements = []ast.Statement{}
+	for !p.peekTokenIs(ast.RBRACE) {
+		if !p.expectPeek(ast.IDENT) {
+			return nil
+		}
 
-	p.nextToken()
+		method := &ast.MethodSignature{
+			Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal},
+		}
 
-	for !p.curTokenIs(ast.RBRACE) && !p.curTokenIs(ast.EOF) {
-		stmt := p.parseStatement()
-		if stmt != nil {
-			block.Statements = append(block.Statements, stmt)
+		if !p.expectPeek(ast.LPAREN) {
+			return nil
 		}
-		p.nextToken()
-	}
 
-	return block
-}
+		method.Parameters = p.parseFunctionParameters()
 
-// parseExpressionStatement parses an expression statement
-func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
-	stmt := &ast.ExpressionStatement{Token: p.curToken}
+		method.ReturnType = p.parseReturnType()
 
-	stmt.Expression = p.parseExpression(LOWEST)
+		// Methods may be separated by semicolons as well as newlines
+		if p.peekTokenIs(ast.SEMICOLON) {
+			p.nextToken()
+		}
 
-	if p.peekTokenIs(ast.SEMICOLON) {
-		p.nextToken()
+		stmt.Methods = append(stmt.Methods, method)
 	}
 
+	p.nextToken()
+	stmt.Rbrace = p.curToken
+
 	return stmt
 }
 
-// parseExpression parses an expression
+// parseReturnType parses the return type of a function, if it has one: a
+// type, or the parenthesized types of several results
+func (p *Parser) parseReturnType() ast.Expression {
+	switch {
+	case p.peekTokenIs(ast.LPAREN):
+		p.nextToken()
+		return p.parseResultList()
+	case p.peekTokenIsType(), p.peekTokenIsNamedType():
+		p.nextToken()
+		return p.parseType()
+	}
+	return nil
+}
+
+// parseResultList parses the types of several results, such as (整数, 错误),
+// or the names and types of named results, such as (商 整数, 余数 整数)
+func (p *Parser) parseResultList() ast.Expression {
+	list := &ast.ResultList{Token: p.curToken}
+
+	for len(list.Types) == 0 || p.peekTokenIs(ast.COMMA) {
+		if len(list.Types) > 0 {
+			p.nextToken()
+		}
+		p.nextToken()
+		// A name followed by a type names the result
+		named := p.curTokenIs(ast.IDENT) && (p.peekTokenIsType() || p.peekTokenIs(ast.IDENT))
+		if len(list.Types) > 0 && named != (len(list.Names) > 0) {
+			p.errorAt(p.curToken, diagnostic.UnexpectedToken, "mixed named and unnamed results")
+			return nil
+		}
+		if named {
+			list.Names = append(list.Names, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+			p.nextToken()
+		}
+		list.Types = append(list.Types, p.parseType())
+	}
+	if !p.expectPeek(ast.RPAREN) {
+		return nil
+	}
+	list.Rparen = p.curToken
+	return list
+}
+
+// peekTokenIsType reports whether the next token starts a built-in, map,
+// slice, array, channel or function type
+func (p *Parser) peekTokenIsType() bool {
+	return p.peekTokenIs(ast.TYPE_INT) || p.peekTokenIs(ast.TYPE_STRING) ||
+		p.peekTokenIs(ast.TYPE_FLOAT) || p.peekTokenIs(ast.TYPE_BOOL) || p.peekTokenIs(ast.TYPE_ERROR) ||
+		p.peekTokenIs(ast.MAP) || p.peekTokenIs(ast.SLICE) || p.peekTokenIs(ast.ARRAY) ||
+		p.peekTokenIs(ast.CHAN) || p.peekTokenIs(ast.FUNC)
+}
+
+// peekTokenIsNamedType reports whether the next token names a declared
+// type following on the same line. On a later line, a name starts the next
+// method of an interface instead.
+func (p *Parser) peekTokenIsNamedType() bool {
+	return p.peekTokenIs(ast.IDENT) && p.peekToken.Line == p.curToken.Line
+}
+
+// parseFunctionParameters parses function parameters
+func (p *Parser) parseFunctionParameters() []*ast.TypedParam {
+	typedParams := []*ast.TypedParam{}
+
+	if p.peekTokenIs(ast.RPAREN) {
+		p.nextToken()
+		return typedParams
+	}
+
+	p.nextToken()
+	typedParams = append(typedParams, p.parseTypedParam())
+
+	for p.peekTokenIs(ast.COMMA) {
+		p.nextToken()
+		p.nextToken()
+		typedParams = append(typedParams, p.parseTypedParam())
+	}
+
+	for _, param := range typedParams[:len(typedParams)-1] {
+		if param.Variadic {
+			p.errorAt(param.Name.Token, diagnostic.UnexpectedToken, "can only use ... with final parameter")
+		}
+	}
+
+	if !p.expectPeek(ast.RPAREN) {
+		return nil
+	}
+
+	return typedParams
+}
+
+// parseTypedParam parses a parameter name and its type annotation, if any.
+// A variadic parameter must have a type, as in 数字 ...整数.
+func (p *Parser) parseTypedParam() *ast.TypedParam {
+	param := &ast.TypedParam{
+		Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal},
+	}
+
+	if p.peekTokenIs(ast.ELLIPSIS) {
+		p.nextToken()
+		param.Variadic = true
+		p.nextToken()
+		param.Type = p.parseType()
+		return param
+	}
+
+	// Check if there is a type annotation, which may name a declared type
+	if p.peekTokenIsType() || p.peekTokenIs(ast.IDENT) {
+		p.nextToken()
+		param.Type = p.parseType()
+	}
+
+	return param
+}
+
+// parseIfStatement parses an if statement
+func (p *Parser) parseIfStatement() *ast.IfStatement {
+	stmt := &ast.IfStatement{Token: p.curToken}
+
+	p.nextToken()
+	stmt.Condition = p.parseHeaderExpression()
+
+	if !p.expectPeek(ast.LBRACE) {
+		return nil
+	}
+
+	stmt.Consequence = p.parseBlockStatement()
+
+	if p.peekTokenIs(ast.ELSE) {
+		p.nextToken()
+
+		if !p.expectPeek(ast.LBRACE) {
+			return nil
+		}
+
+		stmt.Alternative = p.parseBlockStatement()
+	}
+
+	return stmt
+}
+
+// parseForStatement parses a for statement
+func (p *Parser) parseForStatement() *ast.ForStatement {
+	stmt := &ast.ForStatement{Token: p.curToken}
+
+	exprLev := p.exprLev
+	p.exprLev = -1
+	defer func() { p.exprLev = exprLev }()
+
+	// Skip the "循环" token
+	p.nextToken()
+
+	// Parse initialization part
+	if !p.curTokenIs(ast.SEMICOLON) {
+		if p.curTokenIs(ast.VAR) {
+			varStmt := p.parseVarStatement()
+			if varStmt == nil {
+				return nil
+			}
+			if varStmt.Value == nil {
+				p.errors = append(p.errors, diagnostic.AtNode(diagnostic.UnexpectedToken, varStmt,
+					"%s %s in a %s header needs a value", varStmt.Token.Literal, varStmt.Name.Value, stmt.Token.Literal))
+				return nil
+			}
+			stmt.Init = varStmt
+		} else {
+			stmt.Init = p.parseExpressionStatement()
+		}
+	}
+
+	// Skip semicolon after initialization
+	if p.skipHeaderLiteral() {
+		p.nextToken()
+	}
+	if !p.curTokenIs(ast.SEMICOLON) {
+		if !p.expectPeek(ast.SEMICOLON) {
+			return nil
+		}
+	} else {
+		p.nextToken() // Skip semicolon
+	}
+
+	// Parse condition part
+	if !p.curTokenIs(ast.SEMICOLON) {
+		stmt.Condition = p.parseExpression(LOWEST)
+	}
+
+	// Skip semicolon after condition
+	p.skipHeaderLiteral()
+	if !p.expectPeek(ast.SEMICOLON) {
+		return nil
+	}
+
+	// Parse update part
+	if !p.peekTokenIs(ast.LBRACE) {
+		p.nextToken() // Move past the semicolon
+		stmt.Update = p.parseExpressionStatement()
+	} else {
+		p.nextToken() // Move past the semicolon
+	}
+
+	// Expect opening brace for the body
+	if !p.expectPeek(ast.LBRACE) {
+		return nil
+	}
+
+	stmt.Body = p.parseBlockStatement()
+
+	return stmt
+}
+
+// skipHeaderLiteral reports the braces of a composite literal that follow
+// a type name in a for header, as in 循环 ; p == 点{}; {, and skips them,
+// reporting whether it did. The braces are taken as a literal when a ';'
+// follows them, which is then the next token.
+func (p *Parser) skipHeaderLiteral() bool {
+	if !p.peekTokenIs(ast.LBRACE) || !p.curTokenIs(ast.IDENT) && !p.curTokenIs(ast.RBRACKET) {
+		return false
+	}
+
+	// Look past the braces with a copy of the lexer
+	l := *p.l
+	for depth := 1; depth > 0; {
+		switch l.NextToken().Type {
+		case ast.LBRACE:
+			depth++
+		case ast.RBRACE:
+			depth--
+		case ast.EOF:
+			return false
+		}
+	}
+	if l.NextToken().Type != ast.SEMICOLON {
+		return false
+	}
+
+	p.nextToken()
+	p.errorAt(p.curToken, diagnostic.UnexpectedToken,
+		"composite literal in the header of a statement must be parenthesized")
+	p.skipBraces()
+	return true
+}
+
+// skipBraces advances to the '}' closing the braces the current token is
+// the '{' of, or is in without further nesting, or to the last token
+// before the end of the input
+func (p *Parser) skipBraces() {
+	for depth := 1; depth > 0 && !p.peekTokenIs(ast.EOF); {
+		p.nextToken()
+		switch {
+		case p.curTokenIs(ast.LBRACE):
+			depth++
+		case p.curTokenIs(ast.RBRACE):
+			depth--
+		}
+	}
+}
+
+// parseWhileStatement parses a while statement
+func (p *Parser) parseWhileStatement() *ast.WhileStatement {
+	stmt := &ast.WhileStatement{Token: p.curToken}
+
+	p.nextToken()
+	stmt.Condition = p.parseHeaderExpression()
+
+	if !p.expectPeek(ast.LBRACE) {
+		return nil
+	}
+
+	stmt.Body = p.parseBlockStatement()
+
+	return stmt
+}
+
+// parseQueryStatement parses a query statement, whose columns must be typed
+// and whose call must pass the SQL
+func (p *Parser) parseQueryStatement() *ast.QueryStatement {
+	stmt := &ast.QueryStatement{Token: p.curToken}
+
+	if !p.expectPeek(ast.LPAREN) {
+		return nil
+	}
+
+	stmt.Columns = p.parseFunctionParameters()
+	if stmt.Columns == nil {
+		return nil
+	}
+	if len(stmt.Columns) == 0 {
+		p.errorAt(p.curToken, diagnostic.UnexpectedToken, "query needs at least one column")
+		return nil
+	}
+	for _, column := range stmt.Columns {
+		if column.Type == nil {
+			p.errorAt(column.Name.Token, diagnostic.UnexpectedToken, "query column %s needs a type", column.Name.Value)
+			return nil
+		}
+	}
+
+	if !p.expectPeek(ast.ASSIGN) {
+		return nil
+	}
+
+	p.nextToken()
+	call, ok := p.parseExpression(LOWEST).(*ast.CallExpression)
+	if !ok || len(call.Arguments) == 0 {
+		p.errorAt(p.curToken, diagnostic.ExpectedExpression, "query expects a call like 库(\"SELECT ...\", 参数)")
+		return nil
+	}
+	stmt.Call = call
+
+	if !p.expectPeek(ast.LBRACE) {
+		return nil
+	}
+
+	stmt.Body = p.parseBlockStatement()
+
+	return stmt
+}
+
+// parseCharLoopStatement parses a loop over the characters of a string
+func (p *Parser) parseCharLoopStatement() *ast.CharLoopStatement {
+	stmt := &ast.CharLoopStatement{Token: p.curToken}
+
+	if !p.expectPeek(ast.LPAREN) || !p.expectPeek(ast.IDENT) {
+		return nil
+	}
+	stmt.Char = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if p.peekTokenIs(ast.COMMA) {
+		p.nextToken()
+		if !p.expectPeek(ast.IDENT) {
+			return nil
+		}
+		stmt.Index = stmt.Char
+		stmt.Char = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	}
+	if !p.expectPeek(ast.RPAREN) || !p.expectPeek(ast.ASSIGN) {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Value = p.parseHeaderExpression()
+
+	if !p.expectPeek(ast.LBRACE) {
+		return nil
+	}
+
+	stmt.Body = p.parseBlockStatement()
+
+	return stmt
+}
+
+// parseRangeStatement parses a loop over the values of a slice, array,
+// channel or iterator
+func (p *Parser) parseRangeStatement() *ast.RangeStatement {
+	stmt := &ast.RangeStatement{Token: p.curToken}
+
+	if !p.expectPeek(ast.LPAREN) || !p.expectPeek(ast.IDENT) {
+		return nil
+	}
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if p.peekTokenIs(ast.COMMA) {
+		// An index as in Go's for i, v := range; the rest is parsed
+		// either way, so that errors do not cascade
+		p.errorAt(p.peekToken, diagnostic.UnexpectedToken, "范围 binds one value, as in 范围 (值) = 列表")
+		for !p.peekTokenIs(ast.RPAREN) && !p.peekTokenIs(ast.EOF) {
+			p.nextToken()
+		}
+		stmt.Name = nil
+	}
+	if !p.expectPeek(ast.RPAREN) || !p.expectPeek(ast.ASSIGN) {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Value = p.parseHeaderExpression()
+
+	if !p.expectPeek(ast.LBRACE) {
+		return nil
+	}
+
+	stmt.Body = p.parseBlockStatement()
+
+	if stmt.Name == nil {
+		return nil
+	}
+	return stmt
+}
+
+// parseSignalStatement parses a signal handler
+func (p *Parser) parseSignalStatement() *ast.SignalStatement {
+	stmt := &ast.SignalStatement{Token: p.curToken}
+
+	if !p.expectPeek(ast.LBRACE) {
+		return nil
+	}
+
+	stmt.Body = p.parseBlockStatement()
+
+	return stmt
+}
+
+// parseDeferStatement parses a deferred call
+func (p *Parser) parseDeferStatement() *ast.DeferStatement {
+	stmt := &ast.DeferStatement{Token: p.curToken}
+
+	p.nextToken()
+	expr := p.parseExpression(LOWEST)
+	if expr == nil {
+		return nil
+	}
+	call, ok := expr.(*ast.CallExpression)
+	if !ok {
+		p.errors = append(p.errors, diagnostic.AtNode(diagnostic.CallRequired, expr,
+			"expression in %s must be function call", stmt.Token.Literal))
+		return nil
+	}
+	stmt.Call = call
+
+	if p.peekTokenIs(ast.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parseGoStatement parses a call started in a goroutine
+func (p *Parser) parseGoStatement() *ast.GoStatement {
+	stmt := &ast.GoStatement{Token: p.curToken}
+
+	p.nextToken()
+	expr := p.parseExpression(LOWEST)
+	if expr == nil {
+		return nil
+	}
+	call, ok := expr.(*ast.CallExpression)
+	if !ok {
+		p.errors = append(p.errors, diagnostic.AtNode(diagnostic.CallRequired, expr,
+			"expression in %s must be function call", stmt.Token.Literal))
+		return nil
+	}
+	stmt.Call = call
+
+	if p.peekTokenIs(ast.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parseSelectStatement parses a 监听 statement
+func (p *Parser) parseSelectStatement() *ast.SelectStatement {
+	stmt := &ast.SelectStatement{Token: p.curToken}
+
+	if !p.expectPeek(ast.LBRACE) {
+		return nil
+	}
+	p.nextToken()
+
+	var defaultCase *ast.SelectCase
+	for !p.curTokenIs(ast.RBRACE) && !p.curTokenIs(ast.EOF) {
+		clause := p.parseSelectCase()
+		if clause == nil {
+			return nil
+		}
+		if clause.Comm == nil {
+			if defaultCase != nil {
+				p.errorAt(clause.Token, diagnostic.UnexpectedToken, "multiple %s clauses in %s", clause.Token.Literal, stmt.Token.Literal)
+				return nil
+			}
+			defaultCase = clause
+		}
+		stmt.Cases = append(stmt.Cases, clause)
+	}
+
+	if !p.curTokenIs(ast.RBRACE) {
+		p.errorAt(p.curToken, diagnostic.UnexpectedToken, "expected }, got %s instead", p.curToken.Type)
+		return nil
+	}
+	stmt.Rbrace = p.curToken
+
+	return stmt
+}
+
+// parseSelectCase parses a 情况 or 默认 clause of a 监听 statement, ending
+// on the token after its statements
+func (p *Parser) parseSelectCase() *ast.SelectCase {
+	clause := &ast.SelectCase{Token: p.curToken}
+
+	switch p.curToken.Type {
+	case ast.CASE:
+		p.nextToken()
+		var comm ast.Statement
+		if p.curTokenIs(ast.VAR) {
+			varStmt := p.parseVarStatement()
+			if varStmt == nil {
+				return nil
+			}
+			if varStmt.Type != nil {
+				p.errors = append(p.errors, diagnostic.AtNode(diagnostic.UnexpectedToken, varStmt.Type,
+					"%s %s in %s takes the type of the value received", varStmt.Token.Literal, varStmt.Name.Value, clause.Token.Literal))
+				return nil
+			}
+			comm = varStmt
+		} else {
+			comm = p.parseExpressionStatement()
+		}
+		if !isChannelOperation(comm) {
+			p.errors = append(p.errors, diagnostic.AtNode(diagnostic.ChannelOpRequired, comm,
+				"%s in %s must be a send or receive", ast.Sprint(comm), clause.Token.Literal))
+			return nil
+		}
+		clause.Comm = comm
+	case ast.DEFAULT:
+	default:
+		p.errorAt(p.curToken, diagnostic.UnexpectedToken, "expected 情况 or 默认, got %s instead", p.curToken.Type)
+		return nil
+	}
+
+	if !p.expectPeek(ast.COLON) {
+		return nil
+	}
+	clause.Body = p.parseCaseBody()
+
+	return clause
+}
+
+// parseCaseBody parses the statements of a 情况 or 默认 clause after its
+// ':', ending on the token after them
+func (p *Parser) parseCaseBody() []ast.Statement {
+	var body []ast.Statement
+	p.nextToken()
+	for !p.curTokenIs(ast.CASE) && !p.curTokenIs(ast.DEFAULT) &&
+		!p.curTokenIs(ast.RBRACE) && !p.curTokenIs(ast.EOF) {
+		stmt := p.parseStatement()
+		if stmt != nil {
+			body = append(body, stmt)
+		}
+		p.nextToken()
+	}
+	return body
+}
+
+// parseSwitchStatement parses a 选择 statement, with or without a tag
+func (p *Parser) parseSwitchStatement() *ast.SwitchStatement {
+	stmt := &ast.SwitchStatement{Token: p.curToken}
+
+	if !p.peekTokenIs(ast.LBRACE) {
+		p.nextToken()
+		stmt.Tag = p.parseHeaderExpression()
+	}
+	if !p.expectPeek(ast.LBRACE) {
+		return nil
+	}
+	p.nextToken()
+
+	var defaultCase *ast.SwitchCase
+	for !p.curTokenIs(ast.RBRACE) && !p.curTokenIs(ast.EOF) {
+		clause := p.parseSwitchCase()
+		if clause == nil {
+			return nil
+		}
+		if len(clause.Values) == 0 {
+			if defaultCase != nil {
+				p.errorAt(clause.Token, diagnostic.UnexpectedToken, "multiple %s clauses in %s", clause.Token.Literal, stmt.Token.Literal)
+				return nil
+			}
+			defaultCase = clause
+		}
+		stmt.Cases = append(stmt.Cases, clause)
+	}
+
+	if !p.curTokenIs(ast.RBRACE) {
+		p.errorAt(p.curToken, diagnostic.UnexpectedToken, "expected }, got %s instead", p.curToken.Type)
+		return nil
+	}
+	stmt.Rbrace = p.curToken
+
+	return stmt
+}
+
+// parseSwitchCase parses a 情况 clause of a 选择 statement with its values
+// separated by commas, or its 默认 clause, ending on the token after its
+// statements
+func (p *Parser) parseSwitchCase() *ast.SwitchCase {
+	clause := &ast.SwitchCase{Token: p.curToken}
+
+	switch p.curToken.Type {
+	case ast.CASE:
+		for {
+			p.nextToken()
+			value := p.parseExpression(LOWEST)
+			if value == nil {
+				return nil
+			}
+			clause.Values = append(clause.Values, value)
+			if !p.peekTokenIs(ast.COMMA) {
+				break
+			}
+			p.nextToken()
+		}
+	case ast.DEFAULT:
+	default:
+		p.errorAt(p.curToken, diagnostic.UnexpectedToken, "expected 情况 or 默认, got %s instead", p.curToken.Type)
+		return nil
+	}
+
+	if !p.expectPeek(ast.COLON) {
+		return nil
+	}
+	clause.Body = p.parseCaseBody()
+
+	return clause
+}
+
+// isChannelOperation reports whether stmt is a send, a receive, or a
+// receive whose value is assigned or declared, as a 情况 of 监听 must be
+func isChannelOperation(stmt ast.Statement) bool {
+	if _, ok := stmt.(*ast.SendStatement); ok {
+		return true
+	}
+	return ast.Receive(stmt) != nil
+}
+
+// parseBlockStatement parses a block statement
+func (p *Parser) parseBlockStatement() *ast.BlockStatement {
+	block := &ast.BlockStatement{Token: p.curToken}
+	block.Statements = []ast.Statement{}
+
+	// A block in a header, that of a function literal, allows literals
+	exprLev := p.exprLev
+	p.exprLev = 0
+	defer func() { p.exprLev = exprLev }()
+
+	p.nextToken()
+
+	// No statement starts with a field name, as in { x: 1 }: the braces
+	// were meant as a composite literal in the header of a statement. The
+	// block that follows them is taken as the one meant.
+	if p.curTokenIs(ast.IDENT) && p.peekTokenIs(ast.COLON) {
+		p.errorAt(block.Token, diagnostic.UnexpectedToken,
+			"composite literal in the header of a statement must be parenthesized")
+		p.skipBraces()
+		if p.peekTokenIs(ast.LBRACE) {
+			p.nextToken()
+			return p.parseBlockStatement()
+		}
+		return block
+	}
+
+	for !p.curTokenIs(ast.RBRACE) && !p.curTokenIs(ast.EOF) {
+		stmt := p.parseStatement()
+		if stmt != nil {
+			block.Statements = append(block.Statements, stmt)
+		}
+		p.nextToken()
+	}
+
+	if p.curTokenIs(ast.RBRACE) {
+		block.Rbrace = p.curToken
+	} else {
+		p.unterminated(block.Token)
+	}
+
+	// Nor is a block followed by another on the same line, as in
+	// 如果 p == 点{} { ... }: the empty braces were a literal. The second
+	// block is taken as the one meant.
+	if len(block.Statements) == 0 && p.curTokenIs(ast.RBRACE) &&
+		p.peekTokenIs(ast.LBRACE) && p.peekToken.Line == p.curToken.Line {
+		p.errorAt(block.Token, diagnostic.UnexpectedToken,
+			"composite literal in the header of a statement must be parenthesized")
+		p.nextToken()
+		return p.parseBlockStatement()
+	}
+
+	return block
+}
+
+// parseExpressionStatement parses an expression statement
+func (p *Parser) parseExpressionStatement() ast.Statement {
+	stmt := &ast.ExpressionStatement{Token: p.curToken}
+
+	stmt.Expression = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(ast.ARROW) {
+		return p.parseSendStatement(stmt.Expression)
+	}
+	if p.peekTokenIs(ast.COMMA) {
+		return p.parseAssignListStatement(stmt.Expression)
+	}
+
+	// A statement beginning with an identifier that more follows on the
+	// same line is most likely a misspelled keyword, as in "变亮 x = 1",
+	// "如国 真 {" and "如国(x) {". Without a keyword close to it, only an
+	// operand after a lone identifier is reported here.
+	if ident := leadingIdentifier(stmt.Expression); ident != nil && p.peekContinuesLine() {
+		if _, ok := stmt.Expression.(*ast.Identifier); ok && p.peekStartsOperand() || p.closestKeyword(ident) != "" {
+			p.unexpectedAfterIdentifier(ident)
+		}
+	}
+
+	if p.peekTokenIs(ast.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parseSendStatement parses sending a value on channel, the expression
+// before the '<-' peek token
+func (p *Parser) parseSendStatement(channel ast.Expression) *ast.SendStatement {
+	p.nextToken()
+	stmt := &ast.SendStatement{Token: p.curToken, Channel: channel}
+
+	p.nextToken()
+	stmt.Value = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(ast.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parseAssignListStatement parses assigning the results of a call to
+// several targets, the first of which is the expression before the ','
+// peek token
+func (p *Parser) parseAssignListStatement(first ast.Expression) ast.Statement {
+	stmt := &ast.AssignListStatement{Targets: []ast.Expression{first}}
+	for p.peekTokenIs(ast.COMMA) {
+		p.nextToken()
+		p.nextToken()
+		stmt.Targets = append(stmt.Targets, p.parseExpression(EQUALS))
+	}
+	if !p.expectPeek(ast.ASSIGN) {
+		return nil
+	}
+	stmt.Token = p.curToken
+	for _, target := range stmt.Targets {
+		p.checkAssignTarget(target)
+	}
+
+	p.nextToken()
+	stmt.Value = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(ast.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// peekStartsOperand reports whether the peek token is an identifier or
+// literal on the same line as the current token
+func (p *Parser) peekStartsOperand() bool {
+	if p.peekToken.Line != p.curToken.Line {
+		return false
+	}
+	switch p.peekToken.Type {
+	case ast.IDENT, ast.INT, ast.FLOAT, ast.UNIT, ast.STRING:
+		return true
+	}
+	return false
+}
+
+// peekContinuesLine reports whether the peek token is on the same line as
+// the current token and does not end the statement
+func (p *Parser) peekContinuesLine() bool {
+	if p.peekToken.Line != p.curToken.Line {
+		return false
+	}
+	switch p.peekToken.Type {
+	case ast.SEMICOLON, ast.RBRACE, ast.EOF:
+		return false
+	}
+	return true
+}
+
+// leadingIdentifier returns the identifier that expr, an expression
+// statement, begins with when it is one or calls one, and nil otherwise
+func leadingIdentifier(expr ast.Expression) *ast.Identifier {
+	if call, ok := expr.(*ast.CallExpression); ok {
+		expr = call.Function
+	}
+	ident, _ := expr.(*ast.Identifier)
+	return ident
+}
+
+// closestKeyword returns the keyword that ident is probably a misspelling
+// of, or "" when none is close to it
+func (p *Parser) closestKeyword(ident *ast.Identifier) string {
+	keyword, _ := suggest.Closest(ident.Value, ast.KeywordNames())
+	return keyword
+}
+
+// unexpectedAfterIdentifier reports the token following the statement
+// that ident begins, suggesting the keyword ident was probably meant to be
+func (p *Parser) unexpectedAfterIdentifier(ident *ast.Identifier) {
+	keyword := p.closestKeyword(ident)
+	if keyword == "" {
+		p.errorAt(p.peekToken, diagnostic.UnexpectedIdentifier, "unexpected %s after %s", p.peekToken.Literal, ident.Value)
+		return
+	}
+
+	d := diagnostic.AtToken(diagnostic.UnexpectedIdentifier, ident.Token,
+		"unexpected %s after %s; did you mean %s?", p.peekToken.Literal, ident.Value, keyword)
+	d.WithFix(fmt.Sprintf("replace %s with %s", ident.Value, keyword),
+		diagnostic.Replace(ast.TokenRange(ident.Token), keyword))
+	p.errors = append(p.errors, d)
+}
+
+// parseExpression parses an expression
 func (p *Parser) parseExpression(precedence int) ast.Expression {
 	prefix := p.prefixParseFns[p.curToken.Type]
 	if prefix == nil {
@@ -511,6 +1635,7 @@ func (p *Parser) parseAssignExpression(left ast.Expression) ast.Expression {
 		Token: p.curToken,
 		Left:  left,
 	}
+	p.checkAssignTarget(left)
 
 	p.nextToken() // Skip over the '=' token
 	expr.Value = p.parseExpression(LOWEST)
@@ -518,19 +1643,163 @@ func (p *Parser) parseAssignExpression(left ast.Expression) ast.Expression {
 	return expr
 }
 
+// checkAssignTarget reports an assignment to anything but a variable, a
+// member or an element
+func (p *Parser) checkAssignTarget(target ast.Expression) {
+	switch target.(type) {
+	case *ast.Identifier, *ast.MemberExpression, *ast.IndexExpression:
+		return
+	}
+	p.errors = append(p.errors, diagnostic.AtNode(diagnostic.InvalidAssignTarget, target,
+		"cannot assign to %s", ast.Sprint(target)))
+}
+
+// parseCompoundAssignExpression parses an assignment applying an operator,
+// such as x += 1
+func (p *Parser) parseCompoundAssignExpression(left ast.Expression) ast.Expression {
+	expr := &ast.CompoundAssignExpression{
+		Token:    p.curToken,
+		Operator: strings.TrimSuffix(p.curToken.Literal, "="),
+		Left:     left,
+	}
+	p.checkAssignTarget(left)
+
+	p.nextToken() // Skip over the compound assignment token
+	expr.Value = p.parseExpression(LOWEST)
+
+	return expr
+}
+
 // parseIdentifier parses an identifier
 func (p *Parser) parseIdentifier() ast.Expression {
-	return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if p.peekTokenIs(ast.LBRACE) && p.exprLev >= 0 {
+		p.nextToken()
+		return p.parseCompositeLiteral(ident)
+	}
+	return ident
+}
+
+// parseHeaderExpression parses the expression in the header of a statement
+// with a block, which ends at the '{' of the block
+func (p *Parser) parseHeaderExpression() ast.Expression {
+	exprLev := p.exprLev
+	p.exprLev = -1
+	defer func() { p.exprLev = exprLev }()
+
+	return p.parseExpression(LOWEST)
+}
+
+// parseStructLiteral parses a value of an anonymous struct type, as in
+// 结构{ x 整数; y 整数 }{x: 1, y: 2}
+func (p *Parser) parseStructLiteral() ast.Expression {
+	structType := p.parseStructType()
+	if structType == nil {
+		return nil
+	}
+
+	// A type without fields is given to a builtin, as in 创建
+	if p.peekTokenIs(ast.RPAREN) || p.peekTokenIs(ast.COMMA) {
+		return structType
+	}
+	if !p.expectPeek(ast.LBRACE) {
+		return nil
+	}
+	return p.parseCompositeLiteral(structType)
+}
+
+// parseCompositeLiteral parses the braced fields of a struct value, as in
+// 用户{名字: "张三", 年龄: 30}
+func (p *Parser) parseCompositeLiteral(typ ast.Expression) ast.Expression {
+	lit := &ast.CompositeLiteral{Token: p.curToken, Type: typ}
+
+	// The values are nested in the braces, where literals are allowed
+	// even in a header
+	p.exprLev++
+	defer func() { p.exprLev-- }()
+
+	for !p.peekTokenIs(ast.RBRACE) {
+		if !p.expectPeek(ast.IDENT) {
+			return nil
+		}
+		field := &ast.FieldValue{Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}}
+
+		if !p.expectPeek(ast.COLON) {
+			return nil
+		}
+
+		p.nextToken()
+		field.Value = p.parseExpression(LOWEST)
+		lit.Fields = append(lit.Fields, field)
+
+		// A trailing comma is allowed before the closing brace
+		if !p.peekTokenIs(ast.RBRACE) && !p.expectPeek(ast.COMMA) {
+			return nil
+		}
+	}
+
+	p.nextToken()
+	lit.Rbrace = p.curToken
+
+	return lit
 }
 
 // parseIntegerLiteral parses an integer literal
 func (p *Parser) parseIntegerLiteral() ast.Expression {
 	lit := &ast.IntegerLiteral{Token: p.curToken}
 
-	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
-	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
+	if err != nil {
+		p.errorAt(p.curToken, diagnostic.InvalidInteger, "could not parse %q as integer", p.curToken.Literal)
+		return nil
+	}
+
+	lit.Value = value
+
+	return lit
+}
+
+// parseFloatLiteral parses a floating-point literal
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	lit := &ast.FloatLiteral{Token: p.curToken}
+
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if err != nil {
+		p.errorAt(p.curToken, diagnostic.InvalidInteger, "could not parse %q as float", p.curToken.Literal)
+		return nil
+	}
+
+	lit.Value = value
+
+	return lit
+}
+
+// parseUnitLiteral parses a number with a unit suffix
+func (p *Parser) parseUnitLiteral() ast.Expression {
+	lit := &ast.UnitLiteral{Token: p.curToken}
+
+	// The longest matching suffix wins, so 毫秒 is not taken for 秒
+	for unit := range ast.Units {
+		if strings.HasSuffix(p.curToken.Literal, unit) && len(unit) > len(lit.Unit) {
+			lit.Unit = unit
+		}
+	}
+
+	number := lit.Number()
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		// Hexadecimal, octal and binary counts are integers
+		n, intErr := strconv.ParseInt(number, 0, 64)
+		if intErr != nil {
+			p.errorAt(p.curToken, diagnostic.InvalidInteger, "could not parse %q as a number of %s", number, lit.Unit)
+			return nil
+		}
+		value = float64(n)
+	}
+
+	// Like Go constants, literals may not truncate to a partial nanosecond or byte
+	if size := value * float64(ast.Units[lit.Unit].Size); size != math.Trunc(size) {
+		p.errorAt(p.curToken, diagnostic.InvalidInteger, "%s is not a whole number of nanoseconds or bytes", p.curToken.Literal)
 		return nil
 	}
 
@@ -541,7 +1810,19 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 
 // parseStringLiteral parses a string literal
 func (p *Parser) parseStringLiteral() ast.Expression {
-	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+	lit := &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+	if _, err := ast.UnquoteString(lit.Value); err != nil {
+		p.errorAt(p.curToken, diagnostic.InvalidString, "invalid string literal: %s", stringError(lit.Value))
+	}
+	return lit
+}
+
+// stringError describes why the text of a string literal does not decode
+func stringError(value string) string {
+	if strings.ContainsAny(value, "\n\r") {
+		return "a string cannot span lines; write \\n for a line break"
+	}
+	return "unknown escape sequence; write \\\\ for a backslash"
 }
 
 // parseBooleanLiteral parses a boolean literal
@@ -583,6 +1864,9 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 
 // parseGroupedExpression parses a grouped expression
 func (p *Parser) parseGroupedExpression() ast.Expression {
+	p.exprLev++
+	defer func() { p.exprLev-- }()
+
 	p.nextToken()
 
 	exp := p.parseExpression(LOWEST)
@@ -604,6 +1888,12 @@ func (p *Parser) parseMemberExpression(object ast.Expression) ast.Expression {
 	p.nextToken()
 	exp.Property = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
+	// A type of another package, as in 包名.类型{...}
+	if _, ok := object.(*ast.Identifier); ok && p.peekTokenIs(ast.LBRACE) && p.exprLev >= 0 {
+		p.nextToken()
+		return p.parseCompositeLiteral(exp)
+	}
+
 	return exp
 }
 
@@ -614,40 +1904,506 @@ func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 		Function: function,
 	}
 
-	exp.Arguments = p.parseExpressionList(ast.RPAREN)
+	exp.Arguments = p.parseCallArguments(exp)
+	if p.curTokenIs(ast.RPAREN) {
+		exp.Rparen = p.curToken
+	}
 
 	return exp
 }
 
-// parseExpressionList parses a list of expressions
-func (p *Parser) parseExpressionList(end ast.TokenType) []ast.Expression {
-	list := []ast.Expression{}
+// parseCallArguments parses the arguments of a call, up to the closing
+// parenthesis, noting a spread last argument in exp
+func (p *Parser) parseCallArguments(exp *ast.CallExpression) []ast.Expression {
+	args := []ast.Expression{}
+
+	p.exprLev++
+	defer func() { p.exprLev-- }()
 
-	if p.peekTokenIs(end) {
+	if p.peekTokenIs(ast.RPAREN) {
 		p.nextToken()
-		return list
+		return args
 	}
 
 	p.nextToken()
-	list = append(list, p.parseExpression(LOWEST))
+	args = append(args, p.parseExpression(LOWEST))
 
 	for p.peekTokenIs(ast.COMMA) {
 		p.nextToken()
 		p.nextToken()
-		list = append(list, p.parseExpression(LOWEST))
+		args = append(args, p.parseExpression(LOWEST))
+	}
+
+	// Only the last argument can be spread
+	if p.peekTokenIs(ast.ELLIPSIS) {
+		p.nextToken()
+		exp.Ellipsis = true
 	}
 
-	if !p.expectPeek(end) {
+	if !p.expectPeek(ast.RPAREN) {
 		return nil
 	}
 
-	return list
+	return args
+}
+
+// parseIndexExpression parses an index expression like m["a"], or a slice
+// expression like s[1:3]
+func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	lbracket := p.curToken
+	literal := p.exprLev >= 0
+
+	p.exprLev++
+	defer func() { p.exprLev-- }()
+
+	var index ast.Expression
+	if !p.peekTokenIs(ast.COLON) {
+		p.nextToken()
+		index = p.parseTypeArgument()
+	}
+	if p.peekTokenIs(ast.COLON) {
+		return p.parseSliceExpression(lbracket, left, index)
+	}
+	// Several type arguments instantiate a generic function or type, as
+	// in 配对[字符串, 整数]
+	if p.peekTokenIs(ast.COMMA) {
+		list := &ast.ExpressionList{Values: []ast.Expression{index}}
+		for p.peekTokenIs(ast.COMMA) {
+			p.nextToken()
+			p.nextToken()
+			list.Values = append(list.Values, p.parseTypeArgument())
+		}
+		index = list
+	}
+
+	exp := &ast.IndexExpression{
+		Token: lbracket,
+		Left:  left,
+		Index: index,
+	}
+
+	if !p.expectPeek(ast.RBRACKET) {
+		return nil
+	}
+	exp.Rbracket = p.curToken
+
+	// A value of an instantiated generic type, as in 栈[整数]{}
+	switch left.(type) {
+	case *ast.Identifier, *ast.MemberExpression:
+		if literal && p.peekTokenIs(ast.LBRACE) {
+			p.nextToken()
+			return p.parseCompositeLiteral(exp)
+		}
+	}
+
+	return exp
+}
+
+// parseTypeArgument parses an index, which may be a type argument
+// instantiating a generic function or type. A predeclared type can only
+// be one, so it is parsed as a type; other types parse as expressions.
+func (p *Parser) parseTypeArgument() ast.Expression {
+	switch p.curToken.Type {
+	case ast.TYPE_INT, ast.TYPE_STRING, ast.TYPE_FLOAT, ast.TYPE_BOOL, ast.TYPE_ERROR:
+		return p.parseType()
+	}
+	return p.parseExpression(LOWEST)
+}
+
+// parseSliceExpression parses the rest of a slice expression from the
+// colon after its low index, which may be nil
+func (p *Parser) parseSliceExpression(lbracket ast.Token, left, low ast.Expression) ast.Expression {
+	exp := &ast.SliceExpression{
+		Token: lbracket,
+		Left:  left,
+		Low:   low,
+	}
+
+	p.nextToken()
+	if !p.peekTokenIs(ast.COLON) && !p.peekTokenIs(ast.RBRACKET) {
+		p.nextToken()
+		exp.High = p.parseExpression(LOWEST)
+	}
+	if p.peekTokenIs(ast.COLON) {
+		p.nextToken()
+		if exp.High == nil {
+			p.errorAt(p.curToken, diagnostic.ExpectedExpression, "middle index required in 3-index slice")
+			return nil
+		}
+		if p.peekTokenIs(ast.RBRACKET) {
+			p.errorAt(p.peekToken, diagnostic.ExpectedExpression, "final index required in 3-index slice")
+			return nil
+		}
+		p.nextToken()
+		exp.Max = p.parseExpression(LOWEST)
+	}
+
+	if !p.expectPeek(ast.RBRACKET) {
+		return nil
+	}
+	exp.Rbracket = p.curToken
+
+	return exp
+}
+
+// parseMapLiteral parses a map literal like 映射[字符串]整数{"a": 1}
+func (p *Parser) parseMapLiteral() ast.Expression {
+	mapType := p.parseMapType()
+	if mapType == nil {
+		return nil
+	}
+
+	// A type without elements is given to a builtin, as in
+	// 创建(映射[字符串]整数), or is a type argument
+	if p.peekTokenIs(ast.RPAREN) || p.peekTokenIs(ast.COMMA) || p.peekTokenIs(ast.RBRACKET) {
+		return mapType
+	}
+	if !p.expectPeek(ast.LBRACE) {
+		return nil
+	}
+	lit := &ast.MapLiteral{Token: p.curToken, Type: mapType}
+
+	p.exprLev++
+	defer func() { p.exprLev-- }()
+
+	for !p.peekTokenIs(ast.RBRACE) {
+		p.nextToken()
+		pair := &ast.MapPair{Key: p.parseExpression(LOWEST)}
+
+		if !p.expectPeek(ast.COLON) {
+			return nil
+		}
+
+		p.nextToken()
+		pair.Value = p.parseExpression(LOWEST)
+		lit.Pairs = append(lit.Pairs, pair)
+
+		// A trailing comma is allowed before the closing brace
+		if !p.peekTokenIs(ast.RBRACE) && !p.expectPeek(ast.COMMA) {
+			return nil
+		}
+	}
+
+	p.nextToken()
+	lit.Rbrace = p.curToken
+
+	return lit
+}
+
+// parseMapType parses a map type like 映射[字符串]整数
+func (p *Parser) parseMapType() *ast.MapType {
+	mapType := &ast.MapType{Token: p.curToken}
+
+	if !p.expectPeek(ast.LBRACKET) {
+		return nil
+	}
+
+	p.nextToken()
+	mapType.Key = p.parseType()
+
+	if !p.expectPeek(ast.RBRACKET) {
+		return nil
+	}
+
+	p.nextToken()
+	mapType.Value = p.parseType()
+
+	if mapType.Key == nil || mapType.Value == nil {
+		return nil
+	}
+	return mapType
+}
+
+// parseSliceLiteral parses a slice literal like 切片[整数]{1, 2, 3}
+func (p *Parser) parseSliceLiteral() ast.Expression {
+	sliceType := p.parseSliceType()
+	if sliceType == nil {
+		return nil
+	}
+
+	// A type without elements is given to a builtin, as in
+	// 创建(切片[整数], 10), or is a type argument
+	if p.peekTokenIs(ast.RPAREN) || p.peekTokenIs(ast.COMMA) || p.peekTokenIs(ast.RBRACKET) {
+		return sliceType
+	}
+	if !p.expectPeek(ast.LBRACE) {
+		return nil
+	}
+	lit := &ast.SliceLiteral{Token: p.curToken, Type: sliceType}
+
+	p.exprLev++
+	defer func() { p.exprLev-- }()
+
+	for !p.peekTokenIs(ast.RBRACE) {
+		p.nextToken()
+		lit.Elements = append(lit.Elements, p.parseExpression(LOWEST))
+
+		// A trailing comma is allowed before the closing brace
+		if !p.peekTokenIs(ast.RBRACE) && !p.expectPeek(ast.COMMA) {
+			return nil
+		}
+	}
+
+	p.nextToken()
+	lit.Rbrace = p.curToken
+
+	return lit
+}
+
+// parseSliceType parses a slice type like 切片[整数]
+func (p *Parser) parseSliceType() *ast.SliceType {
+	sliceType := &ast.SliceType{Token: p.curToken}
+
+	if !p.expectPeek(ast.LBRACKET) {
+		return nil
+	}
+
+	p.nextToken()
+	sliceType.Elem = p.parseType()
+
+	if !p.expectPeek(ast.RBRACKET) {
+		return nil
+	}
+
+	if sliceType.Elem == nil {
+		return nil
+	}
+	return sliceType
+}
+
+// parseChanType parses a channel type like 通道 整数
+func (p *Parser) parseChanType() ast.Expression {
+	chanType := &ast.ChanType{Token: p.curToken}
+
+	p.nextToken()
+	chanType.Elem = p.parseType()
+	if chanType.Elem == nil {
+		return nil
+	}
+	return chanType
+}
+
+// parseArrayLiteral parses an array literal like 数组[3]整数{1, 2, 3}
+func (p *Parser) parseArrayLiteral() ast.Expression {
+	arrayType := p.parseArrayType()
+	if arrayType == nil {
+		return nil
+	}
+
+	// A type argument
+	if p.peekTokenIs(ast.COMMA) || p.peekTokenIs(ast.RBRACKET) {
+		return arrayType
+	}
+	if !p.expectPeek(ast.LBRACE) {
+		return nil
+	}
+	lit := &ast.ArrayLiteral{Token: p.curToken, Type: arrayType}
+
+	p.exprLev++
+	defer func() { p.exprLev-- }()
+
+	for !p.peekTokenIs(ast.RBRACE) {
+		p.nextToken()
+		lit.Elements = append(lit.Elements, p.parseExpression(LOWEST))
+
+		// A trailing comma is allowed before the closing brace
+		if !p.peekTokenIs(ast.RBRACE) && !p.expectPeek(ast.COMMA) {
+			return nil
+		}
+	}
+
+	p.nextToken()
+	lit.Rbrace = p.curToken
+
+	return lit
+}
+
+// parseArrayType parses an array type like 数组[5]整数, whose length is a
+// constant expression
+func (p *Parser) parseArrayType() *ast.ArrayType {
+	arrayType := &ast.ArrayType{Token: p.curToken}
+
+	if !p.expectPeek(ast.LBRACKET) {
+		return nil
+	}
+
+	p.nextToken()
+	arrayType.Len = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(ast.RBRACKET) {
+		return nil
+	}
+
+	p.nextToken()
+	arrayType.Elem = p.parseType()
+
+	if arrayType.Len == nil || arrayType.Elem == nil {
+		return nil
+	}
+	return arrayType
+}
+
+// parseType parses a type: a built-in or named type, or a map, slice,
+// array, channel or struct type
+func (p *Parser) parseType() ast.Expression {
+	switch p.curToken.Type {
+	case ast.IDENT:
+		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		if p.peekTokenIs(ast.LBRACKET) {
+			p.nextToken()
+			return p.parseTypeArguments(ident)
+		}
+		return ident
+	case ast.TYPE_INT, ast.TYPE_STRING, ast.TYPE_FLOAT, ast.TYPE_BOOL, ast.TYPE_ERROR:
+		return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	case ast.MAP:
+		if mapType := p.parseMapType(); mapType != nil {
+			return mapType
+		}
+		return nil
+	case ast.SLICE:
+		if sliceType := p.parseSliceType(); sliceType != nil {
+			return sliceType
+		}
+		return nil
+	case ast.ARRAY:
+		if arrayType := p.parseArrayType(); arrayType != nil {
+			return arrayType
+		}
+		return nil
+	case ast.CHAN:
+		return p.parseChanType()
+	case ast.STRUCT:
+		if structType := p.parseStructType(); structType != nil {
+			return structType
+		}
+		return nil
+	case ast.FUNC:
+		if funcType := p.parseFuncType(); funcType != nil {
+			return funcType
+		}
+		return nil
+	}
+
+	p.errorAt(p.curToken, diagnostic.UnexpectedToken, "expected a type, got %s instead", p.curToken.Type)
+	return nil
+}
+
+// parseTypeArguments parses the type arguments of an instantiated generic
+// type, as in 栈[整数] or 配对[字符串, 整数], from the '['. Several type
+// arguments are indexed as an expression list.
+func (p *Parser) parseTypeArguments(typ ast.Expression) ast.Expression {
+	exp := &ast.IndexExpression{Token: p.curToken, Left: typ}
+	var args []ast.Expression
+	for {
+		p.nextToken()
+		arg := p.parseType()
+		if arg == nil {
+			return nil
+		}
+		args = append(args, arg)
+		if !p.peekTokenIs(ast.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+	if !p.expectPeek(ast.RBRACKET) {
+		return nil
+	}
+	exp.Rbracket = p.curToken
+
+	exp.Index = args[0]
+	if len(args) > 1 {
+		exp.Index = &ast.ExpressionList{Values: args}
+	}
+	return exp
+}
+
+// parseFuncType parses a function type, whose parameters are types
+// without names and whose last parameter may be variadic
+func (p *Parser) parseFuncType() *ast.FuncType {
+	funcType := &ast.FuncType{Token: p.curToken}
+
+	if !p.expectPeek(ast.LPAREN) {
+		return nil
+	}
+
+	for !p.peekTokenIs(ast.RPAREN) {
+		if len(funcType.Parameters) > 0 && !p.expectPeek(ast.COMMA) {
+			return nil
+		}
+		if funcType.Variadic {
+			p.errorAt(p.curToken, diagnostic.UnexpectedToken, "can only use ... with final parameter")
+			return nil
+		}
+		if p.peekTokenIs(ast.ELLIPSIS) {
+			p.nextToken()
+			funcType.Variadic = true
+		}
+		p.nextToken()
+		param := p.parseType()
+		if param == nil {
+			return nil
+		}
+		funcType.Parameters = append(funcType.Parameters, param)
+	}
+
+	p.nextToken()
+	funcType.Rparen = p.curToken
+	funcType.ReturnType = p.parseReturnType()
+	return funcType
+}
+
+// parseStructType parses a struct type, whose fields are separated by
+// newlines or semicolons
+func (p *Parser) parseStructType() *ast.StructType {
+	structType := &ast.StructType{Token: p.curToken}
+
+	if !p.expectPeek(ast.LBRACE) {
+		return nil
+	}
+
+	for !p.peekTokenIs(ast.RBRACE) {
+		if !p.expectPeek(ast.IDENT) {
+			return nil
+		}
+		field := &ast.Field{Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}}
+
+		p.nextToken()
+		if field.Type = p.parseType(); field.Type == nil {
+			return nil
+		}
+		structType.Fields = append(structType.Fields, field)
+
+		if p.peekTokenIs(ast.SEMICOLON) {
+			p.nextToken()
+		}
+	}
+
+	p.nextToken()
+	structType.Rbrace = p.curToken
+
+	return structType
+}
+
+// unterminated reports that the input ended before the block opened by
+// lbrace was closed. The blocks enclosing it end there too, so it is
+// reported once, for the innermost.
+func (p *Parser) unterminated(lbrace ast.Token) {
+	if p.unclosed {
+		return
+	}
+	p.unclosed = true
+	p.errorAt(p.curToken, diagnostic.UnexpectedToken, "missing } to close the { at line %d:%d", lbrace.Line, lbrace.Column)
 }
 
 // noPrefixParseFnError adds an error when no prefix parse function exists for the token type
 func (p *Parser) noPrefixParseFnError(t ast.TokenType) {
-	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
+	if t == ast.ILLEGAL && strings.HasPrefix(p.curToken.Literal, `"`) {
+		p.errorAt(p.curToken, diagnostic.UnexpectedToken, "string literal not terminated")
+		return
+	}
+	p.errorAt(p.curToken, diagnostic.ExpectedExpression, "no prefix parse function for %s found", t)
 }
 
 // peekPrecedence returns the precedence of the peek token
@@ -689,7 +2445,9 @@ func (p *Parser) expectPeek(t ast.TokenType) bool {
 
 // peekError adds an error when the peek token isn't what was expected
 func (p *Parser) peekError(t ast.TokenType) {
-	msg := fmt.Sprintf("Line %d:%d expected next token to be %s, got %s instead",
-		p.peekToken.Line, p.peekToken.Column, t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+	d := diagnostic.AtToken(diagnostic.UnexpectedToken, p.peekToken, "expected next token to be %s, got %s instead", t, p.peekToken.Type)
+	if t == ast.SEMICOLON {
+		d.WithFix(`insert ";"`, diagnostic.Insert(p.curToken.End, ";"))
+	}
+	p.errors = append(p.errors, d)
 }
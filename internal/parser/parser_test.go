@@ -0,0 +1,159 @@
+package parser_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/lexer"
+	"github.com/saika-m/saika-lang/internal/parser"
+)
+
+// TestHeaderLiteral reports a composite literal in the header of a
+// statement once, without errors cascading from its braces
+func TestHeaderLiteral(t *testing.T) {
+	const want = "Line %d:%d [SK0001] composite literal in the header of a statement must be parenthesized"
+	for name, c := range map[string]struct {
+		body      string
+		line, col int
+	}{
+		"empty in if":     {"\t如果 p == 点{} {\n\t\tp = 点{}\n\t}\n", 9, 11},
+		"fields in if":    {"\t如果 p == 点{x: 1} {\n\t}\n", 9, 11},
+		"empty in while":  {"\t当 p != 点{} {\n\t}\n", 9, 10},
+		"for condition":   {"\t循环 变量 i = 0; p == 点{}; i += 1 {\n\t}\n", 9, 21},
+		"for init":        {"\t循环 p = 点{}; p.x < 3; p.x += 1 {\n\t}\n", 9, 10},
+		"nested in block": {"\t如果 真 {\n\t\t如果 p == 点{} {\n\t\t}\n\t}\n", 10, 12},
+	} {
+		source := "包 main\n\n类型 点 结构 {\n\tx 整数\n}\n\n数 入口() {\n\t变量 p = 点{}\n" + c.body + "}\n"
+		p := parser.New(lexer.New(source))
+		p.ParseProgram()
+		errs := p.Errors()
+		if len(errs) != 1 {
+			t.Errorf("%s: got %d errors, want 1: %q", name, len(errs), errs)
+			continue
+		}
+		if w := fmt.Sprintf(want, c.line, c.col); errs[0] != w {
+			t.Errorf("%s: error = %q, want %q", name, errs[0], w)
+		}
+	}
+}
+
+// TestAssignList parses assigning the results of a call to several
+// targets, and reports targets that cannot be assigned to
+func TestAssignList(t *testing.T) {
+	source := "包 main\n\n数 入口() {\n\ta, 忽略 = 二()\n\t列[0], m.x = 二()\n}\n"
+	p := parser.New(lexer.New(source))
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("parse: %v", errs)
+	}
+	body := program.Statements[1].(*ast.FunctionStatement).Body.Statements
+	for i, want := range []string{"a, 忽略 = 二()", "(列[0]), m.x = 二()"} {
+		stmt, ok := body[i].(*ast.AssignListStatement)
+		if !ok {
+			t.Errorf("statement %d is %T, want *ast.AssignListStatement", i, body[i])
+			continue
+		}
+		if got := stmt.String(); got != want {
+			t.Errorf("statement %d = %q, want %q", i, got, want)
+		}
+	}
+
+	p = parser.New(lexer.New("包 main\n\n数 入口() {\n\ta, 1 = 二()\n}\n"))
+	p.ParseProgram()
+	if errs := p.Errors(); len(errs) != 1 || errs[0] != "Line 4:5 [SK0015] cannot assign to 1" {
+		t.Errorf("errors = %q, want one for assigning to 1", errs)
+	}
+}
+
+// TestMethod parses a function with a receiver at the top level as a
+// method, and a receiver list without one typed receiver as an error
+func TestMethod(t *testing.T) {
+	source := "包 main\n\n数 (v 向量) 加(w 向量) 向量 {\n\t返回 v\n}\n\n数 入口() {\n\t数() {}()\n}\n"
+	p := parser.New(lexer.New(source))
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("parse: %v", errs)
+	}
+	method := program.Statements[1].(*ast.FunctionStatement)
+	if method.Receiver == nil || method.Receiver.Name.Value != "v" || method.Name.Value != "加" {
+		t.Errorf("method = %s, want 加 with receiver v", method)
+	}
+	if got, want := ast.Sprint(method), "数 (v 向量) 加(w 向量) 向量 {\n\t返回 v\n}"; got != want {
+		t.Errorf("printed method = %q, want %q", got, want)
+	}
+	if entry := program.Statements[2].(*ast.FunctionStatement); entry.Receiver != nil {
+		t.Errorf("入口 has receiver %v", entry.Receiver)
+	}
+
+	for _, receivers := range []string{"()", "(v 向量, w 向量)", "(v)"} {
+		p := parser.New(lexer.New("包 main\n\n数 " + receivers + " 加() {\n}\n"))
+		p.ParseProgram()
+		want := "Line 3:3 [SK0001] method needs one receiver with a type, as in (v 向量)"
+		if errs := p.Errors(); len(errs) != 1 || errs[0] != want {
+			t.Errorf("receivers %s: errors = %q, want %q", receivers, errs, want)
+		}
+	}
+}
+
+// TestRange parses a loop over the values of a collection, and reports one
+// naming an index as well as the value
+func TestRange(t *testing.T) {
+	source := "包 main\n\n数 入口() {\n\t范围 (学生) = 名单 {\n\t\t打印(学生)\n\t}\n}\n"
+	p := parser.New(lexer.New(source))
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("parse: %v", errs)
+	}
+	body := program.Statements[1].(*ast.FunctionStatement).Body.Statements
+	stmt, ok := body[0].(*ast.RangeStatement)
+	if !ok {
+		t.Fatalf("statement is %T, want *ast.RangeStatement", body[0])
+	}
+	if got, want := ast.Sprint(stmt), "范围 (学生) = 名单 {\n\t打印(学生)\n}"; got != want {
+		t.Errorf("printed loop = %q, want %q", got, want)
+	}
+
+	p = parser.New(lexer.New("包 main\n\n数 入口() {\n\t范围 (a, b) = 名单 {\n\t}\n}\n"))
+	p.ParseProgram()
+	want := "Line 4:7 [SK0001] 范围 binds one value, as in 范围 (值) = 列表"
+	if errs := p.Errors(); len(errs) != 1 || errs[0] != want {
+		t.Errorf("errors = %q, want %q", errs, want)
+	}
+}
+
+// TestGenerics parses a generic struct type, a method on it and the
+// explicit instantiation of a generic function and of generic types
+func TestGenerics(t *testing.T) {
+	source := "包 main\n\n类型 配对[K 可比较, V 任意] 结构 {\n\t键 K\n\t值 V\n}\n\n数 (p 配对[K, V]) 取() V {\n\t返回 p.值\n}\n\n数 入口() {\n\t变量 p 配对[字符串, 切片[整数]]\n\t变量 q = 配对[整数, 浮点]{键: 1}\n\t打印(首[整数](切片[整数]{}), 首[切片[整数]])\n}\n"
+	p := parser.New(lexer.New(source))
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("parse: %v", errs)
+	}
+	typ := program.Statements[1].(*ast.TypeStatement)
+	if len(typ.TypeParams) != 2 || typ.TypeParams[1].Name.Value != "V" {
+		t.Fatalf("type parameters = %v, want K and V", typ.TypeParams)
+	}
+	method := program.Statements[2].(*ast.FunctionStatement)
+	if got, want := ast.Sprint(method.Receiver.Type), "配对[K, V]"; got != want {
+		t.Errorf("receiver type = %q, want %q", got, want)
+	}
+	body := program.Statements[3].(*ast.FunctionStatement).Body.Statements
+	if got, want := ast.Sprint(body[0].(*ast.VarStatement).Type), "配对[字符串, 切片[整数]]"; got != want {
+		t.Errorf("variable type = %q, want %q", got, want)
+	}
+	if _, ok := body[1].(*ast.VarStatement).Value.(*ast.CompositeLiteral); !ok {
+		t.Errorf("value is %T, want *ast.CompositeLiteral", body[1].(*ast.VarStatement).Value)
+	}
+	if got, want := ast.Sprint(body[2]), "打印(首[整数](切片[整数]{}), 首[切片[整数]])"; got != want {
+		t.Errorf("printed call = %q, want %q", got, want)
+	}
+
+	p = parser.New(lexer.New("包 main\n\n类型 列[T 任意] = 切片[T]\n"))
+	p.ParseProgram()
+	want := "Line 3:12 [SK0001] generic type cannot be an alias"
+	if errs := p.Errors(); len(errs) != 1 || errs[0] != want {
+		t.Errorf("errors = %q, want %q", errs, want)
+	}
+}
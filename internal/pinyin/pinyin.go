@@ -0,0 +1,59 @@
+// Package pinyin spells Chinese names in Latin letters, so that names
+// declared in Chinese can be given Go names that other packages can use.
+// The spelling only depends on the name: the same name is always spelled
+// the same way.
+package pinyin
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// syllables maps each character of the table to its syllable
+var syllables = map[rune]string{}
+
+func init() {
+	for _, line := range strings.Split(strings.TrimSpace(table), "\n") {
+		syllable, chars, _ := strings.Cut(line, " ")
+		for _, r := range chars {
+			if other, ok := syllables[r]; ok {
+				panic(fmt.Sprintf("pinyin: %c listed under both %s and %s", r, other, syllable))
+			}
+			syllables[r] = syllable
+		}
+	}
+}
+
+// Syllable returns the syllable a Chinese character is read as, without
+// its tone, and whether the character is known
+func Syllable(r rune) (string, bool) {
+	s, ok := syllables[r]
+	return s, ok
+}
+
+// Exported spells name with each Chinese character replaced by its
+// syllable, capitalized, and the first letter in upper case, so that the
+// result is an exported Go identifier: 用户 is YongHu, 用户ID is YongHuID
+// and 新建用户 is XinJianYongHu. A character missing from the table is
+// spelled by its code point, as U4E28 for 丨. Letters other than Chinese
+// characters are kept as they are.
+func Exported(name string) string {
+	var out strings.Builder
+	for _, r := range name {
+		if !unicode.Is(unicode.Han, r) {
+			out.WriteRune(r)
+			continue
+		}
+		if s, ok := syllables[r]; ok {
+			out.WriteString(strings.ToUpper(s[:1]) + s[1:])
+		} else {
+			fmt.Fprintf(&out, "U%04X", r)
+		}
+	}
+	spelled := out.String()
+	if first := []rune(spelled)[0]; unicode.IsLower(first) {
+		return string(unicode.ToUpper(first)) + spelled[len(string(first)):]
+	}
+	return spelled
+}
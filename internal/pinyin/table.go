@@ -0,0 +1,405 @@
+package pinyin
+
+// table lists the syllables of Mandarin without tones, each followed by
+// the characters read with it. A character with several readings is
+// listed once, under the reading it most often has in names.
+const table = `
+a 阿啊呵腌锕
+ai 爱哀挨埃癌矮艾碍隘蔼唉皑嗳霭暧哎
+an 安按案暗岸鞍氨俺胺庵谙黯
+ang 昂肮盎
+ao 奥傲澳熬凹敖袄懊翱鳌拗
+ba 八把吧爸拔巴罢霸坝扒叭芭疤靶捌跋笆耙
+bai 白百摆败拜柏佰伯掰
+ban 办版般班半板伴搬扮拌颁斑瓣扳绊阪坂
+bang 帮邦榜棒绑膀傍谤磅镑蚌
+bao 报保包宝暴饱抱堡爆胞豹鲍褒雹苞
+bei 被北备背杯悲贝倍辈碑卑惫焙狈呗
+ben 本奔笨苯
+beng 崩蹦泵绷甭
+bi 比必笔币闭避壁毕彼鼻逼碧臂弊蔽鄙毙痹庇璧匕陛弼
+bian 边变便编遍辩辨鞭贬扁卞汴
+biao 表标彪膘飙镖
+bie 别憋鳖瘪
+bin 宾滨彬斌濒缤鬓
+bing 并病兵冰丙饼柄秉炳禀
+bo 波播博拨玻泊驳薄剥搏勃钵舶膊脖渤帛铂卜
+bu 不部步布补捕埔怖簿哺
+ca 擦
+cai 才采菜财材彩裁猜蔡踩睬
+can 参残惨灿餐蚕
+cang 藏仓苍舱沧
+cao 草操曹槽糙
+ce 测策册侧厕
+cen 岑
+ceng 层曾蹭
+cha 查差插察茶叉茬碴搽岔诧
+chai 拆柴豺
+chan 产缠蝉馋铲阐颤禅搀
+chang 长场常厂唱肠昌尝偿畅倡敞猖
+chao 超朝潮吵抄炒巢钞嘲
+che 车彻撤扯澈
+chen 陈沉晨尘臣辰衬趁忱
+cheng 成城程称承乘诚呈撑秤惩橙澄逞骋
+chi 持吃池迟尺赤齿驰耻斥翅痴弛
+chong 充冲虫崇宠
+chou 抽仇愁丑臭筹酬稠绸瞅
+chu 出处初除础储触楚厨雏锄橱畜矗
+chuai 揣踹
+chuan 传川船穿串喘
+chuang 创窗床闯疮
+chui 吹垂锤炊捶
+chun 春纯唇醇蠢淳
+chuo 戳绰
+ci 此次词辞刺磁雌瓷慈赐
+cong 从丛聪葱匆
+cou 凑
+cu 粗促醋簇
+cuan 窜篡蹿
+cui 催脆崔翠摧粹萃
+cun 村存寸
+cuo 错措挫搓撮
+da 大打达答搭
+dai 代带待贷袋戴呆逮怠殆
+dan 单但担蛋淡胆旦丹诞耽氮
+dang 当党档挡荡
+dao 到道导倒刀岛盗稻悼蹈
+de 的得德
+deng 等灯登邓瞪凳蹬
+di 地第低敌底帝弟递滴抵堤笛迪涤缔蒂
+dian 点电店典垫殿颠淀惦奠碘
+diao 调掉吊雕钓刁
+die 跌叠爹碟蝶谍迭
+ding 定顶订丁盯钉鼎
+diu 丢
+dong 动东懂冬洞冻董栋
+dou 都斗豆抖逗陡
+du 度读独毒督渡堵赌杜肚镀妒
+duan 断段短端锻缎
+dui 对队堆兑
+dun 吨顿蹲盾敦墩钝
+duo 多夺朵躲堕舵跺惰
+e 额恶饿俄鹅蛾讹扼鄂
+en 恩嗯
+er 而二儿耳尔饵
+fa 发法罚伐阀乏筏
+fan 反饭范犯翻凡繁返番烦泛帆贩矾藩
+fang 方放房防访仿纺芳妨肪坊
+fei 非费飞肥废肺匪菲沸啡诽吠斐
+fen 分份粉纷奋愤坟芬粪氛
+feng 风封丰峰锋疯蜂逢奉缝讽冯凤枫
+fo 佛
+fou 否
+fu 服府复福父负夫富副付妇附浮扶符幅腐赴伏辅肤赋抚覆斧俘拂釜甫
+ga 嘎
+gai 该改概盖丐钙
+gan 干感敢赶甘肝杆竿柑尴
+gang 刚港钢岗纲缸杠
+gao 高告搞稿糕膏篙
+ge 个各格歌哥革隔割阁鸽搁戈葛胳
+gei 给
+gen 根跟亘
+geng 更耕耿梗庚
+gong 工公共功供攻宫贡恭巩拱躬弓
+gou 够构购沟狗钩勾苟
+gu 古故顾股鼓骨固估谷孤姑雇菇辜箍
+gua 挂瓜刮寡
+guai 怪乖拐
+guan 关管观官馆惯冠贯灌罐
+guang 光广逛
+gui 规贵归鬼柜跪桂轨龟硅瑰
+gun 滚棍
+guo 国过果锅郭裹
+ha 哈
+hai 海害孩亥骇嗨
+han 含汉寒喊汗韩旱函罕憾涵撼
+hang 航杭
+hao 好号毫豪耗浩郝
+he 和合河何核喝盒荷贺禾赫鹤
+hei 黑嘿
+hen 很恨狠痕
+heng 横衡恒哼
+hong 红宏洪轰虹鸿哄烘
+hou 后候厚侯猴吼喉
+hu 户护乎湖呼忽虎互胡糊壶狐弧
+hua 话化花华划画滑哗
+huai 怀坏淮槐
+huan 换环还欢缓患幻唤焕
+huang 黄皇荒慌晃煌恍谎
+hui 会回汇挥惠辉灰毁绘悔徽恢贿慧晦
+hun 混婚魂浑昏
+huo 或活火获货伙惑霍祸
+ji 机及几级记己技即极基积集计际继济急击纪籍激疾寄鸡吉迹挤季绩祭肌寂既忌剂饥辑姬圾
+jia 家加价假架甲佳夹嘉驾稼颊
+jian 见间建件简检减坚健渐监剑鉴践肩艰兼键尖箭捡剪荐舰溅贱歼碱
+jiang 将讲江降奖疆浆僵酱蒋桨匠
+jiao 教交较叫角脚焦骄郊胶椒礁娇浇矫搅缴轿
+jie 结接界解节街介借阶洁杰届姐戒揭截皆劫捷竭诫
+jin 进金今近尽仅紧禁斤津锦劲晋浸筋巾谨
+jing 经精京境警竟静井景镜净敬晶惊竞睛径颈
+jiong 窘炯
+jiu 就九究久旧酒救纠揪舅灸韭
+ju 局据举具居剧巨聚拒句俱距菊鞠矩锯
+juan 卷捐眷倦娟
+jue 决觉绝掘爵诀抉
+jun 军均君菌俊峻钧骏
+ka 卡咖
+kai 开凯慨楷
+kan 看刊堪砍坎
+kang 康抗扛炕慷
+kao 考靠烤
+ke 可科克客课刻颗渴壳柯棵咳坷
+ken 肯恳啃垦
+keng 坑吭
+kong 空控孔恐
+kou 口扣寇
+ku 苦库哭酷裤枯窟
+kua 跨夸垮挎
+kuai 快块筷
+kuan 宽款
+kuang 况矿狂框旷眶
+kui 亏愧溃葵魁馈窥
+kun 困昆捆坤
+kuo 扩括阔廓
+la 拉啦喇蜡辣腊垃
+lai 来赖莱
+lan 兰蓝栏拦篮烂滥懒览揽澜
+lang 浪朗郎狼廊
+lao 老劳牢涝捞姥
+le 了乐勒
+lei 类累雷泪垒擂蕾
+leng 冷愣棱
+li 里理力利立李历例离丽礼励黎厉粒哩隶璃吏梨
+lia 俩
+lian 连联练脸恋链炼莲廉帘怜涟
+liang 两量良亮梁粮凉辆谅晾
+liao 料疗聊辽僚廖寥
+lie 列烈裂劣猎
+lin 林临邻淋琳磷鳞
+ling 领令另灵零龄岭铃凌玲陵
+liu 流六留刘柳溜琉硫瘤
+long 龙隆笼拢陇聋
+lou 楼漏露搂
+lu 路陆录卢鲁炉鹿碌芦庐颅
+lv 律绿率旅虑履屡吕铝驴滤
+luan 乱卵
+lve 略掠
+lun 论轮伦
+luo 落罗络洛逻螺裸骆萝锣
+ma 吗妈马码麻骂嘛
+mai 买卖麦迈埋脉
+man 满慢漫曼蛮瞒
+mang 忙芒盲茫
+mao 毛猫冒帽贸矛茂貌
+me 么
+mei 没每美妹煤梅媒眉枚霉
+men 们门闷
+meng 梦猛蒙盟孟萌
+mi 米密迷秘蜜谜弥眯幂
+mian 面免棉眠绵勉
+miao 秒妙苗描庙渺
+mie 灭蔑
+min 民敏闽皿
+ming 名明命鸣铭冥
+miu 谬
+mo 莫模末磨默摸魔膜墨漠陌
+mou 某谋
+mu 目母木幕亩墓牧慕穆
+na 那拿哪纳娜
+nai 乃奶耐
+nan 南难男
+nang 囊
+nao 脑闹恼
+ne 呢
+nei 内
+nen 嫩
+neng 能
+ni 你尼泥拟逆腻昵
+nian 年念捻碾
+niang 娘酿
+niao 鸟尿
+nie 捏聂
+nin 您
+ning 宁凝拧
+niu 牛纽扭钮
+nong 农浓弄
+nu 努怒奴
+nv 女
+nuan 暖
+nuo 诺挪懦
+o 哦噢
+ou 欧偶呕鸥耦
+pa 怕爬帕啪趴
+pai 派排牌拍徘
+pan 判盘盼攀潘
+pang 旁胖庞
+pao 跑炮泡抛袍
+pei 配陪培赔佩沛
+pen 喷盆
+peng 朋碰彭捧蓬鹏膨棚
+pi 批皮匹疲脾屁辟披劈琵
+pian 片篇骗偏
+piao 票飘漂
+pie 撇瞥
+pin 品贫频拼聘
+ping 平评瓶凭屏萍苹
+po 破迫坡婆颇泼魄
+pou 剖
+pu 普铺扑朴谱仆葡浦
+qi 起其期气七器齐奇企汽启旗骑妻弃契棋戚歧乞漆欺祈岂砌
+qia 恰洽掐
+qian 前钱千签迁浅欠潜牵谦铅遣谴嵌
+qiang 强墙枪抢腔
+qiao 桥巧敲悄侨瞧乔俏
+qie 切且窃怯茄
+qin 亲琴勤侵秦钦禽寝
+qing 情清青请轻庆晴倾顷卿
+qiong 穷琼
+qiu 求球秋丘邱囚
+qu 去区取曲趣渠屈驱躯娶
+quan 全权泉券劝圈拳犬
+que 确却缺雀鹊
+qun 群裙
+ran 然燃染冉
+rang 让嚷壤
+rao 绕扰饶
+re 热惹
+ren 人认任仁忍刃韧
+reng 仍扔
+ri 日
+rong 容荣融溶绒蓉
+rou 肉柔揉
+ru 如入乳辱儒
+ruan 软阮
+rui 瑞锐蕊
+run 润闰
+ruo 若弱
+sa 撒洒萨
+sai 赛塞腮
+san 三散伞
+sang 桑丧嗓
+sao 扫嫂骚
+se 色涩瑟
+sen 森
+seng 僧
+sha 沙杀傻纱刹鲨砂啥
+shai 晒筛
+shan 山善闪衫扇删珊陕擅
+shang 上商伤尚赏裳
+shao 少烧稍绍哨勺
+she 社设射舍摄涉蛇舌赦
+shei 谁
+shen 身深神审甚申伸慎渗肾绅沈
+sheng 生声省胜升圣剩盛牲绳甥
+shi 是时实事市使世式十石识师始史示失食势士视施室试释适湿诗尸拾饰誓逝狮氏
+shou 手收受首守授兽售瘦
+shu 数书术属树输述熟叔殊鼠舒署梳蔬淑束暑薯竖
+shua 刷耍
+shuai 帅摔衰甩
+shuan 栓拴
+shuang 双爽霜
+shui 水睡税
+shun 顺瞬
+shuo 说硕烁
+si 四思死司私似斯丝寺撕肆
+song 送松宋颂耸诵
+sou 搜艘嗽
+su 速素苏诉俗宿塑肃酥
+suan 算酸蒜
+sui 随虽岁碎遂隋髓
+sun 孙损笋
+suo 所索锁缩琐
+ta 他她它塔踏
+tai 太台态泰抬胎
+tan 谈探弹坦叹滩摊贪炭碳潭
+tang 堂唐糖汤躺趟塘倘
+tao 讨套逃桃陶掏淘涛
+te 特
+teng 腾疼藤
+ti 提题体替梯踢蹄
+tian 天田填甜添恬
+tiao 条跳挑
+tie 铁贴帖
+ting 听停庭挺厅亭廷
+tong 同通统痛童铜桶筒
+tou 头投透偷
+tu 土图突途徒涂吐兔
+tuan 团
+tui 推退腿
+tun 吞屯
+tuo 托脱拖妥拓驼
+wa 挖娃瓦蛙袜哇
+wai 外歪
+wan 万完晚玩湾弯碗顽挽婉
+wang 王望往网忘亡旺汪
+wei 为位未委维卫围危微伟尾威唯味谓胃韦魏喂
+wen 文问闻温稳吻纹
+weng 翁
+wo 我握卧窝沃
+wu 无五物务武午舞误吴屋乌污悟雾伍
+xi 系西习细希息喜洗析戏吸席惜稀溪锡悉夕膝熙犀
+xia 下夏吓峡侠霞狭虾
+xian 现先线县显险限鲜献宪闲仙嫌掀陷弦贤纤
+xiang 想相向象项香乡响详享箱祥巷厢像
+xiao 小校效消笑晓销肖孝萧削
+xie 些写谢协鞋斜血携泄卸械蟹
+xin 新心信辛欣芯薪锌
+xing 行性形型星兴姓幸醒刑邢杏
+xiong 雄兄胸凶熊
+xiu 修休秀袖绣锈嗅
+xu 需许续序须虚绪徐叙蓄絮旭
+xuan 选宣旋悬玄轩渲
+xue 学雪穴薛
+xun 训讯寻询循迅巡逊勋
+ya 压亚呀牙押鸭芽雅崖哑
+yan 研验眼言严演沿延烟颜岩炎盐厌宴艳燕焰掩
+yang 样阳洋养扬羊央仰痒杨氧
+yao 要药摇腰遥咬邀姚耀谣钥
+ye 也业夜页叶爷野液耶冶
+yi 一以已意义依易医议移衣宜亿艺异益忆疑遗仪椅姨伊役译抑溢
+yin 因音引印银饮隐阴殷
+ying 应影营英硬迎映赢盈鹰樱颖
+yo 哟
+yong 用永勇拥涌庸泳咏
+you 有由又友油游优右幼邮犹悠忧尤
+yu 于与语育遇鱼雨预余域玉欲宇誉狱愈羽娱渔愉
+yuan 员元原院远愿园源圆援缘袁怨
+yue 月越约阅跃岳悦
+yun 运云允孕晕匀韵
+za 杂砸咋
+zai 在再载灾栽宰
+zan 赞暂攒咱
+zang 脏葬
+zao 造早遭糟燥澡灶枣噪
+ze 则责择泽
+zei 贼
+zen 怎
+zeng 增赠
+zha 炸扎闸眨榨诈
+zhai 摘宅窄债寨
+zhan 站战展占沾盏粘斩栈
+zhang 张章掌涨障丈仗帐账
+zhao 找照招赵召罩兆
+zhe 这着者折哲浙遮
+zhen 真针阵镇震珍诊侦枕振帧
+zheng 正政证整争征症郑挣蒸
+zhi 之只知制至治直值质指支职止智志纸致置植执织旨秩枝脂址
+zhong 中种重众终钟忠肿仲衷
+zhou 周州洲轴舟粥皱昼
+zhu 主住注助著猪竹株朱逐筑珠诸祝柱驻嘱烛
+zhua 抓
+zhuai 拽
+zhuan 转专砖赚
+zhuang 装状壮庄撞桩妆
+zhui 追坠缀
+zhun 准
+zhuo 桌捉卓浊酌
+zi 子自字资紫姿仔滋
+zong 总宗综纵踪
+zou 走奏邹
+zu 组族足阻租祖
+zuan 钻
+zui 最罪嘴醉
+zun 尊遵
+zuo 作做坐左座昨
+`
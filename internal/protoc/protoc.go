@@ -0,0 +1,199 @@
+// Package protoc builds alias tables that give Chinese names to the Go code
+// generated by protoc-gen-go and protoc-gen-go-grpc. Names come from tags
+// in the comments of the .proto file, which the generators copy into the
+// Go code:
+//
+//	// saika: 用户
+//	message User { ... }
+//
+// makes User available as 用户. Tagging a service also names the gRPC
+// helpers generated for it, e.g. NewUserServiceClient as 新用户服务客户端.
+package protoc
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+
+	"github.com/saika-m/saika-lang/internal/stdlib"
+)
+
+// Tag starts the comment line giving a declaration's Chinese name
+const Tag = "saika:"
+
+// Table is the alias table built from generated files
+type Table struct {
+	*stdlib.Package
+
+	// Enums lists the Go names of tagged enum values, which Wrappers
+	// declares as constants
+	Enums []string
+}
+
+// serviceHelpers derives the names of the gRPC helpers of a service S
+// named X from its client interface SClient or server interface SServer
+var serviceHelpers = []struct {
+	goFormat, format string
+}{
+	{"New%sClient", "新%s客户端"},
+	{"%sClient", "%s客户端"},
+	{"%sServer", "%s服务端"},
+	{"Register%sServer", "注册%s服务端"},
+	{"Unimplemented%sServer", "未实现%s服务端"},
+}
+
+// Build reads generated Go files of one package and returns the alias table
+// for the package at importPath, named name in Saika. An empty name uses
+// the Go package name.
+func Build(files []string, importPath, name string) (*Table, error) {
+	table := &Table{Package: &stdlib.Package{Name: name, Path: importPath, Members: map[string]string{}}}
+	fset := token.NewFileSet()
+
+	for _, filename := range files {
+		file, err := parser.ParseFile(fset, filename, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		if table.Name == "" {
+			table.Name = file.Name.Name
+		}
+
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					if err := table.addSpec(fset, decl, spec); err != nil {
+						return nil, err
+					}
+				}
+			case *ast.FuncDecl:
+				if decl.Recv == nil {
+					if err := table.add(fset, decl.Name, decl.Doc, false); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+	}
+	return table, nil
+}
+
+// addSpec adds the tagged names declared by a type, const or var spec. A
+// spec without its own comment uses the comment of an ungrouped decl.
+func (t *Table) addSpec(fset *token.FileSet, decl *ast.GenDecl, spec ast.Spec) error {
+	fallback := (*ast.CommentGroup)(nil)
+	if !decl.Lparen.IsValid() {
+		fallback = decl.Doc
+	}
+
+	switch spec := spec.(type) {
+	case *ast.TypeSpec:
+		doc := spec.Doc
+		if doc == nil {
+			doc = fallback
+		}
+		if err := t.add(fset, spec.Name, doc, false); err != nil {
+			return err
+		}
+		return t.addService(fset, spec.Name, doc)
+	case *ast.ValueSpec:
+		doc := spec.Doc
+		if doc == nil {
+			doc = spec.Comment
+		}
+		if doc == nil {
+			doc = fallback
+		}
+		for _, ident := range spec.Names {
+			if err := t.add(fset, ident, doc, decl.Tok == token.CONST); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// add records the Chinese name tagged in doc for an exported identifier
+func (t *Table) add(fset *token.FileSet, ident *ast.Ident, doc *ast.CommentGroup, enum bool) error {
+	name := tagged(doc)
+	if name == "" || !ident.IsExported() {
+		return nil
+	}
+	if err := t.define(name, ident.Name); err != nil {
+		return fmt.Errorf("%s: %v", fset.Position(ident.Pos()), err)
+	}
+	if enum {
+		t.Enums = append(t.Enums, ident.Name)
+	}
+	return nil
+}
+
+// addService names the gRPC helpers of a service when ident is its tagged
+// client or server interface
+func (t *Table) addService(fset *token.FileSet, ident *ast.Ident, doc *ast.CommentGroup) error {
+	name := tagged(doc)
+	service, ok := strings.CutSuffix(ident.Name, "Client")
+	if !ok {
+		service, ok = strings.CutSuffix(ident.Name, "Server")
+	}
+	if name == "" || !ok || service == "" {
+		return nil
+	}
+
+	// The interface itself was named after the tag; name it and its
+	// helpers after the service instead
+	delete(t.Members, name)
+	for _, helper := range serviceHelpers {
+		goName := fmt.Sprintf(helper.goFormat, service)
+		if err := t.define(fmt.Sprintf(helper.format, name), goName); err != nil {
+			return fmt.Errorf("%s: %v", fset.Position(ident.Pos()), err)
+		}
+	}
+	return nil
+}
+
+// define maps a Chinese name to a Go name, rejecting conflicting tags
+func (t *Table) define(name, goName string) error {
+	if existing, ok := t.Members[name]; ok && existing != goName {
+		return fmt.Errorf("%s names both %s and %s", name, existing, goName)
+	}
+	t.Members[name] = goName
+	return nil
+}
+
+// tagged returns the Chinese name in a "saika:" line of doc, or ""
+func tagged(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+	for _, line := range strings.Split(doc.Text(), "\n") {
+		if name, ok := strings.CutPrefix(strings.TrimSpace(line), Tag); ok {
+			return strings.TrimSpace(name)
+		}
+	}
+	return ""
+}
+
+// Wrappers returns Saika source declaring a constant for each tagged enum
+// value in package pkg, so programs can use the values without the package
+// name
+func (t *Table) Wrappers(pkg string) string {
+	names := map[string]string{} // Go names to Chinese names
+	for name, goName := range t.Members {
+		names[goName] = name
+	}
+	enums := append([]string(nil), t.Enums...)
+	sort.Strings(enums)
+
+	var out strings.Builder
+	out.WriteString("// 由 saika protoc 生成，请勿编辑。\n\n")
+	fmt.Fprintf(&out, "包 %s\n\n", pkg)
+	fmt.Fprintf(&out, "导入 %q\n\n", t.Path)
+	for _, goName := range enums {
+		fmt.Fprintf(&out, "常量 %s = %s.%s\n", names[goName], t.Name, goName)
+	}
+	return out.String()
+}
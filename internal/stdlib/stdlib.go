@@ -0,0 +1,242 @@
+// Package stdlib maps the Chinese names of Go standard library packages and
+// their members to the Go names that generated code uses. A translated
+// package can be imported and referred to by either name, so 格式化.打印行
+// and fmt.Println are the same function.
+package stdlib
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+)
+
+// AliasFileExt is the extension of alias tables, which give Chinese names
+// to Go packages outside the standard library; see Register
+const AliasFileExt = ".aliases.json"
+
+// Package is a Go package with a Chinese name
+type Package struct {
+	Name    string            `json:"name"`            // Chinese name, e.g. 格式化
+	Path    string            `json:"path"`            // Go import path, e.g. fmt
+	Ident   string            `json:"ident,omitempty"` // Go package name, when not the last element of Path
+	Members map[string]string `json:"members"`         // Chinese member names to Go names
+}
+
+// packages lists every translated package
+var packages = []*Package{
+	{
+		Name: "格式化",
+		Path: "fmt",
+		Members: map[string]string{
+			"打印":    "Print",
+			"打印行":   "Println",
+			"格式打印":  "Printf",
+			"格式字符串": "Sprintf",
+			"拼接":    "Sprint",
+			"拼接行":   "Sprintln",
+			"错误":    "Errorf",
+		},
+	},
+	{
+		Name: "数学",
+		Path: "math",
+		Members: map[string]string{
+			"绝对值":  "Abs",
+			"平方根":  "Sqrt",
+			"幂":    "Pow",
+			"最大值":  "Max",
+			"最小值":  "Min",
+			"向下取整": "Floor",
+			"向上取整": "Ceil",
+			"圆周率":  "Pi",
+		},
+	},
+	{
+		Name: "字符串库",
+		Path: "strings",
+		Members: map[string]string{
+			"包含":  "Contains",
+			"分割":  "Split",
+			"连接":  "Join",
+			"替换":  "ReplaceAll",
+			"重复":  "Repeat",
+			"转大写": "ToUpper",
+			"转小写": "ToLower",
+			"去空白": "TrimSpace",
+			"前缀":  "HasPrefix",
+			"后缀":  "HasSuffix",
+		},
+	},
+	{
+		Name: "转换",
+		Path: "strconv",
+		Members: map[string]string{
+			"转整数":    "Atoi",
+			"整数转字符串": "Itoa",
+		},
+	},
+	{
+		Name: "时间",
+		Path: "time",
+		Members: map[string]string{
+			"现在": "Now",
+			"睡眠": "Sleep",
+			"秒":  "Second",
+			"毫秒": "Millisecond",
+		},
+	},
+	{
+		Name: "系统",
+		Path: "os",
+		Members: map[string]string{
+			"退出":   "Exit",
+			"参数":   "Args",
+			"环境变量": "Getenv",
+			"标准输出": "Stdout",
+			"标准错误": "Stderr",
+		},
+	},
+	{
+		Name: "日志",
+		Path: "log/slog",
+		Members: map[string]string{
+			"调试":      "Debug",
+			"信息":      "Info",
+			"警告":      "Warn",
+			"错误":      "Error",
+			"分组":      "Group",
+			"附加":      "With",
+			"新建":      "New",
+			"默认":      "Default",
+			"设置默认":    "SetDefault",
+			"文本处理器":   "NewTextHandler",
+			"JSON处理器": "NewJSONHandler",
+		},
+	},
+}
+
+// byName and byPath index packages by Chinese name and by import path
+var (
+	byName = map[string]*Package{}
+	byPath = map[string]*Package{}
+)
+
+func init() {
+	for _, pkg := range packages {
+		byName[pkg.Name] = pkg
+		byPath[pkg.Path] = pkg
+	}
+}
+
+// Register adds a translated package, such as one read from an alias
+// table, replacing any registered earlier with the same import path. The
+// Chinese name must not already name another package.
+func Register(pkg *Package) error {
+	if other, ok := byName[pkg.Name]; ok && other.Path != pkg.Path {
+		return fmt.Errorf("%s already names package %q", pkg.Name, other.Path)
+	}
+
+	if old, ok := byPath[pkg.Path]; ok {
+		delete(byName, old.Name)
+		for i, p := range packages {
+			if p == old {
+				packages[i] = pkg
+			}
+		}
+	} else {
+		packages = append(packages, pkg)
+	}
+	byName[pkg.Name] = pkg
+	byPath[pkg.Path] = pkg
+	return nil
+}
+
+// ReadAliasFile reads an alias table written by saika protoc
+func ReadAliasFile(filename string) (*Package, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var pkg Package
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", filename, err)
+	}
+	if pkg.Name == "" || pkg.Path == "" {
+		return nil, fmt.Errorf("%s: alias table needs a name and a path", filename)
+	}
+	return &pkg, nil
+}
+
+// Lookup returns the translated package with the given Chinese name
+func Lookup(name string) (*Package, bool) {
+	pkg, ok := byName[name]
+	return pkg, ok
+}
+
+// LookupPath returns the translated package imported by path, which may be
+// either the Go import path or the Chinese name
+func LookupPath(importPath string) (*Package, bool) {
+	if pkg, ok := byPath[importPath]; ok {
+		return pkg, true
+	}
+	return Lookup(importPath)
+}
+
+// LookupPackage returns the translated package that code refers to by
+// name, which may be either the Chinese name or the Go package name
+func LookupPackage(name string) (*Package, bool) {
+	if pkg, ok := Lookup(name); ok {
+		return pkg, true
+	}
+	for _, pkg := range packages {
+		if pkg.GoName() == name {
+			return pkg, true
+		}
+	}
+	return nil, false
+}
+
+// GoPath returns the Go import path for an import path that may name a
+// translated package in Chinese
+func GoPath(importPath string) string {
+	if pkg, ok := Lookup(importPath); ok {
+		return pkg.Path
+	}
+	return importPath
+}
+
+// GoName returns the package name Go code uses for the package
+func (p *Package) GoName() string {
+	if p.Ident != "" {
+		return p.Ident
+	}
+	return path.Base(p.Path)
+}
+
+// Member returns the Go name of a member given by its Chinese or Go name.
+// Only translated members are known; members used through the Go package
+// name are passed through to Go unchecked.
+func (p *Package) Member(name string) (string, bool) {
+	if goName, ok := p.Members[name]; ok {
+		return goName, true
+	}
+	for _, goName := range p.Members {
+		if goName == name {
+			return goName, true
+		}
+	}
+	return "", false
+}
+
+// MemberNames returns the Chinese and Go names of every member, sorted
+func (p *Package) MemberNames() []string {
+	names := make([]string, 0, 2*len(p.Members))
+	for name, goName := range p.Members {
+		names = append(names, name, goName)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,139 @@
+package stdlib
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// values gives the Go types of the functions, variables and constants of
+// standard library packages whose types are known, keyed by import path
+// and Go name. Untyped constants such as math.Pi have the type Go gives
+// them by default.
+var values = map[string]map[string]reflect.Type{
+	"fmt": {
+		"Print":    reflect.TypeOf(fmt.Print),
+		"Println":  reflect.TypeOf(fmt.Println),
+		"Printf":   reflect.TypeOf(fmt.Printf),
+		"Sprintf":  reflect.TypeOf(fmt.Sprintf),
+		"Sprint":   reflect.TypeOf(fmt.Sprint),
+		"Sprintln": reflect.TypeOf(fmt.Sprintln),
+		"Errorf":   reflect.TypeOf(fmt.Errorf),
+	},
+	"math": {
+		"Abs":   reflect.TypeOf(math.Abs),
+		"Sqrt":  reflect.TypeOf(math.Sqrt),
+		"Pow":   reflect.TypeOf(math.Pow),
+		"Max":   reflect.TypeOf(math.Max),
+		"Min":   reflect.TypeOf(math.Min),
+		"Floor": reflect.TypeOf(math.Floor),
+		"Ceil":  reflect.TypeOf(math.Ceil),
+		"Round": reflect.TypeOf(math.Round),
+		"Trunc": reflect.TypeOf(math.Trunc),
+		"Pi":    reflect.TypeOf(math.Pi),
+		"E":     reflect.TypeOf(math.E),
+	},
+	"errors": {
+		"New":    reflect.TypeOf(errors.New),
+		"Is":     reflect.TypeOf(errors.Is),
+		"Unwrap": reflect.TypeOf(errors.Unwrap),
+		"Join":   reflect.TypeOf(errors.Join),
+	},
+	"strings": {
+		"Contains":   reflect.TypeOf(strings.Contains),
+		"Split":      reflect.TypeOf(strings.Split),
+		"Join":       reflect.TypeOf(strings.Join),
+		"ReplaceAll": reflect.TypeOf(strings.ReplaceAll),
+		"Repeat":     reflect.TypeOf(strings.Repeat),
+		"ToUpper":    reflect.TypeOf(strings.ToUpper),
+		"ToLower":    reflect.TypeOf(strings.ToLower),
+		"TrimSpace":  reflect.TypeOf(strings.TrimSpace),
+		"HasPrefix":  reflect.TypeOf(strings.HasPrefix),
+		"HasSuffix":  reflect.TypeOf(strings.HasSuffix),
+		"Index":      reflect.TypeOf(strings.Index),
+		"Fields":     reflect.TypeOf(strings.Fields),
+	},
+	"sort": {
+		"Slice":       reflect.TypeOf(sort.Slice),
+		"SliceStable": reflect.TypeOf(sort.SliceStable),
+		"Ints":        reflect.TypeOf(sort.Ints),
+		"Strings":     reflect.TypeOf(sort.Strings),
+	},
+	"strconv": {
+		"Atoi":       reflect.TypeOf(strconv.Atoi),
+		"Itoa":       reflect.TypeOf(strconv.Itoa),
+		"ParseFloat": reflect.TypeOf(strconv.ParseFloat),
+		"Quote":      reflect.TypeOf(strconv.Quote),
+	},
+	"time": {
+		"Now":         reflect.TypeOf(time.Now),
+		"Since":       reflect.TypeOf(time.Since),
+		"After":       reflect.TypeOf(time.After),
+		"Tick":        reflect.TypeOf(time.Tick),
+		"Sleep":       reflect.TypeOf(time.Sleep),
+		"Nanosecond":  reflect.TypeOf(time.Nanosecond),
+		"Microsecond": reflect.TypeOf(time.Microsecond),
+		"Millisecond": reflect.TypeOf(time.Millisecond),
+		"Second":      reflect.TypeOf(time.Second),
+		"Minute":      reflect.TypeOf(time.Minute),
+		"Hour":        reflect.TypeOf(time.Hour),
+	},
+	"os": {
+		"Exit":   reflect.TypeOf(os.Exit),
+		"Args":   reflect.TypeOf(os.Args),
+		"Getenv": reflect.TypeOf(os.Getenv),
+		"Stdout": reflect.TypeOf(os.Stdout),
+		"Stderr": reflect.TypeOf(os.Stderr),
+	},
+	"io/fs": {
+		"ReadFile": reflect.TypeOf(fs.ReadFile),
+		"ReadDir":  reflect.TypeOf(fs.ReadDir),
+		"Glob":     reflect.TypeOf(fs.Glob),
+		"Sub":      reflect.TypeOf(fs.Sub),
+	},
+	"log/slog": {
+		"Debug":          reflect.TypeOf(slog.Debug),
+		"Info":           reflect.TypeOf(slog.Info),
+		"Warn":           reflect.TypeOf(slog.Warn),
+		"Error":          reflect.TypeOf(slog.Error),
+		"Group":          reflect.TypeOf(slog.Group),
+		"With":           reflect.TypeOf(slog.With),
+		"New":            reflect.TypeOf(slog.New),
+		"Default":        reflect.TypeOf(slog.Default),
+		"SetDefault":     reflect.TypeOf(slog.SetDefault),
+		"NewTextHandler": reflect.TypeOf(slog.NewTextHandler),
+		"NewJSONHandler": reflect.TypeOf(slog.NewJSONHandler),
+	},
+}
+
+// types gives the named types of standard library packages whose methods
+// are known, keyed by import path and name
+var types = map[string]map[string]reflect.Type{
+	"time": {
+		"Duration": reflect.TypeFor[time.Duration](),
+		"Time":     reflect.TypeFor[time.Time](),
+	},
+}
+
+// ValueType returns the Go type of the function, variable or constant
+// name of the standard library package with import path goPath, when it
+// is known
+func ValueType(goPath, name string) (reflect.Type, bool) {
+	t, ok := values[goPath][name]
+	return t, ok
+}
+
+// NamedType returns the named type name of the standard library package
+// with import path goPath, when its methods are known
+func NamedType(goPath, name string) (reflect.Type, bool) {
+	t, ok := types[goPath][name]
+	return t, ok
+}
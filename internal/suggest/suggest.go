@@ -0,0 +1,62 @@
+// Package suggest finds likely intended names for misspelled ones
+package suggest
+
+// Distance returns the Levenshtein distance between a and b, counted in
+// runes so that each Chinese character is a single edit
+func Distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// MaxDistance returns how many edits a name may be away from a candidate
+// to still be suggested. Short names allow fewer edits, so that a single
+// character is never "corrected" into an unrelated one.
+func MaxDistance(name string) int {
+	return (len([]rune(name)) + 1) / 3
+}
+
+// Closest returns the candidate nearest to name within MaxDistance(name).
+// Ties go to the candidate closest in length, since a mistyped character
+// is more common than a missing one, and then to the earliest candidate.
+// It reports false when no candidate is close enough or name is itself a
+// candidate.
+func Closest(name string, candidates []string) (string, bool) {
+	length := len([]rune(name))
+	best, bestDistance, bestSkew := "", MaxDistance(name)+1, 0
+	for _, candidate := range candidates {
+		if candidate == name {
+			return "", false
+		}
+		d := Distance(name, candidate)
+		skew := abs(len([]rune(candidate)) - length)
+		if d < bestDistance || (d == bestDistance && best != "" && skew < bestSkew) {
+			best, bestDistance, bestSkew = candidate, d, skew
+		}
+	}
+	return best, best != ""
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
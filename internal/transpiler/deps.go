@@ -0,0 +1,84 @@
+package transpiler
+
+import (
+	"path/filepath"
+	"slices"
+
+	"github.com/saika-m/saika-lang/internal/stdlib"
+)
+
+// Inputs returns the files that transpiling the program formed by the
+// sources reads, so that a build system can tell when to transpile it
+// again: the sources, the project and workspace files that configure them,
+// the alias tables next to them, the files of their static directory, the
+// template files they embed, and the sources of the workspace modules they
+// import. Each file is listed
+// once, in the order it is found.
+func (t *Transpiler) Inputs(saikaFilePaths []string) ([]string, error) {
+	var inputs []string
+	add := func(paths ...string) {
+		for _, path := range paths {
+			if !slices.Contains(inputs, path) {
+				inputs = append(inputs, path)
+			}
+		}
+	}
+	addSources := func(sources []string) error {
+		add(sources...)
+		for _, source := range sources {
+			tables, err := filepath.Glob(filepath.Join(filepath.Dir(source), "*"+stdlib.AliasFileExt))
+			if err != nil {
+				return err
+			}
+			add(tables...)
+		}
+		return nil
+	}
+
+	if err := addSources(saikaFilePaths); err != nil {
+		return nil, err
+	}
+	if len(saikaFilePaths) > 0 {
+		p, err := FindProject(filepath.Dir(saikaFilePaths[0]))
+		if err != nil {
+			return nil, err
+		}
+		if p != nil {
+			add(p.Path)
+		}
+		w, err := FindWorkspace(filepath.Dir(saikaFilePaths[0]))
+		if err != nil {
+			return nil, err
+		}
+		if w != nil {
+			add(w.Path)
+		}
+	}
+
+	static, err := StaticFiles(saikaFilePaths)
+	if err != nil {
+		return nil, err
+	}
+	add(static...)
+
+	templates, err := t.TemplateFiles(saikaFilePaths)
+	if err != nil {
+		return nil, err
+	}
+	add(templates...)
+
+	modules, err := t.importedModules(saikaFilePaths)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range modules {
+		sources, err := CollectSources([]string{m.Dir})
+		if err != nil {
+			return nil, err
+		}
+		if err := addSources(sources); err != nil {
+			return nil, err
+		}
+	}
+	return inputs, nil
+}
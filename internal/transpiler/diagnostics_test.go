@@ -0,0 +1,164 @@
+package transpiler_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/saika-m/saika-lang/internal/diagnostic"
+	"github.com/saika-m/saika-lang/internal/transpiler"
+	"github.com/saika-m/saika-lang/internal/vet"
+)
+
+// malformed holds programs that the parser, the checker or vet reject,
+// each meant to produce at least one diagnostic
+var malformed = map[string]string{
+	"missing paren":      "包 main\n\n数 入口( {\n}\n",
+	"missing value":      "包 main\n\n数 入口() {\n\t变量 x =\n}\n",
+	"huge integer":       "包 main\n\n变量 x = 99999999999999999999999\n",
+	"import not string":  "包 main\n\n导入 (\n\t5\n)\n",
+	"unterminated":       "包 main\n\n数 入口() {\n",
+	"unclosed string":    "包 main\n\n变量 s = \"abc\n",
+	"illegal character":  "包 main\n\n变量 x = 1 @ 2\n",
+	"bad result list":    "包 main\n\n数 除(a 整数) (商 整数, 整数) {\n}\n",
+	"empty enum":         "包 main\n\n枚举 色 {}\n",
+	"empty const group":  "包 main\n\n常量 ()\n",
+	"const group value":  "包 main\n\n常量 (\n\t甲\n)\n",
+	"select case":        "包 main\n\n数 入口() {\n\t监听 {\n\t情况 1:\n\t}\n}\n",
+	"header literal":     "包 main\n\n类型 点 结构 { x 整数 }\n\n数 入口() {\n\t变量 p = 点{}\n\t如果 p == 点{} {\n\t}\n}\n",
+	"unused import":      "包 main\n\n导入 \"fmt\"\n\n数 入口() {\n}\n",
+	"undefined":          "包 main\n\n数 入口() {\n\t变量 总数 = 1\n\t总树 = 2\n}\n",
+	"missing import":     "包 main\n\n数 入口() {\n\tfmt.Println(1)\n}\n",
+	"duplicate import":   "包 main\n\n导入 \"fmt\"\n导入 \"fmt\"\n\n数 入口() {\n\tfmt.Println(1)\n}\n",
+	"misplaced option":   "包 main\n\n数 入口() {\n\t选项 n 整数 = 1\n}\n",
+	"non-constant":       "包 main\n\n导入 \"fmt\"\n\n常量 s = fmt.Sprint(1)\n",
+	"assign constant":    "包 main\n\n常量 n = 1\n\n数 入口() {\n\tn = 2\n}\n",
+	"redeclared":         "包 main\n\n数 甲() {\n}\n\n数 甲() {\n}\n",
+	"return mismatch":    "包 main\n\n数 一() 整数 {\n\t返回\n}\n",
+	"defer needs call":   "包 main\n\n数 入口() {\n\t推迟 1\n}\n",
+	"blank as value":     "包 main\n\n导入 \"fmt\"\n\n数 入口() {\n\tfmt.Println(忽略)\n}\n",
+	"iota outside const": "包 main\n\n导入 \"fmt\"\n\n数 入口() {\n\tfmt.Println(序号)\n}\n",
+	"missing method":     "包 main\n\n接口 形状 {\n\t面积() 整数\n}\n\n类型 方 结构 { 边 整数 }\n\n数 入口() {\n\t变量 s 形状 = 方{边: 1}\n}\n",
+	"defer in loop":      "包 main\n\n导入 \"fmt\"\n\n数 入口() {\n\t循环 变量 i = 0; i < 3; i += 1 {\n\t\t推迟 fmt.Println(i)\n\t}\n}\n",
+	"method on int":      "包 main\n\n类型 数字 整数\n\n数 (n 数字) 双() 数字 {\n\t返回 n * 2\n}\n",
+	"operator disabled":  "包 main\n\n类型 点 结构 { x 整数 }\n\n数 (p 点) 加(q 点) 点 {\n\t返回 点{x: p.x + q.x}\n}\n\n变量 和 = 点{} + 点{}\n",
+	"range over map":     "包 main\n\n数 入口() {\n\t范围 (v) = 映射[字符串]整数{} {\n\t}\n}\n",
+	"switch defaults":    "包 main\n\n数 入口() {\n\t选择 1 {\n\t默认:\n\t默认:\n\t}\n}\n",
+	"missing enum case":  "包 main\n\n枚举 色 { 红, 绿 }\n\n数 入口() {\n\t变量 c = 红\n\t选择 c {\n\t情况 红:\n\t}\n}\n",
+	"generic receiver":   "包 main\n\n类型 栈[T 任意] 结构 { 元素 切片[T] }\n\n数 (s 栈) 长度() 整数 {\n\t返回 len(s.元素)\n}\n",
+}
+
+// TestDiagnosticsHaveLocations rejects any diagnostic reported for a
+// malformed program without the line and column it refers to
+func TestDiagnosticsHaveLocations(t *testing.T) {
+	tr := transpiler.New()
+	for name, source := range malformed {
+		program, diags := tr.Check(source)
+		if !diags.HasErrors() {
+			diags = append(diags, vet.Check(program, vet.Naming{})...)
+		}
+		if len(diags) == 0 {
+			t.Errorf("%s: no diagnostic reported", name)
+		}
+		for _, d := range diags {
+			if d.Range.Start.Line <= 0 || d.Range.Start.Column <= 0 {
+				t.Errorf("%s: diagnostic without a location: %s", name, d)
+			}
+			if !d.Range.IsValid() {
+				t.Errorf("%s: diagnostic without an end: %s", name, d)
+			}
+		}
+	}
+}
+
+// TestMisspelledKeywords fixes a keyword misspelled at the start of a
+// statement, whatever follows it
+func TestMisspelledKeywords(t *testing.T) {
+	const want = "包 main\n\n导入 \"fmt\"\n\n数 一() 整数 {\n\t如果 真 {\n\t\t返回 1\n\t}\n" +
+		"\t循环 变量 i = 0; i < 3; i += 1 {\n\t\tfmt.Println(i)\n\t}\n\t如果(1 > 0) {\n\t}\n\t返回\n}\n"
+	src := []byte("包 main\n\n导入 \"fmt\"\n\n数 一() 整数 {\n\t如国 真 {\n\t\t返回 1\n\t}\n" +
+		"\t循欢 变量 i = 0; i < 3; i += 1 {\n\t\tfmt.Println(i)\n\t}\n\t如国(1 > 0) {\n\t}\n\t返货\n}\n")
+
+	tr := transpiler.New()
+	for pass := 0; pass < 3; pass++ {
+		_, diags := tr.Check(string(src))
+		fixed, applied, err := diagnostic.ApplyFixes(src, diags)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if applied == 0 {
+			break
+		}
+		src = fixed
+	}
+	if string(src) != want {
+		t.Errorf("fixed source = %q, want %q", src, want)
+	}
+}
+
+// TestMissingEnumCases adds an empty 情况 for each member of an
+// enumeration a 选择 without 默认 leaves out
+func TestMissingEnumCases(t *testing.T) {
+	const src = "包 main\n\n枚举 色 { 红, 绿, 蓝 }\n\n数 入口() {\n\t变量 c = 红\n" +
+		"\t选择 c {\n\t情况 绿:\n\t}\n\t选择 c {\n\t情况 绿:\n\t默认:\n\t}\n}\n"
+	const want = "包 main\n\n枚举 色 { 红, 绿, 蓝 }\n\n数 入口() {\n\t变量 c = 红\n" +
+		"\t选择 c {\n\t情况 绿:\n\t情况 红:\n\t情况 蓝:\n\t}\n\t选择 c {\n\t情况 绿:\n\t默认:\n\t}\n}\n"
+
+	tr := transpiler.New()
+	program, diags := tr.Check(src)
+	if len(diags) > 0 {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	warnings := vet.Check(program, vet.Naming{})
+	if len(warnings) != 1 || warnings[0].Code != diagnostic.MissingEnumCase || warnings[0].Range.Start.Line != 7 {
+		t.Fatalf("warnings = %v, want one %s on line 7", warnings, diagnostic.MissingEnumCase)
+	}
+	fixed, _, err := diagnostic.ApplyFixes([]byte(src), warnings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(fixed) != want {
+		t.Errorf("fixed source = %q, want %q", fixed, want)
+	}
+}
+
+// TestInvalidIndexes reports what Go rejects when compiling an element of
+// a slice, array or string that is read or assigned to, which the
+// interpreter would otherwise only report when it runs
+func TestInvalidIndexes(t *testing.T) {
+	source := `包 main
+
+导入 "fmt"
+
+数 入口() {
+	变量 组 数组[3]整数
+	变量 列 = 切片[整数]{1, 2}
+	变量 字 = "你好"
+	变量 表 = 映射[字符串]数组[2]整数{}
+	组[3] = 1
+	fmt.Println(列[-1])
+	fmt.Println(列["一"], 组[1.5])
+	字[0] = "a"
+	表["甲"][0] = 1
+	fmt.Println(len(字)[0])
+	组[2] = 列[1]
+	列[0] += 组[2.0]
+	fmt.Println(组, 列, 字[1], 表["甲"][1])
+}
+`
+	want := map[int][]diagnostic.Code{
+		10: {diagnostic.InvalidIndex},
+		11: {diagnostic.InvalidIndex},
+		12: {diagnostic.InvalidIndex, diagnostic.InvalidIndex},
+		13: {diagnostic.InvalidAssignTarget},
+		14: {diagnostic.InvalidAssignTarget},
+		15: {diagnostic.InvalidIndex},
+	}
+	_, diags := transpiler.New().Check(source)
+	got := map[int][]diagnostic.Code{}
+	for _, d := range diags {
+		got[d.Range.Start.Line] = append(got[d.Range.Start.Line], d.Code)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("codes by line = %v, want %v:\n%s", got, want, diags)
+	}
+}
@@ -0,0 +1,34 @@
+package transpiler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/lexer"
+	"github.com/saika-m/saika-lang/internal/parser"
+)
+
+// Format returns Saika code in the canonical layout of ast.Sprint, keeping
+// its comments. The code must parse; the formatted code is parsed again
+// and must yield the same program, so formatting never changes what the
+// code means.
+func (t *Transpiler) Format(saikaCode string) (string, error) {
+	l := lexer.NewWithTabWidth(saikaCode, t.TabWidth)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if diags := p.Diagnostics(); diags.HasErrors() {
+		return "", fmt.Errorf("parser errors:\n%w", diags)
+	}
+
+	var out strings.Builder
+	if err := ast.FprintComments(&out, program, l.Comments()); err != nil {
+		return "", err
+	}
+
+	formatted, diags := t.parse(out.String())
+	if diags.HasErrors() || !ast.Equal(program, formatted) {
+		return "", fmt.Errorf("formatting changed the program; please report this as a bug")
+	}
+	return out.String(), nil
+}
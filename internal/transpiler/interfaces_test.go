@@ -0,0 +1,104 @@
+package transpiler_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/saika-m/saika-lang/internal/diagnostic"
+	"github.com/saika-m/saika-lang/internal/transpiler"
+)
+
+// interfacesSource gives values of 方, which lacks the method of 形状, to
+// a parameter, a variable and a result of type 形状, as literals,
+// variables, fields, call results and elements, and a value of 圆, which
+// has it, through a variable. The method of 计数器 that 可增 needs changes
+// its receiver, so only a pointer to a 计数器 would have it in Go.
+const interfacesSource = `包 main
+
+接口 形状 {
+	面积() 整数
+}
+
+类型 方 结构 {
+	边 整数
+}
+
+类型 圆 结构 {
+	半径 整数
+}
+
+数 (c 圆) 面积() 整数 {
+	返回 3 * c.半径 * c.半径
+}
+
+类型 盒 结构 {
+	内容 方
+}
+
+数 打印(s 形状) {
+}
+
+数 新方() 方 {
+	返回 方{边: 1}
+}
+
+数 取() 形状 {
+	变量 列 = 切片[方]{新方()}
+	返回 列[0]
+}
+
+数 入口() {
+	变量 a = 方{边: 2}
+	打印(a)
+	变量 b = 盒{内容: a}
+	变量 s 形状 = b.内容
+	打印(新方())
+	变量 c = 圆{半径: 1}
+	打印(c)
+	s = c
+	变量 n 计数器
+	变量 x 可增 = n
+	x = n
+	x.增()
+}
+
+接口 可增 {
+	增()
+}
+
+类型 计数器 结构 {
+	n 整数
+}
+
+数 (c 计数器) 增() {
+	c.n += 1
+}
+`
+
+// TestInterfaceValues reports each value given to an interface whose
+// methods its type lacks, whatever expression gives it
+func TestInterfaceValues(t *testing.T) {
+	want := map[int]string{
+		32: "方 does not implement 形状 (missing method 面积)",
+		37: "方 does not implement 形状 (missing method 面积)",
+		39: "方 does not implement 形状 (missing method 面积)",
+		40: "方 does not implement 形状 (missing method 面积)",
+		45: "计数器 does not implement 可增 (method 增 changes its receiver)",
+		46: "计数器 does not implement 可增 (method 增 changes its receiver)",
+	}
+	_, diags := transpiler.New().Check(interfacesSource)
+	got := map[int]string{}
+	for _, d := range diags {
+		if d.Code == diagnostic.MissingMethod {
+			got[d.Range.Start.Line] = d.Message
+		}
+	}
+	for line, reason := range want {
+		if !strings.Contains(got[line], reason) {
+			t.Errorf("line %d: got %q, want a message ending %q", line, got[line], reason)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d diagnostics, want %d:\n%s", len(got), len(want), diags)
+	}
+}
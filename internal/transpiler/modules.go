@@ -0,0 +1,343 @@
+package transpiler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/ir"
+	"github.com/saika-m/saika-lang/internal/pinyin"
+	"github.com/saika-m/saika-lang/internal/stdlib"
+)
+
+// WorkspaceFile is the name of the file listing the Saika modules that are
+// developed together, found in the directory of the sources or above it.
+// It holds the module directories relative to itself:
+//
+//	{"use": ["./app", "./问候"]}
+//
+// A module is a directory of Saika sources forming one package, which the
+// other modules import by the name of the directory. Only its exported
+// names, those starting with an upper-case letter, can be used.
+const WorkspaceFile = "saika.work.json"
+
+// GoModule is the Go module path of a Go workspace that includes modules;
+// a module named 问候 is generated as the Go package GoModule/wenhou
+const GoModule = "saika.work"
+
+// Workspace is a set of Saika modules developed together
+type Workspace struct {
+	Path    string // path of the workspace file
+	Modules []*Module
+}
+
+// Module is one Saika module of a workspace
+type Module struct {
+	Name string // the import path other modules use, the base name of Dir
+	Dir  string
+}
+
+// GoPath returns the Go import path the module is generated as
+func (m *Module) GoPath() string {
+	return GoModule + "/" + m.GoDir()
+}
+
+// GoDir returns the last element of the Go import path of the module,
+// the name of the directory it is generated in. Go only allows ASCII
+// letters, digits and -._~ in import paths, so the name of the module is
+// spelled in lower-case pinyin and other characters by their code point:
+// 问候 is wenhou and 问候2 is wenhou2. Go code still refers to the package
+// by the name of the module.
+func (m *Module) GoDir() string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(pinyin.Exported(m.Name)) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', strings.ContainsRune("-_~", r):
+			b.WriteRune(r)
+		case r == '.' && b.Len() > 0:
+			b.WriteRune(r)
+		default:
+			fmt.Fprintf(&b, "u%04x", r)
+		}
+	}
+	return b.String()
+}
+
+// FindWorkspace reads the workspace file in dir or the nearest directory
+// above it. It returns nil when there is none.
+func FindWorkspace(dir string) (*Workspace, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		path := filepath.Join(dir, WorkspaceFile)
+		if _, err := os.Stat(path); err == nil {
+			return ReadWorkspace(path)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// ReadWorkspace reads a workspace file
+func ReadWorkspace(path string) (*Workspace, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Use []string `json:"use"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	w := &Workspace{Path: path}
+	seen := map[string]string{}
+	goDirs := map[string]string{}
+	for _, use := range file.Use {
+		dir := filepath.Join(filepath.Dir(path), filepath.FromSlash(use))
+		m := &Module{Name: filepath.Base(dir), Dir: dir}
+		if other, ok := seen[m.Name]; ok {
+			return nil, fmt.Errorf("%s: modules %s and %s have the same name %s", path, other, use, m.Name)
+		}
+		if other, ok := goDirs[m.GoDir()]; ok {
+			return nil, fmt.Errorf("%s: modules %s and %s have the same Go import path %s", path, other, use, m.GoPath())
+		}
+		seen[m.Name] = use
+		goDirs[m.GoDir()] = use
+		w.Modules = append(w.Modules, m)
+	}
+	return w, nil
+}
+
+// Module returns the module imported by importPath
+func (w *Workspace) Module(importPath string) (*Module, bool) {
+	for _, m := range w.Modules {
+		if m.Name == importPath {
+			return m, true
+		}
+	}
+	return nil, false
+}
+
+// containing returns the module whose directory holds path
+func (w *Workspace) containing(path string) *Module {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil
+	}
+	for _, m := range w.Modules {
+		if filepath.Dir(abs) == m.Dir {
+			return m
+		}
+	}
+	return nil
+}
+
+// registerModules registers the modules of the workspace around the
+// sources, other than the one the sources belong to, as packages, so that
+// importing one resolves to the module and its exported names are known
+func registerModules(saikaFilePaths []string) error {
+	if len(saikaFilePaths) == 0 {
+		return nil
+	}
+	w, err := FindWorkspace(filepath.Dir(saikaFilePaths[0]))
+	if err != nil || w == nil {
+		return err
+	}
+
+	own := w.containing(saikaFilePaths[0])
+	for _, m := range w.Modules {
+		if m == own {
+			continue
+		}
+		members, err := exportedNames(m)
+		if err != nil {
+			return err
+		}
+		if err := stdlib.Register(&stdlib.Package{Name: m.Name, Path: m.GoPath(), Ident: m.Name, Members: members}); err != nil {
+			return &FileError{Path: w.Path, Err: err}
+		}
+	}
+	return nil
+}
+
+// exportedNames returns the exported top-level names of a module, each
+// mapped to itself as the members of a translated package are. When the
+// project of the module spells its names in pinyin, its Chinese names are
+// exported too, mapped to their Go names.
+func exportedNames(m *Module) (map[string]string, error) {
+	sources, err := CollectSources([]string{m.Dir})
+	if err != nil {
+		return nil, err
+	}
+	project, err := sourcesProject(sources)
+	if err != nil {
+		return nil, err
+	}
+	members := map[string]string{}
+	var programs []*ast.Program
+	for _, source := range sources {
+		src, err := os.ReadFile(source)
+		if err != nil {
+			return nil, err
+		}
+		program, diags := New().parse(string(src))
+		if diags.HasErrors() {
+			return nil, &FileError{Path: source, Err: fmt.Errorf("failed to transpile Saika code: parser errors:\n%w", diags)}
+		}
+		for _, name := range topLevelNames(program) {
+			if token.IsExported(name) {
+				members[name] = name
+			}
+		}
+		programs = append(programs, program)
+	}
+	if project.Pinyin {
+		for name, goName := range ir.PinyinNames(programs) {
+			members[name] = goName
+		}
+	}
+	return members, nil
+}
+
+// topLevelNames returns the names declared at the top level of program
+func topLevelNames(program *ast.Program) []string {
+	var names []string
+	for _, stmt := range program.Statements {
+		switch stmt := stmt.(type) {
+		case *ast.FunctionStatement:
+			if stmt.Receiver == nil {
+				names = append(names, stmt.Name.Value)
+			}
+		case *ast.VarStatement:
+			names = append(names, stmt.Name.Value)
+		case *ast.VarListStatement:
+			for _, name := range stmt.Names {
+				names = append(names, name.Value)
+			}
+		case *ast.ConstStatement:
+			names = append(names, stmt.Name.Value)
+		case *ast.ConstGroupStatement:
+			for _, c := range stmt.Consts {
+				names = append(names, c.Name.Value)
+			}
+		case *ast.InterfaceStatement:
+			names = append(names, stmt.Name.Value)
+		case *ast.TypeStatement:
+			names = append(names, stmt.Name.Value)
+		case *ast.EnumStatement:
+			names = append(names, stmt.Name.Value)
+			for _, member := range stmt.Members {
+				names = append(names, member.Value)
+			}
+		case *ast.OptionStatement:
+			names = append(names, stmt.Name.Value)
+		}
+	}
+	return names
+}
+
+// WriteModules transpiles the workspace modules that the sources import,
+// directly or through other modules, into the subdirectories of dir named
+// after them, and writes the go.mod that makes dir the Go module they are
+// imported from. It reports whether any module was written; without one,
+// dir is left as it is.
+func (t *Transpiler) WriteModules(ctx context.Context, dir string, saikaFilePaths []string) (bool, error) {
+	if len(saikaFilePaths) == 0 {
+		return false, nil
+	}
+	modules, err := t.importedModules(saikaFilePaths)
+	if err != nil || len(modules) == 0 {
+		return false, err
+	}
+
+	for _, m := range modules {
+		sources, err := CollectSources([]string{m.Dir})
+		if err != nil {
+			return false, err
+		}
+		if _, _, err := t.TranspileProjectTo(ctx, filepath.Join(dir, m.GoDir()), sources); err != nil {
+			return false, err
+		}
+	}
+
+	goMod := fmt.Sprintf("module %s\n\ngo 1.23\n", GoModule)
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0644); err != nil {
+		return false, fmt.Errorf("failed to write go.mod: %v", err)
+	}
+	return true, nil
+}
+
+// importedModules returns the workspace modules that the sources import,
+// directly or through other modules, in the order they are found
+func (t *Transpiler) importedModules(saikaFilePaths []string) ([]*Module, error) {
+	if len(saikaFilePaths) == 0 {
+		return nil, nil
+	}
+	w, err := FindWorkspace(filepath.Dir(saikaFilePaths[0]))
+	if err != nil || w == nil {
+		return nil, err
+	}
+
+	var modules []*Module
+	found := map[string]bool{}
+	enqueue := func(sources []string) error {
+		imports, err := t.imports(sources)
+		if err != nil {
+			return err
+		}
+		for _, imp := range imports {
+			if m, ok := w.Module(imp); ok && !found[m.Name] {
+				found[m.Name] = true
+				modules = append(modules, m)
+			}
+		}
+		return nil
+	}
+	if err := enqueue(saikaFilePaths); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < len(modules); i++ {
+		sources, err := CollectSources([]string{modules[i].Dir})
+		if err != nil {
+			return nil, err
+		}
+		if err := enqueue(sources); err != nil {
+			return nil, err
+		}
+	}
+	return modules, nil
+}
+
+// imports returns the import paths of the sources
+func (t *Transpiler) imports(saikaFilePaths []string) ([]string, error) {
+	var paths []string
+	for _, path := range saikaFilePaths {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		program, diags := t.parse(string(src))
+		if diags.HasErrors() {
+			return nil, &FileError{Path: path, Err: fmt.Errorf("failed to transpile Saika code: parser errors:\n%w", diags)}
+		}
+		for _, stmt := range program.Statements {
+			if imp, ok := stmt.(*ast.ImportStatement); ok {
+				paths = append(paths, strings.Trim(imp.Path, "\""))
+			}
+		}
+	}
+	return paths, nil
+}
@@ -0,0 +1,72 @@
+package transpiler_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/saika-m/saika-lang/internal/transpiler"
+)
+
+func TestModuleGoPath(t *testing.T) {
+	for name, want := range map[string]string{
+		"问候":   "saika.work/wenhou",
+		"问候2":  "saika.work/wenhou2",
+		"app":  "saika.work/app",
+		"café": "saika.work/cafu00e9",
+	} {
+		m := &transpiler.Module{Name: name}
+		if got := m.GoPath(); got != want {
+			t.Errorf("GoPath of %s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+// TestModuleImport generates a module named in Chinese under an ASCII
+// import path, imported by the name of the module
+func TestModuleImport(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		transpiler.WorkspaceFile: `{"use": ["./app", "./问候"]}`,
+		"问候/greet.saika":         "包 问候\n\n数 Hello(名 字符串) 字符串 {\n\t返回 \"你好，\" + 名\n}\n",
+		"app/main.saika":         "包 main\n\n导入 \"fmt\"\n导入 \"问候\"\n\n数 入口() {\n\tfmt.Println(问候.Hello(\"世界\"))\n}\n",
+	}
+	for name, code := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(code), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sources := []string{filepath.Join(dir, "app", "main.saika")}
+	tr := transpiler.New()
+	out := t.TempDir()
+	results, _, err := tr.TranspileProjectTo(context.Background(), out, sources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `import 问候 "saika.work/wenhou"`; !strings.Contains(results[0].GoCode, want) {
+		t.Errorf("generated code does not contain %s:\n%s", want, results[0].GoCode)
+	}
+	if ok, err := tr.WriteModules(context.Background(), out, sources); !ok || err != nil {
+		t.Fatalf("WriteModules = %v, %v", ok, err)
+	}
+	if _, err := os.Stat(filepath.Join(out, "wenhou", "greet.saika.go")); err != nil {
+		t.Errorf("module not written under its Go import path: %v", err)
+	}
+
+	if _, err := exec.LookPath("go"); err != nil || testing.Short() {
+		return
+	}
+	cmd := exec.Command("go", "vet", "./...")
+	cmd.Dir = out
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("go vet: %v\n%s", err, output)
+	}
+}
@@ -0,0 +1,200 @@
+package transpiler
+
+import (
+	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/ir"
+)
+
+// Numbers chooses the Go types that Saika's 整数 and 浮点 stand for in
+// generated code, and so the types of variables declared with an untyped
+// number. The zero value keeps Go's defaults, int and float64.
+type Numbers struct {
+	Integer string // int or int64
+	Float   string // float64 or float32
+}
+
+// numberTypes lists the Go types each kind of number may be configured as
+var numberTypes = map[string][]string{
+	"integer": {"int", "int64"},
+	"float":   {"float64", "float32"},
+}
+
+// integer returns the Go type of 整数 and untyped integers, or "" for int
+func (n Numbers) integer() string {
+	if n.Integer == "int" {
+		return ""
+	}
+	return n.Integer
+}
+
+// float returns the Go type of 浮点 and untyped floats, or "" for float64
+func (n Numbers) float() string {
+	if n.Float == "float64" {
+		return ""
+	}
+	return n.Float
+}
+
+// apply makes program use the configured types: 整数 and 浮点 name them,
+// and a variable declared with an untyped number gets its value converted
+// to them, as Go would otherwise give it int or float64. Parameters and
+// results declared with 整数 or 浮点 change along with the variables, so
+// that the values passed between functions keep one type. Where Go gives
+// an int to what the checker found to be 整数, in info, it gets the
+// configured type too: the results of builtins such as len are converted,
+// the type arguments inferred for generic functions are given, and the
+// index of 逐字符 is converted in the body of the loop. It returns the
+// program using them.
+func (n Numbers) apply(program *ast.Program, info *ir.Info) *ast.Program {
+	if n.integer() == "" && n.float() == "" {
+		return program
+	}
+	return ast.Rewrite(program, func(node ast.Node) ast.Node {
+		switch node := node.(type) {
+		case *ast.Identifier:
+			n.rename(node)
+		case *ast.VarStatement:
+			if node.Type == nil {
+				node.Value = n.convert(node.Token, node.Value)
+			}
+		case *ast.VarListStatement:
+			if list, ok := node.Value.(*ast.ExpressionList); ok {
+				for i, value := range list.Values {
+					list.Values[i] = n.convert(node.Token, value)
+				}
+			}
+		case *ast.CharLoopStatement:
+			n.convertIndex(node)
+		case *ast.CallExpression:
+			if args, ok := info.Inferred[node.Token.Offset]; ok {
+				n.instantiate(node, args)
+			}
+			if info.IntResults[node.Token.Offset] {
+				return n.convertResult(node)
+			}
+		}
+		return node
+	}).(*ast.Program)
+}
+
+// rename makes ident name the configured type when it names 整数 or 浮点
+func (n Numbers) rename(ident *ast.Identifier) {
+	switch {
+	case ident.Value == "整数" && n.integer() != "":
+		ident.Value = n.integer()
+	case ident.Value == "浮点" && n.float() != "":
+		ident.Value = n.float()
+	}
+}
+
+// instantiate gives the call of a generic function the type arguments Go
+// infers for it, in the configured types
+func (n Numbers) instantiate(call *ast.CallExpression, args []ast.Expression) {
+	fn, ok := call.Function.(*ast.Identifier)
+	if !ok {
+		return
+	}
+	types := make([]ast.Expression, len(args))
+	for i, arg := range args {
+		types[i] = ast.Rewrite(arg, func(node ast.Node) ast.Node {
+			if ident, ok := node.(*ast.Identifier); ok {
+				n.rename(ident)
+			}
+			return node
+		}).(ast.Expression)
+	}
+	index := types[0]
+	if len(types) > 1 {
+		index = &ast.ExpressionList{Values: types}
+	}
+	call.Function = &ast.IndexExpression{Token: fn.Token, Left: fn, Index: index}
+}
+
+// convertResult wraps a call whose result Go gives the type int in a
+// conversion to the configured integer type
+func (n Numbers) convertResult(call *ast.CallExpression) ast.Expression {
+	if n.integer() == "" {
+		return call
+	}
+	return &ast.CallExpression{
+		Token:     call.Token,
+		Function:  &ast.Identifier{Token: call.Token, Value: n.integer()},
+		Arguments: []ast.Expression{call},
+		Rparen:    call.Rparen,
+	}
+}
+
+// convertIndex declares the index of a 逐字符 loop anew at the start of
+// its body, converted from the int Go gives it to the configured integer
+// type
+func (n Numbers) convertIndex(loop *ast.CharLoopStatement) {
+	if loop.Index == nil || n.integer() == "" {
+		return
+	}
+	index := loop.Index
+	decl := &ast.VarStatement{
+		Token: loop.Token,
+		Name:  &ast.Identifier{Token: index.Token, Value: index.Value},
+		Value: &ast.CallExpression{
+			Token:     loop.Token,
+			Function:  &ast.Identifier{Token: loop.Token, Value: n.integer()},
+			Arguments: []ast.Expression{&ast.Identifier{Token: index.Token, Value: index.Value}},
+		},
+	}
+	loop.Body.Statements = append([]ast.Statement{decl}, loop.Body.Statements...)
+}
+
+// convert wraps the value of a declaration made by tok in a conversion to
+// the configured type of its kind, when it is an untyped number
+func (n Numbers) convert(tok ast.Token, value ast.Expression) ast.Expression {
+	typ := ""
+	switch untypedKind(value) {
+	case ast.INT:
+		typ = n.integer()
+	case ast.FLOAT:
+		typ = n.float()
+	}
+	if typ == "" {
+		return value
+	}
+	return &ast.CallExpression{
+		Token:     tok,
+		Function:  &ast.Identifier{Token: tok, Value: typ},
+		Arguments: []ast.Expression{value},
+	}
+}
+
+// untypedKind returns INT or FLOAT for an expression made of number
+// literals and byte sizes, which Go gives the type int or float64, and ""
+// otherwise
+func untypedKind(expr ast.Expression) ast.TokenType {
+	switch expr := expr.(type) {
+	case *ast.IntegerLiteral:
+		return ast.INT
+	case *ast.FloatLiteral:
+		return ast.FLOAT
+	case *ast.UnitLiteral:
+		if !ast.Units[expr.Unit].Duration {
+			return ast.INT
+		}
+	case *ast.PrefixExpression:
+		if expr.Operator == "-" || expr.Operator == "+" {
+			return untypedKind(expr.Right)
+		}
+	case *ast.InfixExpression:
+		left, right := untypedKind(expr.Left), untypedKind(expr.Right)
+		if left == "" || right == "" {
+			return ""
+		}
+		switch expr.Operator {
+		case "+", "-", "*", "/":
+			if left == ast.FLOAT || right == ast.FLOAT {
+				return ast.FLOAT
+			}
+			return ast.INT
+		case "%", "<<", ">>", "&", "|", "^":
+			return left
+		}
+	}
+	return ""
+}
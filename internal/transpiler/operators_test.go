@@ -0,0 +1,32 @@
+package transpiler_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/saika-m/saika-lang/internal/diagnostic"
+	"github.com/saika-m/saika-lang/internal/transpiler"
+)
+
+// TestOperatorOverloading reports operators on a struct declaring their
+// methods before language version 1.1; TestPrograms runs them from it
+func TestOperatorOverloading(t *testing.T) {
+	main := writeProgram(t, program{source: operatorsSource})
+	_, err := transpiler.New().TranspileProject([]string{main})
+	if err == nil || !strings.Contains(err.Error(), string(diagnostic.InvalidOperator)) {
+		t.Errorf("language 1.0: error = %v, want %s", err, diagnostic.InvalidOperator)
+	}
+}
+
+// TestLanguage rejects a project file naming no known language version
+func TestLanguage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), transpiler.ProjectFile)
+	if err := os.WriteFile(path, []byte(`{"language": "2.0"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := transpiler.ReadProject(path); err == nil || !strings.Contains(err.Error(), `language must be one of 1.0, 1.1, not "2.0"`) {
+		t.Errorf("error = %v, want one for language 2.0", err)
+	}
+}
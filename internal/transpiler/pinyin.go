@@ -0,0 +1,74 @@
+package transpiler
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/ir"
+)
+
+// PinyinFile is the name of the file written next to the Go code of a
+// project that spells its names in pinyin. It maps each Chinese name to
+// the Go name it was given:
+//
+//	{
+//	  "用户": "YongHu",
+//	  "问好": "WenHao"
+//	}
+const PinyinFile = "pinyin.json"
+
+// transliterate gives the top-level Chinese names of the checked programs
+// of a project their pinyin Go names when the project asks for them, and
+// returns the names given. The code generated for a file then depends on
+// the names of the other files too, so when hashes is given, the hash of
+// each file is extended with the names.
+func (t *Transpiler) transliterate(saikaFilePaths []string, programs []*ast.Program, hashes []string) (map[string]string, error) {
+	project, err := sourcesProject(saikaFilePaths)
+	if err != nil || !project.Pinyin {
+		return nil, err
+	}
+	names := ir.PinyinNames(programs)
+	if len(names) == 0 {
+		return nil, nil
+	}
+	for i, program := range programs {
+		programs[i] = ir.Transliterate(program, names)
+	}
+	if hashes != nil {
+		// Maps are encoded with their keys sorted, so the same names
+		// always add the same text
+		encoded, err := json.Marshal(names)
+		if err != nil {
+			return nil, err
+		}
+		for i := range hashes {
+			hashes[i] = fmt.Sprintf("%x", sha256.Sum256([]byte(hashes[i]+"\x00pinyin:"+string(encoded))))
+		}
+	}
+	return names, nil
+}
+
+// writePinyinFile writes the pinyin names of the package generated into
+// dir, as recorded in its results, to the PinyinFile of dir. A file left
+// from an earlier generation is removed when the package has none.
+func writePinyinFile(dir string, results []*TranspileResult) error {
+	path := filepath.Join(dir, PinyinFile)
+	if len(results) == 0 || len(results[0].Pinyin) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(results[0].Pinyin, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
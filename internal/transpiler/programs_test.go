@@ -0,0 +1,674 @@
+package transpiler_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/saika-m/saika-lang/internal/backend"
+	"github.com/saika-m/saika-lang/internal/transpiler"
+)
+
+// program is a Saika program that TestPrograms both compiles and
+// interprets
+type program struct {
+	name string
+	// project is the content of the project file, if any
+	project string
+	source  string
+	// code lists parts of the generated Go code
+	code []string
+	// output is what the program prints, compiled or interpreted
+	output string
+}
+
+// overloadingProject selects the language version with operator
+// overloading
+const overloadingProject = `{"language": "1.1"}`
+
+// operatorsSource declares methods on a struct, overloads operators with
+// some of them and calls methods that change their receiver, through a
+// variable, an element, a field and a method value
+const operatorsSource = `包 main
+
+导入 "fmt"
+
+类型 向量 结构 {
+	X 整数
+	Y 整数
+}
+
+类型 空 结构 {
+}
+
+类型 盒 结构 {
+	位置 向量
+}
+
+数 (v 向量) 加(w 向量) 向量 {
+	返回 向量{X: v.X + w.X, Y: v.Y + w.Y}
+}
+
+数 (v 向量) 等于(w 向量) 布尔 {
+	返回 v.X == w.X
+}
+
+数 (v 向量) 缩放(k 整数) {
+	推迟 数() {
+		恢复()
+	}()
+	v.X *= k
+	v.Y = v.Y * k
+	如果 k == 0 {
+		恐慌("零")
+	}
+}
+
+数 (e 空) 名字() 字符串 {
+	返回 "空"
+}
+
+类型 温度 结构 {
+	度 整数
+}
+
+数 (t 温度) String() 字符串 {
+	返回 fmt.Sprintf("%d°C", t.度)
+}
+
+类型 问题 结构 {
+	原因 字符串
+}
+
+数 (p 问题) Error() 字符串 {
+	返回 "问题：" + p.原因
+}
+
+数 检查() 错误 {
+	返回 问题{原因: "太热"}
+}
+
+数 入口() {
+	变量 a = 向量{X: 1, Y: 2}
+	变量 b = a + a + a
+	b += a
+	fmt.Println(b, a == b, a != b, a+a == 向量{X: 2})
+	b.缩放(2)
+	变量 列 = 切片[向量]{a, b}
+	列[0].缩放(10)
+	变量 盒子 = 盒{位置: a}
+	盒子.位置.缩放(3)
+	a.缩放(0)
+	变量 f = a.加
+	fmt.Println(b, 列, 盒子.位置, a, f(a))
+	变量 e 空
+	fmt.Println(e.名字())
+	fmt.Printf("%v %s %v\n", 温度{度: 5}, 温度{度: 6}, 检查())
+}
+`
+
+// programs are the programs of TestPrograms
+var programs = []program{
+	{
+		name:    "operators",
+		project: overloadingProject,
+		source:  operatorsSource,
+		code: []string{
+			"var b = a.加(a).加(a)", "b = b.加(a)", "a.等于(b)", "!a.等于(b)",
+			"func (v 向量) 加(w 向量) 向量", "func (saikaReceiver *向量) 缩放(k int)",
+		},
+		output: "{4 8} false true true\n{8 16} [{10 20} {8 16}] {3 6} {0 0} {0 0}\n空\n5°C 6°C 问题：太热\n",
+	},
+	{
+		// Calls methods that change their receiver only by calling one
+		// that does, on the receiver, through a method value and on a
+		// field and an array element of it
+		name: "changing methods",
+		source: `包 main
+
+导入 "fmt"
+
+类型 计数器 结构 {
+	n 整数
+}
+
+数 (c 计数器) 增() {
+	c.n += 1
+}
+
+数 (c 计数器) 增两次() {
+	c.增()
+	c.增()
+}
+
+数 (c 计数器) 增四次() {
+	变量 f = c.增两次
+	f()
+	f()
+}
+
+类型 盒 结构 {
+	甲 计数器
+	列 数组[2]计数器
+}
+
+数 (b 盒) 全增() {
+	b.甲.增两次()
+	b.列[1].增()
+}
+
+数 入口() {
+	变量 c 计数器
+	c.增两次()
+	c.增四次()
+	变量 b = 盒{甲: 计数器{}, 列: 数组[2]计数器{计数器{}, 计数器{}}}
+	b.全增()
+	fmt.Println(c.n, b)
+}
+`,
+		code: []string{
+			"func (saikaReceiver *计数器) 增两次() {", "func (saikaReceiver *计数器) 增四次() {",
+			"func (saikaReceiver *盒) 全增() {",
+		},
+		output: "6 {{2} [{0} {1}]}\n",
+	},
+	{
+		// Ranges over an iterator, returning from the loop in a
+		// function, and over a slice and an array
+		name: "iterators",
+		source: `包 main
+
+导入 "fmt"
+
+类型 计数 结构 {
+	当前 整数
+	最大 整数
+}
+
+数 (c 计数) 下一个() 布尔 {
+	c.当前 += 1
+	返回 c.当前 <= c.最大
+}
+
+数 (c 计数) 值() 字符串 {
+	返回 fmt.Sprintf("第%d", c.当前)
+}
+
+数 找(it 计数, 目标 字符串) 布尔 {
+	范围 (v) = it {
+		如果 v == 目标 {
+			返回 真
+		}
+	}
+	返回 假
+}
+
+数 入口() {
+	变量 c = 计数{最大: 3}
+	范围 (v) = c {
+		fmt.Println(v)
+	}
+	fmt.Println(c.当前, 找(c, "第2"), 找(c, "第9"))
+	变量 和 = 0
+	范围 (x) = 切片[整数]{1, 2, 3} {
+		和 += x
+	}
+	范围 (忽略) = 数组[2]字符串{"a", "b"} {
+		和 += 10
+	}
+	fmt.Println(和)
+}
+`,
+		code: []string{
+			"for v := range saikaValues[string](it) {", "for v := range saikaValues[string](c) {",
+			"for _, x := range []int{1, 2, 3} {", `for range [2]string{"a", "b"} {`,
+		},
+		output: "第1\n第2\n第3\n0 true false\n26\n",
+	},
+	{
+		// Declares a generic struct type with methods that change
+		// their receiver and use zero values of its type parameter,
+		// and calls a generic function with type arguments, including
+		// ones that could not be inferred, and without
+		name: "generics",
+		source: `包 main
+
+导入 "fmt"
+
+类型 栈[T 任意] 结构 {
+	元素 切片[T]
+}
+
+数 (s 栈[T]) 压入(v T) {
+	变量 新 = 创建(切片[T], len(s.元素)+1)
+	循环 变量 i = 0; i < len(s.元素); i = i + 1 {
+		新[i] = s.元素[i]
+	}
+	新[len(s.元素)] = v
+	s.元素 = 新
+}
+
+数 (s 栈[T]) 弹出() (T, 布尔) {
+	变量 零 T
+	如果 len(s.元素) == 0 {
+		返回 零, 假
+	}
+	变量 v = s.元素[len(s.元素)-1]
+	s.元素 = s.元素[:len(s.元素)-1]
+	返回 v, 真
+}
+
+类型 配对[K 可比较, V 任意] 结构 {
+	键 K
+	值 V
+}
+
+数 首[T 任意](列 切片[T]) T {
+	变量 零 T
+	如果 len(列) > 0 {
+		返回 列[0]
+	}
+	返回 零
+}
+
+数 新栈[T 任意]() 栈[T] {
+	返回 栈[T]{}
+}
+
+数 入口() {
+	变量 s = 新栈[整数]()
+	s.压入(1)
+	s.压入(2)
+	fmt.Println(s.元素)
+	变量 v, ok = s.弹出()
+	fmt.Println(v, ok)
+	s.弹出()
+	v, ok = s.弹出()
+	fmt.Println(v, ok)
+	变量 p = 配对[字符串, 浮点]{键: "甲"}
+	变量 q 配对[整数, 字符串]
+	fmt.Println(p.键, p.值, q.键, q.值 == "")
+	fmt.Println(首[浮点](切片[浮点]{}), 首[字符串](切片[字符串]{"乙"}), 首[切片[整数]](切片[切片[整数]]{}) == nil)
+	fmt.Println(首(切片[整数]{}), 首(切片[字符串]{}) == "")
+}
+`,
+		code: []string{
+			"type 栈[T any] struct {", "type 配对[K comparable, V any] struct {",
+			"func (saikaReceiver *栈[T]) 压入(v T) {", "func 新栈[T any]() 栈[T] {", "return 栈[T]{}",
+			"var s = 新栈[int]()", `var p = 配对[string, float64]{键: "甲"}`, "var q 配对[int, string]",
+			"首[float64]([]float64{})", "首[[]int]([][]int{}) == nil",
+		},
+		output: "[1 2]\n2 true\n0 false\n甲 0 0 true\n0 乙 true\n0 true\n",
+	},
+	{
+		// Mixes 整数 standing for int64 with the results of builtins,
+		// the indexes of 逐字符 and the type arguments inferred from
+		// untyped constants, which are ints in Go
+		name:    "int64",
+		project: `{"integer": "int64"}`,
+		source: `包 main
+
+导入 "fmt"
+
+数 倍(n 整数) 整数 {
+	返回 n * 2
+}
+
+数 较大[T 整数 | 浮点](a, b T) T {
+	如果 a > b {
+		返回 a
+	}
+	返回 b
+}
+
+数 入口() {
+	变量 s = "你好世界"
+	循环 变量 i = 0; i < len(s); i += 1 {
+		fmt.Print(s[i], 子串(s, i, len(s)), " ")
+	}
+	变量 n = len(s)
+	fmt.Println(倍(len(s)), 倍(cap(切片[整数]{1})), 倍(字符数(s)), n+1)
+	变量 x = 0
+	逐字符 (i, c) = s {
+		x = x + i
+		fmt.Print(c)
+	}
+	变量 y 整数 = 较大(1, 2)
+	变量 f 浮点 = 较大(1, 2.5)
+	fmt.Println(x, 倍(y), f)
+}
+`,
+		code:   []string{"var y int64 = 较大[int64](1, 2)", "var f float64 = 较大[float64](1, 2.5)"},
+		output: "你你好世界 好好世界 世世界 界界 8 2 8 5\n你好世界6 4 2.5\n",
+	},
+	{
+		// Generates byte sizes as untyped constants, which Go accepts
+		// wherever an integer is
+		name: "byte sizes",
+		source: `包 main
+
+导入 "fmt"
+
+数 入口() {
+	变量 x 整数 = 2千字节
+	fmt.Println(x, len("abc")+1千字节, 4096/2千字节, 1.5千字节)
+}
+`,
+		code:   []string{"(2 * saikaKilobyte)", "4096 / (2 * saikaKilobyte)", "(1536 * saikaByte)"},
+		output: "2048 1027 2 1536\n",
+	},
+	{
+		// Appends to slices, with values of other numeric types and of
+		// a struct type and spreading a slice, copies one slice to
+		// another and deletes keys from a map
+		name: "append, copy and delete",
+		source: `包 main
+
+导入 "fmt"
+
+类型 点 结构 {
+	X 整数
+}
+
+数 入口() {
+	变量 xs 切片[整数]
+	xs = append(xs, 1, 2)
+	xs = append(xs, 切片[整数]{3, 4}...)
+	变量 fs = append(切片[浮点]{}, 1, 2.5)
+	变量 ps = append(切片[点]{}, 点{X: 1})
+	变量 ys = 创建(切片[整数], 3)
+	变量 n = copy(ys, xs)
+	fmt.Println(xs, fs, ps, ys, n)
+	变量 m = 映射[字符串]整数{"a": 1, "b": 2}
+	delete(m, "a")
+	delete(m, "z")
+	fmt.Println(m, len(m))
+}
+`,
+		output: "[1 2 3 4] [1 2.5] [{1}] [1 2 3] 3\nmap[b:2] 1\n",
+	},
+	{
+		// Indexes and measures by character the strings that standard
+		// library functions and generic functions return
+		name: "string results",
+		source: `包 main
+
+导入 "fmt"
+导入 "strings"
+
+数 首[T 任意](xs 切片[T]) T {
+	返回 xs[0]
+}
+
+数 入口() {
+	变量 u = strings.ToUpper("ab你")
+	fmt.Println(u[2], len(u), u[1:])
+	变量 xs = strings.Split("你好,世界", ",")
+	fmt.Println(首(xs)[1], len(首(xs)))
+}
+`,
+		output: "你 3 B你\n好 2\n",
+	},
+	{
+		// Takes method expressions of struct types, generic and empty
+		// ones included, and a method value bound to a copy of its
+		// receiver
+		name: "method values",
+		source: `包 main
+
+导入 "fmt"
+
+类型 点 结构 {
+	X 整数
+}
+
+数 (p 点) 双() 整数 {
+	返回 p.X * 2
+}
+
+数 (p 点) 加(n 整数) 整数 {
+	返回 p.X + n
+}
+
+类型 盒[T 任意] 结构 {
+	值 T
+}
+
+数 (b 盒[T]) 取() T {
+	返回 b.值
+}
+
+类型 空 结构 {}
+
+数 (e 空) 名() 字符串 {
+	返回 "空"
+}
+
+数 入口() {
+	变量 p = 点{X: 3}
+	变量 双 = 点.双
+	变量 加 = 点.加
+	变量 f = p.双
+	p.X = 10
+	fmt.Println(双(p), 加(p, 1), f())
+	变量 取 = 盒[字符串].取
+	fmt.Println(取(盒[字符串]{值: "你好"}), 空.名(空{}))
+}
+`,
+		output: "20 11 6\n你好 空\n",
+	},
+	{
+		// Computes untyped constant expressions exactly, as Go does,
+		// before they become float64s
+		name: "constant arithmetic",
+		source: `包 main
+
+导入 "fmt"
+
+常量 十分之一 = 0.1
+
+常量 (
+	甲 = 序号 * 0.1
+	乙
+	丙
+)
+
+数 入口() {
+	fmt.Println(0.1+0.2, 十分之一+0.2, 0.1+0.2 == 0.3, 7/2, 7/2.0, -7%3, 1<<10)
+	常量 三 = 0.3
+	变量 x = 三 - 0.1
+	fmt.Println(x, 丙, 1千字节/3, 1e100/1e99)
+	变量 y = 0.1
+	fmt.Println(y+0.2)
+}
+`,
+		output: "0.3 0.3 true 3 3.5 -1 1024\n0.2 0.2 341 10\n0.30000000000000004\n",
+	},
+	{
+		// Declares enumerations at the package level and in a function
+		// as an integer type and constants of it numbered by 序号
+		name: "enumerations",
+		source: `包 main
+
+导入 "fmt"
+
+枚举 颜色 { 红, 绿, 蓝 }
+
+数 下一个(c 颜色) 颜色 {
+	如果 c == 蓝 {
+		返回 红
+	}
+	返回 c + 1
+}
+
+数 入口() {
+	枚举 方向 { 北, 南 }
+	变量 d 方向 = 南
+	fmt.Println(红, 绿, 蓝, 下一个(绿), 下一个(蓝), d, 北 < 南)
+}
+`,
+		code:   []string{"type 颜色 int", "红 = 颜色(iota)"},
+		output: "0 1 2 2 0 1 true\n",
+	},
+	{
+		name: "switch",
+		source: `包 main
+
+导入 "fmt"
+
+枚举 颜色 { 红, 绿, 蓝 }
+
+数 名字(c 颜色) 字符串 {
+	选择 c {
+	情况 红:
+		返回 "红"
+	情况 绿, 蓝:
+		返回 "绿或蓝"
+	}
+	返回 "?"
+}
+
+数 等级(分 整数) 字符串 {
+	选择 {
+	情况 分 >= 90:
+		返回 "优"
+	情况 分 >= 60:
+		返回 "及格"
+	默认:
+		返回 "不及格"
+	}
+}
+
+数 入口() {
+	fmt.Println(名字(红), 名字(蓝), 等级(95), 等级(70), 等级(10))
+	变量 n = 3
+	选择 n % 2 {
+	默认:
+		fmt.Println("奇")
+	情况 0:
+		fmt.Println("偶")
+	}
+}
+`,
+		code:   []string{"switch c {", "case 绿, 蓝:", "switch {", "default:"},
+		output: "红 绿或蓝 优 及格 不及格\n奇\n",
+	},
+	{
+		name: "elements",
+		source: `包 main
+
+导入 "fmt"
+
+类型 点 结构 { x 整数 }
+
+数 入口() {
+	变量 列表 = 切片[整数]{1, 2, 3}
+	fmt.Println(列表[0], 列表[len(列表)-1])
+	列表[1] = 20
+	列表[2] += 5
+	变量 别名 = 列表
+	别名[0] = 100
+	fmt.Println(列表)
+
+	变量 组 数组[3]整数
+	组[0] = 7
+	变量 副本 = 组
+	副本[0] = 8
+	fmt.Println(组, 副本, 组[0])
+
+	变量 表 = 切片[切片[字符串]]{切片[字符串]{"a", "b"}, 切片[字符串]{"c"}}
+	表[1][0] = "z"
+	fmt.Println(表, 表[0][1])
+
+	变量 点们 = 切片[点]{点{x: 1}}
+	点们[0].x = 9
+	fmt.Println(点们[0].x)
+
+	变量 格 数组[2]数组[2]整数
+	格[1][1] = 4
+	格[0][1] += 2
+	fmt.Println(格)
+}
+`,
+		code:   []string{"列表[1] = 20", "格[1][1] = 4"},
+		output: "1 3\n[100 20 8]\n[7 0 0] [8 0 0] 7\n[[a b] [z]] b\n9\n[[0 2] [0 4]]\n",
+	},
+}
+
+// TestPrograms compiles each program with Go and interprets it, expecting
+// both to print the same output
+func TestPrograms(t *testing.T) {
+	for _, p := range programs {
+		t.Run(p.name, func(t *testing.T) {
+			main := writeProgram(t, p)
+
+			if got := interpret(t, main); got != p.output {
+				t.Errorf("interpreted output = %q, want %q", got, p.output)
+			}
+
+			tr := transpiler.New()
+			results, err := tr.TranspileProject([]string{main})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range p.code {
+				if !strings.Contains(results[0].GoCode, want) {
+					t.Errorf("generated code does not contain %s:\n%s", want, results[0].GoCode)
+				}
+			}
+			if _, err := exec.LookPath("go"); err != nil || testing.Short() {
+				return
+			}
+			goFiles, err := tr.WriteGoPackage(t.TempDir(), results)
+			if err != nil {
+				t.Fatal(err)
+			}
+			output, err := exec.Command("go", append([]string{"run"}, goFiles...)...).CombinedOutput()
+			if err != nil {
+				t.Fatalf("go run: %v\n%s", err, output)
+			}
+			if string(output) != p.output {
+				t.Errorf("compiled output = %q, want %q", output, p.output)
+			}
+		})
+	}
+}
+
+// writeProgram writes the files of p to a new directory and returns the
+// path of its source
+func writeProgram(t *testing.T, p program) string {
+	t.Helper()
+	dir := t.TempDir()
+	if p.project != "" {
+		if err := os.WriteFile(filepath.Join(dir, transpiler.ProjectFile), []byte(p.project), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	main := filepath.Join(dir, "main.saika")
+	if err := os.WriteFile(main, []byte(p.source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return main
+}
+
+// interpret runs the program of source with the interpreter, as run
+// --interp does, and returns its standard output
+func interpret(t *testing.T, source string) string {
+	t.Helper()
+	tr := transpiler.New()
+	tr.Backend = backend.Interp{}
+	lowered, err := tr.LowerProject(context.Background(), []string{source})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stdout bytes.Buffer
+	files := []backend.File{{Path: source, Program: lowered[0]}}
+	if err := (backend.Interp{}).Run(context.Background(), files, backend.RunConfig{Stdout: &stdout}); err != nil {
+		t.Fatal(err)
+	}
+	return stdout.String()
+}
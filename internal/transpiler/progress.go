@@ -0,0 +1,65 @@
+package transpiler
+
+import "github.com/saika-m/saika-lang/internal/diagnostic"
+
+// Phases of transpiling a file, reported in progress events
+const (
+	PhaseParse    = "parse"
+	PhaseCheck    = "check"
+	PhaseGenerate = "generate"
+)
+
+// EventKind identifies what a progress event reports
+type EventKind int
+
+// Progress event kinds
+const (
+	// FileStarted is reported before a file is read
+	FileStarted EventKind = iota
+	// PhaseFinished is reported when a phase completes for a file
+	PhaseFinished
+	// DiagnosticsEmitted is reported when a phase produces diagnostics for
+	// a file, including warnings
+	DiagnosticsEmitted
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case FileStarted:
+		return "file-started"
+	case PhaseFinished:
+		return "phase-finished"
+	case DiagnosticsEmitted:
+		return "diagnostics"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is a progress event reported while transpiling
+type Event struct {
+	Kind        EventKind
+	Path        string          // source file the event is about
+	Phase       string          // phase for PhaseFinished and DiagnosticsEmitted
+	Diagnostics diagnostic.List // diagnostics for DiagnosticsEmitted
+}
+
+// ProgressFunc receives progress events. It is called synchronously from
+// the goroutine doing the work, so it should return quickly.
+type ProgressFunc func(Event)
+
+// report sends an event to the progress callback, if there is one
+func (t *Transpiler) report(e Event) {
+	if t.Progress != nil {
+		t.Progress(e)
+	}
+}
+
+// reportPhase reports that phase finished for path, first reporting any
+// diagnostics it produced
+func (t *Transpiler) reportPhase(path, phase string, diags diagnostic.List) {
+	if len(diags) > 0 {
+		t.report(Event{Kind: DiagnosticsEmitted, Path: path, Phase: phase, Diagnostics: diags})
+	}
+	t.report(Event{Kind: PhaseFinished, Path: path, Phase: phase})
+}
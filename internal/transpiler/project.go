@@ -0,0 +1,240 @@
+package transpiler
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/checker"
+	"github.com/saika-m/saika-lang/internal/lexer"
+	"github.com/saika-m/saika-lang/internal/vet"
+)
+
+// ProjectFile is the name of the file configuring a Saika project, found
+// in the directory of the sources or above it:
+//
+//	{
+//	  "language": "1.1",
+//	  "entry": ["开始", "main"],
+//	  "integer": "int64",
+//	  "float": "float32",
+//	  "naming": {"functions": ["chinese", "camelCase"]},
+//	  "pinyin": true,
+//	  "binaries": [
+//	    {"name": "服务器", "entry": "服务器.saika"},
+//	    {"name": "工具", "entry": "工具.saika"}
+//	  ]
+//	}
+//
+// language is the version of Saika the sources are written in, one of
+// Languages; see Transpiler.Language. entry lists the names, besides 入口, that the function a program starts
+// in may be declared with. integer and float choose the Go types of 整数
+// and 浮点 and of variables declared with untyped numbers; see Numbers.
+// naming gives the naming conventions saika vet enforces; see vet.Naming.
+// pinyin gives the top-level names written in Chinese exported Go names
+// spelled in pinyin, listed in the PinyinFile written with the generated
+// code, so that other Go packages can use them; see ir.PinyinNames.
+// Each of the binaries is built from its entry
+// file, relative to the project file, and the sources that are no
+// binary's entry file.
+const ProjectFile = "saika.json"
+
+// EntryName is the name of the function a program starts in, Go's main
+const EntryName = "入口"
+
+// Languages lists the versions of Saika, oldest first. Version 1.1 adds
+// operator overloading: + - * / == and != on two values of a struct type
+// call its methods 加, 减, 乘, 除 and 等于.
+var Languages = []string{"1.0", "1.1"}
+
+// features returns the language features of version language, or of the
+// first version when it is ""
+func features(language string) checker.Features {
+	return checker.Features{Overloading: slices.Index(Languages, language) >= 1}
+}
+
+// Project is the configuration of a Saika project
+type Project struct {
+	Path     string // path of the project file
+	Language string
+	Entry    []string
+	Numbers  Numbers
+	Naming   vet.Naming
+	Pinyin   bool
+	Binaries []*Binary
+}
+
+// Binary is one of the programs built from a project
+type Binary struct {
+	Name  string
+	Entry string // path of the file declaring its entry function
+}
+
+// FindProject reads the project file in dir or the nearest directory above
+// it. It returns nil when there is none.
+func FindProject(dir string) (*Project, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		path := filepath.Join(dir, ProjectFile)
+		if _, err := os.Stat(path); err == nil {
+			return ReadProject(path)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// ReadProject reads a project file
+func ReadProject(path string) (*Project, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Language string     `json:"language"`
+		Entry    []string   `json:"entry"`
+		Integer  string     `json:"integer"`
+		Float    string     `json:"float"`
+		Naming   vet.Naming `json:"naming"`
+		Pinyin   bool       `json:"pinyin"`
+		Binaries []struct {
+			Name  string `json:"name"`
+			Entry string `json:"entry"`
+		} `json:"binaries"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	if file.Language != "" && !slices.Contains(Languages, file.Language) {
+		return nil, fmt.Errorf("%s: language must be one of %s, not %q", path, strings.Join(Languages, ", "), file.Language)
+	}
+	p := &Project{Path: path, Language: file.Language, Entry: file.Entry, Numbers: Numbers{Integer: file.Integer, Float: file.Float}, Naming: file.Naming, Pinyin: file.Pinyin}
+	for _, name := range p.Entry {
+		if tok := lexer.New(name).NextToken(); tok.Type != ast.IDENT || tok.Literal != name {
+			return nil, fmt.Errorf("%s: entry name %q is not an identifier", path, name)
+		}
+	}
+	for key, value := range map[string]string{"integer": file.Integer, "float": file.Float} {
+		if value != "" && !slices.Contains(numberTypes[key], value) {
+			return nil, fmt.Errorf("%s: %s must be one of %s, not %q", path, key, strings.Join(numberTypes[key], ", "), value)
+		}
+	}
+	if err := file.Naming.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	for _, b := range file.Binaries {
+		if b.Name == "" || b.Entry == "" {
+			return nil, fmt.Errorf("%s: each binary needs a name and an entry file", path)
+		}
+		if _, ok := p.Binary(b.Name); ok {
+			return nil, fmt.Errorf("%s: two binaries are named %s", path, b.Name)
+		}
+		entry := filepath.Join(filepath.Dir(path), filepath.FromSlash(b.Entry))
+		p.Binaries = append(p.Binaries, &Binary{Name: b.Name, Entry: entry})
+	}
+	return p, nil
+}
+
+// Binary returns the binary with the given name
+func (p *Project) Binary(name string) (*Binary, bool) {
+	for _, b := range p.Binaries {
+		if b.Name == name {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+// Dir returns the directory of the project file
+func (p *Project) Dir() string {
+	return filepath.Dir(p.Path)
+}
+
+// Sources returns the sources b is built from: its entry file and those of
+// sources that are no binary's entry file
+func (p *Project) Sources(b *Binary, sources []string) ([]string, error) {
+	entries := map[string]bool{}
+	for _, other := range p.Binaries {
+		abs, err := filepath.Abs(other.Entry)
+		if err != nil {
+			return nil, err
+		}
+		entries[abs] = true
+	}
+	own, err := filepath.Abs(b.Entry)
+	if err != nil {
+		return nil, err
+	}
+
+	// The entry file keeps its spelling if it is among the sources
+	selected, entry := []string{}, b.Entry
+	for _, source := range sources {
+		abs, err := filepath.Abs(source)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case abs == own:
+			entry = source
+		case !entries[abs]:
+			selected = append(selected, source)
+		}
+	}
+	if _, err := os.Stat(entry); err != nil {
+		return nil, fmt.Errorf("entry file of binary %s: %v", b.Name, err)
+	}
+	return append(selected, entry), nil
+}
+
+// sourcesProject returns the project around the sources, or an empty one
+// when there is none
+func sourcesProject(saikaFilePaths []string) (*Project, error) {
+	if len(saikaFilePaths) == 0 {
+		return &Project{}, nil
+	}
+	p, err := FindProject(filepath.Dir(saikaFilePaths[0]))
+	if err != nil || p != nil {
+		return p, err
+	}
+	return &Project{}, nil
+}
+
+// renameEntry renames a top-level function declared with one of the
+// configured entry names to 入口, so that it is the entry function for
+// every later phase and backend
+func renameEntry(program *ast.Program, entry []string) {
+	for _, stmt := range program.Statements {
+		if fn, ok := stmt.(*ast.FunctionStatement); ok && fn.Receiver == nil && slices.Contains(entry, fn.Name.Value) {
+			fn.Name.Value = EntryName
+		}
+	}
+}
+
+// entryHash returns what the configured entry names add to the hash of a
+// source, so that resuming a transpilation notices them change
+func entryHash(entry []string) string {
+	if len(entry) == 0 {
+		return ""
+	}
+	return "\x00entry:" + strings.Join(entry, ",")
+}
+
+// numbersHash returns what the configured number types add to the hash of
+// a source
+func numbersHash(n Numbers) string {
+	if n.integer() == "" && n.float() == "" {
+		return ""
+	}
+	return "\x00numbers:" + n.integer() + "," + n.float()
+}
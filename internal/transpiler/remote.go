@@ -0,0 +1,69 @@
+package transpiler
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/saika-m/saika-lang/internal/buildcache"
+)
+
+// remoteFile is what the remote cache holds for a transpiled file
+type remoteFile struct {
+	Package   string   `json:"package"`
+	Features  []string `json:"features,omitempty"`
+	Templates []string `json:"templates,omitempty"`
+	Code      string   `json:"code"`
+}
+
+// remoteKey returns the key of the file transpiled from a source whose
+// hash is hash. Like the state file, it depends on the backend, pruning,
+// string pooling, readability comments and the version of the generated
+// code.
+func (t *Transpiler) remoteKey(hash string) string {
+	return buildcache.Key(
+		[]byte("transpile"),
+		[]byte(strconv.Itoa(stateVersion)),
+		[]byte(t.backend().Name()),
+		[]byte(strconv.FormatBool(t.Prune)),
+		[]byte(strconv.FormatBool(t.PoolStrings)),
+		[]byte(strconv.FormatBool(t.Readable)),
+		[]byte(hash),
+	)
+}
+
+// fetchRemote returns the file the remote cache holds for a source whose
+// hash is hash, or nil
+func (t *Transpiler) fetchRemote(hash string) *TranspileResult {
+	if t.RemoteCache == nil || t.remoteErr != nil {
+		return nil
+	}
+	data, err := t.RemoteCache.Get(t.remoteKey(hash))
+	if err != nil {
+		t.remoteErr = err
+		return nil
+	}
+	var f remoteFile
+	if data == nil || json.Unmarshal(data, &f) != nil {
+		return nil
+	}
+	return &TranspileResult{GoCode: f.Code, Package: f.Package, Features: f.Features, Templates: f.Templates}
+}
+
+// storeRemote gives the remote cache the file generated for a source whose
+// hash is hash
+func (t *Transpiler) storeRemote(hash string, result *TranspileResult) {
+	if t.RemoteCache == nil || t.remoteErr != nil {
+		return
+	}
+	data, err := json.Marshal(&remoteFile{Package: result.Package, Features: result.Features, Templates: result.Templates, Code: result.GoCode})
+	if err == nil {
+		err = t.RemoteCache.Put(t.remoteKey(hash), data)
+	}
+	t.remoteErr = err
+}
+
+// RemoteCacheError returns the failure that stopped the remote cache from
+// being used, or nil
+func (t *Transpiler) RemoteCacheError() error {
+	return t.remoteErr
+}
@@ -0,0 +1,72 @@
+//go:build windows
+
+package transpiler
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// sourceTree writes empty files at the given slash-separated paths below
+// a new directory and returns it
+func sourceTree(t *testing.T, paths ...string) string {
+	dir := t.TempDir()
+	for _, path := range paths {
+		file := filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestCollectSourcesGlob(t *testing.T) {
+	dir := sourceTree(t, "a.saika", "b.saika", "notes.txt", "子目录/c.saika")
+
+	got, err := CollectSources([]string{dir + `\*.saika`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "a.saika"), filepath.Join(dir, "b.saika")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CollectSources(*.saika) = %q, want %q", got, want)
+	}
+
+	if _, err := CollectSources([]string{dir + `\*.none`}); err == nil {
+		t.Error("CollectSources accepted a pattern matching no file")
+	}
+}
+
+func TestCollectSourcesPaths(t *testing.T) {
+	dir := sourceTree(t, "a.saika", "子目录/c.saika", "子目录/深/d.saika")
+	all := []string{
+		filepath.Join(dir, "a.saika"),
+		filepath.Join(dir, "子目录", "c.saika"),
+		filepath.Join(dir, "子目录", "深", "d.saika"),
+	}
+
+	for _, c := range []struct {
+		arg  string
+		want []string
+	}{
+		{dir, all[:1]},
+		{dir + `\...`, all},
+		{filepath.ToSlash(dir) + "/...", all},
+		{dir + `\子目录\c.saika`, all[1:2]},
+		{filepath.ToSlash(dir) + "/子目录/c.saika", []string{filepath.ToSlash(dir) + "/子目录/c.saika"}},
+	} {
+		got, err := CollectSources([]string{c.arg})
+		if err != nil {
+			t.Errorf("CollectSources(%q): %v", c.arg, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("CollectSources(%q) = %q, want %q", c.arg, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package transpiler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// StateFile is the name of the file in an output directory that records
+// how far TranspileProjectTo got
+const StateFile = ".saika-state.json"
+
+// stateVersion changes whenever generated code changes for the same
+// source, so outputs recorded by an older saika are not reused
+const stateVersion = 3
+
+// ProjectState records the files of a project that have been transpiled
+// into an output directory
+type ProjectState struct {
+	Version  int          `json:"version"`
+	Backend  string       `json:"backend,omitempty"`  // backend that generated the files
+	Prune    bool         `json:"prune,omitempty"`    // whether unreachable functions were removed
+	Pool     bool         `json:"pool,omitempty"`     // whether repeated strings were pooled
+	Readable bool         `json:"readable,omitempty"` // whether the code was commented for learners
+	Files    []*FileState `json:"files"`
+}
+
+// FileState records the transpiled output of one source file
+type FileState struct {
+	Source    string   `json:"source"`
+	Hash      string   `json:"hash"` // SHA-256 of the source when it was transpiled
+	Output    string   `json:"output"`
+	Package   string   `json:"package"`
+	Features  []string `json:"features,omitempty"`
+	Templates []string `json:"templates,omitempty"`
+}
+
+// LoadProjectState reads the state recorded in dir. A missing state file,
+// or one written by a different version, gives an empty state.
+func LoadProjectState(dir string) (*ProjectState, error) {
+	state := &ProjectState{Version: stateVersion}
+	data, err := os.ReadFile(filepath.Join(dir, StateFile))
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var saved ProjectState
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", StateFile, err)
+	}
+	if saved.Version != stateVersion {
+		return state, nil
+	}
+	return &saved, nil
+}
+
+// Save writes the state to dir
+func (s *ProjectState) Save(dir string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, StateFile), data, 0644)
+}
+
+// file returns the recorded state of source, or nil
+func (s *ProjectState) file(source string) *FileState {
+	for _, f := range s.Files {
+		if f.Source == source {
+			return f
+		}
+	}
+	return nil
+}
+
+// record replaces the recorded state of a source
+func (s *ProjectState) record(f *FileState) {
+	for i, existing := range s.Files {
+		if existing.Source == f.Source {
+			s.Files[i] = f
+			return
+		}
+	}
+	s.Files = append(s.Files, f)
+}
+
+// prune drops the state of sources that are no longer in the project
+func (s *ProjectState) prune(sources []string) {
+	keep := map[string]bool{}
+	for _, source := range sources {
+		keep[source] = true
+	}
+	files := s.Files[:0]
+	for _, f := range s.Files {
+		if keep[f.Source] {
+			files = append(files, f)
+		}
+	}
+	s.Files = files
+}
+
+// TranspileProjectTo transpiles a project like TranspileProject and writes
+// the Go package to dir, recording its progress in dir's state file after
+// each file. Files whose source is unchanged since their output was
+// recorded are not generated again, so a run that was interrupted, or
+// paused by cancelling ctx, resumes where it stopped when called again with
+// the same directory. All files are still parsed and checked, because
+// checks span the whole package.
+//
+// Results keep their code in memory up to the transpiler's
+// MaxRetainedCode. When ctx is done between files, the state so far is
+// saved and the context's error is returned.
+func (t *Transpiler) TranspileProjectTo(ctx context.Context, dir string, saikaFilePaths []string) ([]*TranspileResult, []string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, nil, fmt.Errorf("failed to create directory %s: %v", dir, err)
+	}
+	state, err := LoadProjectState(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	if name := t.backend().Name(); state.Backend != name || state.Prune != t.Prune || state.Pool != t.PoolStrings ||
+		state.Readable != t.Readable {
+		// Files generated by another backend, or with other pruning,
+		// pooling or comments, cannot be reused
+		state = &ProjectState{Version: stateVersion, Backend: name, Prune: t.Prune, Pool: t.PoolStrings, Readable: t.Readable}
+	}
+
+	programs, hashes, warnings, err := t.parseProject(ctx, saikaFilePaths)
+	if err != nil {
+		return nil, nil, err
+	}
+	infos, err := t.checkProject(saikaFilePaths, programs, warnings, hashes)
+	if err != nil {
+		return nil, nil, err
+	}
+	t.prune(programs, hashes)
+	pinyin, err := t.transliterate(saikaFilePaths, programs, hashes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	names := GoFileNames(saikaFilePaths)
+	retained := int64(0) // bytes of code kept in results
+	results := make([]*TranspileResult, 0, len(programs))
+	goFiles := make([]string, 0, len(programs)+1)
+	for i, path := range saikaFilePaths {
+		if err := ctx.Err(); err != nil {
+			if saveErr := state.Save(dir); saveErr != nil {
+				return nil, nil, saveErr
+			}
+			return nil, nil, fmt.Errorf("transpilation paused after %d of %d files: %w", i, len(saikaFilePaths), err)
+		}
+
+		goFile := filepath.Join(dir, names[i])
+		result, err := t.resumeFile(state.file(path), hashes[i], goFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		if result == nil {
+			// Another machine may have generated the file already
+			if result = t.fetchRemote(hashes[i]); result == nil {
+				if result, err = t.generate(programs[i], infos[i], hashes[i]); err != nil {
+					return nil, nil, &FileError{Path: path, Err: err}
+				}
+				t.storeRemote(hashes[i], result)
+			}
+			if err := os.WriteFile(goFile, []byte(result.GoCode), 0644); err != nil {
+				return nil, nil, fmt.Errorf("failed to write %s: %v", goFile, err)
+			}
+		}
+		result.SourcePath = path
+		result.GoFile = goFile
+		result.Diagnostics = warnings[i]
+		result.Pinyin = pinyin
+		t.reportPhase(path, PhaseGenerate, nil)
+
+		// The program is no longer needed, and the code only while it fits
+		programs[i] = nil
+		retained += int64(len(result.GoCode))
+		if t.MaxRetainedCode < 0 || (t.MaxRetainedCode > 0 && retained > t.MaxRetainedCode) {
+			retained -= int64(len(result.GoCode))
+			result.GoCode = ""
+		}
+
+		state.record(&FileState{
+			Source:    path,
+			Hash:      hashes[i],
+			Output:    goFile,
+			Package:   result.Package,
+			Features:  result.Features,
+			Templates: result.Templates,
+		})
+		if err := state.Save(dir); err != nil {
+			return nil, nil, err
+		}
+
+		results = append(results, result)
+		goFiles = append(goFiles, goFile)
+	}
+
+	state.prune(saikaFilePaths)
+	if err := state.Save(dir); err != nil {
+		return nil, nil, err
+	}
+
+	supportFile, err := writeSupportFile(dir, results)
+	if err != nil {
+		return nil, nil, err
+	}
+	if supportFile != "" {
+		goFiles = append(goFiles, supportFile)
+	}
+	if err := writePinyinFile(dir, results); err != nil {
+		return nil, nil, err
+	}
+
+	return results, goFiles, nil
+}
+
+// resumeFile returns the result recorded for a file if its source and
+// output are unchanged, or nil when the file must be generated again. The
+// code is only read back when results may retain it.
+func (t *Transpiler) resumeFile(recorded *FileState, hash, goFile string) (*TranspileResult, error) {
+	if recorded == nil || recorded.Hash != hash || recorded.Output != goFile {
+		return nil, nil
+	}
+	result := &TranspileResult{
+		Package:   recorded.Package,
+		Features:  recorded.Features,
+		Templates: recorded.Templates,
+	}
+
+	if t.MaxRetainedCode < 0 {
+		if _, err := os.Stat(goFile); os.IsNotExist(err) {
+			return nil, nil
+		}
+		return result, nil
+	}
+
+	goCode, err := os.ReadFile(goFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.GoCode = string(goCode)
+	return result, nil
+}
@@ -0,0 +1,151 @@
+package transpiler
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/codegen"
+)
+
+// StaticDir returns the static directory of the program formed by the
+// sources, whose files 静态文件 holds: the directory named
+// codegen.StaticDirName next to the project file or, without one, next to
+// the first source. It returns "" when there is none.
+func StaticDir(saikaFilePaths []string) string {
+	if len(saikaFilePaths) == 0 {
+		return ""
+	}
+	dir := filepath.Dir(saikaFilePaths[0])
+	if p, err := FindProject(dir); err == nil && p != nil {
+		dir = p.Dir()
+	}
+	static := filepath.Join(dir, codegen.StaticDirName)
+	if info, err := os.Stat(static); err != nil || !info.IsDir() {
+		return ""
+	}
+	return static
+}
+
+// StaticFiles returns the paths of the files in the static directory of the
+// program formed by the sources, in lexical order
+func StaticFiles(saikaFilePaths []string) ([]string, error) {
+	static := StaticDir(saikaFilePaths)
+	if static == "" {
+		return nil, nil
+	}
+	var files []string
+	err := filepath.WalkDir(static, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.Type().IsRegular() {
+			files = append(files, path)
+		}
+		return err
+	})
+	return files, err
+}
+
+// TemplateFiles returns the paths of the template files that the program
+// formed by the sources embeds: those named by a string literal in a call
+// to 渲染模板 or 输出模板, relative to the directory of the source naming
+// them. Each file is listed once, in the order it is named.
+func (t *Transpiler) TemplateFiles(saikaFilePaths []string) ([]string, error) {
+	var files []string
+	for _, path := range saikaFilePaths {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		program, diags := t.parse(string(src))
+		if diags.HasErrors() {
+			return nil, &FileError{Path: path, Err: fmt.Errorf("failed to transpile Saika code: parser errors:\n%w", diags)}
+		}
+		ast.Inspect(program, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpression)
+			if !ok {
+				return true
+			}
+			if lit, ok := codegen.TemplateFile(call); ok {
+				if name, ok := codegen.EmbeddedTemplate(lit.Text()); ok {
+					file := filepath.Join(filepath.Dir(path), filepath.FromSlash(name))
+					if !slices.Contains(files, file) {
+						files = append(files, file)
+					}
+				}
+			}
+			return true
+		})
+	}
+	return files, nil
+}
+
+// copyStaticDir replaces the static directory in dir with a copy of the
+// static directory of the program source belongs to, so that the support
+// file embeds its current files
+func copyStaticDir(dir, source string) error {
+	static := StaticDir([]string{source})
+	if static == "" {
+		return fmt.Errorf("%s is used, but there is no %s directory next to %s or the sources", codegen.StaticFilesName, codegen.StaticDirName, ProjectFile)
+	}
+	files, err := StaticFiles([]string{source})
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		// go:embed rejects a directory without files
+		return fmt.Errorf("%s is used, but %s has no files", codegen.StaticFilesName, static)
+	}
+
+	copied := filepath.Join(dir, codegen.StaticDirName)
+	if err := os.RemoveAll(copied); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(copied, 0755); err != nil {
+		return err
+	}
+	for _, file := range files {
+		rel, err := filepath.Rel(static, file)
+		if err != nil {
+			return err
+		}
+		if err := copyFile(file, filepath.Join(copied, rel)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyTemplates replaces the template directory in dir with copies of the
+// template files that results embed, found relative to their sources
+func copyTemplates(dir string, results []*TranspileResult) error {
+	copied := filepath.Join(dir, codegen.TemplateDirName)
+	if err := os.RemoveAll(copied); err != nil {
+		return err
+	}
+	for _, result := range results {
+		for _, name := range result.Templates {
+			file := filepath.Join(filepath.Dir(result.SourcePath), filepath.FromSlash(name))
+			if err := copyFile(file, filepath.Join(copied, filepath.FromSlash(name))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// copyFile copies the file source to target, creating its directory
+func copyFile(source, target string) error {
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(target, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", target, err)
+	}
+	return nil
+}
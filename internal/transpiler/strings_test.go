@@ -0,0 +1,105 @@
+package transpiler_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/saika-m/saika-lang/internal/diagnostic"
+	"github.com/saika-m/saika-lang/internal/transpiler"
+)
+
+// TestStringOperations indexes, slices and measures strings by character
+// wherever the checked types show them to be strings, including values
+// declared in another file of the package and results of standard
+// library and generic functions
+func TestStringOperations(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.saika": `包 main
+
+导入 "fmt"
+导入 "strings"
+
+类型 名字 = 字符串
+
+类型 人 结构 {
+	名 字符串
+}
+
+数 入口() {
+	变量 表 = 映射[字符串]字符串{"a": "你好"}
+	变量 列 = 切片[字符串]{"中文"}
+	变量 p = 人{名: "张三"}
+	变量 n 名字 = "李四"
+	变量 数据 = 切片[byte]{1, 2}
+	fmt.Println(表["a"][1], 列[0][1], p.名[1], n[1], 问候()[1], 数据[1])
+	fmt.Println(len(表["a"]), 列[0][1:], n[1:], len(strings.Repeat("中", 2)))
+	变量 u = strings.ToUpper("ab你")
+	fmt.Println(u[2], 首(列)[1], strings.Fields("甲 乙")[0][0:1])
+}
+
+数 首[T 任意](xs 切片[T]) T {
+	返回 xs[0]
+}
+`,
+		"other.saika": "包 main\n\n数 问候() 字符串 {\n\t返回 \"你好\"\n}\n",
+	}
+	var sources []string
+	for _, name := range []string{"main.saika", "other.saika"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(files[name]), 0644); err != nil {
+			t.Fatal(err)
+		}
+		sources = append(sources, path)
+	}
+
+	results, err := transpiler.New().TranspileProject(sources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := results[0].GoCode
+	for _, want := range []string{
+		"saikaRuneAt(表[\"a\"], 1)", "saikaRuneAt(列[0], 1)", "saikaRuneAt(p.名, 1)", "saikaRuneAt(n, 1)",
+		"saikaRuneAt(问候(), 1)", "数据[1]", "saikaRuneCount(表[\"a\"])", "saikaRunesFrom(列[0], 1)",
+		"saikaRunesFrom(n, 1)", "saikaRuneCount(strings.Repeat(\"中\", 2))", "saikaRuneAt(u, 2)",
+		"saikaRuneAt(首(列), 1)", "saikaSubstring(strings.Fields(\"甲 乙\")[0], 0, 1)",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code does not contain %s:\n%s", want, code)
+		}
+	}
+
+}
+
+// TestUnknownStringType reports indexing and measuring a value whose type
+// is not known, rather than leaving it to work on bytes, until the value
+// is given a declared type
+func TestUnknownStringType(t *testing.T) {
+	source := `包 main
+
+导入 "fmt"
+导入 "net/url"
+
+数 入口() {
+	变量 e = url.PathEscape("你 好")
+	fmt.Println(e[1], len(e), e[1:])
+	变量 d 字符串 = url.PathEscape("你 好")
+	fmt.Println(d[1], len(d), d[1:])
+}
+`
+	_, diags := transpiler.New().Check(source)
+	var lines []int
+	for _, d := range diags {
+		if d.Code == diagnostic.UnknownStringType {
+			if d.Severity != diagnostic.Error {
+				t.Errorf("%s is not an error", d)
+			}
+			lines = append(lines, d.Range.Start.Line)
+		}
+	}
+	if len(lines) != 3 || lines[0] != 8 || lines[2] != 8 {
+		t.Errorf("unknown string type errors on lines %v, want three on line 8:\n%s", lines, diags)
+	}
+}
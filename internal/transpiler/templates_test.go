@@ -0,0 +1,66 @@
+package transpiler_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/saika-m/saika-lang/internal/codegen"
+	"github.com/saika-m/saika-lang/internal/transpiler"
+)
+
+// TestTemplatesEmbedded copies the template files named by the sources,
+// relative to them, next to the generated code that embeds them
+func TestTemplatesEmbedded(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "main.saika")
+	code := "包 main\n\n导入 \"fmt\"\n\n数 入口() {\n\tfmt.Print(渲染模板(\"页面/问候.html\", \"世界\"))\n}\n"
+	if err := os.WriteFile(source, []byte(code), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "页面"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "页面", "问候.html"), []byte("你好，{{.}}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tr := transpiler.New()
+	results, err := tr.TranspileProject([]string{source})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(t.TempDir(), "out")
+	if _, err := tr.WriteGoPackage(out, results); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(out, codegen.TemplateDirName, "页面", "问候.html"))
+	if err != nil {
+		t.Fatalf("template not copied: %v", err)
+	}
+	if string(data) != "你好，{{.}}\n" {
+		t.Errorf("copied template = %q", data)
+	}
+	support, err := os.ReadFile(filepath.Join(out, codegen.SupportFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(support), "//go:embed "+codegen.TemplateDirName) {
+		t.Errorf("support code does not embed %s", codegen.TemplateDirName)
+	}
+}
+
+func TestTemplateOutsideSourceDir(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "main.saika")
+	code := "包 main\n\n导入 \"fmt\"\n\n数 入口() {\n\tfmt.Print(渲染模板(\"../问候.html\", 1))\n}\n"
+	if err := os.WriteFile(source, []byte(code), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := transpiler.New().TranspileProject([]string{source})
+	if err == nil || !strings.Contains(err.Error(), "cannot be embedded") {
+		t.Errorf("TranspileProject error = %v, want a template outside the source directory", err)
+	}
+}
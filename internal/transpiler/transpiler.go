@@ -1,47 +1,174 @@
+// Package transpiler is the entry point to the Saika to Go pipeline. Tools
+// should transpile through this package rather than driving the lexer,
+// parser and code generator themselves.
 package transpiler
 
 import (
+	"context"
+	"crypto/sha256"
 	"fmt"
-	"io/ioutil"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 
+	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/backend"
+	"github.com/saika-m/saika-lang/internal/buildcache"
+	"github.com/saika-m/saika-lang/internal/checker"
 	"github.com/saika-m/saika-lang/internal/codegen"
+	"github.com/saika-m/saika-lang/internal/diagnostic"
+	"github.com/saika-m/saika-lang/internal/ir"
 	"github.com/saika-m/saika-lang/internal/lexer"
 	"github.com/saika-m/saika-lang/internal/parser"
+	"github.com/saika-m/saika-lang/internal/stdlib"
 )
 
+// SourceExt is the file extension of Saika source files
+const SourceExt = ".saika"
+
+// TranspileResult holds the Go code generated for a single Saika file
+type TranspileResult struct {
+	SourcePath string
+	GoCode     string
+	Package    string   // Go package name of the generated code
+	Features   []string // support features the code needs, see codegen.SupportSource
+	Templates  []string // template files the code embeds, see codegen.TemplateDirName
+	GoFile     string   // path of the Go file, when written by TranspileProjectTo
+
+	// Functions maps each function to the lines of GoCode generated for
+	// it. Results that TranspileProjectTo resumes from earlier output
+	// have none.
+	Functions []codegen.FunctionMapping
+
+	// Diagnostics holds the warnings reported for the source; a result is
+	// only produced when there are no errors
+	Diagnostics diagnostic.List
+
+	// Pinyin maps the Chinese names of the package to the Go names they
+	// were given, when its project spells them in pinyin
+	Pinyin map[string]string
+}
+
+// Code returns the generated Go code, reading it from GoFile when it was
+// not retained in memory
+func (r *TranspileResult) Code() (string, error) {
+	if r.GoCode != "" || r.GoFile == "" {
+		return r.GoCode, nil
+	}
+	code, err := os.ReadFile(r.GoFile)
+	if err != nil {
+		return "", err
+	}
+	return string(code), nil
+}
+
 // Transpiler represents a Saika to Go transpiler
 type Transpiler struct {
-	// Configuration options could be added here
+	// TabWidth is the tab width used when computing display columns
+	TabWidth int
+
+	// Language is the version of Saika, one of Languages, of code checked
+	// or transpiled on its own; "" is the first. The files of a project
+	// are in the version its project file gives.
+	Language string
+
+	// Progress, if set, receives progress events from TranspileProject
+	Progress ProgressFunc
+
+	// MaxRetainedCode bounds the bytes of generated Go code that
+	// TranspileProjectTo keeps in memory across all results. Code beyond
+	// the bound is only on disk, to be read with TranspileResult.Code.
+	// Zero keeps all code; a negative value keeps none.
+	MaxRetainedCode int64
+
+	// Backend generates the code for each file; nil selects the Go backend
+	Backend backend.Backend
+
+	// Prune removes the functions that 入口, or the exported functions of
+	// a library package, can never reach; see ir.Prune
+	Prune bool
+
+	// PoolStrings declares the string literals each file uses more than
+	// once as package-level constants of the generated code; see
+	// ir.PoolStrings
+	PoolStrings bool
+
+	// Readable has a backend that generates Go comment the code of each
+	// statement with the Saika construct it came from; see
+	// backend.Explainer
+	Readable bool
+
+	// RemoteCache, if set, is asked by TranspileProjectTo for the files it
+	// would otherwise generate, and given those it does generate. It
+	// only saves work: after the first request that fails, it is no
+	// longer used, and RemoteCacheError reports the failure.
+	RemoteCache *buildcache.Remote
+
+	remoteErr error // first failure of RemoteCache
 }
 
 // New creates a new Transpiler
 func New() *Transpiler {
-	return &Transpiler{}
+	return &Transpiler{
+		TabWidth: lexer.DefaultTabWidth,
+	}
 }
 
 // TranspileFile transpiles a Saika file to Go code
 func (t *Transpiler) TranspileFile(saikaFilePath string) (string, error) {
+	result, err := t.TranspileFileResult(saikaFilePath)
+	if err != nil {
+		return "", err
+	}
+	return result.GoCode, nil
+}
+
+// TranspileFileResult transpiles a Saika file that forms a package on its
+// own, returning the Go code along with the package support it needs
+func (t *Transpiler) TranspileFileResult(saikaFilePath string) (*TranspileResult, error) {
 	// Read the Saika file
-	saikaCode, err := ioutil.ReadFile(saikaFilePath)
+	saikaCode, err := os.ReadFile(saikaFilePath)
 	if err != nil {
-		return "", fmt.Errorf("failed to read Saika file: %v", err)
+		return nil, fmt.Errorf("failed to read Saika file: %v", err)
 	}
 
 	// Transpile the code
-	goCode, err := t.Transpile(string(saikaCode))
+	result, err := t.transpile(string(saikaCode))
 	if err != nil {
-		return "", fmt.Errorf("failed to transpile Saika code: %v", err)
+		return nil, fmt.Errorf("failed to transpile Saika code: %w", err)
 	}
 
-	return goCode, nil
+	result.SourcePath = saikaFilePath
+	return result, nil
 }
 
 // Transpile transpiles Saika code to Go code
 func (t *Transpiler) Transpile(saikaCode string) (string, error) {
+	result, err := t.transpile(saikaCode)
+	if err != nil {
+		return "", err
+	}
+	return result.GoCode, nil
+}
+
+// Check parses and checks Saika code that forms a package on its own,
+// without generating Go, returning the program and every diagnostic found.
+// Semantic checks only run when the code parses without errors.
+func (t *Transpiler) Check(saikaCode string) (*ast.Program, diagnostic.List) {
+	program, diags := t.parse(saikaCode)
+	if diags.HasErrors() {
+		return program, diags
+	}
+	checked, _ := checker.CheckPackageInfo([]string{""}, []*ast.Program{program}, features(t.Language))
+	return program, append(diags, checked[0]...)
+}
+
+// parse parses Saika code, returning the program and parser diagnostics
+func (t *Transpiler) parse(saikaCode string) (*ast.Program, diagnostic.List) {
 	// Create a lexer
-	l := lexer.New(saikaCode)
+	l := lexer.NewWithTabWidth(saikaCode, t.TabWidth)
 
 	// Create a parser
 	p := parser.New(l)
@@ -49,32 +176,500 @@ func (t *Transpiler) Transpile(saikaCode string) (string, error) {
 	// Parse the program
 	program := p.ParseProgram()
 
-	// Check for parser errors
-	if len(p.Errors()) > 0 {
-		return "", fmt.Errorf("parser errors: %v", p.Errors())
+	return program, p.Diagnostics()
+}
+
+// transpile runs the full pipeline over Saika code
+func (t *Transpiler) transpile(saikaCode string) (*TranspileResult, error) {
+	program, diags := t.parse(saikaCode)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("parser errors:\n%w", diags)
+	}
+	programs := []*ast.Program{program}
+	checked, infos := checker.CheckPackageInfo([]string{""}, programs, features(t.Language))
+	if checked[0].HasErrors() {
+		return nil, fmt.Errorf("check errors:\n%w", checked[0])
+	}
+	t.prune(programs, nil)
+	return t.generate(programs[0], infos[0], fmt.Sprintf("%x", sha256.Sum256([]byte(saikaCode))))
+}
+
+// backend returns the backend that generates code
+func (t *Transpiler) backend() backend.Backend {
+	b := t.Backend
+	if b == nil {
+		b = backend.Go{}
+	}
+	if explainer, ok := b.(backend.Explainer); ok && t.Readable {
+		return explainer.Explained()
 	}
+	return b
+}
 
-	// Generate Go code
-	g := codegen.New(program)
-	goCode := g.Generate()
+// generate lowers a checked program, with what checking found, and
+// generates code for it with the transpiler's backend. Hash is the hash of
+// its source, whose prefix names the constants of pooled strings apart
+// from those of other files.
+func (t *Transpiler) generate(program *ast.Program, info *ir.Info, hash string) (*TranspileResult, error) {
+	lowered := ir.Lower(program, info)
+	if t.PoolStrings {
+		lowered = ir.PoolStrings(lowered, hash[:8])
+	}
+	artifact, err := t.backend().Generate(lowered)
+	if err != nil {
+		return nil, err
+	}
 
-	return goCode, nil
+	return &TranspileResult{
+		GoCode:    artifact.Code,
+		Package:   artifact.Package,
+		Features:  artifact.Features,
+		Templates: artifact.Templates,
+		Functions: artifact.Functions,
+	}, nil
 }
 
 // CreateTempGoFile creates a temporary Go file with the given code
+//
+// Deprecated: use TranspileProject and CreateTempGoPackage, which also write
+// the support code that programs using builtins such as 构建信息 need.
 func (t *Transpiler) CreateTempGoFile(goCode string) (string, string, error) {
 	// Create a temporary directory
-	tempDir, err := ioutil.TempDir("", "saika-temp")
+	tempDir, err := os.MkdirTemp("", "saika-temp")
 	if err != nil {
 		return "", "", fmt.Errorf("failed to create temp directory: %v", err)
 	}
 
 	// Create a temporary Go file
 	tempFile := filepath.Join(tempDir, "temp.go")
-	if err := ioutil.WriteFile(tempFile, []byte(goCode), 0644); err != nil {
+	if err := os.WriteFile(tempFile, []byte(goCode), 0644); err != nil {
 		os.RemoveAll(tempDir)
 		return "", "", fmt.Errorf("failed to write temp file: %v", err)
 	}
 
 	return tempFile, tempDir, nil
 }
+
+// CollectSources expands command-line arguments into a sorted list of Saika
+// source files. A file is used as-is, a directory contributes the .saika
+// files directly inside it, and a path ending in "/..." contributes every
+// .saika file below that directory. Glob patterns such as *.saika are
+// expanded here because shells like cmd.exe pass them through unexpanded.
+func CollectSources(args []string) ([]string, error) {
+	seen := map[string]bool{}
+	sources := []string{}
+
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			sources = append(sources, path)
+		}
+	}
+
+	for _, arg := range args {
+		if dir, ok := strings.CutSuffix(filepath.ToSlash(arg), "/..."); ok {
+			if dir == "" {
+				dir = "."
+			}
+			err := filepath.WalkDir(filepath.FromSlash(dir), func(path string, d os.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if !d.IsDir() && filepath.Ext(path) == SourceExt {
+					add(path)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to scan %s: %v", arg, err)
+			}
+			continue
+		}
+
+		info, err := os.Stat(arg)
+		if err != nil && strings.ContainsAny(arg, "*?[") {
+			matches, globErr := filepath.Glob(arg)
+			if globErr != nil {
+				return nil, fmt.Errorf("invalid pattern %s: %v", arg, globErr)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no files match %s", arg)
+			}
+			for _, match := range matches {
+				if info, err := os.Stat(match); err == nil && !info.IsDir() {
+					add(match)
+				}
+			}
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", arg, err)
+		}
+
+		if !info.IsDir() {
+			add(arg)
+			continue
+		}
+
+		matches, err := filepath.Glob(filepath.Join(arg, "*"+SourceExt))
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %v", arg, err)
+		}
+		for _, match := range matches {
+			add(match)
+		}
+	}
+
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no Saika source files found in %s", strings.Join(args, " "))
+	}
+
+	sort.Strings(sources)
+	return sources, nil
+}
+
+// FileError is an error in one source file of a project
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// TranspileProject transpiles every file of a project in order. The files
+// form one package and are checked together, so a name declared in one
+// file can be used in the others. An error for a single file is returned
+// as a *FileError.
+func (t *Transpiler) TranspileProject(saikaFilePaths []string) ([]*TranspileResult, error) {
+	return t.TranspileProjectContext(context.Background(), saikaFilePaths)
+}
+
+// TranspileProjectContext is like TranspileProject, but stops between files
+// and returns the context's error once ctx is done
+func (t *Transpiler) TranspileProjectContext(ctx context.Context, saikaFilePaths []string) ([]*TranspileResult, error) {
+	programs, hashes, warnings, err := t.parseProject(ctx, saikaFilePaths)
+	if err != nil {
+		return nil, err
+	}
+	infos, err := t.checkProject(saikaFilePaths, programs, warnings, nil)
+	if err != nil {
+		return nil, err
+	}
+	t.prune(programs, nil)
+	pinyin, err := t.transliterate(saikaFilePaths, programs, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*TranspileResult, 0, len(programs))
+	for i, program := range programs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		result, err := t.generate(program, infos[i], hashes[i])
+		if err != nil {
+			return nil, &FileError{Path: saikaFilePaths[i], Err: err}
+		}
+		result.SourcePath = saikaFilePaths[i]
+		result.Diagnostics = warnings[i]
+		result.Pinyin = pinyin
+		results = append(results, result)
+		t.reportPhase(saikaFilePaths[i], PhaseGenerate, nil)
+	}
+
+	return results, nil
+}
+
+// LowerProject parses and checks a project like TranspileProject, and
+// returns the lowered program of each file instead of generating Go code,
+// for backends that work from the IR directly
+func (t *Transpiler) LowerProject(ctx context.Context, saikaFilePaths []string) ([]*ast.Program, error) {
+	programs, _, warnings, err := t.parseProject(ctx, saikaFilePaths)
+	if err != nil {
+		return nil, err
+	}
+	infos, err := t.checkProject(saikaFilePaths, programs, warnings, nil)
+	if err != nil {
+		return nil, err
+	}
+	t.prune(programs, nil)
+
+	lowered := make([]*ast.Program, len(programs))
+	for i, program := range programs {
+		lowered[i] = ir.Lower(program, infos[i])
+	}
+	return lowered, nil
+}
+
+// References parses the files of a project and resolves the names used
+// in them, returning the declarations and uses of the names they declare;
+// see checker.References. The files must parse, but need not pass the
+// checks, so that names can be searched in a project being edited.
+func (t *Transpiler) References(saikaFilePaths []string) ([]checker.Reference, error) {
+	programs, _, _, err := t.parseProject(context.Background(), saikaFilePaths)
+	if err != nil {
+		return nil, err
+	}
+	return checker.References(programs), nil
+}
+
+// parseProject parses every file of a project, returning the programs, the
+// SHA-256 hash of each source and the warnings found in each
+func (t *Transpiler) parseProject(ctx context.Context, saikaFilePaths []string) ([]*ast.Program, []string, []diagnostic.List, error) {
+	if err := registerAliases(saikaFilePaths); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := registerModules(saikaFilePaths); err != nil {
+		return nil, nil, nil, err
+	}
+	project, err := sourcesProject(saikaFilePaths)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	numbers := t.numbers(project)
+
+	programs := make([]*ast.Program, 0, len(saikaFilePaths))
+	hashes := make([]string, 0, len(saikaFilePaths))
+	warnings := make([]diagnostic.List, 0, len(saikaFilePaths))
+	for _, path := range saikaFilePaths {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, nil, err
+		}
+		t.report(Event{Kind: FileStarted, Path: path})
+		saikaCode, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, nil, &FileError{Path: path, Err: fmt.Errorf("failed to read Saika file: %v", err)}
+		}
+		program, diags := t.parse(string(saikaCode))
+		t.reportPhase(path, PhaseParse, diags)
+		if diags.HasErrors() {
+			return nil, nil, nil, &FileError{Path: path, Err: fmt.Errorf("failed to transpile Saika code: parser errors:\n%w", diags)}
+		}
+		renameEntry(program, project.Entry)
+		programs = append(programs, program)
+		hashes = append(hashes, fmt.Sprintf("%x", sha256.Sum256(append(saikaCode, entryHash(project.Entry)+numbersHash(numbers)...))))
+		warnings = append(warnings, diags)
+	}
+	return programs, hashes, warnings, nil
+}
+
+// registerAliases registers the alias tables, such as those written by saika
+// protoc, found next to the sources, so that the packages they describe
+// can be imported by their Chinese names
+func registerAliases(saikaFilePaths []string) error {
+	seen := map[string]bool{}
+	for _, path := range saikaFilePaths {
+		dir := filepath.Dir(path)
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+
+		tables, err := filepath.Glob(filepath.Join(dir, "*"+stdlib.AliasFileExt))
+		if err != nil {
+			return err
+		}
+		for _, table := range tables {
+			pkg, err := stdlib.ReadAliasFile(table)
+			if err == nil {
+				err = stdlib.Register(pkg)
+			}
+			if err != nil {
+				return &FileError{Path: table, Err: err}
+			}
+		}
+	}
+	return nil
+}
+
+// numbers returns the number types configured by project for the code
+// generated by the backend. Backends running programs themselves model
+// numbers their own way.
+func (t *Transpiler) numbers(project *Project) Numbers {
+	if _, runs := t.backend().(backend.Runner); runs {
+		return Numbers{}
+	}
+	return project.Numbers
+}
+
+// checkProject checks the programs of a project as one package, adding
+// the warnings for each file to warnings, and returns what lowering each
+// file needs to know. Checked programs are made to use the configured
+// number types. Which operations work on strings depends on the other
+// files too, so when hashes is given, the hash of each file is extended
+// with them.
+func (t *Transpiler) checkProject(saikaFilePaths []string, programs []*ast.Program, warnings []diagnostic.List, hashes []string) ([]*ir.Info, error) {
+	project, err := sourcesProject(saikaFilePaths)
+	if err != nil {
+		return nil, err
+	}
+	all, infos := checker.CheckPackageInfo(saikaFilePaths, programs, features(project.Language))
+	for i, diags := range all {
+		diags = append(diags, checker.CheckTemplates(programs[i], filepath.Dir(saikaFilePaths[i]))...)
+		t.reportPhase(saikaFilePaths[i], PhaseCheck, diags)
+		if diags.HasErrors() {
+			return nil, &FileError{Path: saikaFilePaths[i], Err: fmt.Errorf("failed to transpile Saika code: check errors:\n%w", diags)}
+		}
+		warnings[i] = append(warnings[i], diags...)
+		programs[i] = t.numbers(project).apply(programs[i], infos[i])
+		if hashes != nil {
+			hashes[i] = fmt.Sprintf("%x", sha256.Sum256([]byte(hashes[i]+"\x00strings:"+infos[i].Key())))
+		}
+	}
+	return infos, nil
+}
+
+// prune replaces the checked programs of a project with their pruned
+// form when the transpiler prunes. The code generated for a file then
+// depends on the other files too, so when hashes is given, the hash of
+// each file that lost functions is extended with their names.
+func (t *Transpiler) prune(programs []*ast.Program, hashes []string) {
+	if !t.Prune {
+		return
+	}
+	for i, program := range ir.Prune(programs) {
+		if program == programs[i] {
+			continue
+		}
+		if hashes != nil {
+			kept := map[ast.Statement]bool{}
+			for _, stmt := range program.Statements {
+				kept[stmt] = true
+			}
+			removed := hashes[i]
+			for _, stmt := range programs[i].Statements {
+				if fn, ok := stmt.(*ast.FunctionStatement); ok && !kept[stmt] {
+					removed += "\x00" + fn.Name.Value
+				}
+			}
+			hashes[i] = fmt.Sprintf("%x", sha256.Sum256([]byte(removed)))
+		}
+		programs[i] = program
+	}
+}
+
+// CreateTempGoPackage writes each result to its own Go file in a new temporary
+// directory and returns the directory and the paths of the written files
+func (t *Transpiler) CreateTempGoPackage(results []*TranspileResult) (string, []string, error) {
+	tempDir, err := os.MkdirTemp("", "saika-temp")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp directory: %v", err)
+	}
+
+	goFiles, err := t.WriteGoPackage(tempDir, results)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		return "", nil, err
+	}
+
+	return tempDir, goFiles, nil
+}
+
+// WriteGoPackage writes each result to its own Go file in dir, creating the
+// directory if needed, and returns the paths of the written files. When the
+// results need support features, a support file is written as well.
+func (t *Transpiler) WriteGoPackage(dir string, results []*TranspileResult) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory %s: %v", dir, err)
+	}
+
+	sources := make([]string, len(results))
+	for i, result := range results {
+		sources[i] = result.SourcePath
+	}
+
+	goFiles := make([]string, 0, len(results))
+	for i, name := range GoFileNames(sources) {
+		goFile := filepath.Join(dir, name)
+		if err := os.WriteFile(goFile, []byte(results[i].GoCode), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %v", goFile, err)
+		}
+		goFiles = append(goFiles, goFile)
+	}
+
+	supportFile, err := writeSupportFile(dir, results)
+	if err != nil {
+		return nil, err
+	}
+	if supportFile != "" {
+		goFiles = append(goFiles, supportFile)
+	}
+	if err := writePinyinFile(dir, results); err != nil {
+		return nil, err
+	}
+
+	return goFiles, nil
+}
+
+// writeSupportFile writes the support code needed by results to dir and
+// returns its path, or "" when no support code is needed. When the
+// results use 静态文件, the static directory is copied to dir as well, and
+// so are the template files they embed.
+func writeSupportFile(dir string, results []*TranspileResult) (string, error) {
+	features := RequiredFeatures(results)
+	if len(features) == 0 {
+		return "", nil
+	}
+	if slices.Contains(features, codegen.FeatureStatic) {
+		if err := copyStaticDir(dir, results[0].SourcePath); err != nil {
+			return "", err
+		}
+	}
+	if slices.Contains(features, codegen.FeatureTemplateFiles) {
+		if err := copyTemplates(dir, results); err != nil {
+			return "", err
+		}
+	}
+
+	supportFile := filepath.Join(dir, codegen.SupportFileName)
+	support := codegen.SupportSource(results[0].Package, features)
+	if err := os.WriteFile(supportFile, []byte(support), 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %v", supportFile, err)
+	}
+	return supportFile, nil
+}
+
+// RequiredFeatures returns the sorted union of the support features needed by results
+func RequiredFeatures(results []*TranspileResult) []string {
+	seen := map[string]bool{}
+	features := []string{}
+	for _, result := range results {
+		for _, feature := range result.Features {
+			if !seen[feature] {
+				seen[feature] = true
+				features = append(features, feature)
+			}
+		}
+	}
+	sort.Strings(features)
+	return features
+}
+
+// GoFileNames returns the unique Go file name generated for each Saika
+// source path. The ".saika.go" suffix keeps names like server_linux.saika
+// or util_test.saika from being treated as Go build constraints.
+func GoFileNames(sourcePaths []string) []string {
+	names := make([]string, len(sourcePaths))
+	used := map[string]bool{}
+
+	for i, sourcePath := range sourcePaths {
+		base := strings.TrimLeft(strings.TrimSuffix(filepath.Base(sourcePath), SourceExt), "._")
+		if base == "" {
+			base = "main"
+		}
+		name := base + ".saika.go"
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s%d.saika.go", base, n)
+		}
+		used[name] = true
+		names[i] = name
+	}
+
+	return names
+}
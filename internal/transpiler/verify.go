@@ -0,0 +1,114 @@
+package transpiler
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+)
+
+// Verify transpiles a project twice and compares the results byte for
+// byte, catching nondeterminism such as map iteration order leaking into
+// generated code. It returns the results of the first run, which is the
+// only one reported to the progress callback.
+func (t *Transpiler) Verify(saikaFilePaths []string) ([]*TranspileResult, error) {
+	first, err := t.TranspileProject(saikaFilePaths)
+	if err != nil {
+		return nil, err
+	}
+
+	// Progress was already reported by the first run
+	progress := t.Progress
+	t.Progress = nil
+	second, err := t.TranspileProject(saikaFilePaths)
+	t.Progress = progress
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range first {
+		if err := compareResults(first[i], second[i]); err != nil {
+			return nil, &FileError{Path: first[i].SourcePath, Err: err}
+		}
+	}
+	return first, nil
+}
+
+// compareResults reports the first difference between two results for
+// the same source
+func compareResults(a, b *TranspileResult) error {
+	if a.GoCode != b.GoCode {
+		return fmt.Errorf("generated code differs between runs: %s", firstDifference(a.GoCode, b.GoCode))
+	}
+	if strings.Join(a.Features, ",") != strings.Join(b.Features, ",") {
+		return fmt.Errorf("support features differ between runs: %v != %v", a.Features, b.Features)
+	}
+	return nil
+}
+
+// firstDifference describes the first line that differs between a and b
+func firstDifference(a, b string) string {
+	linesA, linesB := strings.Split(a, "\n"), strings.Split(b, "\n")
+	for i := 0; i < len(linesA) || i < len(linesB); i++ {
+		var lineA, lineB string
+		if i < len(linesA) {
+			lineA = linesA[i]
+		}
+		if i < len(linesB) {
+			lineB = linesB[i]
+		}
+		if lineA != lineB {
+			return fmt.Sprintf("line %d is %q, then %q", i+1, lineA, lineB)
+		}
+	}
+	return "no line differs"
+}
+
+// DumpAST parses a Saika file and returns its syntax tree as printed by
+// ast.Dump, for snapshot comparisons
+func (t *Transpiler) DumpAST(saikaFilePath string) (string, error) {
+	saikaCode, err := os.ReadFile(saikaFilePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read Saika file: %v", err)
+	}
+
+	program, diags := t.parse(string(saikaCode))
+	if diags.HasErrors() {
+		return "", fmt.Errorf("parser errors:\n%w", diags)
+	}
+	return ast.Dump(program), nil
+}
+
+// SnapshotExt is appended to the path of a Saika file to name its AST snapshot
+const SnapshotExt = ".ast"
+
+// CheckSnapshot compares the AST of a Saika file with its snapshot file.
+// With update set, the snapshot is written instead; it reports whether the
+// snapshot changed.
+func (t *Transpiler) CheckSnapshot(saikaFilePath string, update bool) (bool, error) {
+	dump, err := t.DumpAST(saikaFilePath)
+	if err != nil {
+		return false, err
+	}
+
+	snapshotPath := saikaFilePath + SnapshotExt
+	snapshot, err := os.ReadFile(snapshotPath)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	if string(snapshot) == dump {
+		return false, nil
+	}
+
+	if update {
+		if err := os.WriteFile(snapshotPath, []byte(dump), 0644); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	if os.IsNotExist(err) {
+		return false, fmt.Errorf("no snapshot %s; run with --update to create it", snapshotPath)
+	}
+	return false, fmt.Errorf("AST differs from %s: %s", snapshotPath, firstDifference(string(snapshot), dump))
+}
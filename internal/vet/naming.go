@@ -0,0 +1,154 @@
+package vet
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/diagnostic"
+)
+
+// Naming holds the naming conventions a project enforces, given as the
+// "naming" object of its project file:
+//
+//	"naming": {
+//	  "functions": ["chinese", "camelCase"],
+//	  "types": ["chinese", "PascalCase"],
+//	  "forbidMixedScripts": true
+//	}
+//
+// Each kind of name, functions, types, constants and variables, lists the
+// styles its names may be written in; a kind without styles may use any.
+// forbidMixedScripts reports names mixing Chinese and Latin letters, such
+// as 用户ID. The zero Naming enforces nothing.
+type Naming struct {
+	Functions          []string `json:"functions"`
+	Types              []string `json:"types"`
+	Constants          []string `json:"constants"`
+	Variables          []string `json:"variables"`
+	ForbidMixedScripts bool     `json:"forbidMixedScripts"`
+}
+
+// styles maps the name of each naming style to whether a name is written
+// in it
+var styles = map[string]func(name string) bool{
+	"chinese": func(name string) bool {
+		return strings.IndexFunc(name, func(r rune) bool { return !unicode.Is(unicode.Han, r) && !unicode.IsDigit(r) }) < 0
+	},
+	"camelCase": func(name string) bool {
+		return asciiWord(name) && unicode.IsLower(rune(name[0]))
+	},
+	"PascalCase": func(name string) bool {
+		return asciiWord(name) && unicode.IsUpper(rune(name[0]))
+	},
+	"snake_case": func(name string) bool {
+		return strings.IndexFunc(name, func(r rune) bool { return !('a' <= r && r <= 'z' || '0' <= r && r <= '9' || r == '_') }) < 0 &&
+			unicode.IsLower(rune(name[0]))
+	},
+}
+
+// asciiWord reports whether name has only ASCII letters and digits
+func asciiWord(name string) bool {
+	return strings.IndexFunc(name, func(r rune) bool { return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9') }) < 0
+}
+
+// Validate reports a style that is not one of those known
+func (n Naming) Validate() error {
+	kinds := []struct {
+		name   string
+		styles []string
+	}{{"functions", n.Functions}, {"types", n.Types}, {"constants", n.Constants}, {"variables", n.Variables}}
+	for _, kind := range kinds {
+		for _, style := range kind.styles {
+			if styles[style] == nil {
+				known := make([]string, 0, len(styles))
+				for name := range styles {
+					known = append(known, name)
+				}
+				sort.Strings(known)
+				return fmt.Errorf("naming style %q of %s is not one of %s", style, kind.name, strings.Join(known, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// naming reports the names declared in program that break the project's
+// naming conventions. The entry function and the blank names are exempt,
+// as are init and main, whose names Go fixes.
+func (v *vetter) naming(program *ast.Program) {
+	n := v.conventions
+	check := func(ident *ast.Identifier, kind string, allowed []string) {
+		name := ident.Value
+		if ast.IsBlank(name) || name == "入口" || name == "init" || name == "main" {
+			return
+		}
+		if n.ForbidMixedScripts && mixesScripts(name) {
+			v.warn(diagnostic.NamingConvention, ident, "%s name %s mixes Chinese and Latin letters", kind, name)
+			return
+		}
+		if len(allowed) > 0 && !slices.ContainsFunc(allowed, func(style string) bool { return styles[style](name) }) {
+			v.warn(diagnostic.NamingConvention, ident, "%s name %s is not written in %s", kind, name, strings.Join(allowed, " or "))
+		}
+	}
+	params := func(params []*ast.TypedParam) {
+		for _, param := range params {
+			check(param.Name, "parameter", n.Variables)
+		}
+	}
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.FunctionStatement:
+			if node.Receiver != nil {
+				check(node.Name, "method", n.Functions)
+				params([]*ast.TypedParam{node.Receiver})
+			} else {
+				check(node.Name, "function", n.Functions)
+			}
+			params(node.Parameters)
+		case *ast.FunctionLiteral:
+			params(node.Parameters)
+		case *ast.TypeStatement:
+			check(node.Name, "type", n.Types)
+		case *ast.InterfaceStatement:
+			check(node.Name, "type", n.Types)
+		case *ast.EnumStatement:
+			check(node.Name, "type", n.Types)
+			for _, member := range node.Members {
+				check(member, "constant", n.Constants)
+			}
+		case *ast.ConstStatement:
+			check(node.Name, "constant", n.Constants)
+		case *ast.VarStatement:
+			check(node.Name, "variable", n.Variables)
+		case *ast.VarListStatement:
+			for _, name := range node.Names {
+				check(name, "variable", n.Variables)
+			}
+		case *ast.OptionStatement:
+			check(node.Name, "variable", n.Variables)
+		case *ast.CharLoopStatement:
+			if node.Index != nil {
+				check(node.Index, "variable", n.Variables)
+			}
+			check(node.Char, "variable", n.Variables)
+		case *ast.RangeStatement:
+			check(node.Name, "variable", n.Variables)
+		case *ast.QueryStatement:
+			for _, column := range node.Columns {
+				check(column.Name, "variable", n.Variables)
+			}
+		}
+		return true
+	})
+}
+
+// mixesScripts reports whether name has both Chinese and Latin letters
+func mixesScripts(name string) bool {
+	return strings.ContainsFunc(name, func(r rune) bool { return unicode.Is(unicode.Han, r) }) &&
+		strings.ContainsFunc(name, func(r rune) bool { return unicode.Is(unicode.Latin, r) })
+}
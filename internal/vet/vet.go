@@ -0,0 +1,160 @@
+// Package vet reports constructs in a checked Saika program that are valid
+// but likely mistakes, such as those behaving differently in the generated
+// Go than they read. Its diagnostics are warnings; saika vet lists them.
+package vet
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/saika-m/saika-lang/internal/ast"
+	"github.com/saika-m/saika-lang/internal/diagnostic"
+)
+
+// Check returns the warnings for a program that checked without errors, in
+// source order, enforcing the given naming conventions
+func Check(program *ast.Program, naming Naming) diagnostic.List {
+	v := &vetter{conventions: naming}
+	v.deferInLoop(program, false)
+	v.missingEnumCases(program)
+	v.naming(program)
+	slices.SortStableFunc(v.diags, func(a, b *diagnostic.Diagnostic) int {
+		return a.Range.Start.Offset - b.Range.Start.Offset
+	})
+	return v.diags
+}
+
+// vetter collects the warnings of the analyses run over a program
+type vetter struct {
+	diags       diagnostic.List
+	conventions Naming // the naming conventions of the project
+}
+
+// warn adds a warning covering node
+func (v *vetter) warn(code diagnostic.Code, node ast.Node, format string, args ...any) {
+	v.diags = append(v.diags, diagnostic.New(diagnostic.Warning, code, ast.NodeRange(node), format, args...))
+}
+
+// deferInLoop reports 推迟 statements in the body of a loop, which run when
+// the function returns rather than at the end of each iteration. A function
+// literal in the loop starts a function of its own.
+func (v *vetter) deferInLoop(node ast.Node, inLoop bool) {
+	ast.Inspect(node, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.ForStatement:
+			if !inLoop {
+				v.deferInLoop(node.Body, true)
+				return false
+			}
+		case *ast.WhileStatement:
+			if !inLoop {
+				v.deferInLoop(node.Body, true)
+				return false
+			}
+		case *ast.RangeStatement:
+			if !inLoop {
+				v.deferInLoop(node.Body, true)
+				return false
+			}
+		case *ast.FunctionLiteral:
+			if inLoop {
+				v.deferInLoop(node.Body, false)
+				return false
+			}
+		case *ast.DeferStatement:
+			if inLoop {
+				v.warn(diagnostic.DeferInLoop, node,
+					"%s in a loop runs when the function returns, not at the end of each iteration", node.Token.Literal)
+			}
+		}
+		return true
+	})
+}
+
+// missingEnumCases reports 选择 statements without 默认 whose cases are
+// members of an enumeration declared in the program but leave some of its
+// members out, with a fix adding an empty 情况 for each of them before
+// the closing '}'
+func (v *vetter) missingEnumCases(program *ast.Program) {
+	enums := map[string]*ast.EnumStatement{} // by member name
+	ast.Inspect(program, func(node ast.Node) bool {
+		if enum, ok := node.(*ast.EnumStatement); ok {
+			for _, member := range enum.Members {
+				enums[member.Value] = enum
+			}
+		}
+		return true
+	})
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		stmt, ok := node.(*ast.SwitchStatement)
+		if !ok || stmt.Tag == nil {
+			return true
+		}
+		var enum *ast.EnumStatement
+		handled := map[string]bool{}
+		for _, clause := range stmt.Cases {
+			if len(clause.Values) == 0 {
+				return true
+			}
+			for _, value := range clause.Values {
+				member, ok := value.(*ast.Identifier)
+				if !ok || enums[member.Value] == nil || enum != nil && enums[member.Value] != enum {
+					return true
+				}
+				enum = enums[member.Value]
+				handled[member.Value] = true
+			}
+		}
+		if enum == nil {
+			return true
+		}
+
+		var missing []string
+		for _, member := range enum.Members {
+			if !handled[member.Value] {
+				missing = append(missing, member.Value)
+			}
+		}
+		if len(missing) == 0 {
+			return true
+		}
+		d := diagnostic.New(diagnostic.Warning, diagnostic.MissingEnumCase, ast.NodeRange(stmt.Tag),
+			"%s on %s has no 情况 for %s and no 默认", stmt.Token.Literal, enum.Name.Value, strings.Join(missing, ", "))
+		v.diags = append(v.diags, d.WithFix(fmt.Sprintf("add 情况 for %s", strings.Join(missing, ", ")),
+			diagnostic.Insert(stmt.Rbrace.Position, caseStubs(stmt, missing))))
+		return true
+	})
+}
+
+// caseStubs returns the empty 情况 clauses for members, inserted before
+// the closing '}' of stmt. When the '}' starts its line, each clause takes
+// a line of its own and the '}' keeps its indentation, which is made of
+// tabs unless tabs would have widened it.
+func caseStubs(stmt *ast.SwitchStatement, members []string) string {
+	// The last clause has a value, and ends with it when it has no body
+	last := stmt.Cases[len(stmt.Cases)-1]
+	var end ast.Node = last.Values[len(last.Values)-1]
+	if len(last.Body) > 0 {
+		end = last.Body[len(last.Body)-1]
+	}
+	rbrace := stmt.Rbrace.Position
+	if ast.NodeRange(end).End.Line == rbrace.Line {
+		var out strings.Builder
+		for _, member := range members {
+			fmt.Fprintf(&out, "情况 %s: ", member)
+		}
+		return out.String()
+	}
+
+	indent := strings.Repeat("\t", rbrace.Column-1)
+	if rbrace.DisplayColumn == rbrace.Column {
+		indent = strings.Repeat(" ", rbrace.Column-1)
+	}
+	var out strings.Builder
+	for _, member := range members {
+		fmt.Fprintf(&out, "情况 %s:\n%s", member, indent)
+	}
+	return out.String()
+}